@@ -11,7 +11,12 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider"
+	aiproviderCatalog "github.com/ppipada/flexigpt-app/pkg/aiprovider/catalog"
 	aiproviderConsts "github.com/ppipada/flexigpt-app/pkg/aiprovider/consts"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	conversationSpec "github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/docstore"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
@@ -26,6 +31,7 @@ type App struct {
 	settingStoreAPI      *SettingStoreWrapper
 	conversationStoreAPI *ConversationCollectionWrapper
 	providerSetAPI       *ProviderSetWrapper
+	docStoreAPI          *docstore.DocumentDBSet
 	configBasePath       string
 	dataBasePath         string
 }
@@ -45,8 +51,8 @@ func NewApp() *App {
 	}
 
 	app := &App{}
-	app.configBasePath = filepath.Join(xdg.ConfigHome, (strings.ToLower(AppTitle)))
-	app.dataBasePath = filepath.Join(xdg.DataHome, (strings.ToLower(AppTitle)))
+	app.configBasePath = filepath.Join(xdg.ConfigHome, strings.ToLower(AppTitle))
+	app.dataBasePath = filepath.Join(xdg.DataHome, strings.ToLower(AppTitle))
 
 	// Wails needs some instance of an struct to create bindings from its methods.
 	// Therefore the pattern followed is that create a hollow struct in new and then init in startup
@@ -88,36 +94,60 @@ func (a *App) initManagers() {
 		panic("Failed to initialize Managers")
 	}
 
-	// Initialize conversation manager
-	conversationDir := filepath.Join(a.dataBasePath, "conversations")
-	slog.Info("Conversation store initialized", "directory", conversationDir)
+	// Initialize model catalog, an invalid user overlay leaves the inbuilt catalog in use.
+	modelCatalog := aiproviderCatalog.Default()
+	modelCatalogPath := filepath.Join(a.configBasePath, aiproviderCatalog.OverlayFileName)
+	if err := modelCatalog.SetOverlayFile(modelCatalogPath); err != nil {
+		slog.Error("Couldnt load model catalog overlay", "Error", err)
+	}
+	go modelCatalog.Watch(context.Background(), aiproviderCatalog.DefaultWatchInterval)
 
-	err = InitConversationCollectionWrapper(a.conversationStoreAPI, conversationDir)
+	err = InitProviderSetWrapper(a.providerSetAPI, aiproviderConsts.ProviderNameOpenAI)
 	if err != nil {
 		slog.Error(
-			"Couldnt initialize conversation store",
-			"Direcotry",
-			conversationDir,
+			"Couldnt initialize providerset",
 			"Error",
 			err,
 		)
 		panic("Failed to initialize Managers")
 	}
 
-	err = InitProviderSetWrapper(a.providerSetAPI, aiproviderConsts.ProviderNameOpenAI)
+	err = InitProviderSetUsingSettings(a.settingStoreAPI, a.providerSetAPI)
 	if err != nil {
 		slog.Error(
-			"Couldnt initialize providerset",
+			"Couldnt initialize providerset from settings",
 			"Error",
 			err,
 		)
 		panic("Failed to initialize Managers")
 	}
 
-	err = InitProviderSetUsingSettings(a.settingStoreAPI, a.providerSetAPI)
+	// Initialize document stores, provider embeddings use the keys of the provider set.
+	docStoreDir := filepath.Join(a.dataBasePath, "docstores")
+	a.docStoreAPI = &docstore.DocumentDBSet{}
+	err = docstore.InitDocumentDBSet(a.docStoreAPI, docStoreDir, a.providerSetAPI.providersetAPI)
+	if err != nil {
+		slog.Error("Couldnt initialize document stores", "Directory", docStoreDir, "Error", err)
+	}
+
+	// Initialize conversation manager
+	conversationDir := filepath.Join(a.dataBasePath, "conversations")
+	slog.Info("Conversation store initialized", "directory", conversationDir)
+
+	err = InitConversationCollectionWrapper(
+		a.conversationStoreAPI,
+		conversationDir,
+		conversationstore.WithTitleGenerator(
+			aiprovider.NewPromptCompleter(a.providerSetAPI.providersetAPI),
+			a.settingStoreAPI.ConversationTitleModel,
+		),
+		conversationstore.WithTrashRetentionGetter(a.settingStoreAPI.TrashRetention),
+	)
 	if err != nil {
 		slog.Error(
-			"Couldnt initialize providerset from settings",
+			"Couldnt initialize conversation store",
+			"Direcotry",
+			conversationDir,
 			"Error",
 			err,
 		)
@@ -160,6 +190,64 @@ func (a *App) SaveFile(
 	defaultFilename string,
 	contentBase64 string,
 	filters []runtime.FileFilter,
+) error {
+	// Decode base64 content
+	contentBytes, err := base64.StdEncoding.DecodeString(contentBase64)
+	if err != nil {
+		return err
+	}
+	return a.saveFile(defaultFilename, contentBytes, filters)
+}
+
+// ExportConversations renders conversations and saves the export with the save file dialog.
+func (a *App) ExportConversations(req *conversationSpec.ExportConversationsRequest) error {
+	resp, err := a.conversationStoreAPI.ExportConversations(req)
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(resp.FileName)
+	return a.saveFile(resp.FileName, resp.Body, []runtime.FileFilter{
+		{DisplayName: strings.ToUpper(strings.TrimPrefix(ext, ".")) + " files", Pattern: "*" + ext},
+	})
+}
+
+// ImportConversations imports a ChatGPT or Claude.ai data export picked with the open file dialog.
+// A cancelled dialog imports nothing and returns a nil response.
+func (a *App) ImportConversations(
+	source conversationSpec.ImportSource,
+	branches bool,
+) (*conversationSpec.ImportConversationsResponseBody, error) {
+	if a.ctx == nil {
+		return nil, errors.New("context is not initialized")
+	}
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import conversations",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Data exports (*.zip, *.json)", Pattern: "*.zip;*.json"},
+		},
+	})
+	if err != nil || filePath == "" {
+		return nil, err
+	}
+	resp, err := a.conversationStoreAPI.ImportConversations(
+		&conversationSpec.ImportConversationsRequest{
+			Body: &conversationSpec.ImportConversationsRequestBody{
+				Source:   source,
+				FilePath: filePath,
+				Branches: branches,
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (a *App) saveFile(
+	defaultFilename string,
+	content []byte,
+	filters []runtime.FileFilter,
 ) error {
 	if a.ctx == nil {
 		return errors.New("context is not initialized")
@@ -178,12 +266,6 @@ func (a *App) SaveFile(
 		return nil
 	}
 
-	// Decode base64 content
-	contentBytes, err := base64.StdEncoding.DecodeString(contentBase64)
-	if err != nil {
-		return err
-	}
-
 	// Write the content to the file
-	return os.WriteFile(savePath, contentBytes, 0o644)
+	return os.WriteFile(savePath, content, 0o644)
 }
@@ -15,10 +15,11 @@ type ConversationCollectionWrapper struct {
 func InitConversationCollectionWrapper(
 	c *ConversationCollectionWrapper,
 	conversationDir string,
+	opts ...conversationstore.Option,
 ) error {
 	conversationStoreAPI, err := conversationstore.NewConversationCollection(
 		conversationDir,
-		conversationstore.WithFTS(true),
+		append([]conversationstore.Option{conversationstore.WithFTS(true)}, opts...)...,
 	)
 	if err != nil {
 		return err
@@ -74,3 +75,123 @@ func (ccw *ConversationCollectionWrapper) PutMessagesToConversation(
 		return ccw.store.PutMessagesToConversation(context.Background(), req)
 	})
 }
+
+func (ccw *ConversationCollectionWrapper) AppendMessages(
+	req *spec.AppendMessagesRequest,
+) (*spec.AppendMessagesResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.AppendMessagesResponse, error) {
+		return ccw.store.AppendMessages(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) UpdateMessage(
+	req *spec.UpdateMessageRequest,
+) (*spec.UpdateMessageResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.UpdateMessageResponse, error) {
+		return ccw.store.UpdateMessage(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) DeleteMessage(
+	req *spec.DeleteMessageRequest,
+) (*spec.DeleteMessageResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.DeleteMessageResponse, error) {
+		return ccw.store.DeleteMessage(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) RenameConversation(
+	req *spec.RenameConversationRequest,
+) (*spec.RenameConversationResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.RenameConversationResponse, error) {
+		return ccw.store.RenameConversation(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) GenerateConversationTitle(
+	req *spec.GenerateConversationTitleRequest,
+) (*spec.GenerateConversationTitleResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.GenerateConversationTitleResponse, error) {
+		return ccw.store.GenerateConversationTitle(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) ForkConversation(
+	req *spec.ForkConversationRequest,
+) (*spec.ForkConversationResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.ForkConversationResponse, error) {
+		return ccw.store.ForkConversation(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) RegenerateMessage(
+	req *spec.RegenerateMessageRequest,
+) (*spec.RegenerateMessageResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.RegenerateMessageResponse, error) {
+		return ccw.store.RegenerateMessage(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) SwitchBranch(
+	req *spec.SwitchBranchRequest,
+) (*spec.SwitchBranchResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.SwitchBranchResponse, error) {
+		return ccw.store.SwitchBranch(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) ExportConversations(
+	req *spec.ExportConversationsRequest,
+) (*spec.ExportConversationsResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.ExportConversationsResponse, error) {
+		return ccw.store.ExportConversations(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) ImportConversations(
+	req *spec.ImportConversationsRequest,
+) (*spec.ImportConversationsResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.ImportConversationsResponse, error) {
+		return ccw.store.ImportConversations(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) ListTrash(
+	req *spec.ListTrashRequest,
+) (*spec.ListTrashResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.ListTrashResponse, error) {
+		return ccw.store.ListTrash(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) RestoreConversation(
+	req *spec.RestoreConversationRequest,
+) (*spec.RestoreConversationResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.RestoreConversationResponse, error) {
+		return ccw.store.RestoreConversation(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) PurgeTrash(
+	req *spec.PurgeTrashRequest,
+) (*spec.PurgeTrashResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.PurgeTrashResponse, error) {
+		return ccw.store.PurgeTrash(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) PutConversationMeta(
+	req *spec.PutConversationMetaRequest,
+) (*spec.PutConversationMetaResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.PutConversationMetaResponse, error) {
+		return ccw.store.PutConversationMeta(context.Background(), req)
+	})
+}
+
+func (ccw *ConversationCollectionWrapper) ListConversationLabels(
+	req *spec.ListConversationLabelsRequest,
+) (*spec.ListConversationLabelsResponse, error) {
+	return middleware.WithRecoveryResp(func() (*spec.ListConversationLabelsResponse, error) {
+		return ccw.store.ListConversationLabels(context.Background(), req)
+	})
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/ppipada/flexigpt-app/pkg/aiprovider"
 	aiproviderAPI "github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
@@ -86,6 +87,56 @@ func (w *ProviderSetWrapper) SetProviderAttribute(
 	)
 }
 
+func (w *ProviderSetWrapper) TestProviderConnection(
+	req *aiproviderAPI.TestProviderConnectionRequest,
+) (*aiproviderAPI.TestProviderConnectionResponse, error) {
+	return middleware.WithRecoveryResp(
+		func() (*aiproviderAPI.TestProviderConnectionResponse, error) {
+			return w.providersetAPI.TestProviderConnection(context.Background(), req)
+		},
+	)
+}
+
+func (w *ProviderSetWrapper) SetProviderNetworkConfig(
+	req *aiproviderAPI.SetProviderNetworkConfigRequest,
+) (*aiproviderAPI.SetProviderNetworkConfigResponse, error) {
+	return middleware.WithRecoveryResp(
+		func() (*aiproviderAPI.SetProviderNetworkConfigResponse, error) {
+			return w.providersetAPI.SetProviderNetworkConfig(context.Background(), req)
+		},
+	)
+}
+
+func (w *ProviderSetWrapper) SetProviderRateLimits(
+	req *aiproviderAPI.SetProviderRateLimitsRequest,
+) (*aiproviderAPI.SetProviderRateLimitsResponse, error) {
+	return middleware.WithRecoveryResp(
+		func() (*aiproviderAPI.SetProviderRateLimitsResponse, error) {
+			return w.providersetAPI.SetProviderRateLimits(context.Background(), req)
+		},
+	)
+}
+
+func (w *ProviderSetWrapper) SetOutboundScanConfig(
+	req *aiproviderAPI.SetOutboundScanConfigRequest,
+) (*aiproviderAPI.SetOutboundScanConfigResponse, error) {
+	return middleware.WithRecoveryResp(
+		func() (*aiproviderAPI.SetOutboundScanConfigResponse, error) {
+			return w.providersetAPI.SetOutboundScanConfig(context.Background(), req)
+		},
+	)
+}
+
+func (w *ProviderSetWrapper) FetchEmbeddings(
+	req *aiproviderAPI.FetchEmbeddingsRequest,
+) (*aiproviderAPI.FetchEmbeddingsResponse, error) {
+	return middleware.WithRecoveryResp(
+		func() (*aiproviderAPI.FetchEmbeddingsResponse, error) {
+			return w.providersetAPI.FetchEmbeddings(context.Background(), req)
+		},
+	)
+}
+
 // FetchCompletion handles the completion request and streams data back to the frontend.
 func (w *ProviderSetWrapper) FetchCompletion(
 	provider string,
@@ -120,3 +171,31 @@ func (w *ProviderSetWrapper) FetchCompletion(
 		return resp, nil
 	})
 }
+
+// FetchCompletionMulti fans out the prompt to multiple targets.
+// Stream data of each target is emitted on "<callbackIDPrefix>-<targetIndex>".
+func (w *ProviderSetWrapper) FetchCompletionMulti(
+	prompt string,
+	targets []aiproviderAPI.FetchCompletionMultiTarget,
+	prevMessages []aiproviderSpec.ChatCompletionRequestMessage,
+	callbackIDPrefix string,
+) (*aiproviderAPI.FetchCompletionMultiResponse, error) {
+	return middleware.WithRecoveryResp(func() (*aiproviderAPI.FetchCompletionMultiResponse, error) {
+		for idx := range targets {
+			callbackID := fmt.Sprintf("%s-%d", callbackIDPrefix, idx)
+			targets[idx].OnStreamData = func(data string) error {
+				runtime.EventsEmit(w.appContext, callbackID, data)
+				return nil
+			}
+		}
+
+		req := &aiproviderAPI.FetchCompletionMultiRequest{
+			Body: &aiproviderAPI.FetchCompletionMultiRequestBody{
+				Prompt:       prompt,
+				PrevMessages: prevMessages,
+				Targets:      targets,
+			},
+		}
+		return w.providersetAPI.FetchCompletionMulti(context.Background(), req)
+	})
+}
@@ -2,10 +2,11 @@ package main
 
 import (
 	"context"
-	"log/slog"
+	"errors"
+	"time"
 
-	aiproviderAPI "github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
-	aiproviderConsts "github.com/ppipada/flexigpt-app/pkg/aiprovider/consts"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
 	"github.com/ppipada/flexigpt-app/pkg/middleware"
 	"github.com/ppipada/flexigpt-app/pkg/settingstore"
 	"github.com/ppipada/flexigpt-app/pkg/settingstore/spec"
@@ -34,61 +35,48 @@ func InitProviderSetUsingSettings(s *SettingStoreWrapper, p *ProviderSetWrapper)
 	if err != nil || allSettingsResponse.Body == nil {
 		return err
 	}
+	_, err = middleware.WithRecoveryResp(func() (struct{}, error) {
+		return struct{}{}, aiprovider.InitProviderSetUsingSettings(
+			context.Background(),
+			p.providersetAPI,
+			allSettingsResponse.Body,
+		)
+	})
+	return err
+}
 
-	for providerName, aiSetting := range allSettingsResponse.Body.AISettings {
-		if _, exists := aiproviderConsts.InbuiltProviderModels[providerName]; exists {
-			// Update inbuilt providers.
-			if aiSetting.APIKey != "" {
-				_, err = p.SetProviderAPIKey(
-					&aiproviderAPI.SetProviderAPIKeyRequest{
-						Provider: providerName,
-						Body: &aiproviderAPI.SetProviderAPIKeyRequestBody{
-							APIKey: aiSetting.APIKey,
-						},
-					},
-				)
-				if err != nil {
-					return err
-				}
-			}
-
-			_, err = p.SetProviderAttribute(
-				&aiproviderAPI.SetProviderAttributeRequest{
-					Provider: providerName,
-					Body: &aiproviderAPI.SetProviderAttributeRequestBody{
-						Origin:                   &aiSetting.Origin,
-						ChatCompletionPathPrefix: &aiSetting.ChatCompletionPathPrefix,
-					},
-				},
-			)
-			if err != nil {
-				return err
-			}
-		} else {
-			// Add custom providers.
-			_, err := p.AddProvider(&aiproviderAPI.AddProviderRequest{
-				Provider: providerName,
-				Body: &aiproviderAPI.AddProviderRequestBody{
-					APIKey:                   aiSetting.APIKey,
-					Origin:                   aiSetting.Origin,
-					ChatCompletionPathPrefix: aiSetting.ChatCompletionPathPrefix,
-				},
-			})
-			if err != nil {
-				return err
-			}
-		}
+// ConversationTitleModel returns the title model from the current settings, nil if titles are off.
+func (w *SettingStoreWrapper) ConversationTitleModel(
+	ctx context.Context,
+) (*conversationstore.TitleModel, error) {
+	resp, err := w.store.GetAllSettings(ctx, &spec.GetAllSettingsRequest{})
+	if err != nil {
+		return nil, err
 	}
-	_, err = p.SetDefaultProvider(&aiproviderAPI.SetDefaultProviderRequest{
-		Body: &aiproviderAPI.SetDefaultProviderRequestBody{
-			Provider: allSettingsResponse.Body.App.DefaultProvider,
-		},
-	})
+	if resp.Body == nil {
+		return nil, errors.New("got empty settings")
+	}
+	provider, params, ok := aiprovider.GetConversationTitleModelUsingSettings(resp.Body)
+	if !ok {
+		return nil, nil
+	}
+	return &conversationstore.TitleModel{
+		Provider:    provider,
+		ModelParams: params,
+		Language:    resp.Body.App.ConversationTitles.Language,
+	}, nil
+}
+
+// TrashRetention returns how long deleted conversations stay in the trash.
+func (w *SettingStoreWrapper) TrashRetention(ctx context.Context) (time.Duration, error) {
+	resp, err := w.store.GetAllSettings(ctx, &spec.GetAllSettingsRequest{})
 	if err != nil {
-		return err
+		return 0, err
 	}
-	slog.Info("InitProviderSetUsingSettings Done.")
-	return nil
+	if resp.Body == nil {
+		return 0, errors.New("got empty settings")
+	}
+	return resp.Body.App.TrashRetention(), nil
 }
 
 // GetAllSettings retrieves all settings without requiring a context.
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider"
+	aiproviderCatalog "github.com/ppipada/flexigpt-app/pkg/aiprovider/catalog"
+	aiproviderConsts "github.com/ppipada/flexigpt-app/pkg/aiprovider/consts"
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/batchjob"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	conversationSpec "github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+	"github.com/ppipada/flexigpt-app/pkg/settingstore"
+	settingSpec "github.com/ppipada/flexigpt-app/pkg/settingstore/spec"
+)
+
+// CLIApp lazily initializes only the stores a command needs.
+type CLIApp struct {
+	opts                 *Options
+	settingStoreAPI      *settingstore.SettingStore
+	settings             *settingSpec.SettingsSchema
+	conversationStoreAPI *conversationstore.ConversationCollection
+	providerSetAPI       *aiprovider.ProviderSetAPI
+	batchJobManager      *batchjob.Manager
+}
+
+func (a *CLIApp) getSettings(ctx context.Context) (*settingSpec.SettingsSchema, error) {
+	if a.settings != nil {
+		return a.settings, nil
+	}
+	if err := os.MkdirAll(a.opts.SettingsDirPath, os.FileMode(0o770)); err != nil {
+		return nil, fmt.Errorf("failed to create settings dir: %w", err)
+	}
+	settingStore := &settingstore.SettingStore{}
+	settingsFilePath := filepath.Join(a.opts.SettingsDirPath, "settings.json")
+	if err := settingstore.InitSettingStore(settingStore, settingsFilePath); err != nil {
+		return nil, fmt.Errorf("failed to initialize setting store: %w", err)
+	}
+	resp, err := settingStore.GetAllSettings(ctx, &settingSpec.GetAllSettingsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body == nil {
+		return nil, errors.New("got empty settings")
+	}
+	// The CLI is short lived, the overlay catalog is loaded once and not watched.
+	overlayPath := filepath.Join(a.opts.SettingsDirPath, aiproviderCatalog.OverlayFileName)
+	if err := aiproviderCatalog.Default().SetOverlayFile(overlayPath); err != nil {
+		slog.Warn("Using the inbuilt model catalog", "error", err)
+	}
+	a.settingStoreAPI = settingStore
+	a.settings = resp.Body
+	return a.settings, nil
+}
+
+func (a *CLIApp) getConversationStore() (*conversationstore.ConversationCollection, error) {
+	if a.conversationStoreAPI != nil {
+		return a.conversationStoreAPI, nil
+	}
+	conversationDir := filepath.Join(a.opts.DataDirPath, "conversations")
+	if err := os.MkdirAll(conversationDir, os.FileMode(0o770)); err != nil {
+		return nil, fmt.Errorf("failed to create conversations dir: %w", err)
+	}
+	cc, err := conversationstore.NewConversationCollection(
+		conversationDir,
+		conversationstore.WithFTS(true),
+		conversationstore.WithTitleGenerator(lazyCompleter{app: a}, a.conversationTitleModel),
+		conversationstore.WithTrashRetentionGetter(a.trashRetention),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize conversation store: %w", err)
+	}
+	a.conversationStoreAPI = cc
+	return cc, nil
+}
+
+// conversationTitleModel returns the title model from the settings, nil if titles are off.
+func (a *CLIApp) conversationTitleModel(
+	ctx context.Context,
+) (*conversationstore.TitleModel, error) {
+	settings, err := a.getSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, params, ok := aiprovider.GetConversationTitleModelUsingSettings(settings)
+	if !ok {
+		return nil, nil
+	}
+	return &conversationstore.TitleModel{
+		Provider:    provider,
+		ModelParams: params,
+		Language:    settings.App.ConversationTitles.Language,
+	}, nil
+}
+
+// trashRetention returns how long deleted conversations stay in the trash.
+func (a *CLIApp) trashRetention(ctx context.Context) (time.Duration, error) {
+	settings, err := a.getSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return settings.App.TrashRetention(), nil
+}
+
+// lazyCompleter initializes the provider set on the first completion, so commands that only
+// read conversations do not load the providers.
+type lazyCompleter struct {
+	app *CLIApp
+}
+
+func (c lazyCompleter) Complete(
+	ctx context.Context,
+	req *promptrun.Request,
+) (*promptrun.Result, error) {
+	ps, err := c.app.getProviderSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return aiprovider.NewPromptCompleter(ps).Complete(ctx, req)
+}
+
+func (a *CLIApp) getProviderSet(ctx context.Context) (*aiprovider.ProviderSetAPI, error) {
+	if a.providerSetAPI != nil {
+		return a.providerSetAPI, nil
+	}
+	settings, err := a.getSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := aiprovider.NewProviderSetAPI(aiproviderConsts.ProviderNameOpenAI, a.opts.Debug)
+	if err != nil {
+		return nil, err
+	}
+	if err := aiprovider.InitProviderSetUsingSettings(ctx, ps, settings); err != nil {
+		return nil, fmt.Errorf("failed to initialize providers from settings: %w", err)
+	}
+	a.providerSetAPI = ps
+	return ps, nil
+}
+
+func (a *CLIApp) getBatchJobManager(ctx context.Context) (*batchjob.Manager, error) {
+	if a.batchJobManager != nil {
+		return a.batchJobManager, nil
+	}
+	ps, err := a.getProviderSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manager, err := batchjob.NewManager(
+		filepath.Join(a.opts.DataDirPath, "batchjobs"),
+		aiprovider.NewPromptCompleter(ps),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize batch jobs: %w", err)
+	}
+	a.batchJobManager = manager
+	return manager, nil
+}
+
+// resolveModel picks the provider and model to use, defaulting to the app settings.
+func (a *CLIApp) resolveModel(
+	ctx context.Context,
+	provider, model string,
+) (aiproviderSpec.ProviderName, aiproviderSpec.ModelParams, error) {
+	settings, err := a.getSettings(ctx)
+	if err != nil {
+		return "", aiproviderSpec.ModelParams{}, err
+	}
+	providerName := aiproviderSpec.ProviderName(provider)
+	if providerName == "" {
+		providerName = settings.App.DefaultProvider
+	}
+	aiSetting, ok := settings.AISettings[providerName]
+	if !ok {
+		return "", aiproviderSpec.ModelParams{}, fmt.Errorf("unknown provider %q", providerName)
+	}
+	modelName := aiproviderSpec.ModelName(model)
+	if modelName == "" {
+		modelName = aiSetting.DefaultModel
+	}
+	if modelName == "" {
+		return "", aiproviderSpec.ModelParams{}, fmt.Errorf(
+			"no model given and provider %q has no default model",
+			providerName,
+		)
+	}
+	return providerName, aiprovider.GetModelParamsUsingSettings(
+		providerName,
+		modelName,
+		&aiSetting,
+	), nil
+}
+
+// findConversation looks up a conversation by ID, the title is needed to locate its file.
+func (a *CLIApp) findConversation(
+	ctx context.Context,
+	id string,
+) (*conversationSpec.Conversation, error) {
+	cc, err := a.getConversationStore()
+	if err != nil {
+		return nil, err
+	}
+	token := ""
+	for {
+		resp, err := cc.ListConversations(ctx, &conversationSpec.ListConversationsRequest{
+			Token: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Body.ConversationItems {
+			if item.ID != id {
+				continue
+			}
+			convo, err := cc.GetConversation(ctx, &conversationSpec.GetConversationRequest{
+				ID:    item.ID,
+				Title: item.Title,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return convo.Body, nil
+		}
+		if resp.Body.NextPageToken == nil || *resp.Body.NextPageToken == "" {
+			return nil, fmt.Errorf("conversation %q not found", id)
+		}
+		token = *resp.Body.NextPageToken
+	}
+}
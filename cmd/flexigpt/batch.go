@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	batchjobSpec "github.com/ppipada/flexigpt-app/pkg/batchjob/spec"
+	"github.com/spf13/cobra"
+)
+
+const batchProgressInterval = time.Second
+
+func newBatchCmd(app *CLIApp) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Run a prompt over every row of a JSONL or CSV dataset",
+	}
+	cmd.AddCommand(
+		newBatchRunCmd(app),
+		newBatchResumeCmd(app),
+		newBatchListCmd(app),
+		newBatchStatusCmd(app),
+	)
+	return cmd
+}
+
+func newBatchRunCmd(app *CLIApp) *cobra.Command {
+	flags := &modelFlags{}
+	var (
+		cfg          batchjobSpec.JobConfig
+		templatePath string
+		presetPath   string
+	)
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Create a batch job and run it, press Ctrl-C to pause it",
+		Long: "Create a batch job and run it.\n" +
+			"Placeholders like {{name}} in the prompt are replaced by the CSV column or JSONL field name.\n" +
+			"A paused job continues with the rows that are not in the output file yet.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			if templatePath != "" {
+				cfg.Template = &aiproviderSpec.PromptTemplate{}
+				if err := readJSONFile(templatePath, cfg.Template); err != nil {
+					return fmt.Errorf("invalid template: %w", err)
+				}
+			}
+			if presetPath != "" {
+				cfg.Preset = &aiproviderSpec.ModelPreset{}
+				if err := readJSONFile(presetPath, cfg.Preset); err != nil {
+					return fmt.Errorf("invalid preset: %w", err)
+				}
+			} else {
+				provider, modelParams, err := app.resolveModel(ctx, flags.provider, flags.model)
+				if err != nil {
+					return err
+				}
+				if flags.systemPrompt != "" {
+					modelParams.SystemPrompt = flags.systemPrompt
+				}
+				cfg.Provider, cfg.ModelParams = provider, modelParams
+			}
+
+			manager, err := app.getBatchJobManager(ctx)
+			if err != nil {
+				return err
+			}
+			resp, err := manager.CreateJob(ctx, &batchjobSpec.CreateJobRequest{
+				Body: &batchjobSpec.CreateJobRequestBody{Config: cfg},
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Created batch job %s\n", resp.Body.ID)
+			return runBatchJob(ctx, app, resp.Body.ID, cmd.ErrOrStderr())
+		},
+	}
+	flags.register(cmd)
+	cmd.Flags().StringVar(&cfg.DatasetPath, "dataset", "", "dataset file, .jsonl or .csv")
+	cmd.Flags().StringVarP(&cfg.OutputPath, "output", "o", "", "results file, .jsonl or .csv")
+	cmd.Flags().StringVar(&cfg.Name, "name", "", "job name")
+	cmd.Flags().StringVar(&cfg.Prompt, "prompt", "", "prompt template of a single user message")
+	cmd.Flags().
+		StringVar(&templatePath, "template", "", "JSON prompt template file with message blocks")
+	cmd.Flags().
+		StringVar(&presetPath, "preset", "", "JSON model preset file, overrides provider and model")
+	cmd.Flags().IntVarP(&cfg.Concurrency, "concurrency", "c", 4, "rows run at once")
+	cmd.Flags().
+		IntVar(&cfg.MaxAttempts, "attempts", 1, "tries per row before its error is recorded")
+	_ = cmd.MarkFlagRequired("dataset")
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func newBatchResumeCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume <id>",
+		Short: "Continue a paused or failed batch job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+			return runBatchJob(ctx, app, args[0], cmd.ErrOrStderr())
+		},
+	}
+}
+
+func newBatchListCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List batch jobs, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := app.getBatchJobManager(cmd.Context())
+			if err != nil {
+				return err
+			}
+			resp, err := manager.ListJobs(cmd.Context(), &batchjobSpec.ListJobsRequest{})
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tCREATED\tSTATUS\tPROGRESS\tFAILED\tNAME")
+			for _, job := range resp.Body.Jobs {
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%d/%d\t%d\t%s\n",
+					job.ID,
+					job.CreatedAt.Local().Format(time.DateTime),
+					job.Status,
+					job.Progress.Processed,
+					job.Progress.Total,
+					job.Progress.Failed,
+					job.Config.Name,
+				)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newBatchStatusCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <id>",
+		Short: "Print a batch job as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := app.getBatchJobManager(cmd.Context())
+			if err != nil {
+				return err
+			}
+			resp, err := manager.GetJob(cmd.Context(), &batchjobSpec.GetJobRequest{ID: args[0]})
+			if err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(resp.Body, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return err
+		},
+	}
+}
+
+// runBatchJob runs a job in the foreground and prints its progress to out.
+func runBatchJob(ctx context.Context, app *CLIApp, id string, out io.Writer) error {
+	manager, err := app.getBatchJobManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	stopProgress := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		ticker := time.NewTicker(batchProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopProgress:
+				return
+			case <-ticker.C:
+				resp, err := manager.GetJob(ctx, &batchjobSpec.GetJobRequest{ID: id})
+				if err == nil {
+					printBatchProgress(out, resp.Body, false)
+				}
+			}
+		}
+	}()
+
+	// Cancelling ctx pauses the job.
+	job, err := manager.RunJob(ctx, id)
+	close(stopProgress)
+	<-progressDone
+	if err != nil {
+		return err
+	}
+	printBatchProgress(out, job, true)
+
+	switch job.Status {
+	case batchjobSpec.JobStatusPaused:
+		fmt.Fprintf(out, "Paused, continue with: flexigpt batch resume %s\n", job.ID)
+	case batchjobSpec.JobStatusFailed:
+		return errors.New(job.Error)
+	default:
+		fmt.Fprintf(out, "Results written to %s\n", job.Config.OutputPath)
+	}
+	return nil
+}
+
+func printBatchProgress(out io.Writer, job *batchjobSpec.Job, final bool) {
+	end := ""
+	if final {
+		end = "\n"
+	}
+	fmt.Fprintf(
+		out,
+		"\r%d/%d rows, %d failed, %d input and %d output tokens%s",
+		job.Progress.Processed,
+		job.Progress.Total,
+		job.Progress.Failed,
+		job.Progress.InputTokens,
+		job.Progress.OutputTokens,
+		end,
+	)
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
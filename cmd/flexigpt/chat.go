@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	aiproviderAPI "github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	conversationSpec "github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/spf13/cobra"
+)
+
+const maxTitleLength = 64
+
+type modelFlags struct {
+	provider     string
+	model        string
+	systemPrompt string
+}
+
+func (f *modelFlags) register(cmd *cobra.Command) {
+	cmd.Flags().
+		StringVarP(&f.provider, "provider", "p", "", "provider name, defaults to the app default")
+	cmd.Flags().
+		StringVarP(&f.model, "model", "m", "", "model name, defaults to the provider default")
+	cmd.Flags().
+		StringVar(&f.systemPrompt, "system", "", "system prompt, overrides the model setting")
+}
+
+// chatSession holds one conversation and persists it after each turn if save is set.
+type chatSession struct {
+	app         *CLIApp
+	provider    aiproviderSpec.ProviderName
+	modelParams aiproviderSpec.ModelParams
+	convo       *conversationSpec.Conversation
+	isNew       bool
+	save        bool
+	out         io.Writer
+	// errOut gets warnings, like outbound scan findings.
+	errOut io.Writer
+	// titles gets the generated title of a new conversation.
+	titles chan string
+}
+
+func newChatSession(
+	ctx context.Context,
+	app *CLIApp,
+	flags *modelFlags,
+	save bool,
+	out, errOut io.Writer,
+) (*chatSession, error) {
+	provider, modelParams, err := app.resolveModel(ctx, flags.provider, flags.model)
+	if err != nil {
+		return nil, err
+	}
+	if flags.systemPrompt != "" {
+		modelParams.SystemPrompt = flags.systemPrompt
+	}
+	u, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &chatSession{
+		app:         app,
+		provider:    provider,
+		modelParams: modelParams,
+		convo: &conversationSpec.Conversation{
+			ConversationItem: conversationSpec.ConversationItem{ID: u.String(), CreatedAt: now},
+			ModifiedAt:       now,
+			Messages:         []conversationSpec.ConversationMessage{},
+		},
+		isNew:  true,
+		save:   save,
+		out:    out,
+		errOut: errOut,
+		titles: make(chan string, 1),
+	}, nil
+}
+
+// send runs one completion turn with the conversation so far as history.
+// A failed turn returns an error after any partial answer and is not stored.
+func (s *chatSession) send(ctx context.Context, prompt string) error {
+	ps, err := s.app.getProviderSet(ctx)
+	if err != nil {
+		return err
+	}
+
+	streamed := false
+	req := &aiproviderAPI.FetchCompletionRequest{
+		Body: &aiproviderAPI.FetchCompletionRequestBody{
+			Provider:     s.provider,
+			Prompt:       prompt,
+			ModelParams:  s.modelParams,
+			PrevMessages: toPrevMessages(s.convo.Messages),
+			OnStreamData: func(data string) error {
+				streamed = true
+				_, err := io.WriteString(s.out, data)
+				return err
+			},
+		},
+	}
+	resp, err := ps.FetchCompletion(ctx, req)
+	if err != nil {
+		return err
+	}
+	if resp.Body == nil {
+		return errors.New("got empty completion response")
+	}
+	for _, f := range resp.Body.OutboundScanFindings {
+		fmt.Fprintf(s.errOut, "Warning: %s matched rule %s, action %s\n",
+			f.Location, f.Rule, f.Action)
+	}
+	content := ""
+	if resp.Body.RespContent != nil {
+		content = *resp.Body.RespContent
+	}
+	if !streamed {
+		_, _ = io.WriteString(s.out, content)
+	}
+	if streamed || content != "" || resp.Body.ErrorDetails == nil {
+		_, _ = io.WriteString(s.out, "\n")
+	}
+	if resp.Body.ErrorDetails != nil {
+		return errors.New(resp.Body.ErrorDetails.Message)
+	}
+
+	userMsg, err := newMessage(conversationSpec.ConversationRoleUser, prompt)
+	if err != nil {
+		return err
+	}
+	assistantMsg, err := newMessage(conversationSpec.ConversationRoleAssistant, content)
+	if err != nil {
+		return err
+	}
+	for _, rc := range resp.Body.ReasoningContents {
+		assistantMsg.ReasoningContents = append(assistantMsg.ReasoningContents,
+			conversationSpec.ReasoningContent{
+				Type:      conversationSpec.ReasoningContentType(rc.Type),
+				Text:      rc.Text,
+				Signature: rc.Signature,
+				Data:      rc.Data,
+			})
+	}
+	setProvenance(&assistantMsg, s.provider, s.modelParams.Name, resp.Body)
+	assistantMsg.ParentID = &userMsg.ID
+	s.convo.Messages = append(s.convo.Messages, userMsg, assistantMsg)
+	if s.convo.Title == "" {
+		s.convo.Title = titleFromPrompt(prompt)
+	}
+	return s.persist(ctx)
+}
+
+func (s *chatSession) persist(ctx context.Context) error {
+	if !s.save {
+		return nil
+	}
+	cc, err := s.app.getConversationStore()
+	if err != nil {
+		return err
+	}
+	select {
+	case title := <-s.titles:
+		s.convo.Title = title
+	default:
+	}
+	s.convo.ModifiedAt = time.Now()
+	s.convo.Model = &conversationSpec.ConversationModel{
+		Provider: string(s.provider),
+		Model:    string(s.modelParams.Name),
+	}
+	if s.isNew {
+		_, err = cc.PutConversation(ctx, &conversationSpec.PutConversationRequest{
+			ID: s.convo.ID,
+			Body: &conversationSpec.PutConversationRequestBody{
+				Title:      s.convo.Title,
+				CreatedAt:  s.convo.CreatedAt,
+				ModifiedAt: s.convo.ModifiedAt,
+				Messages:   s.convo.Messages,
+				Model:      s.convo.Model,
+			},
+		})
+		if err == nil {
+			s.isNew = false
+			go s.generateTitle(context.WithoutCancel(ctx), s.convo.Title)
+		}
+		return err
+	}
+	_, err = cc.PutMessagesToConversation(ctx, &conversationSpec.PutMessagesToConversationRequest{
+		ID: s.convo.ID,
+		Body: &conversationSpec.PutMessagesToConversationRequestBody{
+			Title:    s.convo.Title,
+			Messages: s.convo.Messages,
+			Model:    s.convo.Model,
+		},
+	})
+	return err
+}
+
+// generateTitle titles a new conversation in the background, the chat does not wait for it.
+func (s *chatSession) generateTitle(ctx context.Context, title string) {
+	cc, err := s.app.getConversationStore()
+	if err != nil {
+		return
+	}
+	resp, err := cc.GenerateConversationTitle(
+		ctx,
+		&conversationSpec.GenerateConversationTitleRequest{
+			ID:   s.convo.ID,
+			Body: &conversationSpec.GenerateConversationTitleRequestBody{Title: title},
+		},
+	)
+	if err != nil {
+		if !errors.Is(err, conversationstore.ErrTitlesDisabled) {
+			slog.Debug("Couldnt generate conversation title", "error", err)
+		}
+		return
+	}
+	s.titles <- resp.Body.Title
+}
+
+func newChatCmd(app *CLIApp) *cobra.Command {
+	flags := &modelFlags{}
+	var resumeID string
+	var noSave bool
+	cmd := &cobra.Command{
+		Use:   "chat",
+		Short: "Interactive streaming chat, type /exit or press Ctrl-D to quit",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			session, err := newChatSession(
+				ctx, app, flags, !noSave, cmd.OutOrStdout(), cmd.ErrOrStderr(),
+			)
+			if err != nil {
+				return err
+			}
+			if resumeID != "" {
+				convo, err := app.findConversation(ctx, resumeID)
+				if err != nil {
+					return err
+				}
+				session.convo = convo
+				session.isNew = false
+				if m := convo.Model; m != nil && flags.provider == "" && flags.model == "" {
+					if err := session.useModel(ctx, m.Provider, m.Model, flags.systemPrompt); err != nil {
+						fmt.Fprintf(cmd.ErrOrStderr(), "Using the default model: %v\n", err)
+					}
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Resuming %q with %d messages\n",
+					convo.Title, len(convo.Messages))
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Chatting with %s/%s\n",
+				session.provider, session.modelParams.Name)
+
+			return runChatLoop(ctx, session, cmd.InOrStdin(), cmd.ErrOrStderr())
+		},
+	}
+	flags.register(cmd)
+	cmd.Flags().StringVarP(&resumeID, "resume", "r", "", "ID of a conversation to continue")
+	cmd.Flags().BoolVar(&noSave, "no-save", false, "do not store the conversation")
+	return cmd
+}
+
+func runChatLoop(ctx context.Context, session *chatSession, in io.Reader, prompt io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for {
+		fmt.Fprint(prompt, "> ")
+		text, ok := readInput(scanner)
+		if !ok {
+			fmt.Fprintln(prompt)
+			return scanner.Err()
+		}
+		text = strings.TrimSpace(text)
+		switch text {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		}
+		if err := session.send(ctx, text); err != nil {
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			fmt.Fprintln(prompt, "Error:", err)
+		}
+	}
+}
+
+// readInput reads one prompt, lines ending with a backslash continue on the next line.
+func readInput(scanner *bufio.Scanner) (string, bool) {
+	var lines []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if cut, found := strings.CutSuffix(line, `\`); found {
+			lines = append(lines, cut)
+			continue
+		}
+		lines = append(lines, line)
+		return strings.Join(lines, "\n"), true
+	}
+	if len(lines) > 0 {
+		return strings.Join(lines, "\n"), true
+	}
+	return "", false
+}
+
+func newAskCmd(app *CLIApp) *cobra.Command {
+	flags := &modelFlags{}
+	var save bool
+	cmd := &cobra.Command{
+		Use:   "ask [prompt]",
+		Short: "One shot question, piped stdin is appended to the prompt",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			prompt := strings.Join(args, " ")
+			stdin, err := readPipedStdin(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			if stdin != "" {
+				prompt = strings.TrimSpace(prompt + "\n\n" + stdin)
+			}
+			if prompt == "" {
+				return errors.New("no prompt given as argument or on stdin")
+			}
+
+			session, err := newChatSession(
+				ctx, app, flags, save, cmd.OutOrStdout(), cmd.ErrOrStderr(),
+			)
+			if err != nil {
+				return err
+			}
+			return session.send(ctx, prompt)
+		},
+	}
+	flags.register(cmd)
+	cmd.Flags().BoolVar(&save, "save", false, "store the question and answer as a conversation")
+	return cmd
+}
+
+// readPipedStdin returns stdin if it is not a terminal.
+func readPipedStdin(in io.Reader) (string, error) {
+	if f, ok := in.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+			return "", nil
+		}
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// useModel switches the session to a model, as when a conversation is resumed.
+func (s *chatSession) useModel(ctx context.Context, provider, model, systemPrompt string) error {
+	providerName, modelParams, err := s.app.resolveModel(ctx, provider, model)
+	if err != nil {
+		return err
+	}
+	if systemPrompt != "" {
+		modelParams.SystemPrompt = systemPrompt
+	}
+	s.provider, s.modelParams = providerName, modelParams
+	return nil
+}
+
+// setProvenance records how an assistant message was produced.
+func setProvenance(
+	m *conversationSpec.ConversationMessage,
+	provider aiproviderSpec.ProviderName,
+	model aiproviderSpec.ModelName,
+	resp *aiproviderAPI.CompletionResponse,
+) {
+	m.Provider = string(provider)
+	m.Model = string(model)
+	if resp == nil {
+		return
+	}
+	m.ModelParams = resp.ModelParams
+	if u := resp.Usage; u != nil {
+		m.Usage = &conversationSpec.MessageUsage{
+			InputTokens:      u.InputTokens,
+			OutputTokens:     u.OutputTokens,
+			ReasoningTokens:  u.ReasoningTokens,
+			CacheReadTokens:  u.CacheReadTokens,
+			CacheWriteTokens: u.CacheWriteTokens,
+			TotalTokens:      u.TotalTokens,
+		}
+	}
+	m.Cost = resp.Cost
+	if t := resp.Timing; t != nil {
+		latency := t.LatencyMs
+		m.LatencyMs = &latency
+		m.TimeToFirstTokenMs = t.TimeToFirstTokenMs
+	}
+	m.FinishReason = resp.FinishReason
+	m.RequestID = resp.RequestID
+}
+
+func newMessage(
+	role conversationSpec.ConversationRoleEnum,
+	content string,
+) (conversationSpec.ConversationMessage, error) {
+	u, err := uuid.NewV7()
+	if err != nil {
+		return conversationSpec.ConversationMessage{}, err
+	}
+	now := time.Now()
+	return conversationSpec.ConversationMessage{
+		ID:        u.String(),
+		CreatedAt: &now,
+		Role:      role,
+		Content:   content,
+	}, nil
+}
+
+func toPrevMessages(
+	messages []conversationSpec.ConversationMessage,
+) []aiproviderSpec.ChatCompletionRequestMessage {
+	prev := make([]aiproviderSpec.ChatCompletionRequestMessage, 0, len(messages))
+	for _, m := range messages {
+		var role aiproviderSpec.ChatCompletionRoleEnum
+		switch m.Role {
+		case conversationSpec.ConversationRoleUser:
+			role = aiproviderSpec.User
+		case conversationSpec.ConversationRoleAssistant:
+			role = aiproviderSpec.Assistant
+		case conversationSpec.ConversationRoleSystem:
+			role = aiproviderSpec.System
+		default:
+			continue
+		}
+		content := m.Content
+		msg := aiproviderSpec.ChatCompletionRequestMessage{
+			Role:    role,
+			Content: &content,
+		}
+		for _, rc := range m.ReasoningContents {
+			msg.ReasoningContents = append(msg.ReasoningContents, aiproviderSpec.ReasoningContent{
+				Type:      aiproviderSpec.ReasoningContentType(rc.Type),
+				Text:      rc.Text,
+				Signature: rc.Signature,
+				Data:      rc.Data,
+			})
+		}
+		prev = append(prev, msg)
+	}
+	return prev
+}
+
+func titleFromPrompt(prompt string) string {
+	title := strings.Join(strings.Fields(prompt), " ")
+	if r := []rune(title); len(r) > maxTitleLength {
+		title = string(r[:maxTitleLength])
+	}
+	if title == "" {
+		title = "New conversation"
+	}
+	return title
+}
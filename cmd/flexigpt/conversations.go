@@ -0,0 +1,553 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	conversationSpec "github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/spf13/cobra"
+)
+
+func newConversationsCmd(app *CLIApp) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "conversations",
+		Aliases: []string{"conv"},
+		Short:   "List, search, show, organise, rename, export, import and delete stored conversations",
+	}
+	cmd.AddCommand(
+		newConversationsListCmd(app),
+		newConversationsSearchCmd(app),
+		newConversationsShowCmd(app),
+		newConversationsExportCmd(app),
+		newConversationsImportCmd(app),
+		newConversationsMetaCmd(app),
+		newConversationsRenameCmd(app),
+		newConversationsTitleCmd(app),
+		newConversationsDeleteCmd(app),
+		newConversationsTrashCmd(app),
+	)
+	return cmd
+}
+
+func newConversationsListCmd(app *CLIApp) *cobra.Command {
+	var (
+		limit  int
+		filter conversationSpec.ConversationFilter
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List conversations, pinned and then newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc, err := app.getConversationStore()
+			if err != nil {
+				return err
+			}
+			items := []conversationSpec.ConversationItem{}
+			token := ""
+			for len(items) < limit {
+				resp, err := cc.ListConversations(
+					cmd.Context(),
+					&conversationSpec.ListConversationsRequest{
+						Token:              token,
+						ConversationFilter: filter,
+					},
+				)
+				if err != nil {
+					return err
+				}
+				items = append(items, resp.Body.ConversationItems...)
+				if resp.Body.NextPageToken == nil || *resp.Body.NextPageToken == "" {
+					break
+				}
+				token = *resp.Body.NextPageToken
+			}
+			if len(items) > limit {
+				items = items[:limit]
+			}
+			return printConversationItems(cmd.OutOrStdout(), items)
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "max number of conversations to list")
+	addConversationFilterFlags(cmd, &filter)
+	return cmd
+}
+
+func newConversationsSearchCmd(app *CLIApp) *cobra.Command {
+	var (
+		limit    int
+		from, to string
+		roles    []string
+		req      conversationSpec.SearchConversationsRequest
+	)
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Full text search over conversations",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc, err := app.getConversationStore()
+			if err != nil {
+				return err
+			}
+			req.Query = args[0]
+			req.PageSize = limit
+			for _, r := range roles {
+				req.Roles = append(req.Roles, conversationSpec.ConversationRoleEnum(r))
+			}
+			if req.CreatedFrom, err = parseDateFlag("from", from); err != nil {
+				return err
+			}
+			if req.CreatedTo, err = parseDateFlag("to", to); err != nil {
+				return err
+			}
+			resp, err := cc.SearchConversations(cmd.Context(), &req)
+			if err != nil {
+				return err
+			}
+			return printSearchHits(cmd.OutOrStdout(), resp.Body.ConversationItems)
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "max number of results")
+	cmd.Flags().
+		StringVar((*string)(&req.Mode), "mode", "any", "how query words match: any, all or phrase")
+	cmd.Flags().StringSliceVar(&roles, "role", nil, "only match messages of these roles, e.g. user")
+	cmd.Flags().
+		StringVar(&from, "from", "", "only conversations created on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "only conversations created before this date (YYYY-MM-DD)")
+	cmd.Flags().
+		StringVar(&req.Provider, "provider", "", "only conversations with replies from this provider")
+	cmd.Flags().
+		StringVar(&req.Model, "model", "", "only conversations with replies from this model")
+	addConversationFilterFlags(cmd, &req.ConversationFilter)
+	return cmd
+}
+
+// parseDateFlag parses a YYYY-MM-DD flag in local time, empty is the zero time.
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s date %q, want YYYY-MM-DD", name, value)
+	}
+	return t, nil
+}
+
+func addConversationFilterFlags(cmd *cobra.Command, filter *conversationSpec.ConversationFilter) {
+	cmd.Flags().StringSliceVar(&filter.Tags, "tag", nil, "only conversations with all these tags")
+	cmd.Flags().StringVar(&filter.Folder, "folder", "", "only conversations in this folder")
+	cmd.Flags().BoolVar(&filter.Pinned, "pinned", false, "only pinned conversations")
+	cmd.Flags().BoolVar(&filter.Starred, "starred", false, "only starred conversations")
+}
+
+func newConversationsShowCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Print a conversation",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			convo, err := app.findConversation(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "# %s\n%s\n", convo.Title, convo.CreatedAt.Format(time.RFC3339))
+			if m := convo.Model; m != nil {
+				fmt.Fprintf(out, "Model: %s/%s\n", m.Provider, m.Model)
+			}
+			for _, m := range convo.Messages {
+				fmt.Fprintf(out, "\n[%s]\n%s\n", messageHeader(m), m.Content)
+			}
+			return nil
+		},
+	}
+}
+
+func newConversationsExportCmd(app *CLIApp) *cobra.Command {
+	var (
+		outPath string
+		format  string
+		body    conversationSpec.ExportConversationsRequestBody
+	)
+	cmd := &cobra.Command{
+		Use:   "export <id>",
+		Short: "Export a conversation as JSON, Markdown, HTML or fine-tuning JSONL",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			convo, err := app.findConversation(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			var data []byte
+			if format == "json" {
+				if data, err = json.MarshalIndent(convo, "", "  "); err != nil {
+					return err
+				}
+				data = append(data, '\n')
+			} else {
+				cc, err := app.getConversationStore()
+				if err != nil {
+					return err
+				}
+				body.Format = conversationSpec.ExportFormat(format)
+				body.Conversations = []conversationSpec.ConversationRef{
+					{ID: convo.ID, Title: convo.Title},
+				}
+				resp, err := cc.ExportConversations(
+					cmd.Context(),
+					&conversationSpec.ExportConversationsRequest{Body: &body},
+				)
+				if err != nil {
+					return err
+				}
+				data = resp.Body
+			}
+			if outPath == "" || outPath == "-" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+			return os.WriteFile(outPath, data, 0o600)
+		},
+	}
+	cmd.Flags().StringVarP(&outPath, "output", "o", "", "output file, defaults to stdout")
+	cmd.Flags().
+		StringVarP(&format, "format", "f", "json", "json, markdown, html or jsonl")
+	cmd.Flags().BoolVar(&body.IncludeReasoning, "reasoning", false, "include model reasoning")
+	cmd.Flags().BoolVar(&body.IncludeSystemPrompts, "system", false, "include system prompts")
+	cmd.Flags().BoolVar(&body.IncludeDetails, "details", false, "include message details")
+	return cmd
+}
+
+func newConversationsImportCmd(app *CLIApp) *cobra.Command {
+	var (
+		source string
+		body   conversationSpec.ImportConversationsRequestBody
+	)
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import conversations from a ChatGPT or Claude.ai data export",
+		Long: "Import conversations from a ChatGPT or Claude.ai data export zip file " +
+			"or its conversations.json. Conversations imported before are skipped.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc, err := app.getConversationStore()
+			if err != nil {
+				return err
+			}
+			body.FilePath = args[0]
+			body.Source = conversationSpec.ImportSource(source)
+			resp, err := cc.ImportConversations(
+				cmd.Context(),
+				&conversationSpec.ImportConversationsRequest{Body: &body},
+			)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "imported %d, skipped %d, failed %d\n",
+				resp.Body.Imported, resp.Body.Skipped, len(resp.Body.Failed))
+			for _, f := range resp.Body.Failed {
+				fmt.Fprintf(out, "  %s: %s\n", f.Title, f.Error)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().
+		StringVar(&source, "source", "", "chatgpt or claude, detected from the file if not set")
+	cmd.Flags().
+		BoolVar(&body.Branches, "branches", false, "keep edited and regenerated branches")
+	return cmd
+}
+
+func newConversationsMetaCmd(app *CLIApp) *cobra.Command {
+	var meta conversationSpec.ConversationMeta
+	cmd := &cobra.Command{
+		Use:   "meta <id>",
+		Short: "Set the tags, folder, pinned and starred flags of a conversation",
+		Long:  "Set the tags, folder, pinned and starred flags of a conversation. Unset flags are kept.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			convo, err := app.findConversation(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			current := convo.ConversationMeta
+			flags := cmd.Flags()
+			if flags.Changed("tags") {
+				current.Tags = meta.Tags
+			}
+			if flags.Changed("folder") {
+				current.Folder = meta.Folder
+			}
+			if flags.Changed("pinned") {
+				current.Pinned = meta.Pinned
+			}
+			if flags.Changed("starred") {
+				current.Starred = meta.Starred
+			}
+			cc, err := app.getConversationStore()
+			if err != nil {
+				return err
+			}
+			_, err = cc.PutConversationMeta(
+				cmd.Context(),
+				&conversationSpec.PutConversationMetaRequest{ID: convo.ID, Body: &current},
+			)
+			return err
+		},
+	}
+	cmd.Flags().StringSliceVar(&meta.Tags, "tags", nil, "tags of the conversation, empty to clear")
+	cmd.Flags().StringVar(&meta.Folder, "folder", "", "folder of the conversation, empty to clear")
+	cmd.Flags().BoolVar(&meta.Pinned, "pinned", false, "pin the conversation")
+	cmd.Flags().BoolVar(&meta.Starred, "starred", false, "star the conversation")
+	return cmd
+}
+
+func newConversationsRenameCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <id> <title>",
+		Short: "Change the title of a conversation",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			convo, err := app.findConversation(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			cc, err := app.getConversationStore()
+			if err != nil {
+				return err
+			}
+			_, err = cc.RenameConversation(
+				cmd.Context(),
+				&conversationSpec.RenameConversationRequest{
+					ID: convo.ID,
+					Body: &conversationSpec.RenameConversationRequestBody{
+						Title:    convo.Title,
+						NewTitle: args[1],
+					},
+				},
+			)
+			return err
+		},
+	}
+}
+
+func newConversationsTitleCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "title <id>",
+		Short: "Generate a title for a conversation with the title model from the settings",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			convo, err := app.findConversation(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			cc, err := app.getConversationStore()
+			if err != nil {
+				return err
+			}
+			resp, err := cc.GenerateConversationTitle(
+				cmd.Context(),
+				&conversationSpec.GenerateConversationTitleRequest{
+					ID: convo.ID,
+					Body: &conversationSpec.GenerateConversationTitleRequestBody{
+						Title: convo.Title,
+					},
+				},
+			)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), resp.Body.Title)
+			return nil
+		},
+	}
+}
+
+func newConversationsDeleteCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Move a conversation to the trash",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			convo, err := app.findConversation(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			cc, err := app.getConversationStore()
+			if err != nil {
+				return err
+			}
+			_, err = cc.DeleteConversation(
+				cmd.Context(),
+				&conversationSpec.DeleteConversationRequest{
+					ID:    convo.ID,
+					Title: convo.Title,
+				},
+			)
+			return err
+		},
+	}
+}
+
+func newConversationsTrashCmd(app *CLIApp) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore and purge deleted conversations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			items, err := listTrash(cmd, app)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tDELETED\tTITLE")
+			for _, it := range items {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", it.ID, it.DeletedAt.Format(time.DateTime), it.Title)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "restore <id>",
+			Short: "Restore a deleted conversation",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				ref, err := findTrashItem(cmd, app, args[0])
+				if err != nil {
+					return err
+				}
+				cc, err := app.getConversationStore()
+				if err != nil {
+					return err
+				}
+				_, err = cc.RestoreConversation(
+					cmd.Context(),
+					&conversationSpec.RestoreConversationRequest{ID: ref.ID, Title: ref.Title},
+				)
+				return err
+			},
+		},
+		&cobra.Command{
+			Use:   "purge [id...]",
+			Short: "Permanently delete conversations in the trash, all of them if no id is given",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				body := &conversationSpec.PurgeTrashRequestBody{}
+				for _, id := range args {
+					ref, err := findTrashItem(cmd, app, id)
+					if err != nil {
+						return err
+					}
+					body.Conversations = append(body.Conversations, ref)
+				}
+				cc, err := app.getConversationStore()
+				if err != nil {
+					return err
+				}
+				resp, err := cc.PurgeTrash(
+					cmd.Context(),
+					&conversationSpec.PurgeTrashRequest{Body: body},
+				)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "purged %d\n", resp.Body.Purged)
+				return nil
+			},
+		},
+	)
+	return cmd
+}
+
+func listTrash(cmd *cobra.Command, app *CLIApp) ([]conversationSpec.TrashItem, error) {
+	cc, err := app.getConversationStore()
+	if err != nil {
+		return nil, err
+	}
+	items := []conversationSpec.TrashItem{}
+	token := ""
+	for {
+		resp, err := cc.ListTrash(cmd.Context(), &conversationSpec.ListTrashRequest{Token: token})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, resp.Body.TrashItems...)
+		if resp.Body.NextPageToken == nil || *resp.Body.NextPageToken == "" {
+			return items, nil
+		}
+		token = *resp.Body.NextPageToken
+	}
+}
+
+func findTrashItem(
+	cmd *cobra.Command,
+	app *CLIApp,
+	id string,
+) (conversationSpec.ConversationRef, error) {
+	items, err := listTrash(cmd, app)
+	if err != nil {
+		return conversationSpec.ConversationRef{}, err
+	}
+	for _, it := range items {
+		if it.ID == id {
+			return conversationSpec.ConversationRef{ID: it.ID, Title: it.Title}, nil
+		}
+	}
+	return conversationSpec.ConversationRef{}, fmt.Errorf("conversation %q not in the trash", id)
+}
+
+// messageHeader is the role of a message followed by how it was produced, if known.
+func messageHeader(m conversationSpec.ConversationMessage) string {
+	parts := []string{string(m.Role)}
+	if m.Model != "" {
+		parts = append(parts, m.Provider+"/"+m.Model)
+	}
+	if m.Usage != nil {
+		parts = append(parts, fmt.Sprintf("%d tokens", m.Usage.TotalTokens))
+	}
+	if m.Cost != nil {
+		parts = append(parts, fmt.Sprintf("$%.4f", *m.Cost))
+	}
+	if m.LatencyMs != nil {
+		parts = append(parts, (time.Duration(*m.LatencyMs) * time.Millisecond).String())
+	}
+	if m.FinishReason != "" {
+		parts = append(parts, m.FinishReason)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func printConversationItems(out io.Writer, items []conversationSpec.ConversationItem) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tTITLE")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", item.ID, item.CreatedAt.Format(time.DateTime), item.Title)
+	}
+	return w.Flush()
+}
+
+// printSearchHits prints the hits with their snippet, matched words are in brackets.
+func printSearchHits(out io.Writer, hits []conversationSpec.ConversationSearchHit) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tTITLE\tSNIPPET")
+	for _, h := range hits {
+		var snippet strings.Builder
+		for _, p := range h.Snippet {
+			if p.Match {
+				snippet.WriteString("[" + p.Text + "]")
+			} else {
+				snippet.WriteString(p.Text)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", h.ID, h.CreatedAt.Format(time.DateTime), h.Title,
+			strings.Join(strings.Fields(snippet.String()), " "))
+	}
+	return w.Flush()
+}
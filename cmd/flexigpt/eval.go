@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider"
+	"github.com/ppipada/flexigpt-app/pkg/evals"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+	"github.com/spf13/cobra"
+)
+
+func newEvalCmd(app *CLIApp) *cobra.Command {
+	var (
+		baselinePath   string
+		outputPath     string
+		replayPath     string
+		updateBaseline bool
+	)
+	cmd := &cobra.Command{
+		Use:   "eval <suite.json>",
+		Short: "Run a prompt regression suite and check its assertions",
+		Long: "Run a prompt regression suite and check its assertions.\n" +
+			"With --baseline the report is compared against a stored report, and the command fails\n" +
+			"on regressions instead of on failed cases. With --replay no provider is called, outputs\n" +
+			"are taken from a stored report, which is how suites run in CI.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			suite, err := evals.LoadSuite(args[0])
+			if err != nil {
+				return err
+			}
+
+			var completer promptrun.Completer
+			if replayPath != "" {
+				recorded, err := evals.LoadReport(replayPath)
+				if err != nil {
+					return err
+				}
+				completer = evals.NewReplayCompleter(recorded)
+			} else {
+				ps, err := app.getProviderSet(ctx)
+				if err != nil {
+					return err
+				}
+				completer = aiprovider.NewPromptCompleter(ps)
+			}
+
+			report, err := evals.NewRunner(completer).Run(ctx, suite)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if err := printEvalReport(out, report); err != nil {
+				return err
+			}
+			if outputPath != "" {
+				if err := evals.SaveReport(outputPath, report); err != nil {
+					return err
+				}
+			}
+
+			if baselinePath == "" {
+				if report.Failed > 0 {
+					return fmt.Errorf("%d of %d cases failed", report.Failed, len(report.Cases))
+				}
+				return nil
+			}
+			if updateBaseline {
+				return evals.SaveReport(baselinePath, report)
+			}
+			baseline, err := evals.LoadReport(baselinePath)
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(
+					out,
+					"No baseline at %s, saving this run as the baseline\n",
+					baselinePath,
+				)
+				return evals.SaveReport(baselinePath, report)
+			}
+			if err != nil {
+				return err
+			}
+			cmp := evals.Compare(baseline, report)
+			printEvalComparison(out, cmp)
+			if cmp.Regressions > 0 {
+				return fmt.Errorf("%d cases regressed against %s", cmp.Regressions, baselinePath)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().
+		StringVar(&baselinePath, "baseline", "", "report file to compare against, created if missing")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "file to save the report to")
+	cmd.Flags().StringVar(&replayPath, "replay", "", "report file to take outputs from")
+	cmd.Flags().BoolVar(&updateBaseline, "update-baseline", false, "save this run as the baseline")
+	return cmd
+}
+
+func printEvalReport(out io.Writer, report *evals.Report) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CASE\tRESULT\tLATENCY\tDETAILS")
+	for _, c := range report.Cases {
+		result, details := "pass", []string{}
+		if !c.Passed {
+			result = "FAIL"
+		}
+		if c.Error != "" {
+			details = append(details, "error: "+c.Error)
+		}
+		for _, a := range c.Assertions {
+			if !a.Passed {
+				details = append(details, fmt.Sprintf("%s: %s", a.Type, a.Message))
+			}
+		}
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%dms\t%s\n",
+			c.Name,
+			result,
+			c.LatencyMs,
+			strings.Join(details, "; "),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(
+		out,
+		"\n%s on %s/%s: %d passed, %d failed\n",
+		report.Suite,
+		report.Provider,
+		report.Model,
+		report.Passed,
+		report.Failed,
+	)
+	return err
+}
+
+func printEvalComparison(out io.Writer, cmp *evals.Comparison) {
+	if len(cmp.Diffs) == 0 {
+		fmt.Fprintln(out, "No changes against the baseline")
+		return
+	}
+	fmt.Fprintln(out, "\nChanges against the baseline:")
+	for _, d := range cmp.Diffs {
+		fmt.Fprintf(out, "  %s: %s\n", d.Name, d.Change)
+		for line := range strings.SplitSeq(strings.TrimSuffix(d.OutputDiff, "\n"), "\n") {
+			if line != "" {
+				fmt.Fprintf(out, "    %s\n", line)
+			}
+		}
+	}
+}
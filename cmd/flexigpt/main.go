@@ -0,0 +1,80 @@
+// Command flexigpt is a headless client for FlexiGPT.
+// It shares the settings and conversations directories of the desktop app,
+// so conversations started here show up in the app and the other way round.
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/cobra"
+)
+
+const appName = "FlexiGPT"
+
+// Options are the global flags of the cli.
+type Options struct {
+	SettingsDirPath string
+	DataDirPath     string
+	Debug           bool
+}
+
+func initSlog(debug bool) {
+	level := slog.LevelWarn
+	if debug {
+		level = slog.LevelDebug
+	}
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler))
+}
+
+func newRootCmd() *cobra.Command {
+	opts := &Options{}
+	app := &CLIApp{opts: opts}
+
+	rootCmd := &cobra.Command{
+		Use:           "flexigpt",
+		Short:         "Chat with AI providers from the terminal",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			initSlog(opts.Debug)
+		},
+	}
+	// Same paths as the desktop app.
+	rootCmd.PersistentFlags().StringVar(
+		&opts.SettingsDirPath,
+		"settings-dir",
+		filepath.Join(xdg.ConfigHome, strings.ToLower(appName)),
+		"path to directory of settings file",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.DataDirPath,
+		"data-dir",
+		filepath.Join(xdg.DataHome, strings.ToLower(appName)),
+		"path to app data directory, conversations and batch jobs are stored in its subdirectories",
+	)
+	rootCmd.PersistentFlags().BoolVar(&opts.Debug, "debug", false, "enable debug logs")
+
+	rootCmd.AddCommand(
+		newChatCmd(app),
+		newAskCmd(app),
+		newConversationsCmd(app),
+		newProvidersCmd(app),
+		newModelsCmd(app),
+		newBatchCmd(app),
+		newEvalCmd(app),
+	)
+	return rootCmd
+}
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
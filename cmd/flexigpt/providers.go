@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"text/tabwriter"
+
+	aiproviderCatalog "github.com/ppipada/flexigpt-app/pkg/aiprovider/catalog"
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/spf13/cobra"
+)
+
+func newProvidersCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "providers",
+		Short: "List providers from settings",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := app.getSettings(cmd.Context())
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PROVIDER\tENABLED\tCONFIGURED\tDEFAULT MODEL\t")
+			for _, name := range slices.Sorted(maps.Keys(settings.AISettings)) {
+				aiSetting := settings.AISettings[name]
+				marker := ""
+				if name == settings.App.DefaultProvider {
+					marker = "(default)"
+				}
+				fmt.Fprintf(
+					w,
+					"%s\t%t\t%t\t%s\t%s\n",
+					name,
+					aiSetting.IsEnabled,
+					aiSetting.APIKey != "",
+					aiSetting.DefaultModel,
+					marker,
+				)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newModelsCmd(app *CLIApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "models [provider]",
+		Short: "List models of a provider, defaults to the default provider",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := app.getSettings(cmd.Context())
+			if err != nil {
+				return err
+			}
+			providerName := settings.App.DefaultProvider
+			if len(args) == 1 {
+				providerName = aiproviderSpec.ProviderName(args[0])
+			}
+			aiSetting, ok := settings.AISettings[providerName]
+			if !ok {
+				return fmt.Errorf("unknown provider %q", providerName)
+			}
+
+			// Settings may hold models that are not inbuilt and the other way round.
+			models := map[aiproviderSpec.ModelName]bool{}
+			for name, ms := range aiSetting.ModelSettings {
+				models[name] = ms.IsEnabled
+			}
+			for name, md := range aiproviderCatalog.Default().ModelDefaults(providerName) {
+				if _, exists := models[name]; !exists {
+					models[name] = md.IsEnabled
+				}
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "MODEL\tENABLED\t")
+			for _, name := range slices.Sorted(maps.Keys(models)) {
+				marker := ""
+				if name == aiSetting.DefaultModel {
+					marker = "(default)"
+				}
+				fmt.Fprintf(w, "%s\t%t\t%s\n", name, models[name], marker)
+			}
+			return w.Flush()
+		},
+	}
+}
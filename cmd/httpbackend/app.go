@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/ppipada/flexigpt-app/pkg/batchjob"
 	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	"github.com/ppipada/flexigpt-app/pkg/openaiproxy"
 	"github.com/ppipada/flexigpt-app/pkg/settingstore"
+	settingSpec "github.com/ppipada/flexigpt-app/pkg/settingstore/spec"
 
 	"github.com/ppipada/flexigpt-app/pkg/aiprovider"
+	aiproviderCatalog "github.com/ppipada/flexigpt-app/pkg/aiprovider/catalog"
 	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
 )
 
@@ -45,8 +53,9 @@ func NewBackendApp(
 		defaultInbuiltProvider: defaultInbuiltProvider,
 	}
 	app.initSettingsStore()
-	app.initConversationStore()
+	app.initModelCatalog()
 	app.initProviderSet()
+	app.initConversationStore()
 	return app
 }
 
@@ -78,6 +87,17 @@ func (a *BackendApp) initSettingsStore() {
 	slog.Info("Settings created", "filepath", a.settingsFilePath)
 }
 
+// initModelCatalog loads the user overlay catalog and reloads it on changes.
+// An invalid overlay is logged and the inbuilt catalog is used.
+func (a *BackendApp) initModelCatalog() {
+	c := aiproviderCatalog.Default()
+	overlayPath := filepath.Join(a.settingsDirPath, aiproviderCatalog.OverlayFileName)
+	if err := c.SetOverlayFile(overlayPath); err != nil {
+		slog.Error("Couldnt load model catalog overlay", "Error", err)
+	}
+	go c.Watch(context.Background(), aiproviderCatalog.DefaultWatchInterval)
+}
+
 func (a *BackendApp) initConversationStore() {
 	if err := os.MkdirAll(a.conversationsDirPath, os.FileMode(0o770)); err != nil {
 		slog.Error(
@@ -93,6 +113,11 @@ func (a *BackendApp) initConversationStore() {
 	cc, err := conversationstore.NewConversationCollection(
 		a.conversationsDirPath,
 		conversationstore.WithFTS(true),
+		conversationstore.WithTitleGenerator(
+			aiprovider.NewPromptCompleter(a.providerSetAPI),
+			a.conversationTitleModel,
+		),
+		conversationstore.WithTrashRetentionGetter(a.trashRetention),
 	)
 	if err != nil {
 		slog.Error(
@@ -115,3 +140,71 @@ func (a *BackendApp) initProviderSet() {
 	}
 	a.providerSetAPI = p
 }
+
+// conversationTitleModel returns the title model from the current settings, nil if titles are off.
+func (a *BackendApp) conversationTitleModel(
+	ctx context.Context,
+) (*conversationstore.TitleModel, error) {
+	resp, err := a.settingStoreAPI.GetAllSettings(ctx, &settingSpec.GetAllSettingsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body == nil {
+		return nil, errors.New("got empty settings")
+	}
+	provider, params, ok := aiprovider.GetConversationTitleModelUsingSettings(resp.Body)
+	if !ok {
+		return nil, nil
+	}
+	return &conversationstore.TitleModel{
+		Provider:    provider,
+		ModelParams: params,
+		Language:    resp.Body.App.ConversationTitles.Language,
+	}, nil
+}
+
+// trashRetention returns how long deleted conversations stay in the trash.
+func (a *BackendApp) trashRetention(ctx context.Context) (time.Duration, error) {
+	resp, err := a.settingStoreAPI.GetAllSettings(ctx, &settingSpec.GetAllSettingsRequest{})
+	if err != nil {
+		return 0, err
+	}
+	if resp.Body == nil {
+		return 0, errors.New("got empty settings")
+	}
+	return resp.Body.App.TrashRetention(), nil
+}
+
+// initOpenAIProxy configures the providers from settings and returns the OpenAI compatible handler.
+func (a *BackendApp) initOpenAIProxy(logConversations bool) (*openaiproxy.Server, error) {
+	ctx := context.Background()
+	resp, err := a.settingStoreAPI.GetAllSettings(ctx, &settingSpec.GetAllSettingsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body == nil {
+		return nil, errors.New("got empty settings")
+	}
+	if err := aiprovider.InitProviderSetUsingSettings(ctx, a.providerSetAPI, resp.Body); err != nil {
+		return nil, fmt.Errorf("failed to initialize providers from settings: %w", err)
+	}
+
+	tokens, err := openaiproxy.NewTokenStore(proxyTokensFilePath(a.settingsDirPath))
+	if err != nil {
+		return nil, err
+	}
+	var opts []openaiproxy.Option
+	if logConversations {
+		opts = append(opts, openaiproxy.WithConversationLogger(a.logProxyConversation))
+	}
+	return openaiproxy.NewServer(&proxyBackend{app: a}, tokens, opts...)
+}
+
+// initBatchJobManager loads the batch jobs in dir. Jobs run through the shared provider set.
+func (a *BackendApp) initBatchJobManager(dir string) (*batchjob.Manager, error) {
+	return batchjob.NewManager(dir, aiprovider.NewPromptCompleter(a.providerSetAPI))
+}
+
+func proxyTokensFilePath(settingsDirPath string) string {
+	return filepath.Join(settingsDirPath, "proxy_tokens.json")
+}
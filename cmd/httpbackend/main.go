@@ -8,12 +8,14 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humago"
 	"github.com/danielgtaylor/huma/v2/humacli"
 	"github.com/ppipada/flexigpt-app/pkg/aiprovider"
+	"github.com/ppipada/flexigpt-app/pkg/batchjob"
 	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
 	"github.com/ppipada/flexigpt-app/pkg/logrotate"
 	"github.com/ppipada/flexigpt-app/pkg/settingstore"
@@ -23,12 +25,15 @@ import (
 
 // Options for the server cli.
 type Options struct {
-	Host                 string `doc:"Hostname to listen on."             default:"127.0.0.1"`
-	Port                 int    `doc:"Port to listen on"                  default:"8888"`
-	SettingsDirPath      string `doc:"path to directory of settings file"`
-	ConversationsDirPath string `doc:"path to conversations directory"`
-	LogsDirPath          string `doc:"path to logs directory"`
-	Debug                bool   `doc:"Enable debug logs"`
+	Host                  string `doc:"Hostname to listen on."                                                        default:"127.0.0.1"`
+	Port                  int    `doc:"Port to listen on"                                                             default:"8888"`
+	SettingsDirPath       string `doc:"path to directory of settings file"`
+	ConversationsDirPath  string `doc:"path to conversations directory"`
+	LogsDirPath           string `doc:"path to logs directory"`
+	Debug                 bool   `doc:"Enable debug logs"`
+	OpenAIProxy           bool   `doc:"Expose OpenAI compatible /v1 endpoints, needs a token from the tokens command"`
+	LogProxyConversations bool   `doc:"Store OpenAI proxy exchanges in the conversation store"`
+	BatchJobsDirPath      string `doc:"path to batch jobs directory, the batch jobs API is only exposed if set"`
 }
 
 func initSlog(logsDirPath string, debug bool) *logrotate.Writer {
@@ -63,33 +68,66 @@ func initSlog(logsDirPath string, debug bool) *logrotate.Writer {
 	return writer
 }
 
+func newServer(opts *Options) *http.Server {
+	router := http.NewServeMux()
+	api := humago.New(router, huma.DefaultConfig("FlexiGPTServer API", "1.0.0"))
+	app := NewBackendApp(
+		aiproviderConsts.ProviderNameOpenAI,
+		opts.SettingsDirPath,
+		opts.ConversationsDirPath,
+	)
+	settingstore.InitSettingStoreHandlers(api, app.settingStoreAPI)
+	conversationstore.InitConversationStoreHandlers(api, app.conversationStoreAPI)
+	aiprovider.InitProviderSetHandlers(api, app.providerSetAPI)
+	if opts.BatchJobsDirPath != "" {
+		manager, err := app.initBatchJobManager(opts.BatchJobsDirPath)
+		if err != nil {
+			slog.Error("Failed to initialize batch jobs", "Error", err)
+			panic("Failed to initialize batch jobs")
+		}
+		batchjob.InitBatchJobHandlers(api, manager)
+	}
+	if opts.OpenAIProxy {
+		proxy, err := app.initOpenAIProxy(opts.LogProxyConversations)
+		if err != nil {
+			slog.Error("Failed to initialize OpenAI proxy", "Error", err)
+			panic("Failed to initialize OpenAI proxy")
+		}
+		router.Handle("/v1/", proxy)
+	}
+	// Create the HTTP server.
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", opts.Host, opts.Port),
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
 func main() {
 	cli := humacli.New(func(hooks humacli.Hooks, opts *Options) {
 		log.Printf("Options are %+v\n", opts)
-		writer := initSlog(opts.LogsDirPath, opts.Debug)
-		router := http.NewServeMux()
-		api := humago.New(router, huma.DefaultConfig("FlexiGPTServer API", "1.0.0"))
-		app := NewBackendApp(
-			aiproviderConsts.ProviderNameOpenAI,
-			opts.SettingsDirPath,
-			opts.ConversationsDirPath,
+		// The app is only created when serving, so that subcommands like tokens stay lightweight.
+		var (
+			mu     sync.Mutex
+			server *http.Server
+			writer *logrotate.Writer
 		)
-		settingstore.InitSettingStoreHandlers(api, app.settingStoreAPI)
-		conversationstore.InitConversationStoreHandlers(api, app.conversationStoreAPI)
-		aiprovider.InitProviderSetHandlers(api, app.providerSetAPI)
-		// Create the HTTP server.
-		server := http.Server{
-			Addr:              fmt.Sprintf("%s:%d", opts.Host, opts.Port),
-			Handler:           router,
-			ReadHeaderTimeout: 10 * time.Second,
-		}
 		hooks.OnStart(func() {
+			mu.Lock()
+			writer = initSlog(opts.LogsDirPath, opts.Debug)
+			server = newServer(opts)
+			mu.Unlock()
 			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 				log.Fatalf("listen: %s\n", err)
 			}
 		})
 
 		hooks.OnStop(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if server == nil {
+				return
+			}
 			// Gracefully shutdown your server here.
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
@@ -98,5 +136,6 @@ func main() {
 		})
 	})
 
+	cli.Root().AddCommand(newTokensCmd())
 	cli.Run()
 }
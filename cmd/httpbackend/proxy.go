@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider"
+	aiproviderAPI "github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	aiproviderCatalog "github.com/ppipada/flexigpt-app/pkg/aiprovider/catalog"
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	conversationSpec "github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/openaiproxy"
+	settingSpec "github.com/ppipada/flexigpt-app/pkg/settingstore/spec"
+)
+
+const maxProxyTitleLength = 64
+
+// proxyBackend routes OpenAI proxy requests to the provider set, using model presets from settings.
+type proxyBackend struct {
+	app *BackendApp
+}
+
+func (b *proxyBackend) getSettings(ctx context.Context) (*settingSpec.SettingsSchema, error) {
+	resp, err := b.app.settingStoreAPI.GetAllSettings(ctx, &settingSpec.GetAllSettingsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body == nil {
+		return nil, errors.New("got empty settings")
+	}
+	return resp.Body, nil
+}
+
+func (b *proxyBackend) Complete(
+	ctx context.Context,
+	req *openaiproxy.CompletionRequest,
+) (*openaiproxy.CompletionResult, error) {
+	settings, err := b.getSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aiSetting, ok := settings.AISettings[req.Provider]
+	if !ok || !aiSetting.IsEnabled {
+		return nil, fmt.Errorf("%w: provider %q is not enabled", openaiproxy.ErrModelNotFound,
+			req.Provider)
+	}
+
+	params := aiprovider.GetModelParamsUsingSettings(req.Provider, req.ModelName, &aiSetting)
+	params.Stream = params.Stream && req.Stream
+	if req.SystemPrompt != nil {
+		params.SystemPrompt = *req.SystemPrompt
+	}
+	if req.Temperature != nil {
+		params.Temperature = req.Temperature
+	}
+	if req.MaxTokens != nil {
+		params.MaxOutputLength = *req.MaxTokens
+	}
+
+	body := &aiproviderAPI.FetchCompletionRequestBody{
+		Provider:     req.Provider,
+		Prompt:       req.Prompt,
+		ModelParams:  params,
+		PrevMessages: req.PrevMessages,
+	}
+	if params.Stream {
+		body.OnStreamData = req.OnStreamData
+	}
+	resp, err := b.app.providerSetAPI.FetchCompletion(
+		ctx,
+		&aiproviderAPI.FetchCompletionRequest{Body: body},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body == nil {
+		return nil, errors.New("got empty completion response")
+	}
+	if resp.Body.ErrorDetails != nil {
+		return nil, errors.New(resp.Body.ErrorDetails.Message)
+	}
+
+	result := &openaiproxy.CompletionResult{}
+	if resp.Body.RespContent != nil {
+		result.Content = *resp.Body.RespContent
+	}
+	if u := resp.Body.Usage; u != nil {
+		result.Usage = &openaiproxy.Usage{
+			PromptTokens:     u.InputTokens,
+			CompletionTokens: u.OutputTokens,
+			TotalTokens:      u.TotalTokens,
+		}
+	}
+	return result, nil
+}
+
+// ListModels lists enabled models of enabled providers. Providers are not filtered on an API key,
+// local ones like llama.cpp work without.
+func (b *proxyBackend) ListModels(ctx context.Context) ([]openaiproxy.Model, error) {
+	settings, err := b.getSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	models := []openaiproxy.Model{}
+	for _, providerName := range slices.Sorted(maps.Keys(settings.AISettings)) {
+		aiSetting := settings.AISettings[providerName]
+		if !aiSetting.IsEnabled {
+			continue
+		}
+		// Settings may hold models that are not inbuilt and the other way round.
+		enabled := map[aiproviderSpec.ModelName]bool{}
+		for name, ms := range aiSetting.ModelSettings {
+			enabled[name] = ms.IsEnabled
+		}
+		for name, md := range aiproviderCatalog.Default().ModelDefaults(providerName) {
+			if _, exists := enabled[name]; !exists {
+				enabled[name] = md.IsEnabled
+			}
+		}
+		for _, modelName := range slices.Sorted(maps.Keys(enabled)) {
+			if !enabled[modelName] {
+				continue
+			}
+			models = append(models, openaiproxy.Model{
+				ID:      openaiproxy.ModelID(providerName, modelName),
+				OwnedBy: string(providerName),
+			})
+		}
+	}
+	return models, nil
+}
+
+// logProxyConversation stores each proxied exchange as a new conversation.
+func (a *BackendApp) logProxyConversation(
+	ctx context.Context,
+	req *openaiproxy.CompletionRequest,
+	result *openaiproxy.CompletionResult,
+) error {
+	u, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	messages := make([]conversationSpec.ConversationMessage, 0, len(req.PrevMessages)+2)
+	addMessage := func(role conversationSpec.ConversationRoleEnum, content string) error {
+		mu, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		messages = append(messages, conversationSpec.ConversationMessage{
+			ID:        mu.String(),
+			CreatedAt: &now,
+			Role:      role,
+			Content:   content,
+		})
+		return nil
+	}
+	for _, m := range req.PrevMessages {
+		role := conversationSpec.ConversationRoleUser
+		switch m.Role {
+		case aiproviderSpec.Assistant:
+			role = conversationSpec.ConversationRoleAssistant
+		case aiproviderSpec.System, aiproviderSpec.Developer:
+			role = conversationSpec.ConversationRoleSystem
+		}
+		content := ""
+		if m.Content != nil {
+			content = *m.Content
+		}
+		if err := addMessage(role, content); err != nil {
+			return err
+		}
+	}
+	if err := addMessage(conversationSpec.ConversationRoleUser, req.Prompt); err != nil {
+		return err
+	}
+	if err := addMessage(conversationSpec.ConversationRoleAssistant, result.Content); err != nil {
+		return err
+	}
+	reply := &messages[len(messages)-1]
+	reply.Provider = string(req.Provider)
+	reply.Model = string(req.ModelName)
+	if u := result.Usage; u != nil {
+		reply.Usage = &conversationSpec.MessageUsage{
+			InputTokens:  u.PromptTokens,
+			OutputTokens: u.CompletionTokens,
+			TotalTokens:  u.TotalTokens,
+		}
+	}
+
+	title := strings.Join(strings.Fields(req.Prompt), " ")
+	if r := []rune(title); len(r) > maxProxyTitleLength {
+		title = string(r[:maxProxyTitleLength])
+	}
+	_, err = a.conversationStoreAPI.PutConversation(ctx, &conversationSpec.PutConversationRequest{
+		ID: u.String(),
+		Body: &conversationSpec.PutConversationRequestBody{
+			Title:      title,
+			CreatedAt:  now,
+			ModifiedAt: now,
+			Messages:   messages,
+			Model: &conversationSpec.ConversationModel{
+				Provider: string(req.Provider),
+				Model:    string(req.ModelName),
+			},
+		},
+	})
+	return err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humacli"
+	"github.com/ppipada/flexigpt-app/pkg/openaiproxy"
+	"github.com/spf13/cobra"
+)
+
+func openTokenStore(opts *Options) (*openaiproxy.TokenStore, error) {
+	if opts.SettingsDirPath == "" {
+		return nil, errors.New("settings dir path is required")
+	}
+	if err := os.MkdirAll(opts.SettingsDirPath, os.FileMode(0o770)); err != nil {
+		return nil, fmt.Errorf("failed to create settings dir: %w", err)
+	}
+	return openaiproxy.NewTokenStore(proxyTokensFilePath(opts.SettingsDirPath))
+}
+
+// runWithTokenStore adapts a token command to humacli options, errors exit with status 1.
+func runWithTokenStore(
+	f func(cmd *cobra.Command, args []string, tokens *openaiproxy.TokenStore) error,
+) func(*cobra.Command, []string) {
+	return humacli.WithOptions(func(cmd *cobra.Command, args []string, opts *Options) {
+		tokens, err := openTokenStore(opts)
+		if err == nil {
+			err = f(cmd, args, tokens)
+		}
+		if err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+			os.Exit(1)
+		}
+	})
+}
+
+func newTokensCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage access tokens of the OpenAI compatible proxy",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "issue <name>",
+			Short: "Issue a new token, it is printed only once",
+			Args:  cobra.ExactArgs(1),
+			Run: runWithTokenStore(
+				func(cmd *cobra.Command, args []string, tokens *openaiproxy.TokenStore) error {
+					token, err := tokens.Issue(args[0])
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(cmd.OutOrStdout(), token)
+					return nil
+				},
+			),
+		},
+		&cobra.Command{
+			Use:   "list",
+			Short: "List issued tokens",
+			Args:  cobra.NoArgs,
+			Run: runWithTokenStore(
+				func(cmd *cobra.Command, args []string, tokens *openaiproxy.TokenStore) error {
+					infos, err := tokens.List()
+					if err != nil {
+						return err
+					}
+					w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "NAME\tCREATED\t")
+					for _, info := range infos {
+						fmt.Fprintf(w, "%s\t%s\t\n", info.Name, info.CreatedAt.Format(time.RFC3339))
+					}
+					return w.Flush()
+				},
+			),
+		},
+		&cobra.Command{
+			Use:   "revoke <name>",
+			Short: "Revoke a token",
+			Args:  cobra.ExactArgs(1),
+			Run: runWithTokenStore(
+				func(cmd *cobra.Command, args []string, tokens *openaiproxy.TokenStore) error {
+					return tokens.Revoke(args[0])
+				},
+			),
+		},
+	)
+	return cmd
+}
@@ -26,7 +26,11 @@ function convertConversationToChatMessages(
 	}
 	const chatMessages: ChatCompletionRequestMessage[] = [];
 	conversationMessages.forEach(convoMsg => {
-		chatMessages.push({ role: roleMap[convoMsg.role], content: convoMsg.content });
+		chatMessages.push({
+			role: roleMap[convoMsg.role],
+			content: convoMsg.content,
+			reasoningContents: convoMsg.reasoningContents,
+		});
 	});
 	return chatMessages;
 }
@@ -63,6 +67,14 @@ function parseAPIResponse(convoMessage: ConversationMessage, providerResp: Compl
 
 	convoMessage.content = respContent;
 	convoMessage.details = respDetails;
+	convoMessage.reasoningContents = providerResp?.reasoningContents;
+	convoMessage.modelParams = providerResp?.modelParams;
+	convoMessage.usage = providerResp?.usage;
+	convoMessage.cost = providerResp?.cost;
+	convoMessage.latencyMs = providerResp?.timing?.latencyMs;
+	convoMessage.timeToFirstTokenMs = providerResp?.timing?.timeToFirstTokenMs;
+	convoMessage.finishReason = providerResp?.finishReason;
+	convoMessage.requestID = providerResp?.requestID;
 	return { responseMessage: convoMessage, requestDetails: requestDetails };
 }
 
@@ -96,6 +108,8 @@ export async function GetCompletionMessage(
 	messages?: Array<ConversationMessage>,
 	onStreamData?: (data: string) => void
 ): Promise<{ responseMessage: ConversationMessage | undefined; requestDetails: string | undefined }> {
+	convoMessage.provider = provider;
+	convoMessage.model = modelParams.name;
 	try {
 		const allMessages = convertConversationToChatMessages(messages);
 		const promptMsg = allMessages.pop();
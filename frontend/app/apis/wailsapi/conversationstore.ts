@@ -2,16 +2,24 @@ import type {
 	Conversation,
 	ConversationItem,
 	ConversationMessage,
+	ConversationModel,
+	ConversationSearchHit,
+	ConversationSearchOptions,
 	IConversationStoreAPI,
 } from '@/models/conversationmodel';
 
 import {
+	AppendMessages,
 	DeleteConversation,
+	DeleteMessage,
+	GenerateConversationTitle,
 	GetConversation,
 	ListConversations,
 	PutConversation,
 	PutMessagesToConversation,
+	RenameConversation,
 	SearchConversations,
+	UpdateMessage,
 } from '@/apis/wailsjs/go/main/ConversationCollectionWrapper';
 import type { spec as wailsSpec } from '@/apis/wailsjs/go/models';
 
@@ -19,7 +27,7 @@ import type { spec as wailsSpec } from '@/apis/wailsjs/go/models';
  * @public
  */
 export class WailsConversationStoreAPI implements IConversationStoreAPI {
-	async putConversation(conversation: Conversation): Promise<void> {
+	async putConversation(conversation: Conversation): Promise<number> {
 		const req = {
 			ID: conversation.id,
 			Body: {
@@ -27,22 +35,75 @@ export class WailsConversationStoreAPI implements IConversationStoreAPI {
 				createdAt: conversation.createdAt,
 				modifiedAt: conversation.modifiedAt,
 				messages: conversation.messages as wailsSpec.ConversationMessage[],
+				model: conversation.model,
+				version: conversation.version,
 			} as wailsSpec.PutConversationRequestBody,
 		};
 
-		await PutConversation(req as wailsSpec.PutConversationRequest);
+		const resp = await PutConversation(req as wailsSpec.PutConversationRequest);
+		return resp.Body?.version ?? 0;
 	}
 
-	async putMessagesToConversation(id: string, title: string, messages: ConversationMessage[]): Promise<void> {
+	async putMessagesToConversation(
+		id: string,
+		title: string,
+		messages: ConversationMessage[],
+		model?: ConversationModel,
+		version?: number
+	): Promise<number> {
 		const req = {
 			ID: id,
 			Body: {
 				title: title,
 				messages: messages as wailsSpec.ConversationMessage[],
+				model: model,
+				version: version,
 			} as wailsSpec.PutMessagesToConversationRequestBody,
 		};
 
-		await PutMessagesToConversation(req as wailsSpec.PutMessagesToConversationRequest);
+		const resp = await PutMessagesToConversation(req as wailsSpec.PutMessagesToConversationRequest);
+		return resp.Body?.version ?? 0;
+	}
+
+	async appendMessages(
+		id: string,
+		title: string,
+		version: number,
+		messages: ConversationMessage[],
+		model?: ConversationModel
+	): Promise<{ version: number; messages: ConversationMessage[] }> {
+		const req = {
+			ID: id,
+			Body: {
+				title: title,
+				version: version,
+				messages: messages as wailsSpec.ConversationMessage[],
+				model: model,
+			},
+		};
+		const resp = await AppendMessages(req as wailsSpec.AppendMessagesRequest);
+		return { version: resp.Body?.version ?? 0, messages: (resp.Body?.messages ?? []) as ConversationMessage[] };
+	}
+
+	async updateMessage(id: string, title: string, version: number, message: ConversationMessage): Promise<number> {
+		const req = {
+			ID: id,
+			MessageID: message.id,
+			Body: { title: title, version: version, message: message as wailsSpec.ConversationMessage },
+		};
+		const resp = await UpdateMessage(req as wailsSpec.UpdateMessageRequest);
+		return resp.Body?.version ?? 0;
+	}
+
+	async deleteMessage(
+		id: string,
+		title: string,
+		version: number,
+		messageID: string
+	): Promise<{ version: number; activeLeafID?: string }> {
+		const req = { ID: id, MessageID: messageID, Title: title, Version: version };
+		const resp = await DeleteMessage(req as wailsSpec.DeleteMessageRequest);
+		return { version: resp.Body?.version ?? 0, activeLeafID: resp.Body?.activeLeafID };
 	}
 
 	async deleteConversation(id: string, title: string): Promise<void> {
@@ -50,6 +111,24 @@ export class WailsConversationStoreAPI implements IConversationStoreAPI {
 		await DeleteConversation(req as wailsSpec.DeleteConversationRequest);
 	}
 
+	async renameConversation(id: string, title: string, newTitle: string): Promise<number> {
+		const req = { ID: id, Body: { title: title, newTitle: newTitle } };
+		const resp = await RenameConversation(req as wailsSpec.RenameConversationRequest);
+		return resp.Body?.version ?? 0;
+	}
+
+	async generateConversationTitle(id: string, title: string): Promise<{ title: string; version: number }> {
+		const req = { ID: id, Body: { title: title } };
+		const resp = await GenerateConversationTitle(req as wailsSpec.GenerateConversationTitleRequest);
+		return { title: resp.Body?.title ?? title, version: resp.Body?.version ?? 0 };
+	}
+
+	async suggestConversationTitle(id: string, title: string): Promise<string> {
+		const req = { ID: id, Body: { title: title, suggestOnly: true } };
+		const resp = await GenerateConversationTitle(req as wailsSpec.GenerateConversationTitleRequest);
+		return resp.Body?.title ?? title;
+	}
+
 	async getConversation(id: string, title: string): Promise<Conversation | null> {
 		const req = { ID: id, Title: title };
 		const c = await GetConversation(req as wailsSpec.GetConversationRequest);
@@ -65,10 +144,24 @@ export class WailsConversationStoreAPI implements IConversationStoreAPI {
 	async searchConversations(
 		query: string,
 		token?: string,
-		pageSize?: number
-	): Promise<{ conversations: ConversationItem[]; nextToken?: string }> {
-		const req = { Query: query, Token: token || '', PageSize: pageSize || 10 };
+		pageSize?: number,
+		options?: ConversationSearchOptions
+	): Promise<{ conversations: ConversationSearchHit[]; nextToken?: string }> {
+		const req = {
+			Query: query,
+			Token: token || '',
+			PageSize: pageSize || 10,
+			Mode: options?.mode,
+			Roles: options?.roles,
+			CreatedFrom: options?.createdFrom,
+			CreatedTo: options?.createdTo,
+			Provider: options?.provider,
+			Model: options?.model,
+		};
 		const resp = await SearchConversations(req as wailsSpec.SearchConversationsRequest);
-		return { conversations: resp.Body?.conversationItems as ConversationItem[], nextToken: resp.Body?.nextPageToken };
+		return {
+			conversations: resp.Body?.conversationItems as ConversationSearchHit[],
+			nextToken: resp.Body?.nextPageToken,
+		};
 	}
 }
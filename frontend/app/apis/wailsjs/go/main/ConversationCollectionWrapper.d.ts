@@ -2,8 +2,14 @@
 // This file is automatically generated. DO NOT EDIT
 import {spec} from '../models';
 
+export function AppendMessages(arg1:spec.AppendMessagesRequest):Promise<spec.AppendMessagesResponse>;
+
 export function DeleteConversation(arg1:spec.DeleteConversationRequest):Promise<spec.DeleteConversationResponse>;
 
+export function DeleteMessage(arg1:spec.DeleteMessageRequest):Promise<spec.DeleteMessageResponse>;
+
+export function GenerateConversationTitle(arg1:spec.GenerateConversationTitleRequest):Promise<spec.GenerateConversationTitleResponse>;
+
 export function GetConversation(arg1:spec.GetConversationRequest):Promise<spec.GetConversationResponse>;
 
 export function ListConversations(arg1:spec.ListConversationsRequest):Promise<spec.ListConversationsResponse>;
@@ -12,4 +18,8 @@ export function PutConversation(arg1:spec.PutConversationRequest):Promise<spec.P
 
 export function PutMessagesToConversation(arg1:spec.PutMessagesToConversationRequest):Promise<spec.PutMessagesToConversationResponse>;
 
+export function RenameConversation(arg1:spec.RenameConversationRequest):Promise<spec.RenameConversationResponse>;
+
 export function SearchConversations(arg1:spec.SearchConversationsRequest):Promise<spec.SearchConversationsResponse>;
+
+export function UpdateMessage(arg1:spec.UpdateMessageRequest):Promise<spec.UpdateMessageResponse>;
@@ -2,10 +2,22 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AppendMessages(arg1) {
+  return window['go']['main']['ConversationCollectionWrapper']['AppendMessages'](arg1);
+}
+
 export function DeleteConversation(arg1) {
   return window['go']['main']['ConversationCollectionWrapper']['DeleteConversation'](arg1);
 }
 
+export function DeleteMessage(arg1) {
+  return window['go']['main']['ConversationCollectionWrapper']['DeleteMessage'](arg1);
+}
+
+export function GenerateConversationTitle(arg1) {
+  return window['go']['main']['ConversationCollectionWrapper']['GenerateConversationTitle'](arg1);
+}
+
 export function GetConversation(arg1) {
   return window['go']['main']['ConversationCollectionWrapper']['GetConversation'](arg1);
 }
@@ -22,6 +34,14 @@ export function PutMessagesToConversation(arg1) {
   return window['go']['main']['ConversationCollectionWrapper']['PutMessagesToConversation'](arg1);
 }
 
+export function RenameConversation(arg1) {
+  return window['go']['main']['ConversationCollectionWrapper']['RenameConversation'](arg1);
+}
+
 export function SearchConversations(arg1) {
   return window['go']['main']['ConversationCollectionWrapper']['SearchConversations'](arg1);
 }
+
+export function UpdateMessage(arg1) {
+  return window['go']['main']['ConversationCollectionWrapper']['UpdateMessage'](arg1);
+}
@@ -1053,6 +1053,414 @@ export namespace spec {
 		    return a;
 		}
 	}
+	export class AppendMessagesRequestBody {
+	    title: string;
+	    version: number;
+	    messages: ConversationMessage[];
+	    model?: ConversationModel;
+	
+	    static createFrom(source: any = {}) {
+	        return new AppendMessagesRequestBody(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.version = source["version"];
+	        this.messages = this.convertValues(source["messages"], ConversationMessage);
+	        this.model = this.convertValues(source["model"], ConversationModel);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AppendMessagesRequest {
+	    ID: string;
+	    Body?: AppendMessagesRequestBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new AppendMessagesRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Body = this.convertValues(source["Body"], AppendMessagesRequestBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AppendMessagesResponseBody {
+	    version: number;
+	    messages: ConversationMessage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AppendMessagesResponseBody(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.messages = this.convertValues(source["messages"], ConversationMessage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AppendMessagesResponse {
+	    Body?: AppendMessagesResponseBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new AppendMessagesResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Body = this.convertValues(source["Body"], AppendMessagesResponseBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConversationModel {
+	    provider: string;
+	    model: string;
+	    presetID?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConversationModel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.presetID = source["presetID"];
+	    }
+	}
+	export class ConversationVersionBody {
+	    version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConversationVersionBody(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	    }
+	}
+	export class DeleteMessageRequest {
+	    ID: string;
+	    MessageID: string;
+	    Title: string;
+	    Version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeleteMessageRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.MessageID = source["MessageID"];
+	        this.Title = source["Title"];
+	        this.Version = source["Version"];
+	    }
+	}
+	export class DeleteMessageResponseBody {
+	    version: number;
+	    activeLeafID?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeleteMessageResponseBody(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.activeLeafID = source["activeLeafID"];
+	    }
+	}
+	export class DeleteMessageResponse {
+	    Body?: DeleteMessageResponseBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeleteMessageResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Body = this.convertValues(source["Body"], DeleteMessageResponseBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UpdateMessageRequestBody {
+	    title: string;
+	    version: number;
+	    message: ConversationMessage;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateMessageRequestBody(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.version = source["version"];
+	        this.message = this.convertValues(source["message"], ConversationMessage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UpdateMessageRequest {
+	    ID: string;
+	    MessageID: string;
+	    Body?: UpdateMessageRequestBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateMessageRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.MessageID = source["MessageID"];
+	        this.Body = this.convertValues(source["Body"], UpdateMessageRequestBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UpdateMessageResponse {
+	    Body?: ConversationVersionBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateMessageResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Body = this.convertValues(source["Body"], ConversationVersionBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GenerateConversationTitleRequestBody {
+	    title: string;
+	    suggestOnly?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateConversationTitleRequestBody(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.suggestOnly = source["suggestOnly"];
+	    }
+	}
+	export class GenerateConversationTitleRequest {
+	    ID: string;
+	    Body?: GenerateConversationTitleRequestBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateConversationTitleRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Body = this.convertValues(source["Body"], GenerateConversationTitleRequestBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GenerateConversationTitleResponseBody {
+	    title: string;
+	    version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateConversationTitleResponseBody(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.version = source["version"];
+	    }
+	}
+	export class GenerateConversationTitleResponse {
+	    Body?: GenerateConversationTitleResponseBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateConversationTitleResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Body = this.convertValues(source["Body"], GenerateConversationTitleResponseBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class GetConversationRequest {
 	    ID: string;
 	    Title: string;
@@ -1329,7 +1737,7 @@ export namespace spec {
 	}
 	
 	export class PutConversationResponse {
-	
+	    Body?: ConversationVersionBody;
 	
 	    static createFrom(source: any = {}) {
 	        return new PutConversationResponse(source);
@@ -1337,8 +1745,26 @@ export namespace spec {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	
+	        this.Body = this.convertValues(source["Body"], ConversationVersionBody);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class PutMessagesToConversationRequestBody {
 	    title: string;
@@ -1406,7 +1832,7 @@ export namespace spec {
 	}
 	
 	export class PutMessagesToConversationResponse {
-	
+	    Body?: ConversationVersionBody;
 	
 	    static createFrom(source: any = {}) {
 	        return new PutMessagesToConversationResponse(source);
@@ -1414,8 +1840,103 @@ export namespace spec {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Body = this.convertValues(source["Body"], ConversationVersionBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RenameConversationRequestBody {
+	    title: string;
+	    newTitle: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameConversationRequestBody(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.newTitle = source["newTitle"];
+	    }
+	}
+	export class RenameConversationRequest {
+	    ID: string;
+	    Body?: RenameConversationRequestBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameConversationRequest(source);
+	    }
 	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Body = this.convertValues(source["Body"], RenameConversationRequestBody);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RenameConversationResponse {
+	    Body?: ConversationVersionBody;
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameConversationResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Body = this.convertValues(source["Body"], ConversationVersionBody);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	
 	export class SearchConversationsRequest {
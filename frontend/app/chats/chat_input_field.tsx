@@ -27,6 +27,8 @@ interface ChatInputFieldProps {
 export interface ChatInputFieldHandle {
 	getChatOptions: () => ChatOptions;
 	focus: () => void;
+	// Selects the model a conversation was using, if it is still among the options.
+	selectModel: (provider: string, model: string) => void;
 }
 
 // Custom hook for handling form submission on Enter key press.
@@ -171,6 +173,12 @@ const ChatInputField = forwardRef<ChatInputFieldHandle, ChatInputFieldProps>(({
 				inputRef.current.focus();
 			}
 		},
+		selectModel: (provider: string, model: string) => {
+			const option = allOptions.find(o => o.provider === provider && o.name === model);
+			if (option) {
+				setSelectedModel(option);
+			}
+		},
 	}));
 
 	// Clamps temperature to [0, 1].
@@ -9,6 +9,25 @@ import { ConversationRoleEnum } from '@/models/conversationmodel';
 import ChatMessageContent from '@/chats/chat_message_content';
 import EditBox from '@/chats/chat_message_editbox';
 import ChatMessageFooterArea from '@/chats/chat_message_footer';
+import ChatMessageReasoning from '@/chats/chat_message_reasoning';
+
+// Short line about how an assistant message was produced, e.g. "openai/gpt-4o · 812 tokens · $0.0042 · 2.3s".
+function getMessageSummary(message: ConversationMessage): string {
+	const parts: string[] = [];
+	if (message.model) {
+		parts.push(message.provider ? `${message.provider}/${message.model}` : message.model);
+	}
+	if (message.usage) {
+		parts.push(`${message.usage.totalTokens} tokens`);
+	}
+	if (message.cost !== undefined) {
+		parts.push(`$${message.cost.toFixed(4)}`);
+	}
+	if (message.latencyMs !== undefined) {
+		parts.push(`${(message.latencyMs / 1000).toFixed(1)}s`);
+	}
+	return parts.join(' · ');
+}
 
 interface ChatMessageProps {
 	message: ConversationMessage;
@@ -64,14 +83,19 @@ const ChatMessageInner: FC<ChatMessageProps> = ({ message, onEdit, onResend, str
 						onDiscard={handleDiscard}
 					/>
 				) : (
-					<ChatMessageContent
-						content={message.content}
-						streamedText={streamedMessage}
-						isStreaming={!!streamedMessage}
-						isPending={isPending}
-						align={align}
-						renderAsMarkdown={!isUser}
-					/>
+					<>
+						{!isUser && !streamedMessage && message.reasoningContents && (
+							<ChatMessageReasoning reasoningContents={message.reasoningContents} align={align} />
+						)}
+						<ChatMessageContent
+							content={message.content}
+							streamedText={streamedMessage}
+							isStreaming={!!streamedMessage}
+							isPending={isPending}
+							align={align}
+							renderAsMarkdown={!isUser}
+						/>
+					</>
 				)}
 			</div>
 
@@ -93,6 +117,7 @@ const ChatMessageInner: FC<ChatMessageProps> = ({ message, onEdit, onResend, str
 						onEdit={handleEditClick}
 						onResend={onResend}
 						messageDetails={message.details ?? ''}
+						messageSummary={isUser ? '' : getMessageSummary(message)}
 						isStreaming={!!streamedMessage}
 					/>
 				)}
@@ -13,6 +13,7 @@ interface ChatMessageFooterAreaProps {
 	onEdit: () => void;
 	onResend: () => void;
 	messageDetails: string;
+	messageSummary: string;
 	isStreaming: boolean;
 }
 
@@ -22,6 +23,7 @@ const ChatMessageFooterArea: FC<ChatMessageFooterAreaProps> = ({
 	onEdit,
 	onResend,
 	messageDetails,
+	messageSummary,
 	isStreaming,
 }) => {
 	const [isExpanded, setIsExpanded] = useState(false);
@@ -62,6 +64,11 @@ const ChatMessageFooterArea: FC<ChatMessageFooterAreaProps> = ({
 						)}
 					</>
 				</div>
+				{!isStreaming && messageSummary && (
+					<div className="text-xs text-neutral/60 px-2 truncate" title={messageSummary}>
+						{messageSummary}
+					</div>
+				)}
 				{isStreaming && (
 					<div className="text-sm">
 						<div className="bg-transparent px-4 py-2 flex items-center">
@@ -0,0 +1,51 @@
+import type { FC } from 'react';
+import { useState } from 'react';
+
+import { FiChevronDown, FiChevronUp } from 'react-icons/fi';
+
+import { type ReasoningContent, ReasoningContentType } from '@/models/aiprovidermodel';
+
+import EnhancedMarkdown from '@/components/markdown_enhanced';
+
+interface ChatMessageReasoningProps {
+	reasoningContents: ReasoningContent[];
+	align: string;
+}
+
+// Collapsible thought process of an assistant message, hidden by default.
+const ChatMessageReasoning: FC<ChatMessageReasoningProps> = ({ reasoningContents, align }) => {
+	const [isExpanded, setIsExpanded] = useState(false);
+
+	const text = reasoningContents
+		.filter(rc => rc.type === ReasoningContentType.Thinking && rc.text)
+		.map(rc => rc.text)
+		.join('\n\n');
+	const hasRedacted = reasoningContents.some(rc => rc.type === ReasoningContentType.RedactedThinking);
+	if (!text && !hasRedacted) {
+		return null;
+	}
+
+	return (
+		<div className="bg-base-200 px-4 py-1 text-sm">
+			<button
+				className="btn btn-sm bg-transparent border-none flex items-center shadow-none px-0"
+				onClick={() => {
+					setIsExpanded(!isExpanded);
+				}}
+				aria-label={isExpanded ? 'Hide Thought Process' : 'Show Thought Process'}
+				title={isExpanded ? 'Hide Thought Process' : 'Show Thought Process'}
+			>
+				Thought process
+				{isExpanded ? <FiChevronUp size={16} /> : <FiChevronDown size={16} />}
+			</button>
+			{isExpanded && (
+				<div className="opacity-80 pb-2">
+					{text && <EnhancedMarkdown text={text} align={align} />}
+					{hasRedacted && <p className="italic">Part of the reasoning was redacted by the provider.</p>}
+				</div>
+			)}
+		</div>
+	);
+};
+
+export default ChatMessageReasoning;
@@ -5,7 +5,7 @@ import { v7 as uuidv7 } from 'uuid';
 
 import type { ModelParams } from '@/models/aiprovidermodel';
 import type { Conversation, ConversationItem, ConversationMessage } from '@/models/conversationmodel';
-import { ConversationRoleEnum } from '@/models/conversationmodel';
+import { ConversationRoleEnum, ConversationVersionConflictCode } from '@/models/conversationmodel';
 import { type ChatOptions, DefaultChatOptions } from '@/models/settingmodel';
 
 import { GetCompletionMessage } from '@/apis/aiprovider_helper';
@@ -29,6 +29,34 @@ function initConversation(title = 'New Conversation'): Conversation {
 	};
 }
 
+// What the store has of the open conversation, the last written version and messages.
+interface StoredChat {
+	persisted: boolean;
+	version: number;
+	messages: StoredMessage[];
+}
+
+interface StoredMessage {
+	id: string;
+	json: string;
+}
+
+function storedMessages(messages: ConversationMessage[]): StoredMessage[] {
+	return messages.map(m => ({ id: m.id, json: JSON.stringify(m) }));
+}
+
+function initStoredChat(conversation?: Conversation): StoredChat {
+	return {
+		persisted: !!conversation,
+		version: conversation?.version ?? 0,
+		messages: storedMessages(conversation?.messages ?? []),
+	};
+}
+
+function isVersionConflict(err: unknown): boolean {
+	return String(err).includes(ConversationVersionConflictCode);
+}
+
 function initConversationMessage(role: ConversationRoleEnum, content: string): ConversationMessage {
 	const d = new Date();
 	return {
@@ -51,8 +79,13 @@ const ChatScreen: FC = () => {
 	const bottomRef = useRef<HTMLDivElement>(null);
 
 	const isSubmittingRef = useRef(false);
-	// Has the current conversation already been persisted?
-	const isChatPersistedRef = useRef(false);
+	// The stored state of the current conversation. Each conversation gets its own object, so
+	// queued writes of a previous conversation do not touch the current one.
+	const storedChatRef = useRef<StoredChat>(initStoredChat());
+	// Writes run one after another, each one needs the version written by the one before.
+	const writeQueueRef = useRef<Promise<void>>(Promise.resolve());
+	// Has the title model titled the current conversation? The heuristic title is kept otherwise.
+	const isTitleGeneratedRef = useRef(false);
 
 	// Focus on mount.
 	useEffect(() => {
@@ -70,6 +103,21 @@ const ChatScreen: FC = () => {
 		setSearchRefreshKey(k => k + 1);
 	};
 
+	const enqueueWrite = (write: () => Promise<void>): Promise<void> => {
+		const next = writeQueueRef.current.then(write);
+		writeQueueRef.current = next.catch(() => {});
+		return next;
+	};
+
+	// Another window or the HTTP backend changed the conversation, show what is stored.
+	const reloadOnConflict = async (stored: StoredChat, id: string, title: string, err: unknown) => {
+		if (!isVersionConflict(err)) return;
+		const storedChat = await conversationStoreAPI.getConversation(id, title);
+		if (!storedChat || storedChatRef.current !== stored) return;
+		Object.assign(stored, initStoredChat(storedChat));
+		setChat(storedChat);
+	};
+
 	const handleNewChat = async () => {
 		if (chat.messages.length === 0) {
 			chatInputRef.current?.focus();
@@ -78,21 +126,27 @@ const ChatScreen: FC = () => {
 		// Put the old conversation _fully_ before moving to new chat.
 		// Handles edge cases like someone calls newchat when streaming is ongoing,
 		// or if there is some bug below that forgets to call saveUpdated chat
-		conversationStoreAPI.putConversation(chat);
+		const stored = storedChatRef.current;
+		const oldChat = chat;
+		enqueueWrite(async () => {
+			stored.version = await conversationStoreAPI.putConversation({ ...oldChat, version: stored.version });
+		}).catch(() => {});
 		setChat(initConversation());
 		// New non-persisted conversation started.
-		isChatPersistedRef.current = false;
+		storedChatRef.current = initStoredChat();
+		isTitleGeneratedRef.current = false;
 		chatInputRef.current?.focus();
 	};
 
 	// Persist `updatedChat` using the cheapest API that is still correct.
 	// •  First time we ever write this conversation              → putConversation
 	// •  Title has changed (search index must be updated)        → putConversation
-	// •  Otherwise                                               → putMessagesToConversation
-	// putMessagesToConversation REQUIRES the *full* message list, so we just pass `updatedChat.messages` every time.
-	const saveUpdatedChat = (updatedChat: Conversation) => {
+	// •  Stored messages are unchanged or edited in place        → updateMessage + appendMessages
+	// •  Otherwise, e.g. messages were cut off for a resend      → putMessagesToConversation
+	// Every write carries the stored version, a conflict reloads the conversation.
+	const saveUpdatedChat = (updatedChat: Conversation): Promise<void> => {
 		let newTitle = updatedChat.title;
-		if (updatedChat.messages.length <= 4) {
+		if (updatedChat.messages.length <= 4 && !isTitleGeneratedRef.current) {
 			const userMessages = updatedChat.messages.filter(m => m.role === ConversationRoleEnum.user);
 			if (userMessages.length === 1) {
 				// Always generate title from first user message
@@ -111,29 +165,100 @@ const ChatScreen: FC = () => {
 		}
 
 		// Decide which API to call
-		if (!isChatPersistedRef.current) {
-			// 1st save → create the record + metadata work
-			conversationStoreAPI.putConversation(updatedChat);
-			isChatPersistedRef.current = true;
-			bumpSearchKey(); // now searchable
-		} else if (titleChanged) {
-			// metadata (title) changed → need the heavy PUT
-			conversationStoreAPI.putConversation(updatedChat);
-			bumpSearchKey();
-		} else {
-			// normal case → only messages changed
-			conversationStoreAPI.putMessagesToConversation(updatedChat.id, updatedChat.title, updatedChat.messages);
+		const stored = storedChatRef.current;
+		const isFirstSave = !stored.persisted;
+		stored.persisted = true;
+		const write = async () => {
+			if (isFirstSave || titleChanged) {
+				stored.version = await conversationStoreAPI.putConversation({ ...updatedChat, version: stored.version });
+			} else {
+				const msgs = updatedChat.messages;
+				const isPrefix =
+					stored.messages.length <= msgs.length && stored.messages.every((m, i) => m.id === msgs[i].id);
+				if (isPrefix) {
+					for (let i = 0; i < stored.messages.length; i++) {
+						if (stored.messages[i].json !== JSON.stringify(msgs[i])) {
+							stored.version = await conversationStoreAPI.updateMessage(
+								updatedChat.id,
+								updatedChat.title,
+								stored.version,
+								msgs[i]
+							);
+						}
+					}
+					if (msgs.length > stored.messages.length) {
+						const appended = await conversationStoreAPI.appendMessages(
+							updatedChat.id,
+							updatedChat.title,
+							stored.version,
+							msgs.slice(stored.messages.length),
+							updatedChat.model
+						);
+						stored.version = appended.version;
+					}
+				} else {
+					stored.version = await conversationStoreAPI.putMessagesToConversation(
+						updatedChat.id,
+						updatedChat.title,
+						msgs,
+						updatedChat.model,
+						stored.version
+					);
+				}
+			}
+			stored.messages = storedMessages(updatedChat.messages);
+		};
+		const saved = enqueueWrite(async () => {
+			try {
+				await write();
+			} catch (err) {
+				await reloadOnConflict(stored, updatedChat.id, updatedChat.title, err);
+				throw err;
+			}
+		});
+		if (isFirstSave || titleChanged) {
+			bumpSearchKey(); // now searchable, or searchable under the new title
 		}
 
 		// update local React state
 		setChat(updatedChat);
+		return saved;
+	};
+
+	// Ask the title model for a title once the first exchange is saved. This runs in the background,
+	// if titles are disabled or the call fails the heuristic title stays.
+	// The title model call runs on its own, only the rename is queued with the other writes as it
+	// changes the version. The heuristic title stops as soon as a title is requested, so a later
+	// save cannot write it over the generated one.
+	const generateChatTitle = (savedChat: Conversation) => {
+		const stored = storedChatRef.current;
+		isTitleGeneratedRef.current = true;
+		conversationStoreAPI
+			.suggestConversationTitle(savedChat.id, savedChat.title)
+			.then(title =>
+				enqueueWrite(async () => {
+					stored.version = await conversationStoreAPI.renameConversation(savedChat.id, savedChat.title, title);
+					setChat(prev => (prev.id === savedChat.id ? { ...prev, title } : prev));
+					bumpSearchKey();
+				})
+			)
+			.catch(() => {
+				// Titles are disabled or the call failed, the heuristic title goes on.
+				if (storedChatRef.current === stored) {
+					isTitleGeneratedRef.current = false;
+				}
+			});
 	};
 
 	const handleSelectConversation = useCallback(async (item: ConversationItem) => {
 		const selectedChat = await conversationStoreAPI.getConversation(item.id, item.title);
 		if (selectedChat) {
 			setChat(selectedChat);
-			isChatPersistedRef.current = true;
+			storedChatRef.current = initStoredChat(selectedChat);
+			isTitleGeneratedRef.current = false;
+			if (selectedChat.model) {
+				chatInputRef.current?.selectModel(selectedChat.model.provider, selectedChat.model.model);
+			}
 		}
 	}, []);
 
@@ -205,9 +330,13 @@ const ChatScreen: FC = () => {
 					...updatedChatWithConvoMessage,
 					messages: [...updatedChatWithConvoMessage.messages.slice(0, -1), respMessage],
 					modifiedAt: new Date(),
+					model: { provider: options.provider, model: options.name },
 				};
 
-				saveUpdatedChat(finalChat);
+				const saved = saveUpdatedChat(finalChat);
+				if (!isTitleGeneratedRef.current && finalChat.messages.length === 2) {
+					saved.then(() => generateChatTitle(finalChat)).catch(() => {});
+				}
 			}
 
 			setStreamedMessage('');
@@ -215,7 +344,7 @@ const ChatScreen: FC = () => {
 
 			isSubmittingRef.current = false;
 		},
-		[saveUpdatedChat]
+		[saveUpdatedChat, generateChatTitle]
 	);
 
 	const sendMessage = async (text: string, options: ChatOptions) => {
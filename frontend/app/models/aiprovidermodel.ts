@@ -91,11 +91,25 @@ export interface ChatCompletionRequestMessageFunctionCall {
 	arguments?: string;
 }
 
+export enum ReasoningContentType {
+	Thinking = 'thinking',
+	RedactedThinking = 'redactedThinking',
+}
+
+// Reasoning of the model, kept apart from the message content.
+export interface ReasoningContent {
+	type: ReasoningContentType;
+	text?: string;
+	signature?: string;
+	data?: string;
+}
+
 export interface ChatCompletionRequestMessage {
 	role: ChatCompletionRoleEnum;
 	content?: string;
 	name?: string;
 	functionCall?: ChatCompletionRequestMessageFunctionCall;
+	reasoningContents?: ReasoningContent[];
 }
 
 export interface ChatCompletionResponseMessage {
@@ -147,13 +161,36 @@ export interface APIFetchResponse<T> {
 	errorDetails?: APIErrorDetails;
 }
 
+export interface CompletionUsage {
+	inputTokens: number;
+	outputTokens: number;
+	reasoningTokens?: number;
+	cacheReadTokens?: number;
+	cacheWriteTokens?: number;
+	totalTokens: number;
+}
+
+export interface CompletionTiming {
+	latencyMs: number;
+	timeToFirstTokenMs?: number;
+}
+
 export interface CompletionResponse {
 	requestDetails?: APIRequestDetails;
 	responseDetails?: APIResponseDetails;
 	errorDetails?: APIErrorDetails;
 	respContent?: string;
+	reasoningContents?: ReasoningContent[];
 	functionName?: string;
 	functionArgs?: any;
+	usage?: CompletionUsage;
+	timing?: CompletionTiming;
+	// Cost in USD, from the catalog prices of the model.
+	cost?: number;
+	// Effective params of the request after defaults and limits were applied.
+	modelParams?: ModelParams;
+	finishReason?: string;
+	requestID?: string;
 }
 
 export interface ModelDefaults {
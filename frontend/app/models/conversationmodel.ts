@@ -1,3 +1,5 @@
+import type { CompletionUsage, ModelParams, ReasoningContent } from '@/models/aiprovidermodel';
+
 export enum ConversationRoleEnum {
 	system = 'system',
 	user = 'user',
@@ -7,34 +9,121 @@ export enum ConversationRoleEnum {
 }
 export interface ConversationMessage {
 	id: string;
+	parentID?: string;
 	createdAt?: Date;
 	role: ConversationRoleEnum;
 	content: string;
 	timestamp?: string;
 	name?: string;
 	details?: string;
+	reasoningContents?: ReasoningContent[];
+	provider?: string;
+	model?: string;
+	// How an assistant message was produced, empty if unknown.
+	modelParams?: ModelParams;
+	presetID?: string;
+	usage?: CompletionUsage;
+	cost?: number;
+	latencyMs?: number;
+	timeToFirstTokenMs?: number;
+	finishReason?: string;
+	requestID?: string;
+}
+
+// Model setup a conversation continues with when it is reopened.
+export interface ConversationModel {
+	provider: string;
+	model: string;
+	presetID?: string;
 }
 
-export interface ConversationItem {
+export interface ConversationMeta {
+	tags?: string[];
+	folder?: string;
+	pinned?: boolean;
+	starred?: boolean;
+}
+
+export interface ConversationItem extends ConversationMeta {
 	id: string;
 	title: string;
 	createdAt: Date;
 }
 
+// Starts the message of errors for writes with a stale conversation version.
+export const ConversationVersionConflictCode = 'CONVERSATION_VERSION_CONFLICT';
+
+export enum SearchMode {
+	any = 'any',
+	all = 'all',
+	phrase = 'phrase',
+}
+
+export interface ConversationSearchOptions {
+	mode?: SearchMode;
+	roles?: ConversationRoleEnum[];
+	createdFrom?: Date;
+	createdTo?: Date;
+	provider?: string;
+	model?: string;
+}
+
+export interface SnippetPart {
+	text: string;
+	match?: boolean;
+}
+
+export interface ConversationSearchHit extends ConversationItem {
+	snippet?: SnippetPart[];
+	matchingMessageIDs?: string[];
+}
+
 export type Conversation = ConversationItem & {
 	modifiedAt: Date;
 	messages: ConversationMessage[];
+	activeLeafID?: string;
+	model?: ConversationModel;
+	// Increased by every write, a write with an older version fails with a conflict.
+	version?: number;
 };
 
 export interface IConversationStoreAPI {
-	putConversation: (conversation: Conversation) => Promise<void>;
-	putMessagesToConversation(id: string, title: string, messages: ConversationMessage[]): Promise<void>;
+	// The put and message calls check the version given to them and return the new version.
+	putConversation: (conversation: Conversation) => Promise<number>;
+	putMessagesToConversation(
+		id: string,
+		title: string,
+		messages: ConversationMessage[],
+		model?: ConversationModel,
+		version?: number
+	): Promise<number>;
+	appendMessages: (
+		id: string,
+		title: string,
+		version: number,
+		messages: ConversationMessage[],
+		model?: ConversationModel
+	) => Promise<{ version: number; messages: ConversationMessage[] }>;
+	updateMessage: (id: string, title: string, version: number, message: ConversationMessage) => Promise<number>;
+	// Deletes the message and the replies below it.
+	deleteMessage: (
+		id: string,
+		title: string,
+		version: number,
+		messageID: string
+	) => Promise<{ version: number; activeLeafID?: string }>;
 	deleteConversation: (id: string, title: string) => Promise<void>;
+	renameConversation: (id: string, title: string, newTitle: string) => Promise<number>;
+	// Titles the conversation from its first exchange with the title model and returns the new title.
+	generateConversationTitle: (id: string, title: string) => Promise<{ title: string; version: number }>;
+	// Returns a title from the title model without renaming the conversation.
+	suggestConversationTitle: (id: string, title: string) => Promise<string>;
 	getConversation: (id: string, title: string) => Promise<Conversation | null>;
 	listConversations: (token?: string) => Promise<{ conversations: ConversationItem[]; nextToken?: string }>;
 	searchConversations: (
 		query: string,
 		token?: string,
-		pageSize?: number
-	) => Promise<{ conversations: ConversationItem[]; nextToken?: string }>;
+		pageSize?: number,
+		options?: ConversationSearchOptions
+	) => Promise<{ conversations: ConversationSearchHit[]; nextToken?: string }>;
 }
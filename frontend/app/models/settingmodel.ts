@@ -62,10 +62,21 @@ export const DefaultChatOptions: ChatOptions = {
 	disablePreviousMessages: false,
 };
 
+// Model that titles conversations after the first exchange. Provider and model default to the app defaults.
+export interface ConversationTitleSettings {
+	isEnabled: boolean;
+	provider?: ProviderName;
+	model?: ModelName;
+	language?: string;
+}
+
 export type SettingsSchema = {
 	aiSettings: Record<ProviderName, AISetting>;
 	app: {
 		defaultProvider: ProviderName;
+		conversationTitles?: ConversationTitleSettings;
+		// Days deleted conversations stay in the trash, 0 keeps them until the trash is purged.
+		trashRetentionDays?: number;
 	};
 };
 
@@ -30,17 +30,18 @@ func (api *AnthropicCompatibleAPI) GetLLMsModel(ctx context.Context) llms.Model
 
 func (api *AnthropicCompatibleAPI) InitLLM(ctx context.Context) error {
 	options := []langchainAnthropic.Option{}
-	providerURL := "https://api.anthropic.com/v1"
+	// Rebuild the transport so that network config changes take effect.
+	newClient, err := api.initHTTPClient()
+	if err != nil {
+		return err
+	}
+	// Cache markers and thinking blocks are added outside the logging transport, so that debug
+	// details show them.
+	newClient.Transport = &thinkingTransport{
+		Transport: &promptCachingTransport{Transport: newClient.Transport},
+	}
+	providerURL := api.getProviderURL()
 	if api.ProviderInfo.Origin != "" {
-		baseURL := api.ProviderInfo.Origin
-		// Remove trailing slash from baseURL if present.
-		baseURL = strings.TrimSuffix(baseURL, "/")
-
-		pathPrefix := api.ProviderInfo.ChatCompletionPathPrefix
-		// Remove '/messages' from pathPrefix if present
-		// This is because langchaingo adds '/messages' internally.
-		pathPrefix = strings.TrimSuffix(pathPrefix, "/messages")
-		providerURL = baseURL + pathPrefix
 		options = append(options, langchainAnthropic.WithBaseURL(providerURL))
 	}
 	if api.ProviderInfo.APIKey == "" {
@@ -48,7 +49,6 @@ func (api *AnthropicCompatibleAPI) InitLLM(ctx context.Context) error {
 		return nil
 	}
 	options = append(options, langchainAnthropic.WithToken(api.ProviderInfo.APIKey))
-	newClient := NewDebugHTTPClient(api.Debug)
 	options = append(options, langchainAnthropic.WithHTTPClient(newClient))
 
 	llm, err := langchainAnthropic.New(options...)
@@ -59,3 +59,16 @@ func (api *AnthropicCompatibleAPI) InitLLM(ctx context.Context) error {
 	slog.Info("LLM provider initialize", "Name", string(api.ProviderInfo.Name), "URL", providerURL)
 	return nil
 }
+
+// getProviderURL returns the Anthropic style base URL, i.e. the one to which "/messages" is appended.
+func (api *AnthropicCompatibleAPI) getProviderURL() string {
+	if api.ProviderInfo.Origin == "" {
+		return "https://api.anthropic.com/v1"
+	}
+	// Remove trailing slash from baseURL if present.
+	baseURL := strings.TrimSuffix(api.ProviderInfo.Origin, "/")
+	// Remove '/messages' from pathPrefix if present
+	// This is because langchaingo adds '/messages' internally.
+	pathPrefix := strings.TrimSuffix(api.ProviderInfo.ChatCompletionPathPrefix, "/messages")
+	return baseURL + pathPrefix
+}
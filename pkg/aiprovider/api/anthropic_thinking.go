@@ -0,0 +1,307 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+const thinkingKey = contextKey("Thinking")
+
+// thinkingState carries the reasoning of earlier assistant turns to the transport, and the
+// thinking blocks of the response back to the completion. The langchaingo client keeps only
+// the thinking text, signatures and redacted thinking are read from the raw messages API.
+type thinkingState struct {
+	// replay has the reasoning of every assistant message of the request, in order.
+	replay [][]spec.ReasoningContent
+
+	mu     sync.Mutex
+	blocks []spec.ReasoningContent
+}
+
+func withThinking(
+	ctx context.Context,
+	replay [][]spec.ReasoningContent,
+) (context.Context, *thinkingState) {
+	state := &thinkingState{replay: replay}
+	return context.WithValue(ctx, thinkingKey, state), state
+}
+
+func getThinkingState(ctx context.Context) *thinkingState {
+	state, _ := ctx.Value(thinkingKey).(*thinkingState)
+	return state
+}
+
+// contents returns the thinking blocks of the response, nil if none were seen.
+func (s *thinkingState) contents() []spec.ReasoningContent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.blocks)
+}
+
+// thinkingTransport sends signed thinking blocks of earlier turns back to the Anthropic messages
+// API and reads the thinking blocks of the response.
+type thinkingTransport struct {
+	Transport http.RoundTripper
+}
+
+func (t *thinkingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	state := getThinkingState(req.Context())
+	if state == nil || req.Method != http.MethodPost ||
+		!strings.HasSuffix(req.URL.Path, "/messages") {
+		return t.Transport.RoundTrip(req)
+	}
+
+	if len(state.replay) > 0 && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if replayed, ok := addThinkingBlocks(body, state.replay); ok {
+			body = replayed
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	if resp != nil && resp.Body != nil {
+		resp.Body = &thinkingReadCloser{ReadCloser: resp.Body, state: state}
+	}
+	return resp, err
+}
+
+// addThinkingBlocks puts the signed thinking and redacted thinking blocks of earlier turns before
+// the content of their assistant messages. Anthropic requires them within a tool use turn and
+// ignores them on other turns, so they are sent for every turn. Without thinking enabled they
+// must not be sent. The body is returned unchanged if it cannot be parsed or nothing is added.
+func addThinkingBlocks(body []byte, replay [][]spec.ReasoningContent) ([]byte, bool) {
+	var data map[string]any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return body, false
+	}
+	thinking, _ := data["thinking"].(map[string]any)
+	if thinking == nil || thinking["type"] == "disabled" {
+		return body, false
+	}
+	messages, _ := data["messages"].([]any)
+
+	added := false
+	turn := 0
+	for _, m := range messages {
+		msg, ok := m.(map[string]any)
+		if !ok || msg["role"] != "assistant" {
+			continue
+		}
+		if turn >= len(replay) {
+			break
+		}
+		blocks := thinkingBlocks(replay[turn])
+		turn++
+		if len(blocks) == 0 {
+			continue
+		}
+		switch c := msg["content"].(type) {
+		case string:
+			blocks = append(blocks, map[string]any{"type": "text", "text": c})
+		case []any:
+			blocks = append(blocks, c...)
+		default:
+			continue
+		}
+		msg["content"] = blocks
+		added = true
+	}
+	if !added {
+		return body, false
+	}
+	out, err := json.Marshal(data)
+	if err != nil {
+		return body, false
+	}
+	return out, true
+}
+
+// thinkingBlocks converts reasoning to Anthropic content blocks. Thinking without a signature
+// cannot be verified and is left out.
+func thinkingBlocks(contents []spec.ReasoningContent) []any {
+	var blocks []any
+	for _, rc := range contents {
+		switch {
+		case rc.Type == spec.ReasoningContentTypeThinking && rc.Signature != "":
+			blocks = append(blocks, map[string]any{
+				"type":      "thinking",
+				"thinking":  rc.Text,
+				"signature": rc.Signature,
+			})
+		case rc.Type == spec.ReasoningContentTypeRedactedThinking && rc.Data != "":
+			blocks = append(blocks, map[string]any{
+				"type": "redacted_thinking",
+				"data": rc.Data,
+			})
+		}
+	}
+	return blocks
+}
+
+// thinkingReadCloser reads the thinking blocks of a JSON or SSE response while it is read.
+type thinkingReadCloser struct {
+	io.ReadCloser
+	state *thinkingState
+	// pending is the unparsed rest of the body, the JSON body or an unterminated SSE line.
+	pending  []byte
+	isJSON   bool
+	isStream bool
+	// blocks are the thinking blocks of a stream by content block index.
+	blocks map[int]*spec.ReasoningContent
+	order  []int
+}
+
+func (r *thinkingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.scan(p[:n])
+	}
+	return n, err
+}
+
+func (r *thinkingReadCloser) Close() error {
+	if r.isJSON {
+		r.readMessage(r.pending)
+	} else {
+		r.readEvent(r.pending)
+	}
+	r.pending = nil
+	var blocks []spec.ReasoningContent
+	for _, i := range r.order {
+		blocks = append(blocks, *r.blocks[i])
+	}
+	if len(blocks) > 0 {
+		r.state.mu.Lock()
+		r.state.blocks = blocks
+		r.state.mu.Unlock()
+	}
+	return r.ReadCloser.Close()
+}
+
+// scan parses the complete SSE lines of a chunk and keeps the rest for the next one.
+func (r *thinkingReadCloser) scan(chunk []byte) {
+	r.pending = append(r.pending, chunk...)
+	if !r.isJSON && !r.isStream {
+		trimmed := bytes.TrimLeft(r.pending, " \t\r\n")
+		if len(trimmed) == 0 {
+			return
+		}
+		r.isJSON = trimmed[0] == '{'
+		r.isStream = !r.isJSON
+	}
+	if r.isJSON {
+		return
+	}
+	rest := r.pending
+	for {
+		line, after, ok := bytes.Cut(rest, []byte("\n"))
+		if !ok {
+			break
+		}
+		r.readEvent(line)
+		rest = after
+	}
+	r.pending = append(r.pending[:0], rest...)
+}
+
+type anthropicContentBlock struct {
+	Type      string `json:"type"`
+	Thinking  string `json:"thinking"`
+	Signature string `json:"signature"`
+	Data      string `json:"data"`
+}
+
+// readMessage reads the thinking blocks of a messages response.
+func (r *thinkingReadCloser) readMessage(data []byte) {
+	var msg struct {
+		Content []anthropicContentBlock `json:"content"`
+	}
+	if json.Unmarshal(data, &msg) != nil {
+		return
+	}
+	for i, b := range msg.Content {
+		r.startBlock(i, b)
+	}
+}
+
+// readEvent reads a content_block_start or content_block_delta stream event of a thinking block.
+func (r *thinkingReadCloser) readEvent(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	// Most events are text deltas.
+	if !ok ||
+		!bytes.Contains(data, []byte("thinking")) && !bytes.Contains(data, []byte("signature")) {
+		return
+	}
+	var event struct {
+		Type         string                `json:"type"`
+		Index        int                   `json:"index"`
+		ContentBlock anthropicContentBlock `json:"content_block"`
+		Delta        struct {
+			Type      string `json:"type"`
+			Thinking  string `json:"thinking"`
+			Signature string `json:"signature"`
+		} `json:"delta"`
+	}
+	if json.Unmarshal(data, &event) != nil {
+		return
+	}
+	switch event.Type {
+	case "content_block_start":
+		r.startBlock(event.Index, event.ContentBlock)
+	case "content_block_delta":
+		block := r.blocks[event.Index]
+		if block == nil {
+			return
+		}
+		switch event.Delta.Type {
+		case "thinking_delta":
+			block.Text += event.Delta.Thinking
+		case "signature_delta":
+			block.Signature += event.Delta.Signature
+		}
+	}
+}
+
+func (r *thinkingReadCloser) startBlock(index int, b anthropicContentBlock) {
+	var rc spec.ReasoningContent
+	switch b.Type {
+	case "thinking":
+		rc = spec.ReasoningContent{
+			Type:      spec.ReasoningContentTypeThinking,
+			Text:      b.Thinking,
+			Signature: b.Signature,
+		}
+	case "redacted_thinking":
+		rc = spec.ReasoningContent{
+			Type: spec.ReasoningContentTypeRedactedThinking,
+			Data: b.Data,
+		}
+	default:
+		return
+	}
+	if r.blocks == nil {
+		r.blocks = map[int]*spec.ReasoningContent{}
+	}
+	if _, exists := r.blocks[index]; !exists {
+		r.order = append(r.order, index)
+	}
+	r.blocks[index] = &rc
+}
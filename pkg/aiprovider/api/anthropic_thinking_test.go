@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+func TestAddThinkingBlocks(t *testing.T) {
+	signed := []spec.ReasoningContent{
+		{Type: spec.ReasoningContentTypeThinking, Text: "think", Signature: "sig"},
+		{Type: spec.ReasoningContentTypeRedactedThinking, Data: "enc"},
+	}
+	unsigned := []spec.ReasoningContent{
+		{Type: spec.ReasoningContentTypeThinking, Text: "think"},
+	}
+	messages := `[
+		{"role":"user","content":"u0"},
+		{"role":"assistant","content":[{"type":"text","text":"a1"}]},
+		{"role":"user","content":"u2"},
+		{"role":"assistant","content":"a3"},
+		{"role":"user","content":"u4"}
+	]`
+	thinkingOn := `"thinking":{"type":"enabled","budget_tokens":1024},`
+	tests := []struct {
+		name      string
+		body      string
+		replay    [][]spec.ReasoningContent
+		wantAdded bool
+		// wantTypes are the content block types of each assistant message.
+		wantTypes [][]string
+	}{
+		{
+			name:      "signed blocks of every turn",
+			body:      `{` + thinkingOn + `"messages":` + messages + `}`,
+			replay:    [][]spec.ReasoningContent{signed, signed},
+			wantAdded: true,
+			wantTypes: [][]string{
+				{"thinking", "redacted_thinking", "text"},
+				{"thinking", "redacted_thinking", "text"},
+			},
+		},
+		{
+			name:      "unsigned thinking is left out",
+			body:      `{` + thinkingOn + `"messages":` + messages + `}`,
+			replay:    [][]spec.ReasoningContent{unsigned, signed},
+			wantAdded: true,
+			wantTypes: [][]string{{"text"}, {"thinking", "redacted_thinking", "text"}},
+		},
+		{
+			name:   "nothing signed",
+			body:   `{` + thinkingOn + `"messages":` + messages + `}`,
+			replay: [][]spec.ReasoningContent{unsigned, nil},
+		},
+		{
+			name:   "thinking not enabled",
+			body:   `{"messages":` + messages + `}`,
+			replay: [][]spec.ReasoningContent{signed, signed},
+		},
+		{
+			name:   "thinking disabled",
+			body:   `{"thinking":{"type":"disabled"},"messages":` + messages + `}`,
+			replay: [][]spec.ReasoningContent{signed, signed},
+		},
+		{
+			name:   "invalid body",
+			body:   `{"messages":`,
+			replay: [][]spec.ReasoningContent{signed},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, added := addThinkingBlocks([]byte(tc.body), tc.replay)
+			if added != tc.wantAdded {
+				t.Fatalf("added = %v, want %v", added, tc.wantAdded)
+			}
+			if !added {
+				if string(got) != tc.body {
+					t.Errorf("body changed to %s", got)
+				}
+				return
+			}
+			var data struct {
+				Messages []struct {
+					Role    string          `json:"role"`
+					Content json.RawMessage `json:"content"`
+				} `json:"messages"`
+			}
+			if err := json.Unmarshal(got, &data); err != nil {
+				t.Fatalf("invalid body %s: %v", got, err)
+			}
+			var types [][]string
+			for _, m := range data.Messages {
+				if m.Role != "assistant" {
+					continue
+				}
+				var blocks []struct {
+					Type      string `json:"type"`
+					Signature string `json:"signature"`
+				}
+				if err := json.Unmarshal(m.Content, &blocks); err != nil {
+					t.Fatalf("assistant content %s is not a block list: %v", m.Content, err)
+				}
+				var blockTypes []string
+				for _, b := range blocks {
+					blockTypes = append(blockTypes, b.Type)
+					if b.Type == "thinking" && b.Signature != "sig" {
+						t.Errorf("thinking block without its signature: %s", got)
+					}
+				}
+				types = append(types, blockTypes)
+			}
+			if !slices.EqualFunc(types, tc.wantTypes, slices.Equal) {
+				t.Errorf("block types = %v, want %v", types, tc.wantTypes)
+			}
+		})
+	}
+}
+
+func TestThinkingReadCloser(t *testing.T) {
+	stream := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"content":[],"usage":{"input_tokens":12}}}`,
+		``,
+		`event: content_block_start`,
+		`data: {"type":"content_block_start","index":0,` +
+			`"content_block":{"type":"thinking","thinking":"","signature":""}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,` +
+			`"delta":{"type":"thinking_delta","thinking":"Let me "}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,` +
+			`"delta":{"type":"thinking_delta","thinking":"think."}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,` +
+			`"delta":{"type":"signature_delta","signature":"sig"}}`,
+		``,
+		`event: content_block_start`,
+		`data: {"type":"content_block_start","index":1,` +
+			`"content_block":{"type":"redacted_thinking","data":"enc"}}`,
+		``,
+		`event: content_block_start`,
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":2,` +
+			`"delta":{"type":"text_delta","text":"No more thinking, here is the signature."}}`,
+		``,
+		`event: message_stop`,
+		`data: {"type":"message_stop"}`,
+		``,
+	}, "\n")
+	want := []spec.ReasoningContent{
+		{Type: spec.ReasoningContentTypeThinking, Text: "Let me think.", Signature: "sig"},
+		{Type: spec.ReasoningContentTypeRedactedThinking, Data: "enc"},
+	}
+	tests := []struct {
+		name string
+		body string
+		want []spec.ReasoningContent
+	}{
+		{
+			name: "stream",
+			body: stream,
+			want: want,
+		},
+		{
+			name: "JSON response",
+			body: `{"id":"msg_1","content":[` +
+				`{"type":"thinking","thinking":"Let me think.","signature":"sig"},` +
+				`{"type":"redacted_thinking","data":"enc"},` +
+				`{"type":"text","text":"hi"}]}`,
+			want: want,
+		},
+		{
+			name: "no thinking",
+			body: `{"id":"msg_1","content":[{"type":"text","text":"thinking is off"}]}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			state := &thinkingState{}
+			// One byte reads split every line and JSON value across reads.
+			r := &thinkingReadCloser{
+				ReadCloser: io.NopCloser(iotest.OneByteReader(strings.NewReader(tc.body))),
+				state:      state,
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if string(got) != tc.body {
+				t.Errorf("body changed to %q", got)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+			if blocks := state.contents(); !slices.Equal(blocks, tc.want) {
+				t.Errorf("blocks = %+v, want %+v", blocks, tc.want)
+			}
+		})
+	}
+}
@@ -3,17 +3,21 @@ package api
 import (
 	"context"
 	"errors"
+	"net/http"
 	"slices"
 	"strings"
+	"time"
 
-	"github.com/ppipada/flexigpt-app/pkg/aiprovider/consts"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/catalog"
 	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
 	"github.com/tmc/langchaingo/llms"
 )
 
 type BaseAIAPI struct {
-	ProviderInfo *spec.ProviderInfo
-	Debug        bool
+	ProviderInfo  *spec.ProviderInfo
+	Debug         bool
+	NetworkConfig *spec.NetworkConfig
+	httpClient    *http.Client
 }
 
 // NewOpenAIAPI creates a new instance of BaseAIAPI with input ProviderInfo.
@@ -72,6 +76,51 @@ func (api *BaseAIAPI) SetProviderAttribute(
 	return nil
 }
 
+// SetNetworkConfig validates and sets the proxy and TLS config of a provider.
+// It takes effect on the next InitLLM.
+func (api *BaseAIAPI) SetNetworkConfig(
+	ctx context.Context,
+	networkConfig *spec.NetworkConfig,
+) error {
+	if _, err := NewHTTPTransport(networkConfig); err != nil {
+		return err
+	}
+	api.NetworkConfig = networkConfig
+	return nil
+}
+
+// initHTTPClient rebuilds the HTTP client from the current network config.
+func (api *BaseAIAPI) initHTTPClient() (*http.Client, error) {
+	transport, err := NewHTTPTransport(api.NetworkConfig)
+	if err != nil {
+		return nil, err
+	}
+	api.httpClient = NewDebugHTTPClient(api.Debug, transport)
+	return api.httpClient, nil
+}
+
+// getHTTPClient returns the client built on the last InitLLM, or a default one.
+func (api *BaseAIAPI) getHTTPClient() *http.Client {
+	if api.httpClient == nil {
+		return NewDebugHTTPClient(api.Debug, nil)
+	}
+	return api.httpClient
+}
+
+// setAuthHeader sets the API key header of a request that does not go through the LLM client.
+// A key in the Authorization header is a bearer token. Local servers like llama.cpp may not
+// need a key.
+func (api *BaseAIAPI) setAuthHeader(header http.Header) {
+	if api.ProviderInfo.APIKey == "" {
+		return
+	}
+	value := api.ProviderInfo.APIKey
+	if strings.EqualFold(api.ProviderInfo.APIKeyHeaderKey, "Authorization") {
+		value = "Bearer " + value
+	}
+	header.Set(api.ProviderInfo.APIKeyHeaderKey, value)
+}
+
 func trimInbuiltPrompts(systemPrompt, inbuiltPrompt string) string {
 	// Split both prompts into lines.
 	inbuiltLines := strings.Split(inbuiltPrompt, "\n")
@@ -98,6 +147,7 @@ func (api *BaseAIAPI) getCompletionRequest(
 			AdditionalParameters: modelParams.AdditionalParameters,
 			Temperature:          modelParams.Temperature,
 			Reasoning:            modelParams.Reasoning,
+			PromptCaching:        modelParams.PromptCaching,
 		},
 	}
 
@@ -129,6 +179,22 @@ func (api *BaseAIAPI) getCompletionRequest(
 		completionRequest.ModelParams.Reasoning = nil
 	}
 
+	// Zero timeouts fall back to the inbuilt defaults of the model.
+	completionRequest.ModelParams.Timeout = modelParams.Timeout
+	completionRequest.ModelParams.FirstTokenTimeout = modelParams.FirstTokenTimeout
+	completionRequest.ModelParams.IdleTimeout = modelParams.IdleTimeout
+	if inbuiltModelParams != nil {
+		if completionRequest.ModelParams.Timeout <= 0 {
+			completionRequest.ModelParams.Timeout = inbuiltModelParams.Timeout
+		}
+		if completionRequest.ModelParams.FirstTokenTimeout <= 0 {
+			completionRequest.ModelParams.FirstTokenTimeout = inbuiltModelParams.FirstTokenTimeout
+		}
+		if completionRequest.ModelParams.IdleTimeout <= 0 {
+			completionRequest.ModelParams.IdleTimeout = inbuiltModelParams.IdleTimeout
+		}
+	}
+
 	reqSystemPrompt := modelParams.SystemPrompt
 	if inbuiltModelParams != nil && inbuiltModelParams.SystemPrompt != "" {
 		reqSystemPrompt = trimInbuiltPrompts(reqSystemPrompt, inbuiltModelParams.SystemPrompt)
@@ -164,6 +230,7 @@ func (api *BaseAIAPI) FetchCompletion(
 	inbuiltModelParams *spec.ModelParams,
 	prevMessages []spec.ChatCompletionRequestMessage,
 	onStreamData func(data string) error,
+	onStreamReasoning func(data string) error,
 ) (*CompletionResponse, error) {
 	input := api.getCompletionRequest(prompt, modelParams, inbuiltModelParams, prevMessages)
 	if len(input.Messages) == 0 {
@@ -199,22 +266,87 @@ func (api *BaseAIAPI) FetchCompletion(
 	}
 	options = append(options, llms.WithMaxTokens(input.ModelParams.MaxOutputLength))
 
+	ctx = AddDebugResponseToCtx(ctx)
+	ctx, promptCaching := withPromptCaching(ctx, input.ModelParams.PromptCaching)
+	// The reasoning of every assistant turn, the Anthropic transport replays signed thinking.
+	replay := [][]spec.ReasoningContent{}
+	for _, msg := range input.Messages {
+		if msg.Content != nil && msg.Role == spec.Assistant {
+			replay = append(replay, msg.ReasoningContents)
+		}
+	}
+	ctx, thinking := withThinking(ctx, replay)
+	ctx, cancel, stopTimeout := withCompletionTimeout(
+		ctx,
+		secondsToDuration(input.ModelParams.Timeout),
+	)
+	defer stopTimeout()
+
 	// Wrap onStreamData.
-	var write func(string) error
-	var flush func()
+	var write, writeReasoning func(string) error
+	var flush, flushReasoning func()
+	var firstTokenAt time.Time
+	var watchdog *streamWatchdog
+	markFirstToken := func() {
+		if firstTokenAt.IsZero() {
+			firstTokenAt = time.Now()
+		}
+		if watchdog != nil {
+			watchdog.touch()
+		}
+	}
+	// Partial content is kept so that it can be returned if a timeout fires mid stream.
+	var partial, partialReasoning strings.Builder
 	if input.ModelParams.Stream && onStreamData != nil {
-		write, flush = NewBufferedStreamer(onStreamData, FlushInterval, FlushChunkSize)
+		watchdog = newStreamWatchdog(
+			cancel,
+			secondsToDuration(input.ModelParams.FirstTokenTimeout),
+			secondsToDuration(input.ModelParams.IdleTimeout),
+		)
+		defer watchdog.stop()
+		bufferedWrite, bufferedFlush := NewBufferedStreamer(
+			onStreamData,
+			FlushInterval,
+			FlushChunkSize,
+		)
+		write = func(data string) error {
+			partial.WriteString(data)
+			return bufferedWrite(data)
+		}
+		flush = bufferedFlush
 		if input.ModelParams.Reasoning != nil {
+			if onStreamReasoning != nil {
+				writeReasoning, flushReasoning = NewBufferedStreamer(
+					onStreamReasoning,
+					FlushInterval,
+					FlushChunkSize,
+				)
+			}
 			streamingReasoningFunc := func(ctx context.Context, reasoningChunk []byte, chunk []byte) error {
+				markFirstToken()
 				rc := string(reasoningChunk)
 				if rc != "" {
-					rc = getBlockQuotedReasoning(rc)
+					partialReasoning.WriteString(rc)
+					var err error
+					if writeReasoning != nil {
+						err = writeReasoning(rc)
+					} else {
+						// Inline reasoning is only for display, it does not become part of the content.
+						err = bufferedWrite(getBlockQuotedReasoning(rc))
+					}
+					if err != nil {
+						return err
+					}
+				}
+				if len(chunk) == 0 {
+					return nil
 				}
-				return write(rc + string(chunk))
+				return write(string(chunk))
 			}
 			options = append(options, llms.WithStreamingReasoningFunc(streamingReasoningFunc))
 		} else {
 			streamingFunc := func(ctx context.Context, chunk []byte) error {
+				markFirstToken()
 				return write(string(chunk))
 			}
 			options = append(options, llms.WithStreamingFunc(streamingFunc))
@@ -224,33 +356,76 @@ func (api *BaseAIAPI) FetchCompletion(
 	content := []llms.MessageContent{}
 	if sp := input.ModelParams.SystemPrompt; sp != "" {
 		sysmsg := llms.TextParts(llms.ChatMessageTypeSystem, sp)
-		if api.ProviderInfo.Name == consts.ProviderNameOpenAI &&
-			strings.HasPrefix(string(input.ModelParams.Name), "o") {
-			sysmsg = llms.TextParts(llms.ChatMessageTypeDeveloper, sp)
+		caps, ok := catalog.Default().Capabilities(api.ProviderInfo.Name, input.ModelParams.Name)
+		if ok {
+			switch caps.SystemPrompt {
+			case spec.SystemPromptRoleDeveloper:
+				sysmsg = llms.TextParts(llms.ChatMessageTypeDeveloper, sp)
+			case spec.SystemPromptUnsupported:
+				// Models without a system role get the instructions as the first user message.
+				sysmsg = llms.TextParts(llms.ChatMessageTypeHuman, sp)
+			}
 		}
 		content = append(content, sysmsg)
 	}
 	for _, msg := range input.Messages {
-		if msg.Content != nil {
-			content = append(content, llms.TextParts(LangchainRoleMap[msg.Role], *msg.Content))
+		if msg.Content == nil {
+			continue
+		}
+		text := *msg.Content
+		if msg.Role == spec.Assistant {
+			text = stripInlineReasoning(text)
 		}
+		// Reasoning contents of earlier turns are not part of the text. Deepseek rejects them and
+		// OpenAI keeps reasoning server side, only the Anthropic transport adds signed thinking.
+		content = append(content, llms.TextParts(LangchainRoleMap[msg.Role], text))
 	}
 	if len(content) == 0 {
 		return nil, errors.New("empty input content messages")
 	}
 
-	completionResp := &CompletionResponse{}
+	effectiveParams := input.ModelParams
+	completionResp := &CompletionResponse{ModelParams: &effectiveParams}
 
-	ctx = AddDebugResponseToCtx(ctx)
+	startedAt := time.Now()
 	resp, err := llm.GenerateContent(ctx, content, options...)
 
 	// Make sure buffered data reaches the client.
+	if watchdog != nil {
+		watchdog.stop()
+	}
+	if flushReasoning != nil {
+		flushReasoning()
+	}
 	if flush != nil {
 		flush()
 	}
+	completionResp.Timing = getCompletionTiming(startedAt, firstTokenAt)
 
 	debugResp, ok := GetDebugHTTPResponse(ctx)
 	if err != nil {
+		if te := getTimeoutError(ctx); te != nil {
+			completionResp.ErrorDetails = &APIErrorDetails{
+				Message:     te.Error(),
+				Type:        APIErrorTypeTimeout,
+				TimeoutKind: te.Kind,
+			}
+			if ok && debugResp != nil {
+				completionResp.ErrorDetails.RequestDetails = debugResp.RequestDetails
+				completionResp.ErrorDetails.ResponseDetails = debugResp.ResponseDetails
+			}
+			if partial.Len() > 0 {
+				partialContent := partial.String()
+				completionResp.RespContent = &partialContent
+			}
+			if partialReasoning.Len() > 0 {
+				completionResp.ReasoningContents = []spec.ReasoningContent{{
+					Type: spec.ReasoningContentTypeThinking,
+					Text: partialReasoning.String(),
+				}}
+			}
+			return completionResp, nil
+		}
 		if ok && debugResp != nil && debugResp.ErrorDetails != nil {
 			completionResp.ErrorDetails = debugResp.ErrorDetails
 			return completionResp, nil
@@ -261,6 +436,9 @@ func (api *BaseAIAPI) FetchCompletion(
 		completionResp.RequestDetails = debugResp.RequestDetails
 		completionResp.ErrorDetails = debugResp.ErrorDetails
 		completionResp.ResponseDetails = debugResp.ResponseDetails
+		if debugResp.ResponseDetails != nil {
+			completionResp.RequestID = getRequestID(debugResp.ResponseDetails.Headers)
+		}
 	}
 
 	// PrintJSON(resp).
@@ -279,16 +457,137 @@ func (api *BaseAIAPI) FetchCompletion(
 		return nil, errors.New("got nil response from LLM api")
 	}
 
-	reasoningContent := ""
-	if rc := resp.Choices[0].ReasoningContent; rc != "" {
-		reasoningContent = "> Thought process:\n\n" + getBlockQuotedReasoning(rc) + "\n\n"
+	respContent := resp.Choices[0].Content
+	completionResp.RespContent = &respContent
+	completionResp.ReasoningContents = getReasoningContents(resp.Choices[0], thinking.contents())
+	completionResp.Usage = promptCaching.applyTo(
+		getCompletionUsage(resp.Choices[0].GenerationInfo),
+	)
+	completionResp.FinishReason = resp.Choices[0].StopReason
+	if pricing, ok := catalog.Default().Pricing(api.ProviderInfo.Name, input.ModelParams.Name); ok {
+		completionResp.Cost = getCompletionCost(pricing, completionResp.Usage)
 	}
-	full := reasoningContent + resp.Choices[0].Content
-	completionResp.RespContent = &full
 
 	return completionResp, nil
 }
 
+func getCompletionTiming(startedAt, firstTokenAt time.Time) *CompletionTiming {
+	timing := &CompletionTiming{
+		LatencyMs: time.Since(startedAt).Milliseconds(),
+	}
+	if !firstTokenAt.IsZero() {
+		ttft := firstTokenAt.Sub(startedAt).Milliseconds()
+		timing.TimeToFirstTokenMs = &ttft
+	}
+	return timing
+}
+
+// getCompletionUsage reads token counts from the langchaingo generation info.
+// OpenAI compatible providers report Prompt/Completion tokens, Anthropic reports Input/Output tokens.
+func getCompletionUsage(info map[string]any) *CompletionUsage {
+	if len(info) == 0 {
+		return nil
+	}
+	getInt := func(keys ...string) int {
+		for _, k := range keys {
+			switch v := info[k].(type) {
+			case int:
+				return v
+			case int32:
+				return int(v)
+			case int64:
+				return int(v)
+			case float64:
+				return int(v)
+			}
+		}
+		return 0
+	}
+	usage := &CompletionUsage{
+		InputTokens:      getInt("PromptTokens", "InputTokens"),
+		OutputTokens:     getInt("CompletionTokens", "OutputTokens"),
+		ReasoningTokens:  getInt("ReasoningTokens"),
+		CacheReadTokens:  getInt("CacheReadInputTokens"),
+		CacheWriteTokens: getInt("CacheCreationInputTokens"),
+		TotalTokens:      getInt("TotalTokens"),
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens +
+			usage.CacheReadTokens + usage.CacheWriteTokens
+	}
+	if usage.TotalTokens == 0 {
+		return nil
+	}
+	return usage
+}
+
+// getCompletionCost prices the usage in USD. Input tokens are the uncached part of the prompt,
+// cache reads and writes are priced on their own.
+func getCompletionCost(pricing spec.ModelPricing, usage *CompletionUsage) *float64 {
+	if usage == nil {
+		return nil
+	}
+	cacheReadPrice := pricing.CacheReadPerMillion
+	if cacheReadPrice == 0 {
+		cacheReadPrice = pricing.InputPerMillion
+	}
+	cacheWritePrice := pricing.CacheWritePerMillion
+	if cacheWritePrice == 0 {
+		cacheWritePrice = pricing.InputPerMillion
+	}
+	cost := (float64(usage.InputTokens)*pricing.InputPerMillion +
+		float64(usage.CacheReadTokens)*cacheReadPrice +
+		float64(usage.CacheWriteTokens)*cacheWritePrice +
+		float64(usage.OutputTokens)*pricing.OutputPerMillion) / 1e6
+	return &cost
+}
+
+// getRequestID reads the provider request ID from the response headers.
+// OpenAI compatible APIs send X-Request-Id, Anthropic sends Request-Id.
+func getRequestID(headers map[string]any) string {
+	for _, key := range []string{"X-Request-Id", "Request-Id"} {
+		if id, ok := headers[key].(string); ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// getReasoningContents collects the reasoning of a choice. The client returns reasoning as text
+// only, thinking blocks read from the raw Anthropic response keep their signatures and redacted
+// thinking and are used when there are any.
+func getReasoningContents(
+	choice *llms.ContentChoice,
+	thinkingBlocks []spec.ReasoningContent,
+) []spec.ReasoningContent {
+	if len(thinkingBlocks) > 0 {
+		return thinkingBlocks
+	}
+	if choice.ReasoningContent == "" {
+		return nil
+	}
+	return []spec.ReasoningContent{{
+		Type: spec.ReasoningContentTypeThinking,
+		Text: choice.ReasoningContent,
+	}}
+}
+
+// legacyReasoningPrefix starts the block quoted reasoning that older versions stored in the content.
+const legacyReasoningPrefix = "> Thought process:\n\n"
+
+// stripInlineReasoning removes block quoted reasoning stored in the content by older versions.
+// Every reasoning line is quoted, so the first blank line ends the quote.
+func stripInlineReasoning(content string) string {
+	rest, found := strings.CutPrefix(content, legacyReasoningPrefix)
+	if !found {
+		return content
+	}
+	if _, after, ok := strings.Cut(rest, "\n\n"); ok {
+		return after
+	}
+	return content
+}
+
 func getBlockQuotedReasoning(content string) string {
 	// Split the content into lines.
 	lines := strings.Split(content, "\n")
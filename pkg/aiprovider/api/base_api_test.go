@@ -0,0 +1,191 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestGetCompletionCost(t *testing.T) {
+	sonnet := spec.ModelPricing{
+		InputPerMillion:      3,
+		OutputPerMillion:     15,
+		CacheReadPerMillion:  0.3,
+		CacheWritePerMillion: 3.75,
+	}
+	tests := []struct {
+		name    string
+		pricing spec.ModelPricing
+		usage   *CompletionUsage
+		want    *float64
+	}{
+		{
+			name:    "no usage",
+			pricing: sonnet,
+			usage:   nil,
+			want:    nil,
+		},
+		{
+			name:    "uncached",
+			pricing: sonnet,
+			usage:   &CompletionUsage{InputTokens: 1_000_000, OutputTokens: 100_000},
+			want:    ptr(4.5),
+		},
+		{
+			name:    "cache read and write are on top of the input tokens",
+			pricing: sonnet,
+			usage: &CompletionUsage{
+				InputTokens:      20,
+				OutputTokens:     1000,
+				CacheReadTokens:  100_000,
+				CacheWriteTokens: 10_000,
+			},
+			want: ptr((20*3 + 1000*15 + 100_000*0.3 + 10_000*3.75) / 1e6),
+		},
+		{
+			name:    "cache prices default to the input price",
+			pricing: spec.ModelPricing{InputPerMillion: 2, OutputPerMillion: 8},
+			usage: &CompletionUsage{
+				InputTokens:      1000,
+				CacheReadTokens:  1000,
+				CacheWriteTokens: 1000,
+			},
+			want: ptr(3000 * 2 / 1e6),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getCompletionCost(tt.pricing, tt.usage)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			if got != nil && math.Abs(*got-*tt.want) > 1e-12 {
+				t.Errorf("got %v, want %v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCompletionUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		info      map[string]any
+		wantTotal int
+	}{
+		{
+			name:      "openai reports a total",
+			info:      map[string]any{"PromptTokens": 10, "CompletionTokens": 5, "TotalTokens": 15},
+			wantTotal: 15,
+		},
+		{
+			name: "anthropic total counts cache tokens",
+			info: map[string]any{
+				"InputTokens":              10,
+				"OutputTokens":             5,
+				"CacheReadInputTokens":     100,
+				"CacheCreationInputTokens": 20,
+			},
+			wantTotal: 135,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getCompletionUsage(tt.info)
+			if got == nil || got.TotalTokens != tt.wantTotal {
+				t.Errorf("got %+v, want total %d", got, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestGetReasoningContents(t *testing.T) {
+	tests := []struct {
+		name           string
+		choice         *llms.ContentChoice
+		thinkingBlocks []spec.ReasoningContent
+		want           []spec.ReasoningContent
+	}{
+		{
+			name:   "no reasoning",
+			choice: &llms.ContentChoice{Content: "Paris."},
+			want:   nil,
+		},
+		{
+			name: "reasoning text",
+			choice: &llms.ContentChoice{
+				Content:          "Paris.",
+				ReasoningContent: "The capital of France.",
+				GenerationInfo:   map[string]any{"InputTokens": 10, "OutputTokens": 5},
+			},
+			want: []spec.ReasoningContent{{
+				Type: spec.ReasoningContentTypeThinking,
+				Text: "The capital of France.",
+			}},
+		},
+		{
+			name: "thinking blocks of the raw response",
+			choice: &llms.ContentChoice{
+				Content:          "Paris.",
+				ReasoningContent: "The capital of France.",
+			},
+			thinkingBlocks: []spec.ReasoningContent{
+				{
+					Type:      spec.ReasoningContentTypeThinking,
+					Text:      "The capital of France.",
+					Signature: "sig",
+				},
+				{Type: spec.ReasoningContentTypeRedactedThinking, Data: "enc"},
+			},
+			want: []spec.ReasoningContent{
+				{
+					Type:      spec.ReasoningContentTypeThinking,
+					Text:      "The capital of France.",
+					Signature: "sig",
+				},
+				{Type: spec.ReasoningContentTypeRedactedThinking, Data: "enc"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getReasoningContents(tt.choice, tt.thinkingBlocks)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetAuthHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		headerKey string
+		apiKey    string
+		want      string
+	}{
+		{name: "bearer token", headerKey: "Authorization", apiKey: "sk-1", want: "Bearer sk-1"},
+		{name: "custom header", headerKey: "x-api-key", apiKey: "sk-2", want: "sk-2"},
+		{name: "no key", headerKey: "Authorization", apiKey: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewBaseAIAPI(&spec.ProviderInfo{
+				APIKey:          tt.apiKey,
+				APIKeyHeaderKey: tt.headerKey,
+			}, false)
+			header := http.Header{}
+			api.setAuthHeader(header)
+			if got := header.Get(tt.headerKey); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
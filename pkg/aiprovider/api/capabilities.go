@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+// ErrUnsupportedCapability is returned for requests that need a capability the model does not have.
+var ErrUnsupportedCapability = errors.New("unsupported model capability")
+
+// AdaptModelParams validates model params against the capabilities of the model.
+// Options the model cannot handle are dropped or clamped, and each change is reported as an adjustment.
+// Requests that cannot be adapted, like tool definitions for a model without tool calling, return an error.
+func AdaptModelParams(
+	params spec.ModelParams,
+	caps spec.ModelCapabilities,
+) (adapted spec.ModelParams, adjustments []string, err error) {
+	if _, ok := params.AdditionalParameters["response_format"]; ok && !caps.JSONMode {
+		return params, nil, fmt.Errorf("%w: JSON mode", ErrUnsupportedCapability)
+	}
+	for _, key := range []string{"tools", "functions"} {
+		if _, ok := params.AdditionalParameters[key]; ok && !caps.ToolCalling {
+			return params, nil, fmt.Errorf("%w: tool calling", ErrUnsupportedCapability)
+		}
+	}
+
+	adapted = params
+	if adapted.Temperature != nil && !caps.Temperature {
+		adapted.Temperature = nil
+		adjustments = append(adjustments, "temperature dropped")
+	}
+	if adapted.Stream && !caps.Streaming {
+		adapted.Stream = false
+		adjustments = append(adjustments, "streaming disabled")
+	}
+	if adapted.Reasoning != nil && adapted.Reasoning.Type != caps.ReasoningType {
+		adapted.Reasoning = nil
+		adjustments = append(adjustments, "reasoning dropped")
+	}
+	if caps.MaxOutputTokens > 0 && adapted.MaxOutputLength > caps.MaxOutputTokens {
+		adapted.MaxOutputLength = caps.MaxOutputTokens
+		adjustments = append(adjustments, "max output length clamped")
+	}
+	if caps.ContextWindow > 0 &&
+		adapted.MaxPromptLength+adapted.MaxOutputLength > caps.ContextWindow {
+		adapted.MaxPromptLength = max(caps.ContextWindow-adapted.MaxOutputLength, 0)
+		adjustments = append(adjustments, "max prompt length clamped to the context window")
+	}
+	return adapted, adjustments, nil
+}
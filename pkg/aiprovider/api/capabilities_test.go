@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+func TestAdaptModelParams(t *testing.T) {
+	full := spec.ModelCapabilities{
+		ToolCalling:     true,
+		JSONMode:        true,
+		Temperature:     true,
+		Streaming:       true,
+		ReasoningType:   spec.ReasoningTypeSingleWithLevels,
+		ContextWindow:   128000,
+		MaxOutputTokens: 16000,
+	}
+	withCaps := func(change func(*spec.ModelCapabilities)) spec.ModelCapabilities {
+		caps := full
+		change(&caps)
+		return caps
+	}
+	reasoning := &spec.ReasoningParams{
+		Type:  spec.ReasoningTypeSingleWithLevels,
+		Level: spec.ReasoningLevelHigh,
+	}
+
+	tests := []struct {
+		name            string
+		params          spec.ModelParams
+		caps            spec.ModelCapabilities
+		wantErr         bool
+		wantAdjustments []string
+		check           func(t *testing.T, got spec.ModelParams)
+	}{
+		{
+			name: "supported params are kept",
+			params: spec.ModelParams{
+				Stream:          true,
+				MaxPromptLength: 100000,
+				MaxOutputLength: 8000,
+				Temperature:     ptr(0.2),
+				Reasoning:       reasoning,
+				AdditionalParameters: map[string]any{
+					"response_format": map[string]any{"type": "json_object"},
+					"tools":           []any{},
+				},
+			},
+			caps: full,
+			check: func(t *testing.T, got spec.ModelParams) {
+				t.Helper()
+				if !got.Stream || got.Temperature == nil || got.Reasoning == nil ||
+					got.MaxPromptLength != 100000 || got.MaxOutputLength != 8000 {
+					t.Errorf("params changed: %+v", got)
+				}
+			},
+		},
+		{
+			name: "response format without JSON mode",
+			params: spec.ModelParams{
+				AdditionalParameters: map[string]any{"response_format": "json_object"},
+			},
+			caps:    withCaps(func(c *spec.ModelCapabilities) { c.JSONMode = false }),
+			wantErr: true,
+		},
+		{
+			name:    "tools without tool calling",
+			params:  spec.ModelParams{AdditionalParameters: map[string]any{"tools": []any{}}},
+			caps:    withCaps(func(c *spec.ModelCapabilities) { c.ToolCalling = false }),
+			wantErr: true,
+		},
+		{
+			name:    "functions without tool calling",
+			params:  spec.ModelParams{AdditionalParameters: map[string]any{"functions": []any{}}},
+			caps:    withCaps(func(c *spec.ModelCapabilities) { c.ToolCalling = false }),
+			wantErr: true,
+		},
+		{
+			name:            "temperature dropped",
+			params:          spec.ModelParams{Temperature: ptr(0.7)},
+			caps:            withCaps(func(c *spec.ModelCapabilities) { c.Temperature = false }),
+			wantAdjustments: []string{"temperature dropped"},
+			check: func(t *testing.T, got spec.ModelParams) {
+				t.Helper()
+				if got.Temperature != nil {
+					t.Errorf("temperature = %v, want nil", *got.Temperature)
+				}
+			},
+		},
+		{
+			name:            "streaming disabled",
+			params:          spec.ModelParams{Stream: true},
+			caps:            withCaps(func(c *spec.ModelCapabilities) { c.Streaming = false }),
+			wantAdjustments: []string{"streaming disabled"},
+			check: func(t *testing.T, got spec.ModelParams) {
+				t.Helper()
+				if got.Stream {
+					t.Error("stream is still set")
+				}
+			},
+		},
+		{
+			name:   "reasoning of another type dropped",
+			params: spec.ModelParams{Reasoning: reasoning},
+			caps: withCaps(
+				func(c *spec.ModelCapabilities) { c.ReasoningType = spec.ReasoningTypeHybridWithTokens },
+			),
+			wantAdjustments: []string{"reasoning dropped"},
+			check: func(t *testing.T, got spec.ModelParams) {
+				t.Helper()
+				if got.Reasoning != nil {
+					t.Errorf("reasoning = %+v, want nil", got.Reasoning)
+				}
+			},
+		},
+		{
+			name:            "reasoning dropped for a model without reasoning",
+			params:          spec.ModelParams{Reasoning: reasoning},
+			caps:            withCaps(func(c *spec.ModelCapabilities) { c.ReasoningType = "" }),
+			wantAdjustments: []string{"reasoning dropped"},
+		},
+		{
+			name:            "max output length clamped",
+			params:          spec.ModelParams{MaxPromptLength: 1000, MaxOutputLength: 32000},
+			caps:            full,
+			wantAdjustments: []string{"max output length clamped"},
+			check: func(t *testing.T, got spec.ModelParams) {
+				t.Helper()
+				if got.MaxOutputLength != 16000 || got.MaxPromptLength != 1000 {
+					t.Errorf(
+						"lengths = %d prompt, %d output",
+						got.MaxPromptLength,
+						got.MaxOutputLength,
+					)
+				}
+			},
+		},
+		{
+			name:   "prompt length clamped to the context window",
+			params: spec.ModelParams{MaxPromptLength: 200000, MaxOutputLength: 40000},
+			caps:   full,
+			wantAdjustments: []string{
+				"max output length clamped",
+				"max prompt length clamped to the context window",
+			},
+			check: func(t *testing.T, got spec.ModelParams) {
+				t.Helper()
+				if got.MaxOutputLength != 16000 || got.MaxPromptLength != 112000 {
+					t.Errorf(
+						"lengths = %d prompt, %d output",
+						got.MaxPromptLength,
+						got.MaxOutputLength,
+					)
+				}
+			},
+		},
+		{
+			name:   "unknown limits are not clamped",
+			params: spec.ModelParams{MaxPromptLength: 500000, MaxOutputLength: 100000},
+			caps: withCaps(func(c *spec.ModelCapabilities) {
+				c.ContextWindow = 0
+				c.MaxOutputTokens = 0
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, adjustments, err := AdaptModelParams(tt.params, tt.caps)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedCapability) {
+					t.Fatalf("err = %v, want ErrUnsupportedCapability", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(adjustments, tt.wantAdjustments) {
+				t.Errorf("adjustments = %q, want %q", adjustments, tt.wantAdjustments)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
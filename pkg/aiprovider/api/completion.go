@@ -25,11 +25,28 @@ type APIResponseDetails struct {
 }
 
 type APIErrorDetails struct {
-	Message         string              `json:"message"`
+	Message string `json:"message"`
+	// Type classifies errors that clients may handle specially, like timeouts.
+	Type            APIErrorType        `json:"type,omitempty"`
+	TimeoutKind     TimeoutKind         `json:"timeoutKind,omitempty"`
 	RequestDetails  *APIRequestDetails  `json:"requestDetails,omitempty"`
 	ResponseDetails *APIResponseDetails `json:"responseDetails,omitempty"`
 }
 
+// APIErrorTypeOutboundBlocked marks completions that were not sent because the outbound scan blocked them.
+const APIErrorTypeOutboundBlocked APIErrorType = "outboundBlocked"
+
+// OutboundScanFinding is a match of the outbound scan, the matched text is not included.
+type OutboundScanFinding struct {
+	// Location is systemPrompt, prompt or prevMessages[i].
+	Location    string                  `json:"location"`
+	Rule        string                  `json:"rule"`
+	Description string                  `json:"description,omitempty"`
+	Action      spec.OutboundScanAction `json:"action"`
+	Start       int                     `json:"start"`
+	End         int                     `json:"end"`
+}
+
 type APIFetchResponse[T any] struct {
 	Data            *T                  `json:"data,omitempty"`
 	ResponseDetails *APIResponseDetails `json:"responseDetails,omitempty"`
@@ -37,13 +54,47 @@ type APIFetchResponse[T any] struct {
 	ErrorDetails    *APIErrorDetails    `json:"errorDetails,omitempty"`
 }
 
+// CompletionUsage is the token accounting reported by the provider for a completion.
+type CompletionUsage struct {
+	InputTokens     int `json:"inputTokens"`
+	OutputTokens    int `json:"outputTokens"`
+	ReasoningTokens int `json:"reasoningTokens,omitempty"`
+	// CacheReadTokens and CacheWriteTokens are prompt tokens read from and written to the
+	// cache. They are not part of InputTokens, which are the uncached prompt tokens as Anthropic
+	// reports them, but they are part of TotalTokens.
+	CacheReadTokens  int `json:"cacheReadTokens,omitempty"`
+	CacheWriteTokens int `json:"cacheWriteTokens,omitempty"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// CompletionTiming captures wall clock timings of a completion call.
+// TimeToFirstTokenMs is only available for streamed completions.
+type CompletionTiming struct {
+	LatencyMs          int64  `json:"latencyMs"`
+	TimeToFirstTokenMs *int64 `json:"timeToFirstTokenMs,omitempty"`
+}
+
 type CompletionResponse struct {
 	RequestDetails  *APIRequestDetails  `json:"requestDetails,omitempty"`
 	ResponseDetails *APIResponseDetails `json:"responseDetails,omitempty"`
 	ErrorDetails    *APIErrorDetails    `json:"errorDetails,omitempty"`
 	RespContent     *string             `json:"respContent,omitempty"`
-	FunctionName    *string             `json:"functionName,omitempty"`
-	FunctionArgs    any                 `json:"functionArgs,omitempty"`
+	// ReasoningContents holds the reasoning of the model, it is never part of RespContent.
+	ReasoningContents []spec.ReasoningContent `json:"reasoningContents,omitempty"`
+	FunctionName      *string                 `json:"functionName,omitempty"`
+	FunctionArgs      any                     `json:"functionArgs,omitempty"`
+	Usage             *CompletionUsage        `json:"usage,omitempty"`
+	Timing            *CompletionTiming       `json:"timing,omitempty"`
+	// Cost is in USD from the catalog prices of the model, nil if they are not known.
+	Cost *float64 `json:"cost,omitempty"`
+	// ModelParams are the effective params of the request, after defaults and limits were applied.
+	ModelParams *spec.ModelParams `json:"modelParams,omitempty"`
+	// FinishReason is the reason the model stopped as reported by the provider, e.g. stop or max_tokens.
+	FinishReason string `json:"finishReason,omitempty"`
+	// RequestID is the ID the provider gave the request, for support requests and logs.
+	RequestID string `json:"requestID,omitempty"`
+	// OutboundScanFindings are the secrets and personal data found in the request before it was sent.
+	OutboundScanFindings []OutboundScanFinding `json:"outboundScanFindings,omitempty"`
 }
 
 type CompletionRequest struct {
@@ -69,6 +120,10 @@ type CompletionProvider interface {
 		origin *string,
 		chatCompletionPathPrefix *string,
 	) error
+	SetNetworkConfig(
+		ctx context.Context,
+		networkConfig *spec.NetworkConfig,
+	) error
 	FetchCompletion(
 		ctx context.Context,
 		llm llms.Model,
@@ -77,5 +132,6 @@ type CompletionProvider interface {
 		inbuiltModelParams *spec.ModelParams,
 		prevMessages []spec.ChatCompletionRequestMessage,
 		onStreamData func(data string) error,
+		onStreamReasoning func(data string) error,
 	) (*CompletionResponse, error)
 }
@@ -297,10 +297,14 @@ func (t *LogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 // NewDebugHTTPClient creates a new HTTP client with logging capabilities.
-func NewDebugHTTPClient(logMode bool) *http.Client {
+// A nil transport uses http.DefaultTransport.
+func NewDebugHTTPClient(logMode bool, transport http.RoundTripper) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
 	return &http.Client{
 		Transport: &LogTransport{
-			Transport: http.DefaultTransport,
+			Transport: transport,
 			LogMode:   logMode,
 		},
 	}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+// EmbeddingUsage is the token accounting reported by the provider for an embeddings call.
+type EmbeddingUsage struct {
+	PromptTokens int `json:"promptTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+type EmbeddingResponse struct {
+	ModelName spec.ModelName `json:"modelName"`
+	// Embeddings are in the same order as the inputs.
+	Embeddings   [][]float32      `json:"embeddings"`
+	Dimensions   int              `json:"dimensions"`
+	Usage        *EmbeddingUsage  `json:"usage,omitempty"`
+	ErrorDetails *APIErrorDetails `json:"errorDetails,omitempty"`
+}
+
+// EmbeddingProvider is implemented by providers that can compute embeddings.
+// It is a sibling of CompletionProvider, a provider may implement both.
+type EmbeddingProvider interface {
+	FetchEmbeddings(
+		ctx context.Context,
+		modelName spec.ModelName,
+		inputs []string,
+		dimensions *int,
+	) (*EmbeddingResponse, error)
+}
+
+type openAIEmbeddingRequest struct {
+	Model          string   `json:"model"`
+	Input          []string `json:"input"`
+	EncodingFormat string   `json:"encoding_format"`
+	Dimensions     *int     `json:"dimensions,omitempty"`
+}
+
+type openAIEmbeddingResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage *struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// FetchEmbeddings calls the OpenAI compatible embeddings endpoint of the provider.
+// The endpoint is derived from the chat completions path, i.e. "/v1/chat/completions" maps to "/v1/embeddings".
+func (api *OpenAICompatibleAPI) FetchEmbeddings(
+	ctx context.Context,
+	modelName spec.ModelName,
+	inputs []string,
+	dimensions *int,
+) (*EmbeddingResponse, error) {
+	if modelName == "" || len(inputs) == 0 {
+		return nil, errors.New("got empty model/inputs")
+	}
+
+	reqBody, err := json.Marshal(openAIEmbeddingRequest{
+		Model:          string(modelName),
+		Input:          inputs,
+		EncodingFormat: "float",
+		Dimensions:     dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = AddDebugResponseToCtx(ctx)
+	httpReq, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		api.getProviderURL()+"/embeddings",
+		bytes.NewReader(reqBody),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range api.ProviderInfo.DefaultHeaders {
+		httpReq.Header.Set(k, v)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	api.setAuthHeader(httpReq.Header)
+
+	httpResp, err := api.getHTTPClient().Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		embeddingResp := &EmbeddingResponse{ModelName: modelName}
+		if debugResp, ok := GetDebugHTTPResponse(ctx); ok && debugResp != nil {
+			embeddingResp.ErrorDetails = &APIErrorDetails{
+				Message: fmt.Sprintf(
+					"embeddings request failed with status %d",
+					httpResp.StatusCode,
+				),
+				RequestDetails:  debugResp.RequestDetails,
+				ResponseDetails: debugResp.ResponseDetails,
+			}
+		}
+		return embeddingResp, fmt.Errorf(
+			"embeddings request failed with status %d: %s",
+			httpResp.StatusCode,
+			string(respBody),
+		)
+	}
+
+	var parsed openAIEmbeddingResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", err)
+	}
+	if len(parsed.Data) != len(inputs) {
+		return nil, fmt.Errorf(
+			"invalid embeddings response: got %d embeddings for %d inputs",
+			len(parsed.Data),
+			len(inputs),
+		)
+	}
+
+	embeddings := make([][]float32, len(inputs))
+	for _, d := range parsed.Data {
+		if d.Index < 0 || d.Index >= len(inputs) {
+			return nil, fmt.Errorf("invalid embeddings response: index %d out of range", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+
+	embeddingResp := &EmbeddingResponse{
+		ModelName:  modelName,
+		Embeddings: embeddings,
+		Dimensions: len(embeddings[0]),
+	}
+	if parsed.Usage != nil {
+		embeddingResp.Usage = &EmbeddingUsage{
+			PromptTokens: parsed.Usage.PromptTokens,
+			TotalTokens:  parsed.Usage.TotalTokens,
+		}
+	}
+	return embeddingResp, nil
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+func TestFetchEmbeddings(t *testing.T) {
+	var gotReq openAIEmbeddingRequest
+	var gotPath, gotAuth, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotHeader = r.Header.Get("X-Test")
+		if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		switch gotReq.Model {
+		case "failing":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"bad model"}}`))
+		case "short":
+			_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[1]}]}`))
+		default:
+			// Out of order data is put back in input order.
+			_, _ = w.Write([]byte(`{"model":"embed","data":[` +
+				`{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}],` +
+				`"usage":{"prompt_tokens":4,"total_tokens":4}}`))
+		}
+	}))
+	defer server.Close()
+
+	embedder := NewOpenAICompatibleProvider(spec.ProviderInfo{
+		APIKey:                   "secret",
+		Origin:                   server.URL,
+		ChatCompletionPathPrefix: "/v1/chat/completions",
+		APIKeyHeaderKey:          "Authorization",
+		DefaultHeaders:           map[string]string{"X-Test": "yes"},
+	}, false)
+
+	tests := []struct {
+		name           string
+		model          spec.ModelName
+		dimensions     *int
+		wantErr        string
+		wantErrDetails string
+		check          func(t *testing.T, resp *EmbeddingResponse)
+	}{
+		{
+			name:       "embeddings in input order",
+			model:      "embed",
+			dimensions: ptr(2),
+			check: func(t *testing.T, resp *EmbeddingResponse) {
+				t.Helper()
+				want := [][]float32{{0.1, 0.2}, {0.3, 0.4}}
+				if !slices.EqualFunc(resp.Embeddings, want, slices.Equal) {
+					t.Errorf("embeddings = %v, want %v", resp.Embeddings, want)
+				}
+				if resp.Dimensions != 2 || resp.ModelName != "embed" {
+					t.Errorf("dimensions %d, model %s", resp.Dimensions, resp.ModelName)
+				}
+				if resp.Usage == nil || resp.Usage.PromptTokens != 4 ||
+					resp.Usage.TotalTokens != 4 {
+					t.Errorf("usage = %+v", resp.Usage)
+				}
+				if gotReq.Dimensions == nil || *gotReq.Dimensions != 2 {
+					t.Errorf("dimensions not sent: %+v", gotReq)
+				}
+			},
+		},
+		{
+			name:           "non 2xx response",
+			model:          "failing",
+			wantErr:        "status 400",
+			wantErrDetails: "embeddings request failed with status 400",
+		},
+		{
+			name:    "fewer embeddings than inputs",
+			model:   "short",
+			wantErr: "got 1 embeddings for 2 inputs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotReq = openAIEmbeddingRequest{}
+			resp, err := embedder.FetchEmbeddings(
+				t.Context(),
+				tt.model,
+				[]string{"a", "b"},
+				tt.dimensions,
+			)
+
+			if gotPath != "/v1/embeddings" {
+				t.Errorf("path = %s, want /v1/embeddings", gotPath)
+			}
+			if gotAuth != "Bearer secret" || gotHeader != "yes" {
+				t.Errorf("headers: auth %q, default header %q", gotAuth, gotHeader)
+			}
+			if gotReq.Model != string(tt.model) ||
+				!slices.Equal(gotReq.Input, []string{"a", "b"}) ||
+				gotReq.EncodingFormat != "float" {
+				t.Errorf("request = %+v", gotReq)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if tt.wantErrDetails == "" {
+					return
+				}
+				if resp == nil || resp.ErrorDetails == nil ||
+					resp.ErrorDetails.Message != tt.wantErrDetails {
+					t.Fatalf("error details = %+v, want %q", resp, tt.wantErrDetails)
+				}
+				if rd := resp.ErrorDetails.ResponseDetails; rd == nil ||
+					rd.Status != http.StatusBadRequest {
+					t.Errorf("response details = %+v", rd)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.check(t, resp)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 
 	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
@@ -10,6 +12,15 @@ import (
 	langchainHuggingFace "github.com/tmc/langchaingo/llms/huggingface"
 )
 
+// ErrNetworkConfigUnsupported is returned by providers whose client cannot use a proxy or TLS
+// config.
+var ErrNetworkConfigUnsupported = errors.New("network config is not supported")
+
+var errNetworkConfigHuggingFace = fmt.Errorf(
+	"%w for Huggingface, clear the proxy and TLS config of the provider to use it",
+	ErrNetworkConfigUnsupported,
+)
+
 // HuggingFaceCompatibleAPI struct that implements the CompletionProvider interface.
 type HuggingFaceCompatibleAPI struct {
 	*BaseAIAPI
@@ -24,9 +35,30 @@ func NewHuggingFaceCompatibleAPI(pi spec.ProviderInfo, debug bool) *HuggingFaceC
 }
 
 func (api *HuggingFaceCompatibleAPI) GetLLMsModel(ctx context.Context) llms.Model {
+	// A nil *LLM in the interface would not compare equal to nil.
+	if api.llm == nil {
+		return nil
+	}
 	return api.llm
 }
 
+// SetNetworkConfig keeps a non empty config but returns an error wrapping
+// ErrNetworkConfigUnsupported, the langchaingo client cannot use it. The provider stays
+// uninitialized until the config is cleared, so that requests never bypass the proxy.
+func (api *HuggingFaceCompatibleAPI) SetNetworkConfig(
+	ctx context.Context,
+	networkConfig *spec.NetworkConfig,
+) error {
+	if err := api.BaseAIAPI.SetNetworkConfig(ctx, networkConfig); err != nil {
+		return err
+	}
+	if networkConfig.IsZero() {
+		return nil
+	}
+	api.llm = nil
+	return errNetworkConfigHuggingFace
+}
+
 func (api *HuggingFaceCompatibleAPI) InitLLM(ctx context.Context) error {
 	options := []langchainHuggingFace.Option{}
 
@@ -35,6 +67,10 @@ func (api *HuggingFaceCompatibleAPI) InitLLM(ctx context.Context) error {
 		providerURL = api.ProviderInfo.Origin
 		options = append(options, langchainHuggingFace.WithURL(providerURL))
 	}
+	if !api.NetworkConfig.IsZero() {
+		api.llm = nil
+		return errNetworkConfigHuggingFace
+	}
 	// Setting a debug client is not supproted on HF by langchaingo
 	// if api.BaseAIAPI.Debug {
 	// 	options = append(options, langchainHuggingFace.WithHTTPClient(httputil.DebugHTTPClient))
@@ -30,18 +30,14 @@ func (api *OpenAICompatibleAPI) GetLLMsModel(ctx context.Context) llms.Model {
 
 func (api *OpenAICompatibleAPI) InitLLM(ctx context.Context) error {
 	options := []langchainOpenAI.Option{}
+	// Rebuild the transport so that network config changes take effect.
+	newClient, err := api.initHTTPClient()
+	if err != nil {
+		return err
+	}
 
-	providerURL := "https://api.openai.com/v1"
+	providerURL := api.getProviderURL()
 	if api.ProviderInfo.Origin != "" {
-		baseURL := api.ProviderInfo.Origin
-		// Remove trailing slash from baseURL if present.
-		baseURL = strings.TrimSuffix(baseURL, "/")
-
-		pathPrefix := api.ProviderInfo.ChatCompletionPathPrefix
-		// Remove '/chat/completions' from pathPrefix if present,
-		// This is because langchaingo adds '/chat/completions' internally.
-		pathPrefix = strings.TrimSuffix(pathPrefix, "/chat/completions")
-		providerURL = baseURL + pathPrefix
 		options = append(options, langchainOpenAI.WithBaseURL(providerURL))
 	}
 
@@ -54,7 +50,6 @@ func (api *OpenAICompatibleAPI) InitLLM(ctx context.Context) error {
 		return nil
 	}
 	options = append(options, langchainOpenAI.WithToken(api.ProviderInfo.APIKey))
-	newClient := NewDebugHTTPClient(api.Debug)
 	options = append(options, langchainOpenAI.WithHTTPClient(newClient))
 
 	llm, err := langchainOpenAI.New(options...)
@@ -71,3 +66,16 @@ func (api *OpenAICompatibleAPI) InitLLM(ctx context.Context) error {
 	)
 	return nil
 }
+
+// getProviderURL returns the OpenAI style base URL, i.e. the one to which "/chat/completions" is appended.
+func (api *OpenAICompatibleAPI) getProviderURL() string {
+	if api.ProviderInfo.Origin == "" {
+		return "https://api.openai.com/v1"
+	}
+	// Remove trailing slash from baseURL if present.
+	baseURL := strings.TrimSuffix(api.ProviderInfo.Origin, "/")
+	// Remove '/chat/completions' from pathPrefix if present,
+	// This is because langchaingo adds '/chat/completions' internally.
+	pathPrefix := strings.TrimSuffix(api.ProviderInfo.ChatCompletionPathPrefix, "/chat/completions")
+	return baseURL + pathPrefix
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/tmc/langchaingo/llms"
+)
+
+type ProbeMethod string
+
+const (
+	ProbeMethodListModels ProbeMethod = "listModels"
+	ProbeMethodCompletion ProbeMethod = "completion"
+)
+
+// ConnectionPhaseTimings are the durations of the connection phases of a probe.
+// A phase is nil if it did not happen, e.g. DNS for an IP origin or TLS for plain http.
+type ConnectionPhaseTimings struct {
+	DNSMs       *int64 `json:"dnsMs,omitempty"`
+	ConnectMs   *int64 `json:"connectMs,omitempty"`
+	TLSMs       *int64 `json:"tlsMs,omitempty"`
+	FirstByteMs *int64 `json:"firstByteMs,omitempty"`
+}
+
+// ConnectionTestResult is the outcome of a cheap probe against a provider.
+type ConnectionTestResult struct {
+	Provider spec.ProviderName `json:"provider"`
+	Method   ProbeMethod       `json:"method"`
+	URL      string            `json:"url,omitempty"`
+	// Reachable is true if an HTTP response was received.
+	Reachable bool `json:"reachable"`
+	// DNSError and TLSError are set if the probe failed in that phase.
+	DNSError   *string                `json:"dnsError,omitempty"`
+	TLSError   *string                `json:"tlsError,omitempty"`
+	HTTPStatus int                    `json:"httpStatus,omitempty"`
+	Timings    ConnectionPhaseTimings `json:"timings"`
+	LatencyMs  int64                  `json:"latencyMs"`
+	// AuthValid is nil if the response does not tell, e.g. on a 5xx.
+	AuthValid *bool `json:"authValid,omitempty"`
+	// Models visible to the key, only for the list models probe.
+	Models       []string         `json:"models,omitempty"`
+	Error        *string          `json:"error,omitempty"`
+	ErrorDetails *APIErrorDetails `json:"errorDetails,omitempty"`
+}
+
+// ConnectionProber is implemented by providers that have a cheap model listing endpoint.
+type ConnectionProber interface {
+	ProbeConnection(ctx context.Context) (*ConnectionTestResult, error)
+}
+
+// ProbeConnection lists models at "<base URL>/models".
+func (api *OpenAICompatibleAPI) ProbeConnection(
+	ctx context.Context,
+) (*ConnectionTestResult, error) {
+	return api.probeListModels(ctx, api.getProviderURL()+"/models")
+}
+
+// ProbeConnection lists models at "<origin><path prefix minus /messages>/models".
+func (api *AnthropicCompatibleAPI) ProbeConnection(
+	ctx context.Context,
+) (*ConnectionTestResult, error) {
+	return api.probeListModels(ctx, api.getProviderURL()+"/models")
+}
+
+// ProbeWithCompletion requests a one token completion, for providers without a model listing probe.
+func ProbeWithCompletion(
+	ctx context.Context,
+	provider spec.ProviderName,
+	llm llms.Model,
+	modelName spec.ModelName,
+) *ConnectionTestResult {
+	result := &ConnectionTestResult{Provider: provider, Method: ProbeMethodCompletion}
+	if llm == nil {
+		setProbeError(result, errors.New("provider is not initialized, check the API key"))
+		return result
+	}
+
+	ctx, trace := withProbeTrace(AddDebugResponseToCtx(ctx))
+	_, err := llm.GenerateContent(
+		ctx,
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "ping")},
+		llms.WithModel(string(modelName)),
+		llms.WithMaxTokens(1),
+	)
+	trace.fill(result)
+	if debugResp, ok := GetDebugHTTPResponse(ctx); ok && debugResp != nil {
+		if debugResp.RequestDetails != nil && debugResp.RequestDetails.URL != nil {
+			result.URL = *debugResp.RequestDetails.URL
+		}
+		if debugResp.ResponseDetails != nil {
+			result.Reachable = true
+			result.HTTPStatus = debugResp.ResponseDetails.Status
+		}
+		result.ErrorDetails = debugResp.ErrorDetails
+	}
+	if result.HTTPStatus == 0 && err == nil {
+		// Clients that do not use the debug transport still reached the provider.
+		result.Reachable = true
+		result.HTTPStatus = http.StatusOK
+	}
+	result.AuthValid = authValidFromStatus(result.HTTPStatus)
+	if err != nil {
+		setProbeError(result, err)
+	}
+	return result
+}
+
+func (api *BaseAIAPI) probeListModels(
+	ctx context.Context,
+	url string,
+) (*ConnectionTestResult, error) {
+	result := &ConnectionTestResult{
+		Provider: api.ProviderInfo.Name,
+		Method:   ProbeMethodListModels,
+		URL:      url,
+	}
+
+	ctx, trace := withProbeTrace(AddDebugResponseToCtx(ctx))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range api.ProviderInfo.DefaultHeaders {
+		req.Header.Set(k, v)
+	}
+	api.setAuthHeader(req.Header)
+
+	resp, err := api.getHTTPClient().Do(req)
+	if err != nil {
+		trace.fill(result)
+		setProbeError(result, err)
+		return result, nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	trace.fill(result)
+
+	result.Reachable = true
+	result.HTTPStatus = resp.StatusCode
+	result.AuthValid = authValidFromStatus(resp.StatusCode)
+	if err != nil {
+		setProbeError(result, err)
+		return result, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		setProbeError(result, fmt.Errorf("list models failed with status %d: %s",
+			resp.StatusCode, strings.TrimSpace(string(body))))
+		return result, nil
+	}
+
+	var parsed struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		setProbeError(result, fmt.Errorf("invalid list models response: %w", err))
+		return result, nil
+	}
+	result.Models = make([]string, 0, len(parsed.Data))
+	for _, m := range parsed.Data {
+		result.Models = append(result.Models, m.ID)
+	}
+	return result, nil
+}
+
+func authValidFromStatus(status int) *bool {
+	var valid bool
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		valid = false
+	case status >= 200 && status < 300:
+		valid = true
+	default:
+		return nil
+	}
+	return &valid
+}
+
+func setProbeError(result *ConnectionTestResult, err error) {
+	errStr := err.Error()
+	result.Error = &errStr
+}
+
+// probeTrace records connection phase timings via httptrace.
+type probeTrace struct {
+	mu        sync.Mutex
+	start     time.Time
+	end       time.Time
+	dnsStart  time.Time
+	dnsDone   time.Time
+	connStart time.Time
+	connDone  time.Time
+	tlsStart  time.Time
+	tlsDone   time.Time
+	firstByte time.Time
+	dnsErr    error
+	tlsErr    error
+}
+
+func withProbeTrace(ctx context.Context) (context.Context, *probeTrace) {
+	t := &probeTrace{start: time.Now()}
+	set := func(f func()) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		f()
+	}
+	clientTrace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { set(func() { t.dnsStart = time.Now() }) },
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			set(func() { t.dnsDone, t.dnsErr = time.Now(), info.Err })
+		},
+		ConnectStart: func(string, string) {
+			set(func() {
+				if t.connStart.IsZero() {
+					t.connStart = time.Now()
+				}
+			})
+		},
+		ConnectDone:       func(string, string, error) { set(func() { t.connDone = time.Now() }) },
+		TLSHandshakeStart: func() { set(func() { t.tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			set(func() { t.tlsDone, t.tlsErr = time.Now(), err })
+		},
+		GotFirstResponseByte: func() { set(func() { t.firstByte = time.Now() }) },
+	}
+	return httptrace.WithClientTrace(ctx, clientTrace), t
+}
+
+func (t *probeTrace) fill(result *ConnectionTestResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.end = time.Now()
+	result.LatencyMs = t.end.Sub(t.start).Milliseconds()
+	result.Timings = ConnectionPhaseTimings{
+		DNSMs:       phaseMs(t.dnsStart, t.dnsDone),
+		ConnectMs:   phaseMs(t.connStart, t.connDone),
+		TLSMs:       phaseMs(t.tlsStart, t.tlsDone),
+		FirstByteMs: phaseMs(t.start, t.firstByte),
+	}
+	if t.dnsErr != nil {
+		errStr := t.dnsErr.Error()
+		result.DNSError = &errStr
+	}
+	if t.tlsErr != nil {
+		errStr := t.tlsErr.Error()
+		result.TLSError = &errStr
+	}
+}
+
+func phaseMs(start, end time.Time) *int64 {
+	if start.IsZero() || end.IsZero() {
+		return nil
+	}
+	ms := end.Sub(start).Milliseconds()
+	return &ms
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+func TestAuthValidFromStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   *bool
+	}{
+		{status: http.StatusOK, want: ptr(true)},
+		{status: http.StatusNoContent, want: ptr(true)},
+		{status: http.StatusUnauthorized, want: ptr(false)},
+		{status: http.StatusForbidden, want: ptr(false)},
+		{status: http.StatusNotFound},
+		{status: http.StatusTooManyRequests},
+		{status: http.StatusBadGateway},
+		{status: 0},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			got := authValidFromStatus(tt.status)
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("authValidFromStatus(%d) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+// probeServer answers the models and chat completions endpoints with the given status.
+// A zero status blocks until the request is canceled or the test ends.
+func probeServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing auth header on %s", r.URL.Path)
+		}
+		if status == 0 {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			_, _ = w.Write([]byte(`{"error":{"message":"probe failed"}}`))
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/models"):
+			_, _ = w.Write([]byte(`{"data":[{"id":"m1"},{"id":"m2"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+			_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","model":"m1",` +
+				`"choices":[{"index":0,"message":{"role":"assistant","content":"p"},` +
+				`"finish_reason":"length"}]}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	// Cleanups run last in first out, blocked handlers are released before the server closes.
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	return server
+}
+
+func newProbeAPI(t *testing.T, server *httptest.Server) *OpenAICompatibleAPI {
+	t.Helper()
+	p := NewOpenAICompatibleProvider(spec.ProviderInfo{
+		Name:                     "local",
+		APIKey:                   "secret",
+		Origin:                   server.URL,
+		ChatCompletionPathPrefix: "/v1/chat/completions",
+		APIKeyHeaderKey:          "Authorization",
+	}, false)
+	if err := p.InitLLM(t.Context()); err != nil {
+		t.Fatalf("InitLLM: %v", err)
+	}
+	return p
+}
+
+func TestProbeConnection(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		timeout       time.Duration
+		wantReachable bool
+		wantAuthValid *bool
+		wantModels    []string
+		wantErr       string
+	}{
+		{
+			name:          "ok",
+			status:        http.StatusOK,
+			wantReachable: true,
+			wantAuthValid: ptr(true),
+			wantModels:    []string{"m1", "m2"},
+		},
+		{
+			name:          "unauthorized",
+			status:        http.StatusUnauthorized,
+			wantReachable: true,
+			wantAuthValid: ptr(false),
+			wantErr:       "status 401",
+		},
+		{
+			name:          "forbidden",
+			status:        http.StatusForbidden,
+			wantReachable: true,
+			wantAuthValid: ptr(false),
+			wantErr:       "status 403",
+		},
+		{
+			name:          "no model listing",
+			status:        http.StatusNotFound,
+			wantReachable: true,
+			wantErr:       "status 404",
+		},
+		{
+			name:    "timeout",
+			timeout: 50 * time.Millisecond,
+			wantErr: "context deadline exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := probeServer(t, tt.status)
+			ctx := t.Context()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			result, err := newProbeAPI(t, server).ProbeConnection(ctx)
+			if err != nil {
+				t.Fatalf("ProbeConnection: %v", err)
+			}
+			if result.Method != ProbeMethodListModels || result.URL != server.URL+"/v1/models" {
+				t.Errorf("probed %s at %s", result.Method, result.URL)
+			}
+			checkProbeResult(t, result, tt.status, tt.wantReachable, tt.wantAuthValid, tt.wantErr)
+			if !slices.Equal(result.Models, tt.wantModels) {
+				t.Errorf("models = %v, want %v", result.Models, tt.wantModels)
+			}
+		})
+	}
+}
+
+func TestProbeWithCompletion(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		timeout       time.Duration
+		wantReachable bool
+		wantAuthValid *bool
+		wantErr       string
+	}{
+		{
+			name:          "ok",
+			status:        http.StatusOK,
+			wantReachable: true,
+			wantAuthValid: ptr(true),
+		},
+		{
+			name:          "unauthorized",
+			status:        http.StatusUnauthorized,
+			wantReachable: true,
+			wantAuthValid: ptr(false),
+			wantErr:       "probe failed",
+		},
+		{
+			name:          "forbidden",
+			status:        http.StatusForbidden,
+			wantReachable: true,
+			wantAuthValid: ptr(false),
+			wantErr:       "probe failed",
+		},
+		{
+			name:    "timeout",
+			timeout: 50 * time.Millisecond,
+			wantErr: "context deadline exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := probeServer(t, tt.status)
+			ctx := t.Context()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			p := newProbeAPI(t, server)
+			result := ProbeWithCompletion(ctx, "local", p.GetLLMsModel(ctx), "m1")
+			if result.Method != ProbeMethodCompletion {
+				t.Errorf("method = %s", result.Method)
+			}
+			if tt.wantReachable && result.URL != server.URL+"/v1/chat/completions" {
+				t.Errorf("url = %s", result.URL)
+			}
+			checkProbeResult(t, result, tt.status, tt.wantReachable, tt.wantAuthValid, tt.wantErr)
+		})
+	}
+
+	t.Run("uninitialized provider", func(t *testing.T) {
+		result := ProbeWithCompletion(t.Context(), "local", nil, "m1")
+		if result.Error == nil || result.Reachable {
+			t.Errorf("result = %+v, want an unreachable error", result)
+		}
+	})
+}
+
+func checkProbeResult(
+	t *testing.T,
+	result *ConnectionTestResult,
+	status int,
+	wantReachable bool,
+	wantAuthValid *bool,
+	wantErr string,
+) {
+	t.Helper()
+	if result.Reachable != wantReachable {
+		t.Errorf("reachable = %v, want %v", result.Reachable, wantReachable)
+	}
+	if result.HTTPStatus != status {
+		t.Errorf("status = %d, want %d", result.HTTPStatus, status)
+	}
+	got := result.AuthValid
+	if (got == nil) != (wantAuthValid == nil) || got != nil && *got != *wantAuthValid {
+		t.Errorf("authValid = %v, want %v", got, wantAuthValid)
+	}
+	if wantErr == "" {
+		if result.Error != nil {
+			t.Errorf("unexpected error %q", *result.Error)
+		}
+	} else if result.Error == nil || !strings.Contains(*result.Error, wantErr) {
+		t.Errorf("error = %v, want it to contain %q", result.Error, wantErr)
+	}
+	if wantReachable && result.Timings.FirstByteMs == nil {
+		t.Errorf("first byte timing missing: %+v", result.Timings)
+	}
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+// maxCacheBreakpoints is the Anthropic limit of cache_control markers in a request.
+const maxCacheBreakpoints = 4
+
+const promptCachingKey = contextKey("PromptCaching")
+
+// promptCachingState carries the caching policy of a completion to the transport,
+// and the cache token counts of the response back to the completion.
+type promptCachingState struct {
+	params *spec.PromptCachingParams
+
+	mu          sync.Mutex
+	readTokens  int
+	writeTokens int
+}
+
+func withPromptCaching(
+	ctx context.Context,
+	params *spec.PromptCachingParams,
+) (context.Context, *promptCachingState) {
+	state := &promptCachingState{params: params}
+	return context.WithValue(ctx, promptCachingKey, state), state
+}
+
+func getPromptCachingState(ctx context.Context) *promptCachingState {
+	state, _ := ctx.Value(promptCachingKey).(*promptCachingState)
+	return state
+}
+
+// applyTo adds the cache token counts seen in the response to usage.
+func (s *promptCachingState) applyTo(usage *CompletionUsage) *CompletionUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readTokens == 0 && s.writeTokens == 0 {
+		return usage
+	}
+	if usage == nil {
+		usage = &CompletionUsage{}
+	}
+	read := max(usage.CacheReadTokens, s.readTokens)
+	write := max(usage.CacheWriteTokens, s.writeTokens)
+	// Cache tokens are not part of the input tokens, the total counts them on top.
+	usage.TotalTokens += read - usage.CacheReadTokens + write - usage.CacheWriteTokens
+	usage.CacheReadTokens = read
+	usage.CacheWriteTokens = write
+	return usage
+}
+
+// promptCachingTransport adds cache_control markers to Anthropic messages requests
+// and reads the cache token counts from the response usage.
+type promptCachingTransport struct {
+	Transport http.RoundTripper
+}
+
+func (t *promptCachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	state := getPromptCachingState(req.Context())
+	if state == nil || req.Method != http.MethodPost ||
+		!strings.HasSuffix(req.URL.Path, "/messages") {
+		return t.Transport.RoundTrip(req)
+	}
+
+	if state.params != nil && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if marked, ok := addCacheControl(body, state.params); ok {
+			body = marked
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	if resp != nil && resp.Body != nil {
+		resp.Body = &cacheUsageReadCloser{ReadCloser: resp.Body, state: state}
+	}
+	return resp, err
+}
+
+// addCacheControl marks the system prompt and the selected messages of a request body.
+// The body is returned unchanged if it cannot be parsed or nothing is marked.
+func addCacheControl(body []byte, params *spec.PromptCachingParams) ([]byte, bool) {
+	var data map[string]any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return body, false
+	}
+	messages, _ := data["messages"].([]any)
+
+	remaining := maxCacheBreakpoints
+	if params.SystemPrompt && data["system"] != nil {
+		if system, ok := markLastBlock(data["system"]); ok {
+			data["system"] = system
+			remaining--
+		}
+	}
+
+	// Explicit breakpoints come first, then the last user turns.
+	indexes := []int{}
+	for _, i := range params.Breakpoints {
+		if i >= 0 && i < len(messages) {
+			indexes = append(indexes, i)
+		}
+	}
+	turns := params.LastTurns
+	for i := len(messages) - 1; i >= 0 && turns > 0; i-- {
+		if msg, ok := messages[i].(map[string]any); ok && msg["role"] == "user" {
+			indexes = append(indexes, i)
+			turns--
+		}
+	}
+	marked := map[int]bool{}
+	for _, i := range indexes {
+		if marked[i] {
+			continue
+		}
+		if remaining == 0 {
+			slog.Debug("Prompt caching breakpoints over the limit were dropped",
+				"limit", maxCacheBreakpoints)
+			break
+		}
+		msg, ok := messages[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		if content, ok := markLastBlock(msg["content"]); ok {
+			msg["content"] = content
+			marked[i] = true
+			remaining--
+		}
+	}
+
+	if remaining == maxCacheBreakpoints {
+		return body, false
+	}
+	out, err := json.Marshal(data)
+	if err != nil {
+		return body, false
+	}
+	return out, true
+}
+
+// markLastBlock adds an ephemeral cache_control to the last content block.
+// String content is converted to a single text block first.
+func markLastBlock(content any) (any, bool) {
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return content, false
+		}
+		return []any{map[string]any{
+			"type":          "text",
+			"text":          c,
+			"cache_control": map[string]any{"type": "ephemeral"},
+		}}, true
+	case []any:
+		if len(c) == 0 {
+			return content, false
+		}
+		block, ok := c[len(c)-1].(map[string]any)
+		if !ok {
+			return content, false
+		}
+		block["cache_control"] = map[string]any{"type": "ephemeral"}
+		return c, true
+	}
+	return content, false
+}
+
+// cacheUsageReadCloser reads the cache token counts of a JSON or SSE response while it is read.
+// Stream events are parsed line by line, only a JSON body is kept whole until it is closed.
+type cacheUsageReadCloser struct {
+	io.ReadCloser
+	state *promptCachingState
+	// pending is the unparsed rest of the body, the JSON body or an unterminated SSE line.
+	pending     []byte
+	isJSON      bool
+	isStream    bool
+	readTokens  int
+	writeTokens int
+}
+
+func (r *cacheUsageReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.scan(p[:n])
+	}
+	return n, err
+}
+
+func (r *cacheUsageReadCloser) Close() error {
+	r.readUsage(r.pending)
+	r.pending = nil
+	r.state.mu.Lock()
+	r.state.readTokens = max(r.state.readTokens, r.readTokens)
+	r.state.writeTokens = max(r.state.writeTokens, r.writeTokens)
+	r.state.mu.Unlock()
+	return r.ReadCloser.Close()
+}
+
+// scan parses the complete SSE lines of a chunk and keeps the rest for the next one.
+func (r *cacheUsageReadCloser) scan(chunk []byte) {
+	r.pending = append(r.pending, chunk...)
+	if !r.isJSON && !r.isStream {
+		trimmed := bytes.TrimLeft(r.pending, " \t\r\n")
+		if len(trimmed) == 0 {
+			return
+		}
+		r.isJSON = trimmed[0] == '{'
+		r.isStream = !r.isJSON
+	}
+	if r.isJSON {
+		return
+	}
+	rest := r.pending
+	for {
+		line, after, ok := bytes.Cut(rest, []byte("\n"))
+		if !ok {
+			break
+		}
+		r.readUsage(line)
+		rest = after
+	}
+	r.pending = append(r.pending[:0], rest...)
+}
+
+// readUsage reads the usage of a messages response, or of a message_start or message_delta
+// stream event. Counts are cumulative, so the largest one is kept.
+func (r *cacheUsageReadCloser) readUsage(data []byte) {
+	data = bytes.TrimSpace(data)
+	if r.isStream {
+		var ok bool
+		if data, ok = bytes.CutPrefix(data, []byte("data:")); !ok {
+			return
+		}
+	}
+	// Most events are content deltas without usage.
+	if !bytes.Contains(data, []byte(`"usage"`)) {
+		return
+	}
+	var event struct {
+		Usage   *anthropicCacheUsage `json:"usage"`
+		Message struct {
+			Usage *anthropicCacheUsage `json:"usage"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(data, &event) != nil {
+		return
+	}
+	for _, u := range []*anthropicCacheUsage{event.Usage, event.Message.Usage} {
+		if u != nil {
+			r.readTokens = max(r.readTokens, u.CacheReadInputTokens)
+			r.writeTokens = max(r.writeTokens, u.CacheCreationInputTokens)
+		}
+	}
+}
+
+type anthropicCacheUsage struct {
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
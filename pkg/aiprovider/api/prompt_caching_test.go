@@ -0,0 +1,221 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+// cacheMarks lists the system and message blocks of a request body that have cache_control,
+// as "system.<block>" and "messages.<message>.<block>".
+func cacheMarks(t *testing.T, body []byte) []string {
+	t.Helper()
+	var data struct {
+		System   any `json:"system"`
+		Messages []struct {
+			Content any `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		t.Fatalf("invalid body %s: %v", body, err)
+	}
+	marks := []string{}
+	addMarks := func(prefix string, content any) {
+		blocks, _ := content.([]any)
+		for i, b := range blocks {
+			if block, ok := b.(map[string]any); ok && block["cache_control"] != nil {
+				marks = append(marks, fmt.Sprintf("%s.%d", prefix, i))
+			}
+		}
+	}
+	addMarks("system", data.System)
+	for i, m := range data.Messages {
+		addMarks(fmt.Sprintf("messages.%d", i), m.Content)
+	}
+	return marks
+}
+
+func TestAddCacheControl(t *testing.T) {
+	textBlocks := `[{"type":"text","text":"one"},{"type":"text","text":"two"}]`
+	turns := `[
+		{"role":"user","content":"u0"},
+		{"role":"assistant","content":"a1"},
+		{"role":"user","content":` + textBlocks + `},
+		{"role":"assistant","content":"a3"},
+		{"role":"user","content":"u4"}
+	]`
+	tests := []struct {
+		name       string
+		body       string
+		params     spec.PromptCachingParams
+		wantMarked bool
+		wantMarks  []string
+	}{
+		{
+			name:       "system string",
+			body:       `{"system":"be brief","messages":[{"role":"user","content":"hi"}]}`,
+			params:     spec.PromptCachingParams{SystemPrompt: true},
+			wantMarked: true,
+			wantMarks:  []string{"system.0"},
+		},
+		{
+			name:       "system block array marks the last block",
+			body:       `{"system":` + textBlocks + `,"messages":[]}`,
+			params:     spec.PromptCachingParams{SystemPrompt: true},
+			wantMarked: true,
+			wantMarks:  []string{"system.1"},
+		},
+		{
+			name:       "empty system string",
+			body:       `{"system":"","messages":[{"role":"user","content":"hi"}]}`,
+			params:     spec.PromptCachingParams{SystemPrompt: true},
+			wantMarked: false,
+		},
+		{
+			name:       "nothing requested",
+			body:       `{"system":"be brief","messages":` + turns + `}`,
+			params:     spec.PromptCachingParams{},
+			wantMarked: false,
+		},
+		{
+			name:       "last user turns",
+			body:       `{"messages":` + turns + `}`,
+			params:     spec.PromptCachingParams{LastTurns: 2},
+			wantMarked: true,
+			wantMarks:  []string{"messages.2.1", "messages.4.0"},
+		},
+		{
+			name: "breakpoints and last turns are marked once",
+			body: `{"messages":` + turns + `}`,
+			params: spec.PromptCachingParams{
+				LastTurns:   3,
+				Breakpoints: []int{4, 0, 0},
+			},
+			wantMarked: true,
+			wantMarks:  []string{"messages.0.0", "messages.2.1", "messages.4.0"},
+		},
+		{
+			name:       "out of range breakpoints",
+			body:       `{"messages":` + turns + `}`,
+			params:     spec.PromptCachingParams{Breakpoints: []int{-1, 5}},
+			wantMarked: false,
+		},
+		{
+			name: "four marker limit",
+			body: `{"system":"be brief","messages":` + turns + `}`,
+			params: spec.PromptCachingParams{
+				SystemPrompt: true,
+				Breakpoints:  []int{0, 1, 2, 3, 4},
+			},
+			wantMarked: true,
+			wantMarks:  []string{"system.0", "messages.0.0", "messages.1.0", "messages.2.1"},
+		},
+		{
+			name:       "invalid body",
+			body:       `{"messages":`,
+			params:     spec.PromptCachingParams{SystemPrompt: true, LastTurns: 1},
+			wantMarked: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, marked := addCacheControl([]byte(tc.body), &tc.params)
+			if marked != tc.wantMarked {
+				t.Fatalf("marked = %v, want %v", marked, tc.wantMarked)
+			}
+			if !marked {
+				if string(got) != tc.body {
+					t.Errorf("unmarked body changed to %s", got)
+				}
+				return
+			}
+			if marks := cacheMarks(t, got); !slices.Equal(marks, tc.wantMarks) {
+				t.Errorf("marks = %v, want %v", marks, tc.wantMarks)
+			}
+		})
+	}
+}
+
+func TestCacheUsageReadCloser(t *testing.T) {
+	stream := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"usage":{"input_tokens":12,` +
+			`"cache_creation_input_tokens":40,"cache_read_input_tokens":2000,"output_tokens":1}}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta",` +
+			`"text":"usage is not a key here"}}`,
+		``,
+		`event: message_delta`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":9}}`,
+		``,
+		`event: message_stop`,
+		`data: {"type":"message_stop"}`,
+		``,
+	}, "\n")
+	tests := []struct {
+		name      string
+		body      string
+		wantRead  int
+		wantWrite int
+	}{
+		{
+			name:      "stream",
+			body:      stream,
+			wantRead:  2000,
+			wantWrite: 40,
+		},
+		{
+			name:      "stream without a final newline",
+			body:      `data: {"type":"message_delta","usage":{"cache_read_input_tokens":7}}`,
+			wantRead:  7,
+			wantWrite: 0,
+		},
+		{
+			name: "JSON response",
+			body: "\n" + `{"id":"msg_1","content":[{"type":"text","text":"hi"}],` +
+				`"usage":{"input_tokens":3,"cache_creation_input_tokens":5,` +
+				`"cache_read_input_tokens":0,"output_tokens":2}}`,
+			wantRead:  0,
+			wantWrite: 5,
+		},
+		{
+			name: "no usage",
+			body: `{"type":"error","error":{"type":"overloaded_error"}}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			state := &promptCachingState{}
+			// One byte reads split every line and JSON value across reads.
+			r := &cacheUsageReadCloser{
+				ReadCloser: io.NopCloser(iotest.OneByteReader(strings.NewReader(tc.body))),
+				state:      state,
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if string(got) != tc.body {
+				t.Errorf("body changed to %q", got)
+			}
+			// Complete stream lines are parsed as they arrive and not kept.
+			if r.isStream && strings.HasSuffix(tc.body, "\n") && len(r.pending) > 0 {
+				t.Errorf("stream lines kept after reading: %q", r.pending)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+			if state.readTokens != tc.wantRead || state.writeTokens != tc.wantWrite {
+				t.Errorf("cache tokens = %d read, %d write, want %d, %d",
+					state.readTokens, state.writeTokens, tc.wantRead, tc.wantWrite)
+			}
+		})
+	}
+}
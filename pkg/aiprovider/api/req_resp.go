@@ -42,6 +42,24 @@ type GetConfigurationInfoResponseBody struct {
 	ConfiguredProviders          []spec.ProviderInfo                                         `json:"configuredProviders"`
 	InbuiltProviderModels        map[spec.ProviderName]map[spec.ModelName]spec.ModelParams   `json:"inbuiltProviderModels"`
 	InbuiltProviderModelDefaults map[spec.ProviderName]map[spec.ModelName]spec.ModelDefaults `json:"inbuiltProviderModelDefaults"`
+	// InbuiltProviderModelCapabilities lets clients disable options a model does not support.
+	InbuiltProviderModelCapabilities map[spec.ProviderName]map[spec.ModelName]spec.ModelCapabilities `json:"inbuiltProviderModelCapabilities"`
+	// InbuiltProviderModelSources tells if a model definition is inbuilt or from the user overlay catalog.
+	InbuiltProviderModelSources map[spec.ProviderName]map[spec.ModelName]spec.ModelSource `json:"inbuiltProviderModelSources"`
+	// ModelCatalogError is set if the user overlay catalog could not be loaded.
+	ModelCatalogError string            `json:"modelCatalogError,omitempty"`
+	RateLimitStatus   []RateLimitStatus `json:"rateLimitStatus,omitempty"`
+}
+
+// RateLimitStatus is the live state of the client side limiter of a provider model.
+type RateLimitStatus struct {
+	Provider     spec.ProviderName `json:"provider"`
+	ModelName    spec.ModelName    `json:"modelName"`
+	Limits       spec.RateLimits   `json:"limits"`
+	QueueDepth   int               `json:"queueDepth"`
+	InFlight     int               `json:"inFlight"`
+	LastWaitMs   int64             `json:"lastWaitMs"`
+	OldestWaitMs int64             `json:"oldestWaitMs"`
 }
 
 type SetProviderAPIKeyRequestBody struct {
@@ -67,12 +85,66 @@ type SetProviderAttributeRequest struct {
 
 type SetProviderAttributeResponse struct{}
 
+type TestProviderConnectionRequestBody struct {
+	// ModelName is used for the completion probe of providers without a model listing endpoint.
+	// Defaults to an inbuilt model of the provider.
+	ModelName *spec.ModelName `json:"modelName,omitempty"`
+}
+
+type TestProviderConnectionRequest struct {
+	Provider spec.ProviderName `path:"provider" required:"true"`
+	Body     *TestProviderConnectionRequestBody
+}
+
+type TestProviderConnectionResponse struct {
+	Body *ConnectionTestResult
+}
+
+type SetProviderNetworkConfigRequestBody struct {
+	// NetworkConfig nil or empty resets the provider to the default transport.
+	NetworkConfig *spec.NetworkConfig `json:"networkConfig,omitempty"`
+}
+
+type SetProviderNetworkConfigRequest struct {
+	Provider spec.ProviderName `path:"provider" required:"true"`
+	Body     *SetProviderNetworkConfigRequestBody
+}
+
+type SetProviderNetworkConfigResponse struct{}
+
+type SetProviderRateLimitsRequestBody struct {
+	// RateLimits apply to each model of the provider, nil or empty removes the provider level limits.
+	RateLimits *spec.RateLimits `json:"rateLimits,omitempty"`
+	// ModelRateLimits override RateLimits for specific models.
+	ModelRateLimits map[spec.ModelName]spec.RateLimits `json:"modelRateLimits,omitempty"`
+}
+
+type SetProviderRateLimitsRequest struct {
+	Provider spec.ProviderName `path:"provider" required:"true"`
+	Body     *SetProviderRateLimitsRequestBody
+}
+
+type SetProviderRateLimitsResponse struct{}
+
+type SetOutboundScanConfigRequestBody struct {
+	Config spec.OutboundScanConfig `json:"config" required:"true"`
+}
+
+type SetOutboundScanConfigRequest struct {
+	Body *SetOutboundScanConfigRequestBody
+}
+
+type SetOutboundScanConfigResponse struct{}
+
 type FetchCompletionRequestBody struct {
 	Provider     spec.ProviderName                   `json:"provider"         required:"true"`
 	Prompt       string                              `json:"prompt"           required:"true"`
 	ModelParams  spec.ModelParams                    `json:"spec.ModelParams" required:"true"`
 	PrevMessages []spec.ChatCompletionRequestMessage `json:"prevMessages"`
 	OnStreamData func(data string) error             `json:"-"`
+	// OnStreamReasoning receives reasoning chunks separately from the content.
+	// If nil, reasoning is streamed inline via OnStreamData as a block quote.
+	OnStreamReasoning func(data string) error `json:"-"`
 }
 
 type FetchCompletionRequest struct {
@@ -82,3 +154,77 @@ type FetchCompletionRequest struct {
 type FetchCompletionResponse struct {
 	Body *CompletionResponse
 }
+
+// CompletionStreamData is the SSE event for a chunk of completion content.
+type CompletionStreamData struct {
+	Data string `json:"data"`
+}
+
+// CompletionStreamReasoning is the SSE event for a chunk of reasoning content.
+type CompletionStreamReasoning struct {
+	Data string `json:"data"`
+}
+
+// CompletionStreamError is the final SSE event of a completion that failed.
+type CompletionStreamError struct {
+	Message string `json:"message"`
+}
+
+// FetchCompletionMultiTarget is one provider/model pair of a fan-out request.
+// ModelParams are per target, so each target can override temperature, reasoning etc.
+type FetchCompletionMultiTarget struct {
+	// ID is a caller chosen key echoed back in the result. Defaults to "provider/model".
+	ID           string                  `json:"id,omitempty"`
+	Provider     spec.ProviderName       `json:"provider"     required:"true"`
+	ModelParams  spec.ModelParams        `json:"modelParams"  required:"true"`
+	OnStreamData func(data string) error `json:"-"`
+}
+
+type FetchCompletionMultiRequestBody struct {
+	Prompt       string                              `json:"prompt"       required:"true"`
+	PrevMessages []spec.ChatCompletionRequestMessage `json:"prevMessages"`
+	Targets      []FetchCompletionMultiTarget        `json:"targets"      required:"true"`
+}
+
+type FetchCompletionMultiRequest struct {
+	Body *FetchCompletionMultiRequestBody
+}
+
+// FetchCompletionMultiResult is the outcome of a single target.
+// Error is set for a failed target, with Response if the provider returned error details.
+// A failed target does not affect the others.
+type FetchCompletionMultiResult struct {
+	ID        string              `json:"id"`
+	Provider  spec.ProviderName   `json:"provider"`
+	ModelName spec.ModelName      `json:"modelName"`
+	Response  *CompletionResponse `json:"response,omitempty"`
+	Error     *string             `json:"error,omitempty"`
+}
+
+type FetchCompletionMultiResponseBody struct {
+	// Results are in the same order as the request targets.
+	Results []FetchCompletionMultiResult `json:"results"`
+}
+
+type FetchCompletionMultiResponse struct {
+	Body *FetchCompletionMultiResponseBody
+}
+
+// FetchEmbeddingsRequestBody asks a provider for the embeddings of the inputs.
+type FetchEmbeddingsRequestBody struct {
+	ModelName spec.ModelName `json:"modelName"            required:"true"`
+	Inputs    []string       `json:"inputs"               required:"true"`
+	// Dimensions requests shortened embeddings from models that support it.
+	Dimensions *int `json:"dimensions,omitempty"`
+	// BatchSize is the max number of inputs sent in one provider request.
+	BatchSize int `json:"batchSize,omitempty"`
+}
+
+type FetchEmbeddingsRequest struct {
+	Provider spec.ProviderName `path:"provider" required:"true"`
+	Body     *FetchEmbeddingsRequestBody
+}
+
+type FetchEmbeddingsResponse struct {
+	Body *EmbeddingResponse
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type TimeoutKind string
+
+const (
+	TimeoutKindTotal      TimeoutKind = "total"
+	TimeoutKindFirstToken TimeoutKind = "firstToken"
+	TimeoutKindIdle       TimeoutKind = "idle"
+)
+
+type APIErrorType string
+
+const APIErrorTypeTimeout APIErrorType = "timeout"
+
+// ErrCompletionTimeout matches any TimeoutError with errors.Is.
+var ErrCompletionTimeout = errors.New("completion timeout")
+
+// TimeoutError is the cancel cause of a completion that exceeded one of its timeouts.
+type TimeoutError struct {
+	Kind    TimeoutKind
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("completion %s timeout of %s exceeded", e.Kind, e.Timeout)
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrCompletionTimeout
+}
+
+// withCompletionTimeout applies the overall timeout of a completion, a zero timeout means no limit.
+// The returned context is also canceled by the stream watchdog, with a TimeoutError as cause.
+func withCompletionTimeout(
+	ctx context.Context,
+	timeout time.Duration,
+) (context.Context, context.CancelCauseFunc, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if timeout <= 0 {
+		return ctx, cancel, func() { cancel(context.Canceled) }
+	}
+	timer := time.AfterFunc(timeout, func() {
+		cancel(&TimeoutError{Kind: TimeoutKindTotal, Timeout: timeout})
+	})
+	return ctx, cancel, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// streamWatchdog cancels a streamed completion if the first chunk or the next chunk takes too long.
+type streamWatchdog struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	idle   time.Duration
+	cancel context.CancelCauseFunc
+}
+
+func newStreamWatchdog(
+	cancel context.CancelCauseFunc,
+	firstToken, idle time.Duration,
+) *streamWatchdog {
+	w := &streamWatchdog{idle: idle, cancel: cancel}
+	if firstToken > 0 {
+		w.timer = time.AfterFunc(firstToken, func() {
+			cancel(&TimeoutError{Kind: TimeoutKindFirstToken, Timeout: firstToken})
+		})
+	}
+	return w
+}
+
+// touch restarts the idle timer, it is called for every received chunk.
+func (w *streamWatchdog) touch() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.idle > 0 {
+		w.timer = time.AfterFunc(w.idle, func() {
+			w.cancel(&TimeoutError{Kind: TimeoutKindIdle, Timeout: w.idle})
+		})
+	}
+}
+
+func (w *streamWatchdog) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// getTimeoutError returns the TimeoutError that canceled ctx, if any.
+func getTimeoutError(ctx context.Context) *TimeoutError {
+	var te *TimeoutError
+	if errors.As(context.Cause(ctx), &te) {
+		return te
+	}
+	return nil
+}
+
+func secondsToDuration(s int) time.Duration {
+	return time.Duration(s) * time.Second
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCompletionTimeouts(t *testing.T) {
+	const (
+		short = 20 * time.Millisecond
+		long  = time.Minute
+	)
+	tests := []struct {
+		name       string
+		total      time.Duration
+		firstToken time.Duration
+		idle       time.Duration
+		// chunks is the number of chunks received, one every short/4.
+		chunks   int
+		stop     bool
+		wantKind TimeoutKind
+	}{
+		{
+			name:     "total",
+			total:    short,
+			wantKind: TimeoutKindTotal,
+		},
+		{
+			name:       "first token without chunks",
+			total:      long,
+			firstToken: short,
+			idle:       long,
+			wantKind:   TimeoutKindFirstToken,
+		},
+		{
+			name:       "idle after the first chunk",
+			total:      long,
+			firstToken: long,
+			idle:       short,
+			chunks:     1,
+			wantKind:   TimeoutKindIdle,
+		},
+		{
+			name:       "chunks restart the idle timer",
+			total:      long,
+			firstToken: short,
+			idle:       short,
+			chunks:     8,
+			wantKind:   TimeoutKindIdle,
+		},
+		{
+			name:       "first chunk ends the first token timeout",
+			total:      long,
+			firstToken: short,
+			chunks:     1,
+		},
+		{
+			name:       "stopped watchdog",
+			firstToken: short,
+			idle:       short,
+			stop:       true,
+		},
+		{
+			name: "zero timeouts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel, stop := withCompletionTimeout(context.Background(), tt.total)
+			defer stop()
+			w := newStreamWatchdog(cancel, tt.firstToken, tt.idle)
+			defer w.stop()
+			if tt.stop {
+				w.stop()
+			}
+			for range tt.chunks {
+				w.touch()
+				time.Sleep(short / 4)
+				if err := ctx.Err(); err != nil {
+					t.Fatalf("canceled while chunks arrive: %v", context.Cause(ctx))
+				}
+			}
+
+			if tt.wantKind == "" {
+				select {
+				case <-ctx.Done():
+					t.Fatalf("unexpected cancel: %v", context.Cause(ctx))
+				case <-time.After(10 * short):
+				}
+				return
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(long):
+				t.Fatalf("no %s timeout", tt.wantKind)
+			}
+			te := getTimeoutError(ctx)
+			if te == nil {
+				t.Fatalf("cause = %v, want a TimeoutError", context.Cause(ctx))
+			}
+			if te.Kind != tt.wantKind {
+				t.Errorf("kind = %s, want %s", te.Kind, tt.wantKind)
+			}
+			if !errors.Is(context.Cause(ctx), ErrCompletionTimeout) {
+				t.Errorf("cause %v does not match ErrCompletionTimeout", context.Cause(ctx))
+			}
+		})
+	}
+}
+
+func TestGetTimeoutErrorAfterStop(t *testing.T) {
+	ctx, _, stop := withCompletionTimeout(context.Background(), time.Minute)
+	stop()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", ctx.Err())
+	}
+	if te := getTimeoutError(ctx); te != nil {
+		t.Errorf("timeout error %v after stop", te)
+	}
+}
@@ -76,3 +76,23 @@ func FilterMessagesByTokenCount(
 
 	return filteredMessages
 }
+
+// EstimateRequestTokens estimates the tokens a completion request will consume.
+// Like provider side accounting it reserves the max output length in addition to the input.
+func EstimateRequestTokens(
+	prompt string,
+	modelParams spec.ModelParams,
+	prevMessages []spec.ChatCompletionRequestMessage,
+) int {
+	total := CountTokensInContent(prompt) + CountTokensInContent(modelParams.SystemPrompt)
+	for _, m := range prevMessages {
+		if m.Content != nil {
+			total += CountTokensInContent(*m.Content)
+		}
+	}
+	// History is trimmed to the max prompt length before sending.
+	if modelParams.MaxPromptLength > 0 && total > modelParams.MaxPromptLength {
+		total = modelParams.MaxPromptLength
+	}
+	return total + modelParams.MaxOutputLength
+}
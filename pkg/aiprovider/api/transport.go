@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+// ErrProxyUnreachable is returned when a configured proxy cannot be connected to.
+var ErrProxyUnreachable = errors.New("proxy unreachable")
+
+const proxyDialTimeout = 5 * time.Second
+
+// NewHTTPTransport builds a transport from the network config.
+// A nil or empty config returns http.DefaultTransport.
+func NewHTTPTransport(cfg *spec.NetworkConfig) (http.RoundTripper, error) {
+	if cfg.IsZero() {
+		return http.DefaultTransport, nil
+	}
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unexpected default transport type")
+	}
+	transport := defaultTransport.Clone()
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := parseProxyURL(cfg.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CACertPEM != "" || cfg.ClientCertPEM != "" || cfg.ClientKeyPEM != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.CACertPEM != "" {
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM([]byte(cfg.CACertPEM)) {
+				return nil, errors.New("invalid CA certificate: no PEM encoded certificate found")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.ClientCertPEM != "" || cfg.ClientKeyPEM != "" {
+			if cfg.ClientCertPEM == "" || cfg.ClientKeyPEM == "" {
+				return nil, errors.New(
+					"invalid client certificate: both certificate and key PEM are required",
+				)
+			}
+			cert, err := tls.X509KeyPair([]byte(cfg.ClientCertPEM), []byte(cfg.ClientKeyPEM))
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate/key PEM: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport, nil
+}
+
+// CheckProxyReachable dials the configured proxy, if any.
+// The returned error wraps ErrProxyUnreachable.
+func CheckProxyReachable(ctx context.Context, cfg *spec.NetworkConfig) error {
+	if cfg == nil || cfg.ProxyURL == "" {
+		return nil
+	}
+	proxyURL, err := parseProxyURL(cfg.ProxyURL)
+	if err != nil {
+		return err
+	}
+	host := proxyURL.Host
+	if proxyURL.Port() == "" {
+		host = net.JoinHostPort(proxyURL.Hostname(), defaultProxyPort(proxyURL.Scheme))
+	}
+	dialer := net.Dialer{Timeout: proxyDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrProxyUnreachable, host, err)
+	}
+	_ = conn.Close()
+	return nil
+}
+
+func parseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf(
+			"invalid proxy URL: unsupported scheme %q, use http, https, socks5 or socks5h",
+			proxyURL.Scheme,
+		)
+	}
+	if proxyURL.Hostname() == "" {
+		return nil, errors.New("invalid proxy URL: missing host")
+	}
+	return proxyURL, nil
+}
+
+func defaultProxyPort(scheme string) string {
+	switch scheme {
+	case "https":
+		return "443"
+	case "socks5", "socks5h":
+		return "1080"
+	default:
+		return "80"
+	}
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+// selfSignedPEM returns a self signed certificate and its key as PEM.
+func selfSignedPEM(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "flexigpt test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	certPEM, keyPEM := selfSignedPEM(t)
+	_, otherKeyPEM := selfSignedPEM(t)
+
+	tests := []struct {
+		name    string
+		cfg     *spec.NetworkConfig
+		wantErr string
+		check   func(t *testing.T, transport *http.Transport)
+	}{
+		{
+			name: "nil config",
+		},
+		{
+			name: "proxy, CA and client certificate",
+			cfg: &spec.NetworkConfig{
+				ProxyURL:      "socks5://127.0.0.1:1080",
+				CACertPEM:     certPEM,
+				ClientCertPEM: certPEM,
+				ClientKeyPEM:  keyPEM,
+			},
+			check: func(t *testing.T, transport *http.Transport) {
+				t.Helper()
+				req := httptest.NewRequest(http.MethodGet, "https://api.example.com", nil)
+				proxyURL, err := transport.Proxy(req)
+				if err != nil || proxyURL == nil || proxyURL.Host != "127.0.0.1:1080" {
+					t.Errorf("proxy = %v (%v)", proxyURL, err)
+				}
+				tlsConfig := transport.TLSClientConfig
+				if tlsConfig == nil || tlsConfig.RootCAs == nil ||
+					len(tlsConfig.Certificates) != 1 {
+					t.Errorf("TLS config not applied: %+v", tlsConfig)
+				}
+			},
+		},
+		{
+			name:    "unsupported proxy scheme",
+			cfg:     &spec.NetworkConfig{ProxyURL: "ftp://proxy.example.com:21"},
+			wantErr: "unsupported scheme",
+		},
+		{
+			name:    "proxy without host",
+			cfg:     &spec.NetworkConfig{ProxyURL: "http://:8080"},
+			wantErr: "missing host",
+		},
+		{
+			name:    "bad CA PEM",
+			cfg:     &spec.NetworkConfig{CACertPEM: "not a certificate"},
+			wantErr: "invalid CA certificate",
+		},
+		{
+			name:    "client certificate without key",
+			cfg:     &spec.NetworkConfig{ClientCertPEM: certPEM},
+			wantErr: "both certificate and key PEM are required",
+		},
+		{
+			name:    "client key without certificate",
+			cfg:     &spec.NetworkConfig{ClientKeyPEM: keyPEM},
+			wantErr: "both certificate and key PEM are required",
+		},
+		{
+			name:    "client key of another certificate",
+			cfg:     &spec.NetworkConfig{ClientCertPEM: certPEM, ClientKeyPEM: otherKeyPEM},
+			wantErr: "invalid client certificate/key PEM",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, err := NewHTTPTransport(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.check == nil {
+				if rt != http.DefaultTransport {
+					t.Errorf("expected the default transport")
+				}
+				return
+			}
+			transport, ok := rt.(*http.Transport)
+			if !ok {
+				t.Fatalf("unexpected transport type %T", rt)
+			}
+			tt.check(t, transport)
+		})
+	}
+}
+
+func TestCheckProxyReachable(t *testing.T) {
+	proxy := httptest.NewServer(http.NotFoundHandler())
+	defer proxy.Close()
+
+	// A port that was just released has nothing listening on it.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedAddr := l.Addr().String()
+	_ = l.Close()
+
+	tests := []struct {
+		name            string
+		cfg             *spec.NetworkConfig
+		wantErr         bool
+		wantUnreachable bool
+	}{
+		{
+			name: "no proxy",
+			cfg:  &spec.NetworkConfig{CACertPEM: "ignored"},
+		},
+		{
+			name: "reachable proxy",
+			cfg:  &spec.NetworkConfig{ProxyURL: proxy.URL},
+		},
+		{
+			name:            "unreachable proxy",
+			cfg:             &spec.NetworkConfig{ProxyURL: "http://" + closedAddr},
+			wantErr:         true,
+			wantUnreachable: true,
+		},
+		{
+			name:    "unsupported proxy scheme",
+			cfg:     &spec.NetworkConfig{ProxyURL: "ftp://" + closedAddr},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckProxyReachable(t.Context(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrProxyUnreachable) != tt.wantUnreachable {
+				t.Errorf("err = %v, want ErrProxyUnreachable %v", err, tt.wantUnreachable)
+			}
+		})
+	}
+}
+
+func TestHuggingFaceNetworkConfig(t *testing.T) {
+	hf := NewHuggingFaceCompatibleAPI(spec.ProviderInfo{APIKey: "key"}, false)
+	if err := hf.InitLLM(t.Context()); err != nil || hf.GetLLMsModel(t.Context()) == nil {
+		t.Fatalf("InitLLM without network config: %v", err)
+	}
+
+	cfg := &spec.NetworkConfig{ProxyURL: "http://127.0.0.1:3128"}
+	if err := hf.SetNetworkConfig(t.Context(), cfg); !errors.Is(err, ErrNetworkConfigUnsupported) {
+		t.Fatalf("SetNetworkConfig err = %v, want ErrNetworkConfigUnsupported", err)
+	}
+	if err := hf.InitLLM(t.Context()); !errors.Is(err, ErrNetworkConfigUnsupported) {
+		t.Fatalf("InitLLM err = %v, want ErrNetworkConfigUnsupported", err)
+	}
+	if hf.GetLLMsModel(t.Context()) != nil {
+		t.Fatal("provider usable without its proxy")
+	}
+
+	if err := hf.SetNetworkConfig(t.Context(), nil); err != nil {
+		t.Fatalf("clearing the network config: %v", err)
+	}
+	if err := hf.InitLLM(t.Context()); err != nil || hf.GetLLMsModel(t.Context()) == nil {
+		t.Fatalf("InitLLM after clearing the network config: %v", err)
+	}
+}
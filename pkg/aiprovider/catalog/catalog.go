@@ -0,0 +1,399 @@
+// Package catalog holds the model catalog of the inbuilt providers.
+// The inbuilt catalog is embedded, a user overlay file can add models, change them or disable them.
+package catalog
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+//go:embed inbuilt_catalog.json
+var inbuiltCatalogJSON []byte
+
+// OverlayFileName is the name of the user overlay catalog in the settings directory.
+const OverlayFileName = "model_catalog.json"
+
+// ErrInvalidCatalog is returned for catalog files that do not match the schema.
+var ErrInvalidCatalog = errors.New("invalid model catalog")
+
+type models map[spec.ProviderName]map[spec.ModelName]ModelInfo
+
+// Catalog is the resolved model catalog, it is safe for concurrent use.
+type Catalog struct {
+	mu      sync.RWMutex
+	inbuilt *File
+	models  models
+
+	overlayPath    string
+	overlayState   fileState
+	overlayLoadErr error
+}
+
+var (
+	defaultCatalog     *Catalog
+	defaultCatalogOnce sync.Once
+)
+
+// Default returns the process wide catalog.
+func Default() *Catalog {
+	defaultCatalogOnce.Do(func() {
+		c, err := NewCatalog()
+		if err != nil {
+			panic(err)
+		}
+		defaultCatalog = c
+	})
+	return defaultCatalog
+}
+
+// NewCatalog returns a catalog with the inbuilt models only.
+func NewCatalog() (*Catalog, error) {
+	inbuilt, err := parseFile(inbuiltCatalogJSON)
+	if err != nil {
+		return nil, fmt.Errorf("inbuilt catalog: %w", err)
+	}
+	c := &Catalog{inbuilt: inbuilt}
+	m, err := resolve(inbuilt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("inbuilt catalog: %w", err)
+	}
+	c.models = m
+	return c, nil
+}
+
+// SetOverlayFile sets the path of the user overlay file and loads it.
+// A missing file is not an error. If the file is invalid, the previous catalog is kept,
+// the error is returned and also reported by Snapshot until a valid file is loaded.
+func (c *Catalog) SetOverlayFile(path string) error {
+	c.mu.Lock()
+	c.overlayPath = path
+	c.overlayState = fileState{}
+	c.mu.Unlock()
+	return c.Reload()
+}
+
+// Reload loads the overlay file again.
+func (c *Catalog) Reload() error {
+	c.mu.RLock()
+	path := c.overlayPath
+	c.mu.RUnlock()
+
+	var overlay *File
+	state, data, err := readFile(path)
+	if err == nil && data != nil {
+		overlay, err = parseFile(data)
+	}
+	var m models
+	if err == nil {
+		m, err = resolve(c.inbuilt, overlay)
+	}
+	if err != nil {
+		err = fmt.Errorf("overlay catalog %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.overlayState = state
+	c.overlayLoadErr = err
+	if err == nil {
+		c.models = m
+	}
+	return err
+}
+
+// ModelInfo returns the catalog definition of a model.
+func (c *Catalog) ModelInfo(provider spec.ProviderName, model spec.ModelName) (ModelInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	info, ok := c.models[provider][model]
+	if !ok {
+		return ModelInfo{}, false
+	}
+	return info.clone(), true
+}
+
+// ModelParams returns the default params of a model.
+func (c *Catalog) ModelParams(
+	provider spec.ProviderName,
+	model spec.ModelName,
+) (spec.ModelParams, bool) {
+	info, ok := c.ModelInfo(provider, model)
+	return info.Params, ok
+}
+
+// Capabilities returns the capabilities of a model, if they are known.
+func (c *Catalog) Capabilities(
+	provider spec.ProviderName,
+	model spec.ModelName,
+) (spec.ModelCapabilities, bool) {
+	info, ok := c.ModelInfo(provider, model)
+	if !ok || info.Capabilities == nil {
+		return spec.ModelCapabilities{}, false
+	}
+	return *info.Capabilities, true
+}
+
+// Pricing returns the prices of a model, if they are known.
+func (c *Catalog) Pricing(
+	provider spec.ProviderName,
+	model spec.ModelName,
+) (spec.ModelPricing, bool) {
+	info, ok := c.ModelInfo(provider, model)
+	if !ok || info.Pricing == nil {
+		return spec.ModelPricing{}, false
+	}
+	return *info.Pricing, true
+}
+
+// ModelDefaults returns the display defaults of all models of a provider.
+func (c *Catalog) ModelDefaults(provider spec.ProviderName) map[spec.ModelName]spec.ModelDefaults {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[spec.ModelName]spec.ModelDefaults, len(c.models[provider]))
+	for name, info := range c.models[provider] {
+		out[name] = info.Defaults
+	}
+	return out
+}
+
+// ModelNames returns the sorted model names of a provider.
+func (c *Catalog) ModelNames(provider spec.ProviderName) []spec.ModelName {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Sorted(maps.Keys(c.models[provider]))
+}
+
+// Snapshot is a copy of the catalog, split in the maps reported by the configuration API.
+type Snapshot struct {
+	Params       map[spec.ProviderName]map[spec.ModelName]spec.ModelParams
+	Defaults     map[spec.ProviderName]map[spec.ModelName]spec.ModelDefaults
+	Capabilities map[spec.ProviderName]map[spec.ModelName]spec.ModelCapabilities
+	Sources      map[spec.ProviderName]map[spec.ModelName]spec.ModelSource
+	// OverlayError is the error of the last overlay load, the catalog is unchanged by a failed load.
+	OverlayError string
+}
+
+func (c *Catalog) Snapshot() Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s := Snapshot{
+		Params:       map[spec.ProviderName]map[spec.ModelName]spec.ModelParams{},
+		Defaults:     map[spec.ProviderName]map[spec.ModelName]spec.ModelDefaults{},
+		Capabilities: map[spec.ProviderName]map[spec.ModelName]spec.ModelCapabilities{},
+		Sources:      map[spec.ProviderName]map[spec.ModelName]spec.ModelSource{},
+	}
+	for provider, pmodels := range c.models {
+		s.Params[provider] = map[spec.ModelName]spec.ModelParams{}
+		s.Defaults[provider] = map[spec.ModelName]spec.ModelDefaults{}
+		s.Capabilities[provider] = map[spec.ModelName]spec.ModelCapabilities{}
+		s.Sources[provider] = map[spec.ModelName]spec.ModelSource{}
+		for name, info := range pmodels {
+			info = info.clone()
+			s.Params[provider][name] = info.Params
+			s.Defaults[provider][name] = info.Defaults
+			if info.Capabilities != nil {
+				s.Capabilities[provider][name] = *info.Capabilities
+			}
+			s.Sources[provider][name] = info.Source
+		}
+	}
+	if c.overlayLoadErr != nil {
+		s.OverlayError = c.overlayLoadErr.Error()
+	}
+	return s
+}
+
+func (info ModelInfo) clone() ModelInfo {
+	if info.Params.Reasoning != nil {
+		r := *info.Params.Reasoning
+		info.Params.Reasoning = &r
+	}
+	if info.Params.Temperature != nil {
+		t := *info.Params.Temperature
+		info.Params.Temperature = &t
+	}
+	if info.Params.AdditionalParameters != nil {
+		info.Params.AdditionalParameters = maps.Clone(info.Params.AdditionalParameters)
+	}
+	if info.Capabilities != nil {
+		caps := *info.Capabilities
+		info.Capabilities = &caps
+	}
+	if info.Pricing != nil {
+		pricing := *info.Pricing
+		info.Pricing = &pricing
+	}
+	return info
+}
+
+var (
+	fileRegistry = huma.NewMapRegistry("#/components/schemas/", huma.DefaultSchemaNamer)
+	fileSchema   = huma.SchemaFromType(fileRegistry, reflect.TypeOf(File{}))
+)
+
+// parseFile validates data against the catalog schema and decodes it.
+func parseFile(data []byte) (*File, error) {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCatalog, err)
+	}
+	res := &huma.ValidateResult{}
+	huma.Validate(
+		fileRegistry,
+		fileSchema,
+		huma.NewPathBuffer([]byte(""), 0),
+		huma.ModeWriteToServer,
+		raw,
+		res,
+	)
+	if len(res.Errors) > 0 {
+		msgs := make([]string, 0, len(res.Errors))
+		for _, e := range res.Errors {
+			msgs = append(msgs, e.Error())
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCatalog, strings.Join(msgs, "; "))
+	}
+
+	f := &File{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(f); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCatalog, err)
+	}
+	return f, nil
+}
+
+// resolve merges the overlay into the inbuilt catalog, overlay may be nil.
+func resolve(inbuilt, overlay *File) (models, error) {
+	m := models{}
+	if err := apply(m, inbuilt, spec.ModelSourceInbuilt); err != nil {
+		return nil, err
+	}
+	if overlay != nil {
+		if err := apply(m, overlay, spec.ModelSourceUserOverlay); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func apply(m models, f *File, source spec.ModelSource) error {
+	for provider, pentry := range f.Providers {
+		if m[provider] == nil {
+			m[provider] = map[spec.ModelName]ModelInfo{}
+		}
+		for name, entry := range pentry.Models {
+			if entry.Disabled {
+				delete(m[provider], name)
+				continue
+			}
+			info, exists := m[provider][name]
+			if exists {
+				info = info.clone()
+				if source == spec.ModelSourceUserOverlay {
+					info.Source = spec.ModelSourceInbuiltWithUserOverlay
+				}
+			} else {
+				info = ModelInfo{
+					Defaults: spec.ModelDefaults{DisplayName: string(name)},
+					Params:   spec.ModelParams{Name: name},
+					Source:   source,
+				}
+			}
+			entry.applyTo(&info)
+			if err := validateModel(info); err != nil {
+				return fmt.Errorf("%w: %s/%s: %w", ErrInvalidCatalog, provider, name, err)
+			}
+			m[provider][name] = info
+		}
+	}
+	return nil
+}
+
+func (e ModelEntry) applyTo(info *ModelInfo) {
+	setIfPresent(&info.Defaults.DisplayName, e.DisplayName)
+	setIfPresent(&info.Defaults.IsEnabled, e.IsEnabled)
+
+	if p := e.Params; p != nil {
+		setIfPresent(&info.Params.Stream, p.Stream)
+		setIfPresent(&info.Params.MaxPromptLength, p.MaxPromptLength)
+		setIfPresent(&info.Params.MaxOutputLength, p.MaxOutputLength)
+		setIfPresent(&info.Params.SystemPrompt, p.SystemPrompt)
+		setIfPresent(&info.Params.Timeout, p.Timeout)
+		setIfPresent(&info.Params.FirstTokenTimeout, p.FirstTokenTimeout)
+		setIfPresent(&info.Params.IdleTimeout, p.IdleTimeout)
+		if p.Temperature != nil {
+			t := *p.Temperature
+			info.Params.Temperature = &t
+		}
+		if p.Reasoning != nil {
+			r := *p.Reasoning
+			info.Params.Reasoning = &r
+		}
+		if p.AdditionalParameters != nil {
+			info.Params.AdditionalParameters = maps.Clone(p.AdditionalParameters)
+		}
+	}
+
+	if c := e.Capabilities; c != nil {
+		if info.Capabilities == nil {
+			info.Capabilities = &spec.ModelCapabilities{SystemPrompt: spec.SystemPromptRoleSystem}
+		}
+		caps := info.Capabilities
+		setIfPresent(&caps.Vision, c.Vision)
+		setIfPresent(&caps.ToolCalling, c.ToolCalling)
+		setIfPresent(&caps.JSONMode, c.JSONMode)
+		setIfPresent(&caps.SystemPrompt, c.SystemPrompt)
+		setIfPresent(&caps.Temperature, c.Temperature)
+		setIfPresent(&caps.Streaming, c.Streaming)
+		setIfPresent(&caps.ReasoningType, c.ReasoningType)
+		setIfPresent(&caps.ContextWindow, c.ContextWindow)
+		setIfPresent(&caps.MaxOutputTokens, c.MaxOutputTokens)
+	}
+
+	if p := e.Pricing; p != nil {
+		if info.Pricing == nil {
+			info.Pricing = &spec.ModelPricing{}
+		}
+		setIfPresent(&info.Pricing.InputPerMillion, p.InputPerMillion)
+		setIfPresent(&info.Pricing.OutputPerMillion, p.OutputPerMillion)
+		setIfPresent(&info.Pricing.CacheReadPerMillion, p.CacheReadPerMillion)
+		setIfPresent(&info.Pricing.CacheWritePerMillion, p.CacheWritePerMillion)
+	}
+}
+
+func setIfPresent[T any](dst *T, src *T) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+// validateModel checks what the schema cannot express, like limits that new models must set.
+func validateModel(info ModelInfo) error {
+	if info.Params.MaxPromptLength <= 0 || info.Params.MaxOutputLength <= 0 {
+		return errors.New("maxPromptLength and maxOutputLength are required")
+	}
+	validReasoning := func(t spec.ReasoningType) bool {
+		return t == spec.ReasoningTypeHybridWithTokens || t == spec.ReasoningTypeSingleWithLevels
+	}
+	if r := info.Params.Reasoning; r != nil && !validReasoning(r.Type) {
+		return fmt.Errorf("invalid reasoning type %q", r.Type)
+	}
+	if caps := info.Capabilities; caps != nil {
+		if caps.ReasoningType != "" && !validReasoning(caps.ReasoningType) {
+			return fmt.Errorf("invalid capability reasoning type %q", caps.ReasoningType)
+		}
+	}
+	return nil
+}
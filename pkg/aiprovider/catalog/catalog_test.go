@@ -0,0 +1,222 @@
+package catalog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/consts"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+func writeOverlay(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "model_catalog.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write overlay: %v", err)
+	}
+	return path
+}
+
+func TestNewCatalog_Inbuilt(t *testing.T) {
+	c, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog: %v", err)
+	}
+	for provider := range consts.InbuiltProviders {
+		if len(c.ModelNames(provider)) == 0 {
+			t.Errorf("provider %q has no models", provider)
+		}
+	}
+	info, ok := c.ModelInfo(consts.ProviderNameDeepseek, consts.DeepseekReasoner)
+	if !ok {
+		t.Fatal("deepseek reasoner not in catalog")
+	}
+	if info.Params.Name != consts.DeepseekReasoner {
+		t.Errorf("got model name %q", info.Params.Name)
+	}
+	if info.Source != spec.ModelSourceInbuilt {
+		t.Errorf("got source %q", info.Source)
+	}
+	if info.Capabilities == nil {
+		t.Error("inbuilt model has no capabilities")
+	}
+}
+
+func TestSetOverlayFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		overlay string
+		wantErr bool
+		check   func(t *testing.T, c *Catalog)
+	}{
+		{
+			name:    "missing file keeps inbuilt catalog",
+			overlay: "",
+			check: func(t *testing.T, c *Catalog) {
+				t.Helper()
+				if _, ok := c.ModelInfo(consts.ProviderNameOpenAI, consts.GPT41); !ok {
+					t.Error("inbuilt model missing")
+				}
+			},
+		},
+		{
+			name: "add model",
+			overlay: `{"version":"1","providers":{"openai":{"models":{"gpt-x":{
+				"displayName":"GPT X","isEnabled":true,
+				"params":{"stream":true,"maxPromptLength":1000,"maxOutputLength":500}}}}}}`,
+			check: func(t *testing.T, c *Catalog) {
+				t.Helper()
+				info, ok := c.ModelInfo(consts.ProviderNameOpenAI, "gpt-x")
+				if !ok {
+					t.Fatal("added model missing")
+				}
+				if info.Source != spec.ModelSourceUserOverlay {
+					t.Errorf("got source %q", info.Source)
+				}
+				if info.Params.Name != "gpt-x" || info.Params.MaxPromptLength != 1000 ||
+					!info.Defaults.IsEnabled || info.Defaults.DisplayName != "GPT X" {
+					t.Errorf("unexpected model info %+v", info)
+				}
+				if info.Capabilities != nil {
+					t.Error("expected no capabilities")
+				}
+			},
+		},
+		{
+			name: "change limits",
+			overlay: `{"version":"1","providers":{"openai":{"models":{"gpt-4.1":{
+				"params":{"maxOutputLength":1234},"capabilities":{"contextWindow":5000}}}}}}`,
+			check: func(t *testing.T, c *Catalog) {
+				t.Helper()
+				info, _ := c.ModelInfo(consts.ProviderNameOpenAI, consts.GPT41)
+				if info.Source != spec.ModelSourceInbuiltWithUserOverlay {
+					t.Errorf("got source %q", info.Source)
+				}
+				if info.Params.MaxOutputLength != 1234 || info.Capabilities.ContextWindow != 5000 {
+					t.Errorf("overlay not applied: %+v", info)
+				}
+				if info.Params.MaxPromptLength == 0 || !info.Capabilities.Streaming {
+					t.Error("fields absent from the overlay were changed")
+				}
+			},
+		},
+		{
+			name: "change price",
+			overlay: `{"version":"1","providers":{"openai":{"models":{"gpt-4.1":{
+				"pricing":{"outputPerMillion":9.5}}}}}}`,
+			check: func(t *testing.T, c *Catalog) {
+				t.Helper()
+				pricing, ok := c.Pricing(consts.ProviderNameOpenAI, consts.GPT41)
+				if !ok {
+					t.Fatal("pricing missing")
+				}
+				if pricing.OutputPerMillion != 9.5 || pricing.InputPerMillion == 0 {
+					t.Errorf("overlay not merged into inbuilt pricing: %+v", pricing)
+				}
+			},
+		},
+		{
+			name: "negative price",
+			overlay: `{"version":"1","providers":{"openai":{"models":{"gpt-4.1":{
+				"pricing":{"inputPerMillion":-1}}}}}}`,
+			wantErr: true,
+		},
+		{
+			name: "disable model",
+			overlay: `{"version":"1","providers":{"openai":{"models":{
+				"gpt-4.1":{"disabled":true}}}}}`,
+			check: func(t *testing.T, c *Catalog) {
+				t.Helper()
+				if _, ok := c.ModelInfo(consts.ProviderNameOpenAI, consts.GPT41); ok {
+					t.Error("disabled model still in catalog")
+				}
+			},
+		},
+		{
+			name:    "unknown field",
+			overlay: `{"version":"1","providers":{"openai":{"models":{"gpt-4.1":{"maxTokens":1}}}}}`,
+			wantErr: true,
+		},
+		{
+			name: "negative limit",
+			overlay: `{"version":"1","providers":{"openai":{"models":{"gpt-4.1":{
+				"params":{"maxOutputLength":-1}}}}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "new model without limits",
+			overlay: `{"version":"1","providers":{"openai":{"models":{"gpt-x":{"isEnabled":true}}}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			overlay: `{"version":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCatalog()
+			if err != nil {
+				t.Fatalf("NewCatalog: %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "model_catalog.json")
+			if tt.overlay != "" {
+				path = writeOverlay(t, filepath.Dir(path), tt.overlay)
+			}
+			err = c.SetOverlayFile(path)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCatalog) {
+					t.Fatalf("expected ErrInvalidCatalog, got %v", err)
+				}
+				if c.Snapshot().OverlayError == "" {
+					t.Error("snapshot does not report the overlay error")
+				}
+				if _, ok := c.ModelInfo(consts.ProviderNameOpenAI, consts.GPT41); !ok {
+					t.Error("inbuilt catalog not kept after invalid overlay")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetOverlayFile: %v", err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestReload_KeepsLastValidCatalog(t *testing.T) {
+	c, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog: %v", err)
+	}
+	dir := t.TempDir()
+	path := writeOverlay(t, dir, `{"version":"1","providers":{"openai":{"models":{
+		"gpt-4.1":{"params":{"maxOutputLength":1234}}}}}}`)
+	if err := c.SetOverlayFile(path); err != nil {
+		t.Fatalf("SetOverlayFile: %v", err)
+	}
+
+	writeOverlay(t, dir, `{"version":"1","providers":{"openai":{"models":{"gpt-4.1":{"x":1}}}}}`)
+	if err := c.Reload(); err == nil {
+		t.Fatal("expected reload error")
+	}
+	params, _ := c.ModelParams(consts.ProviderNameOpenAI, consts.GPT41)
+	if params.MaxOutputLength != 1234 {
+		t.Errorf("last valid overlay not kept, got %d", params.MaxOutputLength)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove overlay: %v", err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	info, _ := c.ModelInfo(consts.ProviderNameOpenAI, consts.GPT41)
+	if info.Source != spec.ModelSourceInbuilt || c.Snapshot().OverlayError != "" {
+		t.Errorf("removed overlay still applied: %+v", info)
+	}
+}
@@ -0,0 +1,69 @@
+package catalog
+
+import "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+
+// File is the format of the inbuilt catalog and of the user overlay catalog.
+// An overlay entry is merged into the inbuilt entry of the same provider and model,
+// only the fields present in the overlay are changed.
+type File struct {
+	Version   string                              `json:"version"`
+	Providers map[spec.ProviderName]ProviderEntry `json:"providers"`
+}
+
+type ProviderEntry struct {
+	Models map[spec.ModelName]ModelEntry `json:"models"`
+}
+
+type ModelEntry struct {
+	// Disabled drops the model from the catalog, all other fields are ignored.
+	Disabled     bool               `json:"disabled,omitempty"`
+	DisplayName  *string            `json:"displayName,omitempty"`
+	IsEnabled    *bool              `json:"isEnabled,omitempty"`
+	Params       *ParamsEntry       `json:"params,omitempty"`
+	Capabilities *CapabilitiesEntry `json:"capabilities,omitempty"`
+	Pricing      *PricingEntry      `json:"pricing,omitempty"`
+}
+
+type ParamsEntry struct {
+	Stream               *bool                 `json:"stream,omitempty"`
+	MaxPromptLength      *int                  `json:"maxPromptLength,omitempty"      minimum:"1"`
+	MaxOutputLength      *int                  `json:"maxOutputLength,omitempty"      minimum:"1"`
+	Temperature          *float64              `json:"temperature,omitempty"          minimum:"0"`
+	Reasoning            *spec.ReasoningParams `json:"reasoning,omitempty"`
+	SystemPrompt         *string               `json:"systemPrompt,omitempty"`
+	Timeout              *int                  `json:"timeout,omitempty"              minimum:"0"`
+	FirstTokenTimeout    *int                  `json:"firstTokenTimeout,omitempty"    minimum:"0"`
+	IdleTimeout          *int                  `json:"idleTimeout,omitempty"          minimum:"0"`
+	AdditionalParameters map[string]any        `json:"additionalParameters,omitempty"`
+}
+
+type CapabilitiesEntry struct {
+	Vision          *bool                     `json:"vision,omitempty"`
+	ToolCalling     *bool                     `json:"toolCalling,omitempty"`
+	JSONMode        *bool                     `json:"jsonMode,omitempty"`
+	SystemPrompt    *spec.SystemPromptSupport `json:"systemPrompt,omitempty"    enum:"system,developer,unsupported"`
+	Temperature     *bool                     `json:"temperature,omitempty"`
+	Streaming       *bool                     `json:"streaming,omitempty"`
+	ReasoningType   *spec.ReasoningType       `json:"reasoningType,omitempty"`
+	ContextWindow   *int                      `json:"contextWindow,omitempty"                                       minimum:"0"`
+	MaxOutputTokens *int                      `json:"maxOutputTokens,omitempty"                                     minimum:"0"`
+}
+
+// PricingEntry is in USD per million tokens.
+type PricingEntry struct {
+	InputPerMillion      *float64 `json:"inputPerMillion,omitempty"      minimum:"0"`
+	OutputPerMillion     *float64 `json:"outputPerMillion,omitempty"     minimum:"0"`
+	CacheReadPerMillion  *float64 `json:"cacheReadPerMillion,omitempty"  minimum:"0"`
+	CacheWritePerMillion *float64 `json:"cacheWritePerMillion,omitempty" minimum:"0"`
+}
+
+// ModelInfo is the resolved catalog definition of a model.
+type ModelInfo struct {
+	Defaults spec.ModelDefaults `json:"defaults"`
+	Params   spec.ModelParams   `json:"params"`
+	// Capabilities is nil for models without known capabilities, requests to them are not adapted.
+	Capabilities *spec.ModelCapabilities `json:"capabilities,omitempty"`
+	// Pricing is nil for models without known prices, their completions have no cost.
+	Pricing *spec.ModelPricing `json:"pricing,omitempty"`
+	Source  spec.ModelSource   `json:"source"`
+}
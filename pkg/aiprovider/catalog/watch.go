@@ -0,0 +1,77 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// DefaultWatchInterval is how often Watch checks the overlay file for changes.
+const DefaultWatchInterval = 2 * time.Second
+
+// fileState identifies a version of the overlay file, a changed state triggers a reload.
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// readFile returns the state and content of the overlay file, data is nil if there is no file.
+func readFile(path string) (fileState, []byte, error) {
+	if path == "" {
+		return fileState{}, nil, nil
+	}
+	state, err := statFile(path)
+	if err != nil || !state.exists {
+		return state, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, nil, err
+	}
+	return state, data, nil
+}
+
+func statFile(path string) (fileState, error) {
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{exists: true, modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// Watch polls the overlay file and reloads the catalog when it changes, until ctx is done.
+// Load errors are logged, the last valid catalog stays in use.
+func (c *Catalog) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.RLock()
+		path, prev := c.overlayPath, c.overlayState
+		c.mu.RUnlock()
+		if path == "" {
+			continue
+		}
+		state, err := statFile(path)
+		if err != nil || state == prev {
+			continue
+		}
+		if err := c.Reload(); err != nil {
+			slog.Error("Could not reload model catalog", "error", err)
+		} else {
+			slog.Info("Reloaded model catalog", "path", path)
+		}
+	}
+}
@@ -28,128 +28,6 @@ const (
 	DisplayNameClaude3Haiku   = "Claude 3 Haiku"
 )
 
-var AnthropicModelDefaults = map[spec.ModelName]spec.ModelDefaults{
-	Claude4Opus: {
-		DisplayName: DisplayNameClaude4Opus,
-		IsEnabled:   true,
-	},
-	Claude4Sonnet: {
-		DisplayName: DisplayNameClaude4Sonnet,
-		IsEnabled:   true,
-	},
-	Claude37Sonnet: {
-		DisplayName: DisplayNameClaude37Sonnet,
-		IsEnabled:   false,
-	},
-	Claude35Sonnet: {
-		DisplayName: DisplayNameClaude35Sonnet,
-		IsEnabled:   false,
-	},
-	Claude35Haiku: {
-		DisplayName: DisplayNameClaude35Haiku,
-		IsEnabled:   false,
-	},
-	Claude3Opus: {
-		DisplayName: DisplayNameClaude3Opus,
-		IsEnabled:   false,
-	},
-	Claude3Sonnet: {
-		DisplayName: DisplayNameClaude3Sonnet,
-		IsEnabled:   false,
-	},
-	Claude3Haiku: {
-		DisplayName: DisplayNameClaude3Haiku,
-		IsEnabled:   false,
-	},
-}
-
-var AnthropicModels = map[spec.ModelName]spec.ModelParams{
-	Claude4Opus: {
-		Name:            Claude4Opus,
-		MaxPromptLength: 65536,
-		MaxOutputLength: 16384,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		Reasoning: &spec.ReasoningParams{
-			Type:   spec.ReasoningTypeHybridWithTokens,
-			Tokens: 1024,
-		},
-		SystemPrompt: "",
-		Timeout:      120,
-	},
-	Claude4Sonnet: {
-		Name:            Claude4Sonnet,
-		MaxPromptLength: 65536,
-		MaxOutputLength: 16384,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		Reasoning: &spec.ReasoningParams{
-			Type:   spec.ReasoningTypeHybridWithTokens,
-			Tokens: 1024,
-		},
-		SystemPrompt: "",
-		Timeout:      120,
-	},
-	Claude37Sonnet: {
-		Name:            Claude37Sonnet,
-		MaxPromptLength: 65536,
-		MaxOutputLength: 16384,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		Reasoning: &spec.ReasoningParams{
-			Type:   spec.ReasoningTypeHybridWithTokens,
-			Tokens: 1024,
-		},
-		SystemPrompt: "",
-		Timeout:      120,
-	},
-	Claude35Sonnet: {
-		Name:            Claude35Sonnet,
-		MaxPromptLength: 16384,
-		MaxOutputLength: 8192,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	Claude35Haiku: {
-		Name:            Claude35Haiku,
-		MaxPromptLength: 16384,
-		MaxOutputLength: 8192,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	Claude3Opus: {
-		Name:            Claude3Opus,
-		MaxPromptLength: 16384,
-		MaxOutputLength: 4096,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	Claude3Sonnet: {
-		Name:            Claude3Sonnet,
-		MaxPromptLength: 8192,
-		MaxOutputLength: 4096,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	Claude3Haiku: {
-		Name:            Claude3Haiku,
-		MaxPromptLength: 8192,
-		MaxOutputLength: 4096,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-}
-
 var AnthropicProviderInfo = spec.ProviderInfo{
 	Name:   ProviderNameAnthropic,
 	APIKey: "",
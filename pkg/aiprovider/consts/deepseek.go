@@ -16,42 +16,6 @@ const (
 	DisplayNameDeepseekReasoner = "Deepseek Reasoner"
 )
 
-var DeepseekModelDefaults = map[spec.ModelName]spec.ModelDefaults{
-	DeepseekChat: {
-		DisplayName: DisplayNameDeepseekChat,
-		IsEnabled:   true,
-	},
-	DeepseekReasoner: {
-		DisplayName: DisplayNameDeepseekReasoner,
-		IsEnabled:   true,
-	},
-}
-
-var DeepseekModels = map[spec.ModelName]spec.ModelParams{
-	DeepseekChat: {
-		Name:            DeepseekChat,
-		MaxPromptLength: 8192,
-		MaxOutputLength: 8192,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	DeepseekReasoner: {
-		Name:            DeepseekChat,
-		MaxPromptLength: 8192,
-		MaxOutputLength: 8192,
-		Temperature:     Float64Ptr(1.0),
-		Stream:          true,
-		Reasoning: &spec.ReasoningParams{
-			Type:  spec.ReasoningTypeSingleWithLevels,
-			Level: spec.ReasoningLevelMedium,
-		},
-		SystemPrompt: "",
-		Timeout:      120,
-	},
-}
-
 var DeepseekProviderInfo = spec.ProviderInfo{
 	Name:   ProviderNameDeepseek,
 	APIKey: "",
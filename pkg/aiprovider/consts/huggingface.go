@@ -12,25 +12,6 @@ const (
 
 const DisplayNameDeepseekCoder13BInstruct = "HF Deepseek Coder 1.3b"
 
-var HuggingfaceModelDefaults = map[spec.ModelName]spec.ModelDefaults{
-	DeepseekCoder13BInstruct: {
-		DisplayName: DisplayNameDeepseekCoder13BInstruct,
-		IsEnabled:   true,
-	},
-}
-
-var HuggingfaceModels = map[spec.ModelName]spec.ModelParams{
-	DeepseekCoder13BInstruct: {
-		Name:            DeepseekCoder13BInstruct,
-		MaxPromptLength: 4096,
-		MaxOutputLength: 4096,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-}
-
 var HuggingfaceProviderInfo = spec.ProviderInfo{
 	Name:   ProviderNameHuggingFace,
 	APIKey: "",
@@ -16,38 +16,6 @@ const (
 	DisplayNameLlama31 = "Llama 3.1"
 )
 
-var LlamacppModelDefaults = map[spec.ModelName]spec.ModelDefaults{
-	Llama31: {
-		DisplayName: DisplayNameLlama31,
-		IsEnabled:   true,
-	},
-	Llama3: {
-		DisplayName: DisplayNameLlama3,
-		IsEnabled:   true,
-	},
-}
-
-var LlamacppModels = map[spec.ModelName]spec.ModelParams{
-	Llama3: {
-		Name:            Llama3,
-		MaxPromptLength: 4096,
-		MaxOutputLength: 4096,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	Llama31: {
-		Name:            Llama31,
-		MaxPromptLength: 4096,
-		MaxOutputLength: 4096,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-}
-
 var LlamacppProviderInfo = spec.ProviderInfo{
 	Name:   ProviderNameLlamaCPP,
 	APIKey: "",
@@ -35,158 +35,6 @@ const (
 	DisplayNameGPT35Turbo = "OpenAI GPT 3.5 turbo"
 )
 
-var OpenAIModelDefaults = map[spec.ModelName]spec.ModelDefaults{
-	GPTO4Mini: {
-		DisplayName: DisplayNameGPTO4Mini,
-		IsEnabled:   true,
-	},
-	GPTO3: {
-		DisplayName: DisplayNameGPTO3,
-		IsEnabled:   true,
-	},
-	GPTO3Mini: {
-		DisplayName: DisplayNameGPTO3Mini,
-		IsEnabled:   false,
-	},
-	GPTO1: {
-		DisplayName: DisplayNameGPTO1,
-		IsEnabled:   false,
-	},
-	GPT41: {
-		DisplayName: DisplayNameGPT41,
-		IsEnabled:   true,
-	},
-	GPT41Mini: {
-		DisplayName: DisplayNameGPT41Mini,
-		IsEnabled:   false,
-	},
-	GPT4O: {
-		DisplayName: DisplayNameGPT4O,
-		IsEnabled:   false,
-	},
-	GPT4OMini: {
-		DisplayName: DisplayNameGPT4OMini,
-		IsEnabled:   false,
-	},
-	GPT4: {
-		DisplayName: DisplayNameGPT4,
-		IsEnabled:   false,
-	},
-	GPT35Turbo: {
-		DisplayName: DisplayNameGPT35Turbo,
-		IsEnabled:   false,
-	},
-}
-
-var OpenAIModels = map[spec.ModelName]spec.ModelParams{
-	GPTO4Mini: {
-		Name:            GPTO4Mini,
-		Stream:          true,
-		MaxPromptLength: 32768,
-		MaxOutputLength: 32768,
-		Temperature:     Float64Ptr(1.0),
-		Reasoning: &spec.ReasoningParams{
-			Type:  spec.ReasoningTypeSingleWithLevels,
-			Level: spec.ReasoningLevelMedium,
-		},
-		SystemPrompt: "Formatting re-enabled.\nAlways output in Markdown format.",
-		Timeout:      120,
-	},
-	GPTO3: {
-		Name:            GPTO3,
-		MaxPromptLength: 32768,
-		MaxOutputLength: 32768,
-		Temperature:     Float64Ptr(1.0),
-		Stream:          true,
-		Reasoning: &spec.ReasoningParams{
-			Type:  spec.ReasoningTypeSingleWithLevels,
-			Level: spec.ReasoningLevelMedium,
-		},
-		SystemPrompt: "Formatting re-enabled.\nAlways output in Markdown format.",
-		Timeout:      120,
-	},
-	GPTO3Mini: {
-		Name:            GPTO3Mini,
-		Stream:          true,
-		MaxPromptLength: 16384,
-		MaxOutputLength: 16384,
-		Temperature:     Float64Ptr(1.0),
-		Reasoning: &spec.ReasoningParams{
-			Type:  spec.ReasoningTypeSingleWithLevels,
-			Level: spec.ReasoningLevelMedium,
-		},
-		SystemPrompt: "Formatting re-enabled.\nAlways output in Markdown format.",
-		Timeout:      120,
-	},
-	GPTO1: {
-		Name:            GPTO1,
-		MaxPromptLength: 16384,
-		MaxOutputLength: 16384,
-		Temperature:     Float64Ptr(1.0),
-		Stream:          true,
-		Reasoning: &spec.ReasoningParams{
-			Type:  spec.ReasoningTypeSingleWithLevels,
-			Level: spec.ReasoningLevelMedium,
-		},
-		SystemPrompt: "Formatting re-enabled.\nAlways output in Markdown format.",
-		Timeout:      120,
-	},
-	GPT41: {
-		Name:            GPT41,
-		MaxPromptLength: 32768,
-		MaxOutputLength: 32768,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	GPT41Mini: {
-		Name:            GPT41Mini,
-		MaxPromptLength: 32768,
-		MaxOutputLength: 32768,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	GPT4O: {
-		Name:            GPT4O,
-		MaxPromptLength: 16384,
-		MaxOutputLength: 16384,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	GPT4: {
-		Name:            GPT4,
-		MaxPromptLength: 4096,
-		MaxOutputLength: 4096,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	GPT35Turbo: {
-		Name:            GPT35Turbo,
-		MaxPromptLength: 2400,
-		MaxOutputLength: 2400,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-	GPT4OMini: {
-		Name:            GPT4OMini,
-		MaxPromptLength: 4096,
-		MaxOutputLength: 4096,
-		Temperature:     Float64Ptr(0.1),
-		Stream:          true,
-		SystemPrompt:    "",
-		Timeout:         120,
-	},
-}
-
 var OpenAIProviderInfo = spec.ProviderInfo{
 	Name:                     ProviderNameOpenAI,
 	APIKey:                   "",
@@ -17,21 +17,3 @@ var InbuiltProviders = map[spec.ProviderName]spec.ProviderInfo{
 	ProviderNameLlamaCPP:    LlamacppProviderInfo,
 	ProviderNameOpenAI:      OpenAIProviderInfo,
 }
-
-var InbuiltProviderModels = map[spec.ProviderName]map[spec.ModelName]spec.ModelParams{
-	ProviderNameAnthropic:   AnthropicModels,
-	ProviderNameDeepseek:    DeepseekModels,
-	ProviderNameGoogle:      GoogleModels,
-	ProviderNameHuggingFace: HuggingfaceModels,
-	ProviderNameLlamaCPP:    LlamacppModels,
-	ProviderNameOpenAI:      OpenAIModels,
-}
-
-var InbuiltProviderModelDefaults = map[spec.ProviderName]map[spec.ModelName]spec.ModelDefaults{
-	ProviderNameAnthropic:   AnthropicModelDefaults,
-	ProviderNameDeepseek:    DeepseekModelDefaults,
-	ProviderNameGoogle:      GoogleModelDefaults,
-	ProviderNameHuggingFace: HuggingfaceModelDefaults,
-	ProviderNameLlamaCPP:    LlamacppModelDefaults,
-	ProviderNameOpenAI:      OpenAIModelDefaults,
-}
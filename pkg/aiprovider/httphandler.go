@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/sse"
 )
 
 const (
@@ -66,6 +67,42 @@ func InitProviderSetHandlers(api huma.API, providerSetAPI *ProviderSetAPI) {
 		Tags:        []string{tag},
 	}, providerSetAPI.SetProviderAttribute)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "test-provider-connection",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/providers/{provider}/connectiontest",
+		Summary:     "Test provider connection",
+		Description: "Probe a provider and report DNS/TLS/HTTP status, auth validity, latency and visible models",
+		Tags:        []string{tag},
+	}, providerSetAPI.TestProviderConnection)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-provider-network",
+		Method:      http.MethodPut,
+		Path:        pathPrefix + "/providers/{provider}/network",
+		Summary:     "Set provider network config",
+		Description: "Set proxy, CA certificate and client certificate config for a provider",
+		Tags:        []string{tag},
+	}, providerSetAPI.SetProviderNetworkConfig)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-provider-ratelimits",
+		Method:      http.MethodPut,
+		Path:        pathPrefix + "/providers/{provider}/ratelimits",
+		Summary:     "Set provider rate limits",
+		Description: "Set client side rate limits for a provider and its models",
+		Tags:        []string{tag},
+	}, providerSetAPI.SetProviderRateLimits)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-outbound-scan-config",
+		Method:      http.MethodPut,
+		Path:        pathPrefix + "/outboundscan",
+		Summary:     "Set outbound scan config",
+		Description: "Set the secret and personal data scan applied to completion requests before they are sent",
+		Tags:        []string{tag},
+	}, providerSetAPI.SetOutboundScanConfig)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "fetch-provider-completion",
 		Method:      http.MethodPost,
@@ -74,4 +111,31 @@ func InitProviderSetHandlers(api huma.API, providerSetAPI *ProviderSetAPI) {
 		Description: "Fetch completion for a provider",
 		Tags:        []string{tag},
 	}, providerSetAPI.FetchCompletion)
+
+	sse.Register(api, huma.Operation{
+		OperationID: "stream-provider-completion",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/providers/{provider}/completion/stream",
+		Summary:     "Stream completion for a provider",
+		Description: "Stream completion chunks and reasoning as server sent events, the last event is the full completion response or an error",
+		Tags:        []string{tag},
+	}, CompletionStreamEventTypes, providerSetAPI.StreamCompletion)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "fetch-provider-embeddings",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/providers/{provider}/embeddings",
+		Summary:     "Fetch embeddings for a provider",
+		Description: "Fetch embeddings for a list of inputs using a provider model",
+		Tags:        []string{tag},
+	}, providerSetAPI.FetchEmbeddings)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "fetch-multi-completion",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/completions/multi",
+		Summary:     "Fetch completion from multiple provider/models",
+		Description: "Send one prompt to multiple provider/model pairs concurrently and return all results",
+		Tags:        []string{tag},
+	}, providerSetAPI.FetchCompletionMulti)
 }
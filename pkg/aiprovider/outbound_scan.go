@@ -0,0 +1,117 @@
+package aiprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/secretscan"
+)
+
+// SetOutboundScanConfig sets the scan applied to every completion request before it is sent.
+// A disabled config removes the scan.
+func (ps *ProviderSetAPI) SetOutboundScanConfig(
+	ctx context.Context,
+	req *api.SetOutboundScanConfigRequest,
+) (*api.SetOutboundScanConfigResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("got empty outbound scan config input")
+	}
+	cfg := req.Body.Config
+	if !cfg.Enabled {
+		ps.outboundScanner.Store(nil)
+		return &api.SetOutboundScanConfigResponse{}, nil
+	}
+
+	scanCfg := secretscan.Config{
+		DisableBuiltinRules: cfg.DisableBuiltinRules,
+		ActionOverrides:     map[string]secretscan.Action{},
+	}
+	for name, action := range cfg.ActionOverrides {
+		scanCfg.ActionOverrides[name] = secretscan.Action(action)
+	}
+	for _, rule := range cfg.Rules {
+		scanCfg.Rules = append(scanCfg.Rules, secretscan.Rule{
+			Name:        rule.Name,
+			Pattern:     rule.Pattern,
+			Action:      secretscan.Action(rule.Action),
+			Description: rule.Description,
+		})
+	}
+	scanner, err := secretscan.NewScanner(scanCfg)
+	if err != nil {
+		return nil, err
+	}
+	ps.outboundScanner.Store(scanner)
+	return &api.SetOutboundScanConfigResponse{}, nil
+}
+
+// scanOutbound scans the system prompt, prompt and previous messages of a completion request.
+// Masked content is applied to a copy of the request. If any finding blocks the request,
+// the returned message explains why and the request must not be sent.
+func (ps *ProviderSetAPI) scanOutbound(
+	req *api.FetchCompletionRequest,
+) (*api.FetchCompletionRequest, []api.OutboundScanFinding, string) {
+	scanner := ps.outboundScanner.Load()
+	if scanner == nil {
+		return req, nil, ""
+	}
+
+	body := *req.Body
+	var findings []api.OutboundScanFinding
+	scan := func(location, text string) string {
+		masked, found := scanner.Scan(text)
+		for _, f := range found {
+			findings = append(findings, api.OutboundScanFinding{
+				Location:    location,
+				Rule:        f.Rule,
+				Description: f.Description,
+				Action:      spec.OutboundScanAction(f.Action),
+				Start:       f.Start,
+				End:         f.End,
+			})
+		}
+		return masked
+	}
+
+	body.ModelParams.SystemPrompt = scan("systemPrompt", body.ModelParams.SystemPrompt)
+	body.Prompt = scan("prompt", body.Prompt)
+	body.PrevMessages = slices.Clone(body.PrevMessages)
+	for i, msg := range body.PrevMessages {
+		if msg.Content == nil {
+			continue
+		}
+		content := scan(fmt.Sprintf("prevMessages[%d]", i), *msg.Content)
+		body.PrevMessages[i].Content = &content
+	}
+	if len(findings) == 0 {
+		return req, nil, ""
+	}
+
+	blocked := []string{}
+	for _, f := range findings {
+		switch f.Action {
+		case spec.OutboundScanActionBlock:
+			blocked = append(blocked, fmt.Sprintf("%s in %s", getFindingLabel(f), f.Location))
+		case spec.OutboundScanActionWarn:
+			slog.Warn("Outbound scan found sensitive data", "rule", f.Rule, "location", f.Location)
+		}
+	}
+	if len(blocked) > 0 {
+		return req, findings, "Request was not sent, the outbound scan found: " +
+			strings.Join(blocked, ", ") + ". Remove them from the messages and try again."
+	}
+	return &api.FetchCompletionRequest{Body: &body}, findings, ""
+}
+
+func getFindingLabel(f api.OutboundScanFinding) string {
+	if f.Description != "" {
+		return f.Description
+	}
+	return f.Rule
+}
@@ -0,0 +1,55 @@
+package aiprovider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+// PromptCompleter runs rendered prompts of batch jobs and evals through the provider set,
+// so that rate limits and the outbound scan apply to them as to any other completion.
+type PromptCompleter struct {
+	ps *ProviderSetAPI
+}
+
+func NewPromptCompleter(ps *ProviderSetAPI) *PromptCompleter {
+	return &PromptCompleter{ps: ps}
+}
+
+func (c *PromptCompleter) Complete(
+	ctx context.Context,
+	req *promptrun.Request,
+) (*promptrun.Result, error) {
+	resp, err := c.ps.FetchCompletion(ctx, &api.FetchCompletionRequest{
+		Body: &api.FetchCompletionRequestBody{
+			Provider:     req.Provider,
+			Prompt:       req.Prompt,
+			ModelParams:  req.ModelParams,
+			PrevMessages: req.PrevMessages,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body == nil {
+		return nil, errors.New("got empty completion response")
+	}
+	if resp.Body.ErrorDetails != nil {
+		return nil, errors.New(resp.Body.ErrorDetails.Message)
+	}
+
+	result := &promptrun.Result{}
+	if resp.Body.RespContent != nil {
+		result.Content = *resp.Body.RespContent
+	}
+	if u := resp.Body.Usage; u != nil {
+		result.Usage = &promptrun.Usage{
+			InputTokens:  u.InputTokens,
+			OutputTokens: u.OutputTokens,
+			TotalTokens:  u.TotalTokens,
+		}
+	}
+	return result, nil
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/catalog"
 	"github.com/ppipada/flexigpt-app/pkg/aiprovider/consts"
 	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/middleware"
+	"github.com/ppipada/flexigpt-app/pkg/secretscan"
 )
 
 func getInbuiltProviderAPI(debug bool) map[spec.ProviderName]api.CompletionProvider {
@@ -39,10 +45,16 @@ func getInbuiltProviderAPI(debug bool) map[spec.ProviderName]api.CompletionProvi
 	}
 }
 
+// defaultEmbeddingBatchSize is the number of inputs sent per embeddings request if not specified.
+const defaultEmbeddingBatchSize = 256
+
 // Define the ProviderSetAPI struct.
 type ProviderSetAPI struct {
 	defaultProvider spec.ProviderName
 	providers       map[spec.ProviderName]api.CompletionProvider
+	rateLimiters    *rateLimiters
+	// outboundScanner is nil while the outbound scan is disabled.
+	outboundScanner atomic.Pointer[secretscan.Scanner]
 	debug           bool
 }
 
@@ -58,6 +70,7 @@ func NewProviderSetAPI(
 	return &ProviderSetAPI{
 		defaultProvider: defaultInbuiltProvider,
 		providers:       getInbuiltProviderAPI(debug),
+		rateLimiters:    newRateLimiters(),
 		debug:           debug,
 	}, nil
 }
@@ -90,12 +103,17 @@ func (ps *ProviderSetAPI) GetConfigurationInfo(
 			configuredProviders = append(configuredProviders, *providerAPI.GetProviderInfo(ctx))
 		}
 	}
+	snapshot := catalog.Default().Snapshot()
 	return &api.GetConfigurationInfoResponse{
 		Body: &api.GetConfigurationInfoResponseBody{
-			DefaultProvider:              ps.defaultProvider,
-			ConfiguredProviders:          configuredProviders,
-			InbuiltProviderModels:        consts.InbuiltProviderModels,
-			InbuiltProviderModelDefaults: consts.InbuiltProviderModelDefaults,
+			DefaultProvider:                  ps.defaultProvider,
+			ConfiguredProviders:              configuredProviders,
+			InbuiltProviderModels:            snapshot.Params,
+			InbuiltProviderModelDefaults:     snapshot.Defaults,
+			InbuiltProviderModelCapabilities: snapshot.Capabilities,
+			InbuiltProviderModelSources:      snapshot.Sources,
+			ModelCatalogError:                snapshot.OverlayError,
+			RateLimitStatus:                  ps.rateLimiters.status(),
 		},
 	}, nil
 }
@@ -177,6 +195,7 @@ func (ps *ProviderSetAPI) DeleteProvider(
 		)
 	}
 	delete(ps.providers, req.Provider)
+	ps.rateLimiters.remove(req.Provider)
 	slog.Info("DeleteProvider", "Name", req.Provider)
 	return &api.DeleteProviderResponse{}, nil
 }
@@ -236,6 +255,101 @@ func (ps *ProviderSetAPI) SetProviderAttribute(
 	return &api.SetProviderAttributeResponse{}, nil
 }
 
+// TestProviderConnection runs a cheap probe against the configured origin and path prefix of a provider.
+// Providers with a model listing endpoint list models, others get a one token completion request.
+// Servers that do not implement the model listing, i.e. answer it with a 404, get the completion
+// probe too. Probe failures are reported in the result and not as an error.
+func (ps *ProviderSetAPI) TestProviderConnection(
+	ctx context.Context,
+	req *api.TestProviderConnectionRequest,
+) (*api.TestProviderConnectionResponse, error) {
+	if req == nil || req.Provider == "" {
+		return nil, errors.New("got empty provider input")
+	}
+	p, exists := ps.providers[req.Provider]
+	if !exists {
+		return nil, errors.New("invalid provider")
+	}
+
+	var modelName spec.ModelName
+	if req.Body != nil && req.Body.ModelName != nil {
+		modelName = *req.Body.ModelName
+	} else if models := catalog.Default().ModelNames(req.Provider); len(models) > 0 {
+		modelName = models[0]
+	}
+
+	if prober, ok := p.(api.ConnectionProber); ok {
+		result, err := prober.ProbeConnection(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if result.HTTPStatus != http.StatusNotFound || modelName == "" {
+			return &api.TestProviderConnectionResponse{Body: result}, nil
+		}
+	}
+
+	if modelName == "" {
+		return nil, errors.New("no model given for the connection probe")
+	}
+	result := api.ProbeWithCompletion(ctx, req.Provider, p.GetLLMsModel(ctx), modelName)
+	return &api.TestProviderConnectionResponse{Body: result}, nil
+}
+
+// SetProviderNetworkConfig sets the proxy and TLS config for a given provider and reinitializes it.
+// The config is applied even if the proxy is unreachable, so that requests never bypass the proxy,
+// and an error wrapping api.ErrProxyUnreachable is returned. Providers that cannot use the config
+// return api.ErrNetworkConfigUnsupported and stay uninitialized until it is cleared.
+func (ps *ProviderSetAPI) SetProviderNetworkConfig(
+	ctx context.Context,
+	req *api.SetProviderNetworkConfigRequest,
+) (*api.SetProviderNetworkConfigResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("got empty provider input")
+	}
+	p, exists := ps.providers[req.Provider]
+	if !exists {
+		return nil, errors.New("invalid provider")
+	}
+
+	err := p.SetNetworkConfig(ctx, req.Body.NetworkConfig)
+	if err != nil {
+		return nil, err
+	}
+	err = p.InitLLM(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = api.CheckProxyReachable(ctx, req.Body.NetworkConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &api.SetProviderNetworkConfigResponse{}, nil
+}
+
+// SetProviderRateLimits sets client side rate limits for a given provider and its models.
+// Limits are applied to in flight queues immediately.
+func (ps *ProviderSetAPI) SetProviderRateLimits(
+	ctx context.Context,
+	req *api.SetProviderRateLimitsRequest,
+) (*api.SetProviderRateLimitsResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("got empty provider input")
+	}
+	if _, exists := ps.providers[req.Provider]; !exists {
+		return nil, errors.New("invalid provider")
+	}
+	if err := validateRateLimits(req.Body.RateLimits); err != nil {
+		return nil, err
+	}
+	for _, rl := range req.Body.ModelRateLimits {
+		if err := validateRateLimits(&rl); err != nil {
+			return nil, err
+		}
+	}
+	ps.rateLimiters.set(req.Provider, req.Body.RateLimits, req.Body.ModelRateLimits)
+	return &api.SetProviderRateLimitsResponse{}, nil
+}
+
 // FetchCompletion processes a completion request for a given provider.
 func (ps *ProviderSetAPI) FetchCompletion(
 	ctx context.Context,
@@ -251,12 +365,84 @@ func (ps *ProviderSetAPI) FetchCompletion(
 	}
 
 	var inbuiltModelParams *spec.ModelParams = nil
-	if pmodels, providerExists := consts.InbuiltProviderModels[provider]; providerExists {
-		if params, paramExists := pmodels[req.Body.ModelParams.Name]; paramExists {
-			inbuiltModelParams = &params
+	if params, ok := catalog.Default().ModelParams(provider, req.Body.ModelParams.Name); ok {
+		inbuiltModelParams = &params
+	}
+
+	if caps, ok := catalog.Default().Capabilities(provider, req.Body.ModelParams.Name); ok {
+		params, adjustments, err := api.AdaptModelParams(req.Body.ModelParams, caps)
+		if err != nil {
+			return nil, err
+		}
+		if len(adjustments) > 0 {
+			slog.Debug(
+				"Adapted completion request to model capabilities",
+				"provider", provider,
+				"model", params.Name,
+				"adjustments", adjustments,
+			)
+			// Copy the body so that the caller's request is not modified.
+			body := *req.Body
+			body.ModelParams = params
+			req = &api.FetchCompletionRequest{Body: &body}
 		}
 	}
 
+	req, findings, blockedMsg := ps.scanOutbound(req)
+	if blockedMsg != "" {
+		return &api.FetchCompletionResponse{Body: &api.CompletionResponse{
+			ErrorDetails: &api.APIErrorDetails{
+				Message: blockedMsg,
+				Type:    api.APIErrorTypeOutboundBlocked,
+			},
+			OutboundScanFindings: findings,
+		}}, nil
+	}
+
+	resp, err := ps.fetchCompletionWithRateLimit(ctx, p, inbuiltModelParams, req)
+	if resp != nil && resp.Body != nil {
+		resp.Body.OutboundScanFindings = findings
+	}
+	return resp, err
+}
+
+func (ps *ProviderSetAPI) fetchCompletionWithRateLimit(
+	ctx context.Context,
+	p api.CompletionProvider,
+	inbuiltModelParams *spec.ModelParams,
+	req *api.FetchCompletionRequest,
+) (*api.FetchCompletionResponse, error) {
+	provider := req.Body.Provider
+	if limiter := ps.rateLimiters.get(provider, req.Body.ModelParams.Name); limiter != nil {
+		estimate := api.EstimateRequestTokens(
+			req.Body.Prompt,
+			req.Body.ModelParams,
+			req.Body.PrevMessages,
+		)
+		release, err := limiter.Acquire(ctx, estimate)
+		if err != nil {
+			return nil, errors.Join(err, errors.New("rate limit wait aborted"))
+		}
+		// Unknown usage keeps the estimate as consumed.
+		actualTokens := -1
+		defer func() { release(actualTokens) }()
+
+		resp, err := ps.fetchCompletion(ctx, p, inbuiltModelParams, req)
+		if resp != nil && resp.Body != nil && resp.Body.Usage != nil {
+			actualTokens = resp.Body.Usage.TotalTokens
+		}
+		return resp, err
+	}
+
+	return ps.fetchCompletion(ctx, p, inbuiltModelParams, req)
+}
+
+func (ps *ProviderSetAPI) fetchCompletion(
+	ctx context.Context,
+	p api.CompletionProvider,
+	inbuiltModelParams *spec.ModelParams,
+	req *api.FetchCompletionRequest,
+) (*api.FetchCompletionResponse, error) {
 	resp, err := p.FetchCompletion(
 		ctx,
 		p.GetLLMsModel(ctx),
@@ -265,6 +451,7 @@ func (ps *ProviderSetAPI) FetchCompletion(
 		inbuiltModelParams,
 		req.Body.PrevMessages,
 		req.Body.OnStreamData,
+		req.Body.OnStreamReasoning,
 	)
 	if err != nil {
 		return nil, errors.Join(err, errors.New("error in fetch completion"))
@@ -272,3 +459,144 @@ func (ps *ProviderSetAPI) FetchCompletion(
 
 	return &api.FetchCompletionResponse{Body: resp}, nil
 }
+
+// FetchEmbeddings computes embeddings for the inputs using a provider that supports embeddings.
+// Inputs are sent in batches and the embeddings are returned in input order.
+func (ps *ProviderSetAPI) FetchEmbeddings(
+	ctx context.Context,
+	req *api.FetchEmbeddingsRequest,
+) (*api.FetchEmbeddingsResponse, error) {
+	if req == nil || req.Body == nil || req.Body.ModelName == "" || len(req.Body.Inputs) == 0 {
+		return nil, errors.New("got empty provider/model/inputs input")
+	}
+	p, exists := ps.providers[req.Provider]
+	if !exists {
+		return nil, errors.New("invalid provider")
+	}
+	ep, ok := p.(api.EmbeddingProvider)
+	if !ok {
+		return nil, errors.New("provider does not support embeddings")
+	}
+
+	batchSize := req.Body.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEmbeddingBatchSize
+	}
+
+	result := &api.EmbeddingResponse{
+		ModelName:  req.Body.ModelName,
+		Embeddings: make([][]float32, 0, len(req.Body.Inputs)),
+	}
+	for start := 0; start < len(req.Body.Inputs); start += batchSize {
+		end := min(start+batchSize, len(req.Body.Inputs))
+		resp, err := ep.FetchEmbeddings(
+			ctx,
+			req.Body.ModelName,
+			req.Body.Inputs[start:end],
+			req.Body.Dimensions,
+		)
+		if err != nil {
+			if resp != nil {
+				result.ErrorDetails = resp.ErrorDetails
+			}
+			return &api.FetchEmbeddingsResponse{Body: result}, errors.Join(
+				err,
+				errors.New("error in fetch embeddings"),
+			)
+		}
+		if result.Dimensions == 0 {
+			result.Dimensions = resp.Dimensions
+		} else if result.Dimensions != resp.Dimensions {
+			return nil, errors.New("provider returned embeddings with mismatched dimensions")
+		}
+		result.Embeddings = append(result.Embeddings, resp.Embeddings...)
+		if resp.Usage != nil {
+			if result.Usage == nil {
+				result.Usage = &api.EmbeddingUsage{}
+			}
+			result.Usage.PromptTokens += resp.Usage.PromptTokens
+			result.Usage.TotalTokens += resp.Usage.TotalTokens
+		}
+	}
+
+	return &api.FetchEmbeddingsResponse{Body: result}, nil
+}
+
+// Embed returns embeddings for the inputs from a configured provider.
+// It allows stores to use the keys and endpoints already configured in the provider set.
+func (ps *ProviderSetAPI) Embed(
+	ctx context.Context,
+	provider spec.ProviderName,
+	modelName spec.ModelName,
+	inputs []string,
+) ([][]float32, error) {
+	resp, err := ps.FetchEmbeddings(ctx, &api.FetchEmbeddingsRequest{
+		Provider: provider,
+		Body: &api.FetchEmbeddingsRequestBody{
+			ModelName: modelName,
+			Inputs:    inputs,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body.Embeddings, nil
+}
+
+// FetchCompletionMulti sends the same prompt and history to multiple provider/model targets concurrently.
+// Each target streams independently and a failure in one target does not cancel the others.
+// Results can be stored as sibling assistant replies to the same user message.
+func (ps *ProviderSetAPI) FetchCompletionMulti(
+	ctx context.Context,
+	req *api.FetchCompletionMultiRequest,
+) (*api.FetchCompletionMultiResponse, error) {
+	if req == nil || req.Body == nil || req.Body.Prompt == "" || len(req.Body.Targets) == 0 {
+		return nil, errors.New("got empty prompt/targets input")
+	}
+
+	results := make([]api.FetchCompletionMultiResult, len(req.Body.Targets))
+	var wg sync.WaitGroup
+	for idx, target := range req.Body.Targets {
+		id := target.ID
+		if id == "" {
+			id = string(target.Provider) + "/" + string(target.ModelParams.Name)
+		}
+		results[idx] = api.FetchCompletionMultiResult{
+			ID:        id,
+			Provider:  target.Provider,
+			ModelName: target.ModelParams.Name,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := middleware.WithRecoveryResp(func() (*api.FetchCompletionResponse, error) {
+				return ps.FetchCompletion(ctx, &api.FetchCompletionRequest{
+					Body: &api.FetchCompletionRequestBody{
+						Provider:     target.Provider,
+						Prompt:       req.Body.Prompt,
+						ModelParams:  target.ModelParams,
+						PrevMessages: req.Body.PrevMessages,
+						OnStreamData: target.OnStreamData,
+					},
+				})
+			})
+			if err != nil {
+				errStr := err.Error()
+				results[idx].Error = &errStr
+				return
+			}
+			results[idx].Response = resp.Body
+			// The partial response is kept, the error marks the target as failed.
+			if resp.Body != nil && resp.Body.ErrorDetails != nil {
+				errStr := resp.Body.ErrorDetails.Message
+				results[idx].Error = &errStr
+			}
+		}()
+	}
+	wg.Wait()
+
+	return &api.FetchCompletionMultiResponse{
+		Body: &api.FetchCompletionMultiResponseBody{Results: results},
+	}, nil
+}
@@ -0,0 +1,347 @@
+package aiprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// stubProvider is a CompletionProvider whose completions are returned by fetch.
+type stubProvider struct {
+	api.CompletionProvider
+	fetch func(
+		ctx context.Context,
+		prompt string,
+		onStreamData func(data string) error,
+		onStreamReasoning func(data string) error,
+	) (*api.CompletionResponse, error)
+}
+
+func (p *stubProvider) GetLLMsModel(ctx context.Context) llms.Model {
+	return nil
+}
+
+func (p *stubProvider) FetchCompletion(
+	ctx context.Context,
+	llm llms.Model,
+	prompt string,
+	modelParams spec.ModelParams,
+	inbuiltModelParams *spec.ModelParams,
+	prevMessages []spec.ChatCompletionRequestMessage,
+	onStreamData func(data string) error,
+	onStreamReasoning func(data string) error,
+) (*api.CompletionResponse, error) {
+	return p.fetch(ctx, prompt, onStreamData, onStreamReasoning)
+}
+
+func newStubProviderSet(providers map[spec.ProviderName]api.CompletionProvider) *ProviderSetAPI {
+	return &ProviderSetAPI{
+		providers:    providers,
+		rateLimiters: newRateLimiters(),
+	}
+}
+
+func TestFetchCompletionMulti(t *testing.T) {
+	reply := func(text string) *stubProvider {
+		return &stubProvider{fetch: func(
+			ctx context.Context,
+			prompt string,
+			onStreamData, onStreamReasoning func(string) error,
+		) (*api.CompletionResponse, error) {
+			return &api.CompletionResponse{RespContent: &text}, nil
+		}}
+	}
+	ps := newStubProviderSet(map[spec.ProviderName]api.CompletionProvider{
+		// slow finishes last so that results cannot be in completion order.
+		"slow": &stubProvider{fetch: func(
+			ctx context.Context,
+			prompt string,
+			onStreamData, onStreamReasoning func(string) error,
+		) (*api.CompletionResponse, error) {
+			time.Sleep(50 * time.Millisecond)
+			text := "slow reply"
+			return &api.CompletionResponse{RespContent: &text}, nil
+		}},
+		"fast": reply("fast reply"),
+		"failing": &stubProvider{fetch: func(
+			ctx context.Context,
+			prompt string,
+			onStreamData, onStreamReasoning func(string) error,
+		) (*api.CompletionResponse, error) {
+			return nil, errors.New("connection refused")
+		}},
+		"error-details": &stubProvider{fetch: func(
+			ctx context.Context,
+			prompt string,
+			onStreamData, onStreamReasoning func(string) error,
+		) (*api.CompletionResponse, error) {
+			text := "partial"
+			return &api.CompletionResponse{
+				RespContent:  &text,
+				ErrorDetails: &api.APIErrorDetails{Message: "stream interrupted"},
+			}, nil
+		}},
+		"panicking": &stubProvider{fetch: func(
+			ctx context.Context,
+			prompt string,
+			onStreamData, onStreamReasoning func(string) error,
+		) (*api.CompletionResponse, error) {
+			panic("provider bug")
+		}},
+	})
+
+	targets := []api.FetchCompletionMultiTarget{
+		{Provider: "slow", ModelParams: spec.ModelParams{Name: "m1"}},
+		{ID: "named", Provider: "failing", ModelParams: spec.ModelParams{Name: "m1"}},
+		{Provider: "error-details", ModelParams: spec.ModelParams{Name: "m1"}},
+		{Provider: "panicking", ModelParams: spec.ModelParams{Name: "m1"}},
+		{Provider: "fast", ModelParams: spec.ModelParams{Name: "m2"}},
+		{Provider: "missing", ModelParams: spec.ModelParams{Name: "m1"}},
+	}
+	resp, err := ps.FetchCompletionMulti(t.Context(), &api.FetchCompletionMultiRequest{
+		Body: &api.FetchCompletionMultiRequestBody{Prompt: "hi", Targets: targets},
+	})
+	if err != nil {
+		t.Fatalf("FetchCompletionMulti: %v", err)
+	}
+
+	tests := []struct {
+		id           string
+		wantContent  string
+		wantErr      string
+		wantResponse bool
+	}{
+		{id: "slow/m1", wantContent: "slow reply", wantResponse: true},
+		{id: "named", wantErr: "connection refused"},
+		{
+			id:           "error-details/m1",
+			wantContent:  "partial",
+			wantErr:      "stream interrupted",
+			wantResponse: true,
+		},
+		{id: "panicking/m1", wantErr: "panic recovered: provider bug"},
+		{id: "fast/m2", wantContent: "fast reply", wantResponse: true},
+		{id: "missing/m1", wantErr: "invalid provider"},
+	}
+	results := resp.Body.Results
+	if len(results) != len(tests) {
+		t.Fatalf("got %d results, want %d", len(results), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got := results[i]
+			if got.ID != tt.id || got.Provider != targets[i].Provider {
+				t.Fatalf("result %d is %s of %s, want %s", i, got.ID, got.Provider, tt.id)
+			}
+			if tt.wantErr == "" {
+				if got.Error != nil {
+					t.Errorf("unexpected error %q", *got.Error)
+				}
+			} else if got.Error == nil || !strings.Contains(*got.Error, tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", got.Error, tt.wantErr)
+			}
+			if (got.Response != nil) != tt.wantResponse {
+				t.Fatalf("response = %+v, want response %v", got.Response, tt.wantResponse)
+			}
+			if tt.wantResponse && *got.Response.RespContent != tt.wantContent {
+				t.Errorf("content = %q, want %q", *got.Response.RespContent, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestFetchEmbeddingsBatches(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		mu.Lock()
+		batches = append(batches, req.Input)
+		batch := len(batches)
+		mu.Unlock()
+		if req.Model == "failing" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Every embedding is the input number repeated, the mismatched model grows it per batch.
+		dims := 2
+		if req.Model == "mismatched" {
+			dims = batch
+		}
+		var data []string
+		for i, in := range req.Input {
+			emb := strings.TrimSuffix(strings.Repeat(in[1:]+",", dims), ",")
+			data = append(data, fmt.Sprintf(`{"index":%d,"embedding":[%s]}`, i, emb))
+		}
+		_, _ = fmt.Fprintf(w, `{"data":[%s],"usage":{"prompt_tokens":%d,"total_tokens":%d}}`,
+			strings.Join(data, ","), len(req.Input), 2*len(req.Input))
+	}))
+	defer server.Close()
+
+	ps := newStubProviderSet(map[spec.ProviderName]api.CompletionProvider{
+		"local": api.NewOpenAICompatibleProvider(spec.ProviderInfo{
+			Origin:                   server.URL,
+			ChatCompletionPathPrefix: "/v1/chat/completions",
+		}, false),
+		"completions-only": &stubProvider{},
+	})
+	inputs := []string{"i1", "i2", "i3", "i4", "i5"}
+	fetch := func(
+		provider spec.ProviderName,
+		model spec.ModelName,
+	) (*api.FetchEmbeddingsResponse, error) {
+		batches = nil
+		return ps.FetchEmbeddings(t.Context(), &api.FetchEmbeddingsRequest{
+			Provider: provider,
+			Body: &api.FetchEmbeddingsRequestBody{
+				ModelName: model,
+				Inputs:    inputs,
+				BatchSize: 2,
+			},
+		})
+	}
+
+	t.Run("batches are merged", func(t *testing.T) {
+		resp, err := fetch("local", "embed")
+		if err != nil {
+			t.Fatalf("FetchEmbeddings: %v", err)
+		}
+		wantBatches := [][]string{{"i1", "i2"}, {"i3", "i4"}, {"i5"}}
+		if !slices.EqualFunc(batches, wantBatches, slices.Equal) {
+			t.Errorf("batches = %v, want %v", batches, wantBatches)
+		}
+		want := [][]float32{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}}
+		if !slices.EqualFunc(resp.Body.Embeddings, want, slices.Equal) {
+			t.Errorf("embeddings = %v, want %v", resp.Body.Embeddings, want)
+		}
+		if resp.Body.Dimensions != 2 {
+			t.Errorf("dimensions = %d, want 2", resp.Body.Dimensions)
+		}
+		if u := resp.Body.Usage; u == nil || u.PromptTokens != 5 || u.TotalTokens != 10 {
+			t.Errorf("usage = %+v, want 5 prompt and 10 total tokens", u)
+		}
+	})
+
+	t.Run("mismatched dimensions", func(t *testing.T) {
+		_, err := fetch("local", "mismatched")
+		if err == nil || !strings.Contains(err.Error(), "mismatched dimensions") {
+			t.Fatalf("err = %v, want a dimension mismatch", err)
+		}
+		if len(batches) != 2 {
+			t.Errorf("sent %d batches, want to stop after the mismatched second one", len(batches))
+		}
+	})
+
+	t.Run("non 2xx response", func(t *testing.T) {
+		resp, err := fetch("local", "failing")
+		if err == nil || !strings.Contains(err.Error(), "status 401") {
+			t.Fatalf("err = %v, want a 401 error", err)
+		}
+		if resp == nil || resp.Body == nil || resp.Body.ErrorDetails == nil ||
+			resp.Body.ErrorDetails.ResponseDetails == nil ||
+			resp.Body.ErrorDetails.ResponseDetails.Status != http.StatusUnauthorized {
+			t.Fatalf("error details not returned: %+v", resp)
+		}
+		if len(batches) != 1 {
+			t.Errorf("sent %d batches, want to stop after the failed first one", len(batches))
+		}
+	})
+
+	t.Run("provider without embeddings", func(t *testing.T) {
+		_, err := fetch("completions-only", "embed")
+		if err == nil || !strings.Contains(err.Error(), "does not support embeddings") {
+			t.Fatalf("err = %v, want an unsupported provider error", err)
+		}
+	})
+}
+
+func TestTestProviderConnectionFallback(t *testing.T) {
+	modelsStatus := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/models") {
+			w.WriteHeader(modelsStatus)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","model":"m1",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"p"},` +
+			`"finish_reason":"length"}]}`))
+	}))
+	defer server.Close()
+
+	local := api.NewOpenAICompatibleProvider(spec.ProviderInfo{
+		Name:                     "local",
+		APIKey:                   "secret",
+		Origin:                   server.URL,
+		ChatCompletionPathPrefix: "/v1/chat/completions",
+		APIKeyHeaderKey:          "Authorization",
+	}, false)
+	if err := local.InitLLM(t.Context()); err != nil {
+		t.Fatalf("InitLLM: %v", err)
+	}
+	ps := newStubProviderSet(map[spec.ProviderName]api.CompletionProvider{"local": local})
+	modelName := spec.ModelName("m1")
+
+	tests := []struct {
+		name         string
+		modelsStatus int
+		modelName    *spec.ModelName
+		wantMethod   api.ProbeMethod
+		wantStatus   int
+	}{
+		{
+			name:         "404 falls back to the completion probe",
+			modelsStatus: http.StatusNotFound,
+			modelName:    &modelName,
+			wantMethod:   api.ProbeMethodCompletion,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "404 without a model to probe",
+			modelsStatus: http.StatusNotFound,
+			wantMethod:   api.ProbeMethodListModels,
+			wantStatus:   http.StatusNotFound,
+		},
+		{
+			name:         "401 is reported as is",
+			modelsStatus: http.StatusUnauthorized,
+			modelName:    &modelName,
+			wantMethod:   api.ProbeMethodListModels,
+			wantStatus:   http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modelsStatus = tt.modelsStatus
+			resp, err := ps.TestProviderConnection(t.Context(), &api.TestProviderConnectionRequest{
+				Provider: "local",
+				Body:     &api.TestProviderConnectionRequestBody{ModelName: tt.modelName},
+			})
+			if err != nil {
+				t.Fatalf("TestProviderConnection: %v", err)
+			}
+			if resp.Body.Method != tt.wantMethod || resp.Body.HTTPStatus != tt.wantStatus {
+				t.Errorf("probed %s with status %d, want %s with status %d",
+					resp.Body.Method, resp.Body.HTTPStatus, tt.wantMethod, tt.wantStatus)
+			}
+		})
+	}
+}
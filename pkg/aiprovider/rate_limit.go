@@ -0,0 +1,140 @@
+package aiprovider
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/ratelimit"
+)
+
+// rateLimiters keeps one limiter per provider and model.
+// Provider limits apply to every model of the provider unless a model specific limit is set.
+type rateLimiters struct {
+	mu             sync.Mutex
+	providerLimits map[spec.ProviderName]spec.RateLimits
+	modelLimits    map[spec.ProviderName]map[spec.ModelName]spec.RateLimits
+	limiters       map[spec.ProviderName]map[spec.ModelName]*ratelimit.Limiter
+}
+
+func newRateLimiters() *rateLimiters {
+	return &rateLimiters{
+		providerLimits: map[spec.ProviderName]spec.RateLimits{},
+		modelLimits:    map[spec.ProviderName]map[spec.ModelName]spec.RateLimits{},
+		limiters:       map[spec.ProviderName]map[spec.ModelName]*ratelimit.Limiter{},
+	}
+}
+
+func validateRateLimits(rl *spec.RateLimits) error {
+	if rl == nil {
+		return nil
+	}
+	if rl.RequestsPerMinute < 0 || rl.TokensPerMinute < 0 || rl.MaxConcurrentRequests < 0 {
+		return errors.New("invalid rate limits: values cannot be negative")
+	}
+	return nil
+}
+
+func toLimits(rl spec.RateLimits) ratelimit.Limits {
+	return ratelimit.Limits{
+		RequestsPerMinute:     rl.RequestsPerMinute,
+		TokensPerMinute:       rl.TokensPerMinute,
+		MaxConcurrentRequests: rl.MaxConcurrentRequests,
+	}
+}
+
+// Must be called with the lock held.
+func (r *rateLimiters) effectiveLimits(
+	provider spec.ProviderName,
+	model spec.ModelName,
+) spec.RateLimits {
+	if ml, ok := r.modelLimits[provider][model]; ok {
+		return ml
+	}
+	return r.providerLimits[provider]
+}
+
+// set replaces all limits of a provider and updates live limiters in place.
+func (r *rateLimiters) set(
+	provider spec.ProviderName,
+	providerLimits *spec.RateLimits,
+	modelLimits map[spec.ModelName]spec.RateLimits,
+) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if providerLimits == nil || *providerLimits == (spec.RateLimits{}) {
+		delete(r.providerLimits, provider)
+	} else {
+		r.providerLimits[provider] = *providerLimits
+	}
+	if len(modelLimits) == 0 {
+		delete(r.modelLimits, provider)
+	} else {
+		r.modelLimits[provider] = modelLimits
+	}
+
+	for model, l := range r.limiters[provider] {
+		limits := toLimits(r.effectiveLimits(provider, model))
+		l.SetLimits(limits)
+		// Callers that hold the limiter finish with it, new ones are not limited.
+		if limits.IsZero() {
+			delete(r.limiters[provider], model)
+		}
+	}
+}
+
+// remove drops a provider and wakes up its waiters.
+func (r *rateLimiters) remove(provider spec.ProviderName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.limiters[provider] {
+		l.Close()
+	}
+	delete(r.limiters, provider)
+	delete(r.providerLimits, provider)
+	delete(r.modelLimits, provider)
+}
+
+// get returns the limiter for a provider model, nil if the model is not limited.
+func (r *rateLimiters) get(provider spec.ProviderName, model spec.ModelName) *ratelimit.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.limiters[provider][model]; ok {
+		return l
+	}
+	limits := toLimits(r.effectiveLimits(provider, model))
+	if limits.IsZero() {
+		return nil
+	}
+	if r.limiters[provider] == nil {
+		r.limiters[provider] = map[spec.ModelName]*ratelimit.Limiter{}
+	}
+	l := ratelimit.New(limits)
+	r.limiters[provider][model] = l
+	return l
+}
+
+func (r *rateLimiters) status() []api.RateLimitStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := []api.RateLimitStatus{}
+	for provider, models := range r.limiters {
+		for model, l := range models {
+			stats := l.Stats()
+			statuses = append(statuses, api.RateLimitStatus{
+				Provider:     provider,
+				ModelName:    model,
+				Limits:       r.effectiveLimits(provider, model),
+				QueueDepth:   stats.QueueDepth,
+				InFlight:     stats.InFlight,
+				LastWaitMs:   stats.LastWait.Milliseconds(),
+				OldestWaitMs: stats.OldestWait.Milliseconds(),
+			})
+		}
+	}
+	return statuses
+}
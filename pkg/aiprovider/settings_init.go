@@ -0,0 +1,209 @@
+package aiprovider
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/catalog"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/consts"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	settingSpec "github.com/ppipada/flexigpt-app/pkg/settingstore/spec"
+)
+
+// InitProviderSetUsingSettings configures a provider set from stored settings.
+// Inbuilt providers get their key and attributes updated, other providers are added as custom providers.
+// Network config and rate limits are applied after that, then the outbound scan config,
+// and the default provider is set last.
+func InitProviderSetUsingSettings(
+	ctx context.Context,
+	ps *ProviderSetAPI,
+	settings *settingSpec.SettingsSchema,
+) error {
+	if ps == nil || settings == nil {
+		return errors.New("got empty provider set or settings")
+	}
+
+	for providerName, aiSetting := range settings.AISettings {
+		if _, exists := consts.InbuiltProviders[providerName]; exists {
+			// Update inbuilt providers.
+			if aiSetting.APIKey != "" {
+				_, err := ps.SetProviderAPIKey(ctx, &api.SetProviderAPIKeyRequest{
+					Provider: providerName,
+					Body: &api.SetProviderAPIKeyRequestBody{
+						APIKey: aiSetting.APIKey,
+					},
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err := ps.SetProviderAttribute(ctx, &api.SetProviderAttributeRequest{
+				Provider: providerName,
+				Body: &api.SetProviderAttributeRequestBody{
+					Origin:                   &aiSetting.Origin,
+					ChatCompletionPathPrefix: &aiSetting.ChatCompletionPathPrefix,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			// Add custom providers.
+			_, err := ps.AddProvider(ctx, &api.AddProviderRequest{
+				Provider: providerName,
+				Body: &api.AddProviderRequestBody{
+					APIKey:                   aiSetting.APIKey,
+					Origin:                   aiSetting.Origin,
+					ChatCompletionPathPrefix: aiSetting.ChatCompletionPathPrefix,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		networkConfig := aiSetting.Network
+		if networkConfig.IsZero() {
+			networkConfig = settings.App.Network
+		}
+		if !networkConfig.IsZero() {
+			_, err := ps.SetProviderNetworkConfig(ctx, &api.SetProviderNetworkConfigRequest{
+				Provider: providerName,
+				Body: &api.SetProviderNetworkConfigRequestBody{
+					NetworkConfig: networkConfig,
+				},
+			})
+			// The config is applied even if the proxy is down or the provider cannot use it and
+			// stays uninitialized, do not block startup on it.
+			if errors.Is(err, api.ErrProxyUnreachable) ||
+				errors.Is(err, api.ErrNetworkConfigUnsupported) {
+				slog.Warn("InitProviderSetUsingSettings", "Provider", providerName, "Error", err)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		modelRateLimits := map[spec.ModelName]spec.RateLimits{}
+		for modelName, modelSetting := range aiSetting.ModelSettings {
+			if modelSetting.RateLimits != nil {
+				modelRateLimits[modelName] = *modelSetting.RateLimits
+			}
+		}
+		if aiSetting.RateLimits != nil || len(modelRateLimits) != 0 {
+			_, err := ps.SetProviderRateLimits(ctx, &api.SetProviderRateLimitsRequest{
+				Provider: providerName,
+				Body: &api.SetProviderRateLimitsRequestBody{
+					RateLimits:      aiSetting.RateLimits,
+					ModelRateLimits: modelRateLimits,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if settings.App.OutboundScan != nil {
+		_, err := ps.SetOutboundScanConfig(ctx, &api.SetOutboundScanConfigRequest{
+			Body: &api.SetOutboundScanConfigRequestBody{Config: *settings.App.OutboundScan},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := ps.SetDefaultProvider(ctx, &api.SetDefaultProviderRequest{
+		Body: &api.SetDefaultProviderRequestBody{
+			Provider: settings.App.DefaultProvider,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("InitProviderSetUsingSettings Done.")
+	return nil
+}
+
+// GetModelParamsUsingSettings builds the model params for a provider model.
+// Inbuilt params of the model are used as the base and the model setting, if any, overrides them.
+func GetModelParamsUsingSettings(
+	provider spec.ProviderName,
+	modelName spec.ModelName,
+	aiSetting *settingSpec.AISetting,
+) spec.ModelParams {
+	params := spec.ModelParams{Name: modelName, Stream: true}
+	if inbuilt, ok := catalog.Default().ModelParams(provider, modelName); ok {
+		params = inbuilt
+	}
+	if aiSetting == nil {
+		return params
+	}
+	ms, ok := aiSetting.ModelSettings[modelName]
+	if !ok {
+		return params
+	}
+	if ms.Stream != nil {
+		params.Stream = *ms.Stream
+	}
+	if ms.MaxPromptLength != nil {
+		params.MaxPromptLength = *ms.MaxPromptLength
+	}
+	if ms.MaxOutputLength != nil {
+		params.MaxOutputLength = *ms.MaxOutputLength
+	}
+	if ms.Temperature != nil {
+		params.Temperature = ms.Temperature
+	}
+	if ms.Reasoning != nil {
+		params.Reasoning = ms.Reasoning
+	}
+	if ms.SystemPrompt != nil {
+		params.SystemPrompt = *ms.SystemPrompt
+	}
+	if ms.Timeout != nil {
+		params.Timeout = *ms.Timeout
+	}
+	if ms.FirstTokenTimeout != nil {
+		params.FirstTokenTimeout = *ms.FirstTokenTimeout
+	}
+	if ms.IdleTimeout != nil {
+		params.IdleTimeout = *ms.IdleTimeout
+	}
+	if ms.PromptCaching != nil {
+		params.PromptCaching = ms.PromptCaching
+	}
+	if ms.AdditionalParameters != nil {
+		params.AdditionalParameters = *ms.AdditionalParameters
+	}
+	return params
+}
+
+// GetConversationTitleModelUsingSettings returns the model that titles conversations.
+// ok is false if titles are not enabled or the provider has no model to use.
+func GetConversationTitleModelUsingSettings(
+	settings *settingSpec.SettingsSchema,
+) (provider spec.ProviderName, params spec.ModelParams, ok bool) {
+	titles := settings.App.ConversationTitles
+	if titles == nil || !titles.IsEnabled {
+		return "", spec.ModelParams{}, false
+	}
+	provider = titles.Provider
+	if provider == "" {
+		provider = settings.App.DefaultProvider
+	}
+	aiSetting, exists := settings.AISettings[provider]
+	if !exists {
+		return "", spec.ModelParams{}, false
+	}
+	modelName := titles.Model
+	if modelName == "" {
+		modelName = aiSetting.DefaultModel
+	}
+	if modelName == "" {
+		return "", spec.ModelParams{}, false
+	}
+	return provider, GetModelParamsUsingSettings(provider, modelName, &aiSetting), true
+}
@@ -21,11 +21,30 @@ type ChatCompletionRequestMessageFunctionCall struct {
 	Arguments *string `json:"arguments,omitempty"`
 }
 
+type ReasoningContentType string
+
+const (
+	ReasoningContentTypeThinking         ReasoningContentType = "thinking"
+	ReasoningContentTypeRedactedThinking ReasoningContentType = "redactedThinking"
+)
+
+// ReasoningContent is a block of model reasoning, returned and stored apart from the message content.
+type ReasoningContent struct {
+	Type ReasoningContentType `json:"type"`
+	Text string               `json:"text,omitempty"`
+	// Signature verifies an Anthropic thinking block when it is sent back to the model.
+	Signature string `json:"signature,omitempty"`
+	// Data is the encrypted payload of a redacted thinking block.
+	Data string `json:"data,omitempty"`
+}
+
 type ChatCompletionRequestMessage struct {
 	Role         ChatCompletionRoleEnum                    `json:"role"`
 	Content      *string                                   `json:"content,omitempty"`
 	Name         *string                                   `json:"name,omitempty"`
 	FunctionCall *ChatCompletionRequestMessageFunctionCall `json:"functionCall,omitempty"`
+	// ReasoningContents of an earlier assistant turn, replayed only to providers that accept them.
+	ReasoningContents []ReasoningContent `json:"reasoningContents,omitempty"`
 }
 
 type ChatCompletionResponseMessage struct {
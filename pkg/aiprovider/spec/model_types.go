@@ -25,22 +25,79 @@ type ReasoningParams struct {
 	Tokens int            `json:"tokens"`
 }
 
+// PromptCachingParams places cache breakpoints in requests to Anthropic compatible providers.
+// Anthropic allows up to 4 breakpoints per request, the system prompt is marked first,
+// then explicit breakpoints and then the last turns. Other providers ignore it.
+type PromptCachingParams struct {
+	SystemPrompt bool `json:"systemPrompt,omitempty"`
+	// LastTurns marks the last N user messages, so that the next turn reads the cached history.
+	LastTurns int `json:"lastTurns,omitempty"    minimum:"0"`
+	// Breakpoints are indexes into the request messages, the system prompt is not counted.
+	Breakpoints []int `json:"breakpoints,omitempty"`
+}
+
 type ModelDefaults struct {
 	DisplayName string `json:"displayName"`
 	IsEnabled   bool   `json:"isEnabled"`
 }
 
+// ModelSource tells where the definition of a model in the catalog came from.
+type ModelSource string
+
+const (
+	ModelSourceInbuilt                ModelSource = "inbuilt"
+	ModelSourceUserOverlay            ModelSource = "userOverlay"
+	ModelSourceInbuiltWithUserOverlay ModelSource = "inbuiltWithUserOverlay"
+)
+
+type SystemPromptSupport string
+
+const (
+	SystemPromptRoleSystem    SystemPromptSupport = "system"
+	SystemPromptRoleDeveloper SystemPromptSupport = "developer"
+	SystemPromptUnsupported   SystemPromptSupport = "unsupported"
+)
+
+// ModelCapabilities is what a model supports, requests are validated and adapted against it.
+type ModelCapabilities struct {
+	Vision       bool                `json:"vision"`
+	ToolCalling  bool                `json:"toolCalling"`
+	JSONMode     bool                `json:"jsonMode"`
+	SystemPrompt SystemPromptSupport `json:"systemPrompt"`
+	Temperature  bool                `json:"temperature"`
+	Streaming    bool                `json:"streaming"`
+	// ReasoningType is empty for models without reasoning.
+	ReasoningType   ReasoningType `json:"reasoningType,omitempty"`
+	ContextWindow   int           `json:"contextWindow"`
+	MaxOutputTokens int           `json:"maxOutputTokens"`
+}
+
+// ModelPricing is the price of a model in USD per million tokens.
+// Cache prices of zero mean cached tokens cost the same as other input tokens.
+type ModelPricing struct {
+	InputPerMillion      float64 `json:"inputPerMillion"`
+	OutputPerMillion     float64 `json:"outputPerMillion"`
+	CacheReadPerMillion  float64 `json:"cacheReadPerMillion,omitempty"`
+	CacheWritePerMillion float64 `json:"cacheWritePerMillion,omitempty"`
+}
+
 // ModelParams represents input information about a model to a completion.
 type ModelParams struct {
-	Name                 ModelName        `json:"name"`
-	Stream               bool             `json:"stream"`
-	MaxPromptLength      int              `json:"maxPromptLength"`
-	MaxOutputLength      int              `json:"maxOutputLength"`
-	Temperature          *float64         `json:"temperature,omitempty"`
-	Reasoning            *ReasoningParams `json:"reasoning"`
-	SystemPrompt         string           `json:"systemPrompt"`
-	Timeout              int              `json:"timeout"`
-	AdditionalParameters map[string]any   `json:"additionalParameters"`
+	Name            ModelName        `json:"name"`
+	Stream          bool             `json:"stream"`
+	MaxPromptLength int              `json:"maxPromptLength"`
+	MaxOutputLength int              `json:"maxOutputLength"`
+	Temperature     *float64         `json:"temperature,omitempty"`
+	Reasoning       *ReasoningParams `json:"reasoning"`
+	SystemPrompt    string           `json:"systemPrompt"`
+	// Timeout is the overall limit of a completion in seconds.
+	Timeout int `json:"timeout"`
+	// FirstTokenTimeout limits the wait for the first streamed chunk in seconds.
+	FirstTokenTimeout int `json:"firstTokenTimeout,omitempty"`
+	// IdleTimeout limits the gap between two streamed chunks in seconds.
+	IdleTimeout          int                  `json:"idleTimeout,omitempty"`
+	PromptCaching        *PromptCachingParams `json:"promptCaching,omitempty"`
+	AdditionalParameters map[string]any       `json:"additionalParameters"`
 }
 
 // Entire “model + default knobs” bundle the user can pick.
@@ -74,6 +131,8 @@ type ModelPreset struct {
 	Stream  bool `json:"stream"`
 	Timeout int  `json:"timeout"`
 
+	PromptCaching *PromptCachingParams `json:"promptCaching,omitempty"`
+
 	// Anything provider-specific or experimental lands here.
 	AdditionalParameters map[string]any `json:"additionalParameters,omitempty"`
 
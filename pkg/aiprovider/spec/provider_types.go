@@ -21,3 +21,54 @@ type ProviderInfo struct {
 	DefaultHeaders           map[string]string `json:"defaultHeaders"`
 	Type                     ProviderType      `json:"type"`
 }
+
+// RateLimits are client side limits applied before a request is sent to a provider model.
+// A zero value for any field means that dimension is unlimited.
+type RateLimits struct {
+	RequestsPerMinute     int `json:"requestsPerMinute,omitempty"`
+	TokensPerMinute       int `json:"tokensPerMinute,omitempty"`
+	MaxConcurrentRequests int `json:"maxConcurrentRequests,omitempty"`
+}
+
+type OutboundScanAction string
+
+const (
+	OutboundScanActionBlock OutboundScanAction = "block"
+	OutboundScanActionMask  OutboundScanAction = "mask"
+	OutboundScanActionWarn  OutboundScanAction = "warn"
+)
+
+// OutboundScanRule is a user defined regular expression checked against outgoing messages.
+type OutboundScanRule struct {
+	Name        string             `json:"name"                  required:"true"`
+	Pattern     string             `json:"pattern"               required:"true"`
+	Action      OutboundScanAction `json:"action"                required:"true" enum:"block,mask,warn"`
+	Description string             `json:"description,omitempty"`
+}
+
+// OutboundScanConfig configures the secret and personal data scan of prompts before they are sent.
+type OutboundScanConfig struct {
+	Enabled             bool `json:"enabled"`
+	DisableBuiltinRules bool `json:"disableBuiltinRules,omitempty"`
+	// ActionOverrides changes the action of built-in rules, keyed by rule name.
+	ActionOverrides map[string]OutboundScanAction `json:"actionOverrides,omitempty"`
+	Rules           []OutboundScanRule            `json:"rules,omitempty"`
+}
+
+// NetworkConfig configures the HTTP transport used to reach a provider.
+// All PEM values are the full PEM text, not file paths.
+type NetworkConfig struct {
+	// ProxyURL supports http, https, socks5 and socks5h schemes.
+	ProxyURL string `json:"proxyURL,omitempty"`
+	// CACertPEM is appended to the system roots, e.g. for a TLS inspecting proxy.
+	CACertPEM string `json:"caCertPEM,omitempty"`
+	// ClientCertPEM and ClientKeyPEM enable mutual TLS, both must be set together.
+	ClientCertPEM string `json:"clientCertPEM,omitempty"`
+	ClientKeyPEM  string `json:"clientKeyPEM,omitempty"`
+}
+
+// IsZero reports whether no network customization is set.
+func (n *NetworkConfig) IsZero() bool {
+	return n == nil ||
+		(n.ProxyURL == "" && n.CACertPEM == "" && n.ClientCertPEM == "" && n.ClientKeyPEM == "")
+}
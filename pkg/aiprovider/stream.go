@@ -0,0 +1,65 @@
+package aiprovider
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/danielgtaylor/huma/v2/sse"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+)
+
+// CompletionStreamEventTypes maps SSE event names to the values sent by StreamCompletion.
+var CompletionStreamEventTypes = map[string]any{
+	"data":       api.CompletionStreamData{},
+	"reasoning":  api.CompletionStreamReasoning{},
+	"completion": api.CompletionResponse{},
+	"error":      api.CompletionStreamError{},
+}
+
+// StreamCompletion runs a completion and streams content and reasoning chunks as SSE events.
+// The last event is either the full completion response or an error.
+// The upstream request is canceled when the client disconnects or an event cannot be sent.
+func (ps *ProviderSetAPI) StreamCompletion(
+	ctx context.Context,
+	req *api.FetchCompletionRequest,
+	send sse.Sender,
+) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Content and reasoning are flushed from different goroutines.
+	var mu sync.Mutex
+	sendEvent := func(v any) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := send.Data(v); err != nil {
+			cancel()
+			return err
+		}
+		return nil
+	}
+
+	if req != nil && req.Body != nil {
+		req.Body.OnStreamData = func(data string) error {
+			return sendEvent(api.CompletionStreamData{Data: data})
+		}
+		req.Body.OnStreamReasoning = func(data string) error {
+			return sendEvent(api.CompletionStreamReasoning{Data: data})
+		}
+	}
+
+	resp, err := ps.FetchCompletion(ctx, req)
+	if ctx.Err() != nil {
+		// Client is gone, nothing more can be sent.
+		return
+	}
+	if err != nil {
+		err = sendEvent(api.CompletionStreamError{Message: err.Error()})
+	} else {
+		err = sendEvent(resp.Body)
+	}
+	if err != nil {
+		slog.Debug("Could not send final completion event", "error", err)
+	}
+}
@@ -0,0 +1,143 @@
+package aiprovider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/sse"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/api"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+func TestStreamCompletion(t *testing.T) {
+	content := "ab"
+	errSend := errors.New("client gone")
+	tests := []struct {
+		name string
+		// fetch streams its chunks and returns the completion of the stub provider.
+		fetch func(
+			ctx context.Context,
+			onStreamData, onStreamReasoning func(string) error,
+		) (*api.CompletionResponse, error)
+		// failSendAt is the 1 based number of the event that cannot be sent, 0 if all can.
+		failSendAt     int
+		wantEvents     []any
+		wantCanceled   bool
+		wantNoProvider bool
+	}{
+		{
+			name: "data, reasoning and completion",
+			fetch: func(
+				ctx context.Context,
+				onStreamData, onStreamReasoning func(string) error,
+			) (*api.CompletionResponse, error) {
+				for _, send := range []func() error{
+					func() error { return onStreamReasoning("think") },
+					func() error { return onStreamData("a") },
+					func() error { return onStreamData("b") },
+				} {
+					if err := send(); err != nil {
+						return nil, err
+					}
+				}
+				return &api.CompletionResponse{RespContent: &content}, nil
+			},
+			wantEvents: []any{
+				api.CompletionStreamReasoning{Data: "think"},
+				api.CompletionStreamData{Data: "a"},
+				api.CompletionStreamData{Data: "b"},
+				&api.CompletionResponse{RespContent: &content},
+			},
+		},
+		{
+			name: "provider error",
+			fetch: func(
+				ctx context.Context,
+				onStreamData, onStreamReasoning func(string) error,
+			) (*api.CompletionResponse, error) {
+				if err := onStreamData("a"); err != nil {
+					return nil, err
+				}
+				return nil, errors.New("upstream failed")
+			},
+			wantEvents: []any{
+				api.CompletionStreamData{Data: "a"},
+				api.CompletionStreamError{
+					Message: "upstream failed\nerror in fetch completion",
+				},
+			},
+		},
+		{
+			name: "send failure cancels the upstream request",
+			fetch: func(
+				ctx context.Context,
+				onStreamData, onStreamReasoning func(string) error,
+			) (*api.CompletionResponse, error) {
+				for _, chunk := range []string{"a", "b", "c"} {
+					if err := onStreamData(chunk); err != nil {
+						if ctx.Err() == nil {
+							return nil, errors.New("not canceled after the send failure")
+						}
+						return nil, err
+					}
+				}
+				return &api.CompletionResponse{RespContent: &content}, nil
+			},
+			failSendAt:   2,
+			wantEvents:   []any{api.CompletionStreamData{Data: "a"}},
+			wantCanceled: true,
+		},
+		{
+			name:           "invalid provider",
+			wantNoProvider: true,
+			wantEvents: []any{
+				api.CompletionStreamError{Message: "invalid provider"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var canceled bool
+			ps := newStubProviderSet(map[spec.ProviderName]api.CompletionProvider{
+				"stub": &stubProvider{fetch: func(
+					ctx context.Context,
+					prompt string,
+					onStreamData, onStreamReasoning func(string) error,
+				) (*api.CompletionResponse, error) {
+					resp, err := tt.fetch(ctx, onStreamData, onStreamReasoning)
+					canceled = ctx.Err() != nil
+					return resp, err
+				}},
+			})
+
+			var events []any
+			send := sse.Sender(func(msg sse.Message) error {
+				if len(events)+1 == tt.failSendAt {
+					return errSend
+				}
+				events = append(events, msg.Data)
+				return nil
+			})
+			provider := spec.ProviderName("stub")
+			if tt.wantNoProvider {
+				provider = "missing"
+			}
+			ps.StreamCompletion(t.Context(), &api.FetchCompletionRequest{
+				Body: &api.FetchCompletionRequestBody{
+					Provider:    provider,
+					Prompt:      "hi",
+					ModelParams: spec.ModelParams{Name: "m1", Stream: true},
+				},
+			}, send)
+
+			if !reflect.DeepEqual(events, tt.wantEvents) {
+				t.Errorf("events = %#v, want %#v", events, tt.wantEvents)
+			}
+			if canceled != tt.wantCanceled {
+				t.Errorf("upstream canceled = %v, want %v", canceled, tt.wantCanceled)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package batchjob
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ppipada/flexigpt-app/pkg/batchjob/spec"
+)
+
+// getFormat returns format, or the format matching the extension of path if it is empty.
+func getFormat(path string, format spec.DataFormat) (spec.DataFormat, error) {
+	if format != "" {
+		if format != spec.DataFormatJSONL && format != spec.DataFormatCSV {
+			return "", fmt.Errorf("unknown format %q", format)
+		}
+		return format, nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return spec.DataFormatJSONL, nil
+	case ".csv":
+		return spec.DataFormatCSV, nil
+	}
+	return "", fmt.Errorf("cannot infer format of %s, set it explicitly", path)
+}
+
+// readDataset reads all rows of a dataset file.
+// JSONL rows must be objects, CSV files must have a header row.
+func readDataset(path string, format spec.DataFormat) ([]map[string]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	if format == spec.DataFormatCSV {
+		return readCSVDataset(f)
+	}
+	return readJSONLDataset(f)
+}
+
+func readJSONLDataset(r io.Reader) ([]map[string]any, error) {
+	dec := json.NewDecoder(r)
+	// Numbers are kept as written, so that they render the same in prompts.
+	dec.UseNumber()
+	rows := []map[string]any{}
+	for {
+		var row map[string]any
+		err := dec.Decode(&row)
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid dataset row %d: %w", len(rows), err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readCSVDataset(r io.Reader) ([]map[string]any, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return []map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid dataset header: %w", err)
+	}
+	rows := []map[string]any{}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid dataset row %d: %w", len(rows), err)
+		}
+		row := make(map[string]any, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,68 @@
+package batchjob
+
+import (
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+const (
+	tag        = "BatchJobs"
+	pathPrefix = "/batchjobs"
+)
+
+func InitBatchJobHandlers(api huma.API, manager *Manager) {
+	huma.Register(api, huma.Operation{
+		OperationID: "create-batch-job",
+		Method:      http.MethodPost,
+		Path:        pathPrefix,
+		Summary:     "Create a batch job",
+		Description: "Create a job that runs a prompt over every row of a dataset file",
+		Tags:        []string{tag},
+	}, manager.CreateJob)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-batch-jobs",
+		Method:      http.MethodGet,
+		Path:        pathPrefix,
+		Summary:     "List batch jobs",
+		Description: "List batch jobs with their status and progress",
+		Tags:        []string{tag},
+	}, manager.ListJobs)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-batch-job",
+		Method:      http.MethodGet,
+		Path:        pathPrefix + "/{id}",
+		Summary:     "Get a batch job",
+		Description: "Get a batch job with its status and progress",
+		Tags:        []string{tag},
+	}, manager.GetJob)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "resume-batch-job",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/{id}/resume",
+		Summary:     "Start or resume a batch job",
+		Description: "Start or resume a batch job, rows already in the output file are skipped",
+		Tags:        []string{tag},
+	}, manager.ResumeJob)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "cancel-batch-job",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/{id}/cancel",
+		Summary:     "Cancel a batch job",
+		Description: "Stop a running batch job, it can be resumed later",
+		Tags:        []string{tag},
+	}, manager.CancelJob)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-batch-job",
+		Method:      http.MethodDelete,
+		Path:        pathPrefix + "/{id}",
+		Summary:     "Delete a batch job",
+		Description: "Delete a batch job record, the output file is kept",
+		Tags:        []string{tag},
+	}, manager.DeleteJob)
+}
@@ -0,0 +1,548 @@
+// Package batchjob runs a prompt over every row of a JSONL or CSV dataset and writes
+// the results to a file. Jobs can be cancelled and resumed, the output file is the checkpoint.
+package batchjob
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ppipada/flexigpt-app/pkg/batchjob/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/encdec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filestore"
+)
+
+const (
+	defaultConcurrency = 4
+	defaultMaxAttempts = 1
+)
+
+var (
+	ErrJobNotFound = errors.New("batch job not found")
+	ErrJobRunning  = errors.New("batch job is running")
+)
+
+type jobEntry struct {
+	job   spec.Job
+	store *filestore.MapFileStore
+	// cancel and done are set while the job runs.
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager keeps batch job records in a directory, one JSON file per job, and runs the jobs.
+type Manager struct {
+	dir       string
+	completer promptrun.Completer
+
+	mu   sync.Mutex
+	jobs map[string]*jobEntry
+}
+
+// NewManager loads the jobs in dir. Jobs that were running when the process stopped are
+// marked as paused, so that they can be resumed.
+func NewManager(dir string, completer promptrun.Completer) (*Manager, error) {
+	if dir == "" || completer == nil {
+		return nil, errors.New("got empty batch job dir or completer")
+	}
+	if err := os.MkdirAll(dir, os.FileMode(0o770)); err != nil {
+		return nil, fmt.Errorf("failed to create batch job dir: %w", err)
+	}
+	m := &Manager{dir: dir, completer: completer, jobs: map[string]*jobEntry{}}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		entry, err := loadJobEntry(file)
+		if err != nil {
+			slog.Warn("Skipping invalid batch job", "file", file, "error", err)
+			continue
+		}
+		if entry.job.Status == spec.JobStatusRunning {
+			entry.job.Status = spec.JobStatusPaused
+			entry.job.Error = "interrupted"
+			if err := m.saveLocked(entry); err != nil {
+				return nil, err
+			}
+		}
+		m.jobs[entry.job.ID] = entry
+	}
+	return m, nil
+}
+
+func loadJobEntry(file string) (*jobEntry, error) {
+	store, err := filestore.NewMapFileStore(
+		file,
+		map[string]any{},
+		filestore.WithAutoFlush(true),
+		filestore.WithEncoderDecoder(encdec.JSONEncoderDecoder{}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	data, err := store.GetAll(false)
+	if err != nil {
+		return nil, err
+	}
+	entry := &jobEntry{store: store}
+	if err := encdec.MapToStructWithJSONTags(data, &entry.job); err != nil {
+		return nil, err
+	}
+	if entry.job.ID == "" {
+		return nil, errors.New("job has no id")
+	}
+	return entry, nil
+}
+
+func (m *Manager) saveLocked(entry *jobEntry) error {
+	entry.job.ModifiedAt = time.Now().UTC()
+	data, err := encdec.StructWithJSONTagsToMap(entry.job)
+	if err != nil {
+		return err
+	}
+	return entry.store.SetAll(data)
+}
+
+// CreateJob validates and saves a job, and starts it if asked to.
+func (m *Manager) CreateJob(
+	ctx context.Context,
+	req *spec.CreateJobRequest,
+) (*spec.CreateJobResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("got empty batch job input")
+	}
+	cfg, total, err := normalizeConfig(req.Body.Config)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	entry := &jobEntry{job: spec.Job{
+		ID:        id.String(),
+		Config:    cfg,
+		Status:    spec.JobStatusQueued,
+		Progress:  spec.JobProgress{Total: total},
+		CreatedAt: now,
+	}}
+	entry.store, err = filestore.NewMapFileStore(
+		filepath.Join(m.dir, entry.job.ID+".json"),
+		map[string]any{},
+		filestore.WithCreateIfNotExists(true),
+		filestore.WithAutoFlush(true),
+		filestore.WithEncoderDecoder(encdec.JSONEncoderDecoder{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch job file: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.saveLocked(entry); err != nil {
+		return nil, err
+	}
+	m.jobs[entry.job.ID] = entry
+	if req.Body.Start {
+		if _, err := m.startLocked(context.Background(), entry); err != nil {
+			return nil, err
+		}
+	}
+	job := entry.job
+	return &spec.CreateJobResponse{Body: &job}, nil
+}
+
+// normalizeConfig fills in defaults and checks that the dataset and prompt can be used.
+// It returns the number of dataset rows.
+func normalizeConfig(cfg spec.JobConfig) (spec.JobConfig, int, error) {
+	if cfg.DatasetPath == "" || cfg.OutputPath == "" {
+		return cfg, 0, errors.New("dataset and output paths are required")
+	}
+	datasetPath, err := filepath.Abs(cfg.DatasetPath)
+	if err != nil {
+		return cfg, 0, err
+	}
+	outputPath, err := filepath.Abs(cfg.OutputPath)
+	if err != nil {
+		return cfg, 0, err
+	}
+	if datasetPath == outputPath {
+		return cfg, 0, errors.New("output path must differ from the dataset path")
+	}
+	cfg.DatasetPath, cfg.OutputPath = datasetPath, outputPath
+
+	if cfg.DatasetFormat, err = getFormat(cfg.DatasetPath, cfg.DatasetFormat); err != nil {
+		return cfg, 0, fmt.Errorf("dataset: %w", err)
+	}
+	if cfg.OutputFormat, err = getFormat(cfg.OutputPath, cfg.OutputFormat); err != nil {
+		return cfg, 0, fmt.Errorf("output: %w", err)
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if _, err := promptrun.NewRenderer(getTarget(cfg)); err != nil {
+		return cfg, 0, err
+	}
+	rows, err := readDataset(cfg.DatasetPath, cfg.DatasetFormat)
+	if err != nil {
+		return cfg, 0, err
+	}
+	return cfg, len(rows), nil
+}
+
+func getTarget(cfg spec.JobConfig) promptrun.Target {
+	return promptrun.Target{
+		Prompt:      cfg.Prompt,
+		Template:    cfg.Template,
+		Provider:    cfg.Provider,
+		ModelParams: cfg.ModelParams,
+		Preset:      cfg.Preset,
+	}
+}
+
+// GetJob returns a job with its current progress.
+func (m *Manager) GetJob(
+	ctx context.Context,
+	req *spec.GetJobRequest,
+) (*spec.GetJobResponse, error) {
+	if req == nil {
+		return nil, errors.New("got empty batch job id")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.jobs[req.ID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	job := entry.job
+	return &spec.GetJobResponse{Body: &job}, nil
+}
+
+// ListJobs returns all jobs, newest first.
+func (m *Manager) ListJobs(
+	ctx context.Context,
+	req *spec.ListJobsRequest,
+) (*spec.ListJobsResponse, error) {
+	m.mu.Lock()
+	jobs := make([]spec.Job, 0, len(m.jobs))
+	for _, entry := range m.jobs {
+		jobs = append(jobs, entry.job)
+	}
+	m.mu.Unlock()
+	slices.SortFunc(jobs, func(a, b spec.Job) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), strings.Compare(b.ID, a.ID))
+	})
+	return &spec.ListJobsResponse{Body: &spec.ListJobsResponseBody{Jobs: jobs}}, nil
+}
+
+// ResumeJob starts a queued, paused or failed job in the background.
+// Rows already in the output file are skipped.
+func (m *Manager) ResumeJob(
+	ctx context.Context,
+	req *spec.ResumeJobRequest,
+) (*spec.ResumeJobResponse, error) {
+	if req == nil {
+		return nil, errors.New("got empty batch job id")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.jobs[req.ID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	// The job outlives the request, so it does not use the request context.
+	if _, err := m.startLocked(context.Background(), entry); err != nil {
+		return nil, err
+	}
+	job := entry.job
+	return &spec.ResumeJobResponse{Body: &job}, nil
+}
+
+// RunJob runs a job and waits for it to stop. Cancelling ctx pauses the job.
+func (m *Manager) RunJob(ctx context.Context, id string) (*spec.Job, error) {
+	m.mu.Lock()
+	entry, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrJobNotFound
+	}
+	done, err := m.startLocked(ctx, entry)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	<-done
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job := entry.job
+	return &job, nil
+}
+
+// CancelJob stops a running job and waits for its in flight rows to end. The job is paused.
+func (m *Manager) CancelJob(
+	ctx context.Context,
+	req *spec.CancelJobRequest,
+) (*spec.CancelJobResponse, error) {
+	if req == nil {
+		return nil, errors.New("got empty batch job id")
+	}
+	m.mu.Lock()
+	entry, ok := m.jobs[req.ID]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrJobNotFound
+	}
+	cancel, done := entry.cancel, entry.done
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job := entry.job
+	return &spec.CancelJobResponse{Body: &job}, nil
+}
+
+// DeleteJob removes the record of a job that is not running. The output file is kept.
+func (m *Manager) DeleteJob(
+	ctx context.Context,
+	req *spec.DeleteJobRequest,
+) (*spec.DeleteJobResponse, error) {
+	if req == nil {
+		return nil, errors.New("got empty batch job id")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.jobs[req.ID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	if entry.cancel != nil {
+		return nil, ErrJobRunning
+	}
+	if err := os.Remove(filepath.Join(m.dir, req.ID+".json")); err != nil &&
+		!errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	delete(m.jobs, req.ID)
+	return &spec.DeleteJobResponse{}, nil
+}
+
+// startLocked marks the job as running and runs it in a new goroutine.
+// The returned channel is closed once the job stops.
+func (m *Manager) startLocked(ctx context.Context, entry *jobEntry) (<-chan struct{}, error) {
+	switch entry.job.Status {
+	case spec.JobStatusRunning:
+		return nil, ErrJobRunning
+	case spec.JobStatusCompleted:
+		return nil, fmt.Errorf("batch job %s is already completed", entry.job.ID)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	entry.cancel = cancel
+	entry.done = make(chan struct{})
+	now := time.Now().UTC()
+	entry.job.Status = spec.JobStatusRunning
+	entry.job.Error = ""
+	entry.job.StartedAt = &now
+	entry.job.FinishedAt = nil
+	if err := m.saveLocked(entry); err != nil {
+		cancel()
+		entry.cancel, entry.done = nil, nil
+		return nil, err
+	}
+
+	done := entry.done
+	go func() {
+		defer close(done)
+		err := m.run(ctx, entry)
+		cancelled := ctx.Err() != nil
+		cancel()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		entry.cancel, entry.done = nil, nil
+		finished := time.Now().UTC()
+		entry.job.FinishedAt = &finished
+		switch {
+		case err != nil:
+			entry.job.Status = spec.JobStatusFailed
+			entry.job.Error = err.Error()
+		case cancelled:
+			entry.job.Status = spec.JobStatusPaused
+		default:
+			entry.job.Status = spec.JobStatusCompleted
+		}
+		if err := m.saveLocked(entry); err != nil {
+			slog.Error("Failed to save batch job", "id", entry.job.ID, "error", err)
+		}
+	}()
+	return done, nil
+}
+
+// run processes the rows that are not in the output file yet.
+// Row errors are recorded in the output, only errors that stop the whole job are returned.
+func (m *Manager) run(ctx context.Context, entry *jobEntry) error {
+	m.mu.Lock()
+	cfg := entry.job.Config
+	m.mu.Unlock()
+
+	renderer, err := promptrun.NewRenderer(getTarget(cfg))
+	if err != nil {
+		return err
+	}
+	rows, err := readDataset(cfg.DatasetPath, cfg.DatasetFormat)
+	if err != nil {
+		return err
+	}
+	writer, previous, err := openResultWriter(cfg.OutputPath, cfg.OutputFormat)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := writer.Close(); err != nil {
+			slog.Error("Failed to close batch job output", "id", entry.job.ID, "error", err)
+		}
+	}()
+
+	doneRows := map[int]bool{}
+	progress := spec.JobProgress{Total: len(rows)}
+	for _, res := range previous {
+		// Rows outside the dataset are left from an earlier version of it.
+		if doneRows[res.Index] || res.Index < 0 || res.Index >= len(rows) {
+			continue
+		}
+		doneRows[res.Index] = true
+		addResult(&progress, res)
+	}
+	m.mu.Lock()
+	entry.job.Progress = progress
+	err = m.saveLocked(entry)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var writeErr error
+	var writeErrOnce sync.Once
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	for range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				res, ok := m.runRow(runCtx, renderer, cfg.MaxAttempts, i, rows[i])
+				if !ok {
+					// Cancelled rows are left for the next run.
+					continue
+				}
+				if cfg.OutputFormat == spec.DataFormatJSONL {
+					res.Input = rows[i]
+				}
+				if err := writer.write(res); err != nil {
+					writeErrOnce.Do(func() {
+						writeErr = fmt.Errorf("failed to write result: %w", err)
+						stop()
+					})
+					continue
+				}
+				m.mu.Lock()
+				addResult(&entry.job.Progress, res)
+				if err := m.saveLocked(entry); err != nil {
+					slog.Warn("Failed to save batch job progress", "id", entry.job.ID, "error", err)
+				}
+				m.mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range rows {
+		if doneRows[i] {
+			continue
+		}
+		select {
+		case indexes <- i:
+		case <-runCtx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return writeErr
+}
+
+// runRow renders and runs a row. It returns false if ctx was cancelled before the row finished.
+func (m *Manager) runRow(
+	ctx context.Context,
+	renderer *promptrun.Renderer,
+	maxAttempts, index int,
+	row map[string]any,
+) (spec.RowResult, bool) {
+	res := spec.RowResult{Index: index}
+	req, err := renderer.Render(row)
+	if err != nil {
+		res.Error = err.Error()
+		return res, true
+	}
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		result, err := m.completer.Complete(ctx, req)
+		res.LatencyMs = time.Since(start).Milliseconds()
+		if ctx.Err() != nil {
+			return res, false
+		}
+		if err == nil {
+			res.Output = result.Content
+			res.Usage = result.Usage
+			res.Error = ""
+			return res, true
+		}
+		res.Error = err.Error()
+		if attempt >= maxAttempts {
+			return res, true
+		}
+		select {
+		case <-time.After(time.Duration(attempt) * time.Second):
+		case <-ctx.Done():
+			return res, false
+		}
+	}
+}
+
+func addResult(progress *spec.JobProgress, res spec.RowResult) {
+	progress.Processed++
+	if res.Error != "" {
+		progress.Failed++
+	}
+	if res.Usage != nil {
+		progress.InputTokens += res.Usage.InputTokens
+		progress.OutputTokens += res.Usage.OutputTokens
+	}
+}
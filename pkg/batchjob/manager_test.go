@@ -0,0 +1,271 @@
+package batchjob
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/batchjob/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+type fakeCompleter struct {
+	mu      sync.Mutex
+	prompts []string
+	// failOn makes prompts containing it fail.
+	failOn string
+	// block makes calls wait until ctx is done.
+	block bool
+}
+
+func (f *fakeCompleter) Complete(
+	ctx context.Context,
+	req *promptrun.Request,
+) (*promptrun.Result, error) {
+	f.mu.Lock()
+	f.prompts = append(f.prompts, req.Prompt)
+	f.mu.Unlock()
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.failOn != "" && strings.Contains(req.Prompt, f.failOn) {
+		return nil, errors.New("provider error")
+	}
+	return &promptrun.Result{
+		Content: "echo: " + req.Prompt,
+		Usage:   &promptrun.Usage{InputTokens: 2, OutputTokens: 3, TotalTokens: 5},
+	}, nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readJSONLOutput(t *testing.T, path string) map[int]spec.RowResult {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	results := map[int]spec.RowResult{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var res spec.RowResult
+		if err := json.Unmarshal(s.Bytes(), &res); err != nil {
+			t.Fatalf("invalid output line %q: %v", s.Text(), err)
+		}
+		if _, ok := results[res.Index]; ok {
+			t.Fatalf("row %d written twice", res.Index)
+		}
+		results[res.Index] = res
+	}
+	return results
+}
+
+func newTestJob(
+	t *testing.T,
+	m *Manager,
+	cfg spec.JobConfig,
+) *spec.Job {
+	t.Helper()
+	if cfg.Provider == "" {
+		cfg.Provider = "openai"
+		cfg.ModelParams = aiproviderSpec.ModelParams{Name: "gpt-4.1"}
+	}
+	resp, err := m.CreateJob(t.Context(), &spec.CreateJobRequest{
+		Body: &spec.CreateJobRequestBody{Config: cfg},
+	})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	return resp.Body
+}
+
+func TestRunJob_JSONL(t *testing.T) {
+	dir := t.TempDir()
+	dataset := filepath.Join(dir, "in.jsonl")
+	output := filepath.Join(dir, "out.jsonl")
+	writeFile(t, dataset, `{"ticket":"printer broken","id":1}
+{"ticket":"FAIL login","id":2}
+{"id":3}
+`)
+	completer := &fakeCompleter{failOn: "FAIL"}
+	m, err := NewManager(filepath.Join(dir, "jobs"), completer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := newTestJob(t, m, spec.JobConfig{
+		DatasetPath: dataset,
+		OutputPath:  output,
+		Prompt:      "Classify {{ticket}} ({{id}})",
+		MaxAttempts: 1,
+	})
+	if job.Progress.Total != 3 || job.Status != spec.JobStatusQueued {
+		t.Fatalf("unexpected new job %+v", job)
+	}
+
+	job, err = m.RunJob(t.Context(), job.ID)
+	if err != nil {
+		t.Fatalf("RunJob: %v", err)
+	}
+	if job.Status != spec.JobStatusCompleted {
+		t.Fatalf("status = %s, error %q", job.Status, job.Error)
+	}
+	want := spec.JobProgress{Total: 3, Processed: 3, Failed: 2, InputTokens: 2, OutputTokens: 3}
+	if job.Progress != want {
+		t.Errorf("progress = %+v, want %+v", job.Progress, want)
+	}
+
+	results := readJSONLOutput(t, output)
+	if got := results[0].Output; got != "echo: Classify printer broken (1)" {
+		t.Errorf("row 0 output = %q", got)
+	}
+	if results[0].Input["ticket"] != "printer broken" {
+		t.Errorf("row 0 input = %v", results[0].Input)
+	}
+	if results[1].Error != "provider error" {
+		t.Errorf("row 1 error = %q", results[1].Error)
+	}
+	if !strings.Contains(results[2].Error, "ticket") {
+		t.Errorf("row 2 error = %q", results[2].Error)
+	}
+	// Rows with missing values are not sent.
+	if len(completer.prompts) != 2 {
+		t.Errorf("completer called %d times", len(completer.prompts))
+	}
+}
+
+func TestRunJob_ResumeSkipsRecordedRows(t *testing.T) {
+	dir := t.TempDir()
+	dataset := filepath.Join(dir, "in.csv")
+	output := filepath.Join(dir, "out.csv")
+	writeFile(t, dataset, "name\na\nb\nc\n")
+	completer := &fakeCompleter{}
+	m, err := NewManager(filepath.Join(dir, "jobs"), completer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := newTestJob(t, m, spec.JobConfig{
+		DatasetPath: dataset,
+		OutputPath:  output,
+		Prompt:      "hi {{name}}",
+	})
+	// Row 1 is already done and a crash left half of the next row.
+	writeFile(t, output, strings.Join(csvHeader, ",")+"\n1,done,,5,1,1,2\n2,par")
+
+	job, err = m.RunJob(t.Context(), job.ID)
+	if err != nil {
+		t.Fatalf("RunJob: %v", err)
+	}
+	if job.Progress.Processed != 3 || job.Progress.InputTokens != 5 {
+		t.Errorf("progress = %+v", job.Progress)
+	}
+	if strings.Join(completer.prompts, ",") != "hi a,hi c" &&
+		strings.Join(completer.prompts, ",") != "hi c,hi a" {
+		t.Errorf("prompts = %v", completer.prompts)
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	results, _ := readCSVResults(f)
+	if len(results) != 3 {
+		t.Fatalf("got %d results: %+v", len(results), results)
+	}
+}
+
+func TestCancelJob_PausesAndReloads(t *testing.T) {
+	dir := t.TempDir()
+	dataset := filepath.Join(dir, "in.jsonl")
+	writeFile(t, dataset, `{"q":"one"}`+"\n")
+	jobsDir := filepath.Join(dir, "jobs")
+	m, err := NewManager(jobsDir, &fakeCompleter{block: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := newTestJob(t, m, spec.JobConfig{
+		DatasetPath: dataset,
+		OutputPath:  filepath.Join(dir, "out.jsonl"),
+		Prompt:      "{{q}}",
+	})
+	if _, err := m.ResumeJob(t.Context(), &spec.ResumeJobRequest{ID: job.ID}); err != nil {
+		t.Fatalf("ResumeJob: %v", err)
+	}
+	if _, err := m.ResumeJob(t.Context(), &spec.ResumeJobRequest{ID: job.ID}); !errors.Is(
+		err,
+		ErrJobRunning,
+	) {
+		t.Errorf("second resume error = %v", err)
+	}
+	resp, err := m.CancelJob(t.Context(), &spec.CancelJobRequest{ID: job.ID})
+	if err != nil {
+		t.Fatalf("CancelJob: %v", err)
+	}
+	if resp.Body.Status != spec.JobStatusPaused || resp.Body.Progress.Processed != 0 {
+		t.Errorf("cancelled job = %+v", resp.Body)
+	}
+
+	m2, err := NewManager(jobsDir, &fakeCompleter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := m2.GetJob(t.Context(), &spec.GetJobRequest{ID: job.ID})
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got.Body.Status != spec.JobStatusPaused || got.Body.Config.Prompt != "{{q}}" {
+		t.Errorf("reloaded job = %+v", got.Body)
+	}
+}
+
+func TestCreateJob_InvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	dataset := filepath.Join(dir, "in.jsonl")
+	writeFile(t, dataset, "{}\n")
+	m, err := NewManager(filepath.Join(dir, "jobs"), &fakeCompleter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		cfg  spec.JobConfig
+	}{
+		{"no prompt", spec.JobConfig{DatasetPath: dataset, OutputPath: "out.jsonl"}},
+		{"same paths", spec.JobConfig{DatasetPath: dataset, OutputPath: dataset, Prompt: "x"}},
+		{
+			"unknown format",
+			spec.JobConfig{DatasetPath: dataset, OutputPath: "out.txt", Prompt: "x"},
+		},
+		{"missing dataset", spec.JobConfig{
+			DatasetPath: filepath.Join(dir, "none.csv"),
+			OutputPath:  "out.csv",
+			Prompt:      "x",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Provider = "openai"
+			tt.cfg.ModelParams.Name = "gpt-4.1"
+			_, err := m.CreateJob(t.Context(), &spec.CreateJobRequest{
+				Body: &spec.CreateJobRequestBody{Config: tt.cfg},
+			})
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
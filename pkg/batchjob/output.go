@@ -0,0 +1,183 @@
+package batchjob
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/ppipada/flexigpt-app/pkg/batchjob/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+var csvHeader = []string{
+	"index",
+	"output",
+	"error",
+	"latencyMs",
+	"inputTokens",
+	"outputTokens",
+	"totalTokens",
+}
+
+// resultWriter appends row results to the output file. The output file is also the checkpoint
+// of a job, rows found in it are not run again on resume.
+type resultWriter struct {
+	mu     sync.Mutex
+	f      *os.File
+	format spec.DataFormat
+	csv    *csv.Writer
+}
+
+// openResultWriter returns the results already in the output file and a writer that appends to it.
+// A partly written last row, left by a crash, is cut off.
+func openResultWriter(
+	path string,
+	format spec.DataFormat,
+) (*resultWriter, []spec.RowResult, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o660)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open output: %w", err)
+	}
+	var results []spec.RowResult
+	var offset int64
+	if format == spec.DataFormatCSV {
+		results, offset = readCSVResults(f)
+	} else {
+		results, offset = readJSONLResults(f)
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to truncate output: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to seek output: %w", err)
+	}
+
+	w := &resultWriter{f: f, format: format}
+	if format == spec.DataFormatCSV {
+		w.csv = csv.NewWriter(f)
+		if offset == 0 {
+			if err := w.writeCSV(csvHeader); err != nil {
+				f.Close()
+				return nil, nil, err
+			}
+		}
+	}
+	return w, results, nil
+}
+
+// readJSONLResults returns the valid results and the offset just after the last one.
+func readJSONLResults(r io.Reader) ([]spec.RowResult, int64) {
+	br := bufio.NewReader(r)
+	results := []spec.RowResult{}
+	var offset int64
+	for {
+		// A line without a line break was not completely written.
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			return results, offset
+		}
+		var res spec.RowResult
+		if err := json.Unmarshal(line, &res); err != nil {
+			return results, offset
+		}
+		results = append(results, res)
+		offset += int64(len(line))
+	}
+}
+
+// readCSVResults returns the valid results and the offset just after the last one.
+// If only a broken header is found, the offset is 0 and the header is written again.
+func readCSVResults(r io.Reader) ([]spec.RowResult, int64) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+	results := []spec.RowResult{}
+	if _, err := cr.Read(); err != nil {
+		return results, 0
+	}
+	offset := cr.InputOffset()
+	for {
+		record, err := cr.Read()
+		if err != nil {
+			return results, offset
+		}
+		res, err := parseCSVResult(record)
+		if err != nil {
+			return results, offset
+		}
+		results = append(results, res)
+		offset = cr.InputOffset()
+	}
+}
+
+func parseCSVResult(record []string) (spec.RowResult, error) {
+	ints := make([]int64, 0, 5)
+	for _, i := range []int{0, 3, 4, 5, 6} {
+		n, err := strconv.ParseInt(record[i], 10, 64)
+		if err != nil {
+			return spec.RowResult{}, err
+		}
+		ints = append(ints, n)
+	}
+	res := spec.RowResult{
+		Index:     int(ints[0]),
+		Output:    record[1],
+		Error:     record[2],
+		LatencyMs: ints[1],
+	}
+	if ints[4] != 0 {
+		res.Usage = &promptrun.Usage{
+			InputTokens:  int(ints[2]),
+			OutputTokens: int(ints[3]),
+			TotalTokens:  int(ints[4]),
+		}
+	}
+	return res, nil
+}
+
+func (w *resultWriter) write(res spec.RowResult) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.format == spec.DataFormatCSV {
+		usage := promptrun.Usage{}
+		if res.Usage != nil {
+			usage = *res.Usage
+		}
+		return w.writeCSV([]string{
+			strconv.Itoa(res.Index),
+			res.Output,
+			res.Error,
+			strconv.FormatInt(res.LatencyMs, 10),
+			strconv.Itoa(usage.InputTokens),
+			strconv.Itoa(usage.OutputTokens),
+			strconv.Itoa(usage.TotalTokens),
+		})
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	_, err = w.f.Write(append(b, '\n'))
+	return err
+}
+
+func (w *resultWriter) writeCSV(record []string) error {
+	if err := w.csv.Write(record); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *resultWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return errors.Join(w.f.Sync(), w.f.Close())
+}
@@ -0,0 +1,55 @@
+package spec
+
+type CreateJobRequest struct {
+	Body *CreateJobRequestBody
+}
+
+type CreateJobRequestBody struct {
+	Config JobConfig `json:"config" required:"true"`
+	// Start runs the job right away, otherwise it stays queued until resumed.
+	Start bool `json:"start"`
+}
+
+type CreateJobResponse struct {
+	Body *Job
+}
+
+type GetJobRequest struct {
+	ID string `path:"id" required:"true"`
+}
+
+type GetJobResponse struct {
+	Body *Job
+}
+
+type ListJobsRequest struct{}
+
+type ListJobsResponse struct {
+	Body *ListJobsResponseBody
+}
+
+type ListJobsResponseBody struct {
+	Jobs []Job `json:"jobs"`
+}
+
+type ResumeJobRequest struct {
+	ID string `path:"id" required:"true"`
+}
+
+type ResumeJobResponse struct {
+	Body *Job
+}
+
+type CancelJobRequest struct {
+	ID string `path:"id" required:"true"`
+}
+
+type CancelJobResponse struct {
+	Body *Job
+}
+
+type DeleteJobRequest struct {
+	ID string `path:"id" required:"true"`
+}
+
+type DeleteJobResponse struct{}
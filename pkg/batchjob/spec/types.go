@@ -0,0 +1,90 @@
+package spec
+
+import (
+	"time"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+type JobStatus string
+
+const (
+	JobStatusQueued  JobStatus = "queued"
+	JobStatusRunning JobStatus = "running"
+	// JobStatusPaused is a job that was cancelled or interrupted, it can be resumed.
+	JobStatusPaused    JobStatus = "paused"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// DataFormat is the format of a dataset or output file.
+// If it is empty, it is taken from the file extension.
+type DataFormat string
+
+const (
+	DataFormatJSONL DataFormat = "jsonl"
+	DataFormatCSV   DataFormat = "csv"
+)
+
+// JobConfig describes what a batch job runs.
+//
+// Each dataset row is rendered into the prompt using {{name}} placeholders, where name is a
+// CSV column or a top level JSONL field. Either Prompt or Template must be set.
+// The model comes from Preset if it is set, otherwise from ModelParams.
+type JobConfig struct {
+	Name          string     `json:"name,omitempty"`
+	DatasetPath   string     `json:"datasetPath"             required:"true"`
+	DatasetFormat DataFormat `json:"datasetFormat,omitempty"                 enum:"jsonl,csv"`
+	OutputPath    string     `json:"outputPath"              required:"true"`
+	OutputFormat  DataFormat `json:"outputFormat,omitempty"                  enum:"jsonl,csv"`
+
+	// Prompt is a single user message template.
+	Prompt string `json:"prompt,omitempty"`
+	// Template is a list of message blocks. System and developer blocks become the system prompt,
+	// the last block must be a user block and is sent as the prompt.
+	Template *aiproviderSpec.PromptTemplate `json:"template,omitempty"`
+
+	Provider    aiproviderSpec.ProviderName `json:"provider,omitempty"`
+	ModelParams aiproviderSpec.ModelParams  `json:"modelParams,omitempty"`
+	Preset      *aiproviderSpec.ModelPreset `json:"preset,omitempty"`
+
+	// Concurrency is the number of rows run at once, default is 4.
+	Concurrency int `json:"concurrency,omitempty" minimum:"0" maximum:"64"`
+	// MaxAttempts is the number of tries of a row before its error is recorded, default is 1.
+	MaxAttempts int `json:"maxAttempts,omitempty" minimum:"0" maximum:"10"`
+}
+
+// JobProgress counts the rows of a job.
+// Rows recorded in the output file before a resume are counted as processed.
+type JobProgress struct {
+	Total     int `json:"total"`
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+	// Tokens used by the rows run so far, including earlier runs of the job.
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+type Job struct {
+	ID         string      `json:"id"`
+	Config     JobConfig   `json:"config"`
+	Status     JobStatus   `json:"status"`
+	Progress   JobProgress `json:"progress"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	ModifiedAt time.Time   `json:"modifiedAt"`
+	StartedAt  *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+}
+
+// RowResult is a line of the output file.
+// Input is only written to JSONL output, CSV output has the result columns only.
+type RowResult struct {
+	Index     int              `json:"index"`
+	Input     map[string]any   `json:"input,omitempty"`
+	Output    string           `json:"output"`
+	Usage     *promptrun.Usage `json:"usage,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	LatencyMs int64            `json:"latencyMs"`
+}
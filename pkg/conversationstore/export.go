@@ -0,0 +1,224 @@
+package conversationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+// maxExportConversations bounds search and date range exports.
+const maxExportConversations = 1000
+
+var exportFileNameRE = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+type exportOptions struct {
+	reasoning     bool
+	systemPrompts bool
+	details       bool
+}
+
+// ExportConversations renders the active paths of the selected conversations to one file.
+func (cc *ConversationCollection) ExportConversations(
+	ctx context.Context,
+	req *spec.ExportConversationsRequest,
+) (*spec.ExportConversationsResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	convos, err := cc.selectConversations(ctx, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	opts := exportOptions{
+		reasoning:     req.Body.IncludeReasoning,
+		systemPrompts: req.Body.IncludeSystemPrompts,
+		details:       req.Body.IncludeDetails,
+	}
+
+	resp := &spec.ExportConversationsResponse{}
+	var ext string
+	switch req.Body.Format {
+	case spec.ExportFormatMarkdown:
+		resp.Body = renderMarkdown(convos, opts, time.Now())
+		resp.ContentType, ext = "text/markdown; charset=utf-8", ".md"
+	case spec.ExportFormatHTML:
+		resp.Body = renderHTML(convos, opts)
+		resp.ContentType, ext = "text/html; charset=utf-8", ".html"
+	case spec.ExportFormatJSONL:
+		if resp.Body, err = renderJSONL(convos, opts); err != nil {
+			return nil, err
+		}
+		resp.ContentType, ext = "application/jsonl", ".jsonl"
+	default:
+		return nil, fmt.Errorf("unknown export format %q", req.Body.Format)
+	}
+	resp.FileName = exportFileName(convos) + ext
+	resp.ContentDisposition = fmt.Sprintf("attachment; filename=%q", resp.FileName)
+	return resp, nil
+}
+
+func (cc *ConversationCollection) selectConversations(
+	ctx context.Context,
+	body *spec.ExportConversationsRequestBody,
+) ([]*spec.Conversation, error) {
+	hasRange := body.CreatedFrom != nil || body.CreatedTo != nil
+	selections := 0
+	for _, set := range []bool{len(body.Conversations) > 0, body.Query != "", hasRange} {
+		if set {
+			selections++
+		}
+	}
+	if selections != 1 {
+		return nil, errors.New(
+			"exactly one of conversations, query or a created date range is required",
+		)
+	}
+
+	refs := body.Conversations
+	var err error
+	switch {
+	case body.Query != "":
+		refs, err = cc.searchRefs(ctx, body.Query)
+	case hasRange:
+		refs, err = cc.createdRangeRefs(ctx, body.CreatedFrom, body.CreatedTo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	convos := make([]*spec.Conversation, 0, len(refs))
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		convo, err := cc.getConversationTree(ref.ID, ref.Title)
+		if err != nil {
+			return nil, fmt.Errorf("conversation %s: %w", ref.ID, err)
+		}
+		convo.Messages = activePath(convo)
+		convos = append(convos, convo)
+	}
+	return convos, nil
+}
+
+// searchRefs returns the search hits in rank order.
+func (cc *ConversationCollection) searchRefs(
+	ctx context.Context,
+	query string,
+) ([]spec.ConversationRef, error) {
+	refs := []spec.ConversationRef{}
+	token := ""
+	for len(refs) < maxExportConversations {
+		resp, err := cc.SearchConversations(ctx, &spec.SearchConversationsRequest{
+			Query:    query,
+			Token:    token,
+			PageSize: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Body.ConversationItems {
+			refs = append(refs, spec.ConversationRef{ID: item.ID, Title: item.Title})
+		}
+		if resp.Body.NextPageToken == nil || *resp.Body.NextPageToken == "" {
+			break
+		}
+		token = *resp.Body.NextPageToken
+	}
+	return refs[:min(len(refs), maxExportConversations)], nil
+}
+
+// createdRangeRefs returns the conversations created in [from, to), oldest first.
+func (cc *ConversationCollection) createdRangeRefs(
+	ctx context.Context,
+	from, to *time.Time,
+) ([]spec.ConversationRef, error) {
+	items := []spec.ConversationItem{}
+	token := ""
+	for {
+		resp, err := cc.ListConversations(ctx, &spec.ListConversationsRequest{Token: token})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Body.ConversationItems {
+			if (from == nil || !item.CreatedAt.Before(*from)) &&
+				(to == nil || item.CreatedAt.Before(*to)) {
+				items = append(items, item)
+			}
+		}
+		if len(items) > maxExportConversations {
+			return nil, fmt.Errorf(
+				"more than %d conversations in the date range",
+				maxExportConversations,
+			)
+		}
+		if resp.Body.NextPageToken == nil || *resp.Body.NextPageToken == "" {
+			break
+		}
+		token = *resp.Body.NextPageToken
+	}
+	slices.SortFunc(items, func(a, b spec.ConversationItem) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	refs := make([]spec.ConversationRef, 0, len(items))
+	for _, item := range items {
+		refs = append(refs, spec.ConversationRef{ID: item.ID, Title: item.Title})
+	}
+	return refs, nil
+}
+
+// exportedMessages drops the messages that the options leave out.
+func exportedMessages(
+	c *spec.Conversation,
+	opts exportOptions,
+) []spec.ConversationMessage {
+	msgs := make([]spec.ConversationMessage, 0, len(c.Messages))
+	for _, m := range c.Messages {
+		if m.Role == spec.ConversationRoleSystem && !opts.systemPrompts {
+			continue
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+func exportFileName(convos []*spec.Conversation) string {
+	name := "conversations"
+	if len(convos) == 1 {
+		name = strings.Trim(exportFileNameRE.ReplaceAllString(convos[0].Title, "-"), "-")
+		name = strings.ToLower(name)
+		if name == "" {
+			name = "conversation"
+		}
+	}
+	return name
+}
+
+func roleTitle(m spec.ConversationMessage) string {
+	role := string(m.Role)
+	if role != "" {
+		role = strings.ToUpper(role[:1]) + role[1:]
+	}
+	if m.Name != nil && *m.Name != "" {
+		role += " (" + *m.Name + ")"
+	}
+	return role
+}
+
+func reasoningText(m spec.ConversationMessage) string {
+	parts := []string{}
+	for _, rc := range m.ReasoningContents {
+		if rc.Type == spec.ReasoningContentTypeRedactedThinking || rc.Text == "" {
+			parts = append(parts, "[redacted]")
+			continue
+		}
+		parts = append(parts, rc.Text)
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,137 @@
+package conversationstore
+
+import (
+	"html"
+	"strings"
+)
+
+// codeKeywords are the keywords of common languages, highlighting is not language exact.
+var codeKeywords = map[string]bool{}
+
+func init() {
+	for _, kw := range strings.Fields(`
+		abstract and as async await break case catch chan class const continue def default
+		defer del do elif else end enum except export extends false finally fn for from func
+		function go goto if impl implements import in instanceof interface is lambda let local
+		map match mod new nil none not null or package pass pub raise range return select self
+		static struct super switch then this throw throws trait true try type typeof use var
+		void where while with yield
+		insert update delete into values join on group by order having limit create table
+		alter drop distinct union`) {
+		codeKeywords[kw] = true
+	}
+}
+
+var (
+	// plainLanguages are not highlighted.
+	plainLanguages = map[string]bool{
+		"": true, "text": true, "txt": true, "plaintext": true, "markdown": true, "md": true,
+		"console": true, "output": true,
+	}
+	hashCommentLanguages = map[string]bool{
+		"python": true, "py": true, "sh": true, "bash": true, "shell": true, "zsh": true,
+		"yaml": true, "yml": true, "toml": true, "ruby": true, "rb": true, "r": true,
+		"perl": true, "dockerfile": true, "makefile": true, "powershell": true, "ps1": true,
+		"ini": true, "conf": true,
+	}
+	dashCommentLanguages = map[string]bool{
+		"sql": true, "lua": true, "haskell": true, "hs": true,
+	}
+)
+
+// highlightCode escapes code and wraps comments, strings, numbers and keywords in spans.
+// It is a generic lexer that knows only how the language writes comments.
+func highlightCode(code, lang string) string {
+	lang = strings.ToLower(lang)
+	if plainLanguages[lang] {
+		return html.EscapeString(code)
+	}
+	hashComments := hashCommentLanguages[lang]
+	dashComments := dashCommentLanguages[lang]
+	cComments := !hashComments && !dashComments
+
+	var sb strings.Builder
+	span := func(class, text string) {
+		sb.WriteString(`<span class="tok-` + class + `">` + html.EscapeString(text) + `</span>`)
+	}
+	for i := 0; i < len(code); {
+		c := code[i]
+		rest := code[i:]
+		switch {
+		case (cComments && strings.HasPrefix(rest, "//")) ||
+			(hashComments && c == '#') ||
+			(dashComments && strings.HasPrefix(rest, "--")):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			span("comment", rest[:end])
+			i += end
+
+		case cComments && strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+			span("comment", rest[:end])
+			i += end
+
+		case c == '"' || c == '\'' || c == '`':
+			end := stringEnd(rest)
+			span("string", rest[:end])
+			i += end
+
+		case isDigit(c) && (i == 0 || !isIdentByte(code[i-1])):
+			end := 1
+			for end < len(rest) && (isIdentByte(rest[end]) || rest[end] == '.') {
+				end++
+			}
+			span("number", rest[:end])
+			i += end
+
+		case isIdentByte(c):
+			end := 1
+			for end < len(rest) && isIdentByte(rest[end]) {
+				end++
+			}
+			if word := rest[:end]; codeKeywords[strings.ToLower(word)] {
+				span("keyword", word)
+			} else {
+				sb.WriteString(html.EscapeString(word))
+			}
+			i += end
+
+		default:
+			sb.WriteString(html.EscapeString(rest[:1]))
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// stringEnd returns the length of the quoted string at the start of s. Strings end at their
+// closing quote or, except for backticks, at the end of the line.
+func stringEnd(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+		case s[i] == quote:
+			return i + 1
+		case s[i] == '\n' && quote != '`':
+			return i
+		}
+	}
+	return len(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
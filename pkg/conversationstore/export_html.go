@@ -0,0 +1,380 @@
+package conversationstore
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+const htmlExportStyle = `body{font-family:system-ui,-apple-system,"Segoe UI",sans-serif;line-height:1.5;max-width:56rem;margin:2rem auto;padding:0 1rem;color:#1f2328}
+header.conversation{margin-top:3rem;border-bottom:1px solid #d0d7de}
+.meta{color:#656d76;font-size:.875rem}
+section.message{margin:1rem 0;padding:.5rem 1rem;border-radius:.5rem;background:#f6f8fa}
+section.message.user{background:#ddf4ff}
+section.message h3{margin:.25rem 0;font-size:.875rem;text-transform:uppercase;color:#656d76}
+pre{background:#0d1117;color:#e6edf3;padding:.75rem;border-radius:.375rem;overflow-x:auto}
+code{font-family:ui-monospace,SFMono-Regular,Menlo,monospace;font-size:.875em}
+:not(pre)>code{background:#afb8c133;padding:.1em .3em;border-radius:.25rem}
+.tok-keyword{color:#ff7b72}.tok-string{color:#a5d6ff}.tok-comment{color:#8b949e;font-style:italic}.tok-number{color:#79c0ff}
+blockquote{margin:0;padding-left:1rem;border-left:.25rem solid #d0d7de;color:#656d76}
+table{border-collapse:collapse}th,td{border:1px solid #d0d7de;padding:.25rem .5rem}
+details{margin:.5rem 0}summary{cursor:pointer;color:#656d76}
+.math{font-family:ui-monospace,monospace}div.math{white-space:pre-wrap;margin:.5rem 0}`
+
+var (
+	headingRE      = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	ruleRE         = regexp.MustCompile(`^(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	listItemRE     = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	tableSepRE     = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	linkRE         = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	boldRE         = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	italicRE       = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+	strikeRE       = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	safeURLRE      = regexp.MustCompile(`^(?i:https?://|mailto:|#)`)
+	escapableChars = "\\`*_{}[]()#+-.!|~<>"
+)
+
+// renderHTML writes a self-contained page, styles are inline and nothing is loaded from
+// elsewhere. Math is kept as written, in elements of class math, for a math renderer to pick up.
+func renderHTML(convos []*spec.Conversation, opts exportOptions) []byte {
+	title := "FlexiGPT conversations"
+	if len(convos) == 1 {
+		title = convos[0].Title
+	}
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString(
+		"<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n",
+	)
+	fmt.Fprintf(&sb, "<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n",
+		html.EscapeString(title), htmlExportStyle)
+
+	for _, c := range convos {
+		fmt.Fprintf(
+			&sb,
+			"<header class=\"conversation\">\n<h1>%s</h1>\n<p class=\"meta\">%s</p>\n</header>\n",
+			html.EscapeString(c.Title),
+			html.EscapeString(c.CreatedAt.UTC().Format(time.DateTime)),
+		)
+		for _, m := range exportedMessages(c, opts) {
+			fmt.Fprintf(&sb, "<section class=\"message %s\">\n<h3>%s</h3>\n",
+				html.EscapeString(string(m.Role)), html.EscapeString(roleTitle(m)))
+			if opts.reasoning && len(m.ReasoningContents) > 0 {
+				writeHTMLDetails(&sb, "Reasoning", reasoningText(m))
+			}
+			sb.WriteString(markdownToHTML(m.Content))
+			if opts.details && m.Details != nil && *m.Details != "" {
+				writeHTMLDetails(&sb, "Details", *m.Details)
+			}
+			sb.WriteString("</section>\n")
+		}
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return []byte(sb.String())
+}
+
+func writeHTMLDetails(sb *strings.Builder, summary, content string) {
+	fmt.Fprintf(sb, "<details>\n<summary>%s</summary>\n%s</details>\n",
+		summary, markdownToHTML(content))
+}
+
+// markdownToHTML converts the Markdown that models usually write: headings, paragraphs,
+// fenced code, lists, block quotes, tables, rules and display math.
+// It is not a complete CommonMark implementation.
+func markdownToHTML(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var sb strings.Builder
+	var para []string
+	flushPara := func() {
+		if len(para) > 0 {
+			fmt.Fprintf(&sb, "<p>%s</p>\n", renderInline(strings.Join(para, "\n")))
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			flushPara()
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flushPara()
+			fence := trimmed[:3]
+			lang := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			if f := strings.Fields(lang); len(f) > 0 {
+				lang = f[0]
+			}
+			code := []string{}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if lang != "" {
+				class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(lang))
+			}
+			fmt.Fprintf(&sb, "<pre><code%s>%s</code></pre>\n",
+				class, highlightCode(strings.Join(code, "\n"), lang))
+
+		case strings.HasPrefix(trimmed, "$$"):
+			flushPara()
+			math := []string{lines[i]}
+			if trimmed == "$$" || !strings.HasSuffix(trimmed, "$$") {
+				for i++; i < len(lines); i++ {
+					math = append(math, lines[i])
+					if strings.HasSuffix(strings.TrimSpace(lines[i]), "$$") {
+						break
+					}
+				}
+			}
+			fmt.Fprintf(&sb, "<div class=\"math\">%s</div>\n",
+				html.EscapeString(strings.Join(math, "\n")))
+
+		case headingRE.MatchString(trimmed):
+			flushPara()
+			m := headingRE.FindStringSubmatch(trimmed)
+			fmt.Fprintf(&sb, "<h%d>%s</h%d>\n", len(m[1]), renderInline(m[2]), len(m[1]))
+
+		case ruleRE.MatchString(trimmed):
+			flushPara()
+			sb.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			quoted := []string{}
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(t, ">") {
+					i--
+					break
+				}
+				t = strings.TrimPrefix(t, ">")
+				quoted = append(quoted, strings.TrimPrefix(t, " "))
+			}
+			fmt.Fprintf(&sb, "<blockquote>\n%s</blockquote>\n",
+				markdownToHTML(strings.Join(quoted, "\n")))
+
+		case listItemRE.MatchString(lines[i]) && (len(para) == 0 || startsList(lines[i])):
+			flushPara()
+			i = writeList(&sb, lines, i)
+
+		case strings.Contains(trimmed, "|") && len(para) == 0 &&
+			i+1 < len(lines) && strings.Contains(lines[i+1], "|") &&
+			tableSepRE.MatchString(lines[i+1]):
+			i = writeTable(&sb, lines, i)
+
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flushPara()
+	return sb.String()
+}
+
+// writeList writes the list starting at lines[start] and returns the index of its last line.
+// Indented lines belong to the item above them and are converted recursively.
+func writeList(sb *strings.Builder, lines []string, start int) int {
+	first := listItemRE.FindStringSubmatch(lines[start])
+	indent := len(first[1])
+	tag := "ul"
+	if first[2][0] >= '0' && first[2][0] <= '9' {
+		tag = "ol"
+	}
+	fmt.Fprintf(sb, "<%s>\n", tag)
+
+	var item []string
+	// contentIndent is where the text of the current item starts.
+	contentIndent := 0
+	flushItem := func() {
+		if item == nil {
+			return
+		}
+		body := markdownToHTML(strings.Join(item, "\n"))
+		// An item that starts with its only paragraph is written without it, as a tight list.
+		if strings.Count(body, "<p>") == 1 && strings.HasPrefix(body, "<p>") {
+			body = strings.Replace(strings.TrimPrefix(body, "<p>"), "</p>", "", 1)
+		}
+		fmt.Fprintf(sb, "<li>%s</li>\n", strings.TrimSuffix(body, "\n"))
+		item = nil
+	}
+
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := listItemRE.FindStringSubmatch(line); m != nil && len(m[1]) <= indent {
+			if i > start && len(m[1]) < indent {
+				break
+			}
+			flushItem()
+			item = []string{m[3]}
+			contentIndent = len(m[1]) + len(m[2]) + 1
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// A blank line ends the list unless the next line is indented or another item.
+			if i+1 < len(lines) && (leadingSpaces(lines[i+1]) > indent ||
+				listItemRE.MatchString(lines[i+1])) {
+				item = append(item, "")
+				continue
+			}
+			break
+		}
+		if leadingSpaces(line) <= indent && i > start &&
+			strings.TrimSpace(lines[i-1]) == "" {
+			break
+		}
+		item = append(item, line[min(leadingSpaces(line), contentIndent):])
+	}
+	flushItem()
+	fmt.Fprintf(sb, "</%s>\n", tag)
+	return i - 1
+}
+
+// writeTable writes the table whose header is lines[start] and returns the index of its last row.
+func writeTable(sb *strings.Builder, lines []string, start int) int {
+	sb.WriteString("<table>\n<thead>\n")
+	writeTableRow(sb, lines[start], "th")
+	sb.WriteString("</thead>\n<tbody>\n")
+	i := start + 2
+	for ; i < len(lines); i++ {
+		if !strings.Contains(lines[i], "|") || strings.TrimSpace(lines[i]) == "" {
+			break
+		}
+		writeTableRow(sb, lines[i], "td")
+	}
+	sb.WriteString("</tbody>\n</table>\n")
+	return i - 1
+}
+
+func writeTableRow(sb *strings.Builder, line, cellTag string) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	sb.WriteString("<tr>")
+	for _, cell := range strings.Split(line, "|") {
+		fmt.Fprintf(sb, "<%s>%s</%s>", cellTag, renderInline(strings.TrimSpace(cell)), cellTag)
+	}
+	sb.WriteString("</tr>\n")
+}
+
+// startsList reports whether a list item line can interrupt a paragraph,
+// as in CommonMark only bullets and ordered lists starting at 1 can.
+func startsList(line string) bool {
+	m := listItemRE.FindStringSubmatch(line)
+	return m != nil && (len(m[2]) == 1 || m[2][:len(m[2])-1] == "1")
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// renderInline converts code spans, math, emphasis and links of a paragraph.
+// Code and math are escaped but otherwise left as written.
+func renderInline(s string) string {
+	var sb, text strings.Builder
+	flushText := func() {
+		formatted := formatInline(html.EscapeString(text.String()))
+		sb.WriteString(strings.ReplaceAll(formatted, "\n", "<br>\n"))
+		text.Reset()
+	}
+	writeRaw := func(class, raw string) {
+		flushText()
+		if class == "" {
+			fmt.Fprintf(&sb, "<code>%s</code>", html.EscapeString(raw))
+			return
+		}
+		fmt.Fprintf(&sb, "<span class=\"%s\">%s</span>", class, html.EscapeString(raw))
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '(' || s[i+1] == '['):
+			closing := `\)`
+			if s[i+1] == '[' {
+				closing = `\]`
+			}
+			if end := strings.Index(s[i+2:], closing); end >= 0 {
+				end += i + 2 + len(closing)
+				writeRaw("math", s[i:end])
+				i = end
+				continue
+			}
+			text.WriteByte(c)
+			i++
+
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(escapableChars, s[i+1]) >= 0:
+			flushText()
+			sb.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+
+		case c == '`':
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			fence := s[i : i+n]
+			if end := strings.Index(s[i+n:], fence); end >= 0 {
+				writeRaw("", strings.TrimSpace(s[i+n:i+n+end]))
+				i += n + end + n
+				continue
+			}
+			text.WriteString(fence)
+			i += n
+
+		case c == '$':
+			if end := mathEnd(s, i); end > 0 {
+				writeRaw("math", s[i:end])
+				i = end
+				continue
+			}
+			text.WriteByte(c)
+			i++
+
+		default:
+			text.WriteByte(c)
+			i++
+		}
+	}
+	flushText()
+	return sb.String()
+}
+
+// mathEnd returns the end of the $...$ or $$...$$ span starting at i, or 0 if there is none.
+// Like most renderers, "$5 and $6" is not math: the opening $ must not be followed by a space
+// and the closing $ must not be preceded by a space or followed by a digit.
+func mathEnd(s string, i int) int {
+	delim := "$"
+	if strings.HasPrefix(s[i:], "$$") {
+		delim = "$$"
+	}
+	start := i + len(delim)
+	if start >= len(s) || s[start] == ' ' {
+		return 0
+	}
+	for j := start + 1; j <= len(s)-len(delim); j++ {
+		if !strings.HasPrefix(s[j:], delim) || s[j-1] == ' ' || s[j-1] == '\\' {
+			continue
+		}
+		end := j + len(delim)
+		if delim == "$" && end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			continue
+		}
+		return end
+	}
+	return 0
+}
+
+// formatInline applies emphasis and links to escaped text.
+func formatInline(escaped string) string {
+	escaped = linkRE.ReplaceAllStringFunc(escaped, func(m string) string {
+		parts := linkRE.FindStringSubmatch(m)
+		if !safeURLRE.MatchString(parts[2]) {
+			return parts[1]
+		}
+		return fmt.Sprintf("<a href=\"%s\">%s</a>", parts[2], parts[1])
+	})
+	escaped = boldRE.ReplaceAllString(escaped, "<strong>$1$2</strong>")
+	escaped = italicRE.ReplaceAllString(escaped, "<em>$1</em>")
+	return strikeRE.ReplaceAllString(escaped, "<del>$1</del>")
+}
@@ -0,0 +1,53 @@
+package conversationstore
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+type fineTuningMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type fineTuningExample struct {
+	Messages []fineTuningMessage `json:"messages"`
+}
+
+// renderJSONL writes one fine-tuning example per conversation. The format only has system,
+// user and assistant messages, so reasoning and details are never exported.
+// Conversations without an assistant reply are not examples and are left out.
+func renderJSONL(convos []*spec.Conversation, opts exportOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, c := range convos {
+		example := fineTuningExample{Messages: []fineTuningMessage{}}
+		hasReply := false
+		for _, m := range exportedMessages(c, opts) {
+			switch m.Role {
+			case spec.ConversationRoleSystem, spec.ConversationRoleUser,
+				spec.ConversationRoleAssistant:
+			default:
+				continue
+			}
+			if m.Content == "" {
+				continue
+			}
+			hasReply = hasReply || m.Role == spec.ConversationRoleAssistant
+			example.Messages = append(example.Messages, fineTuningMessage{
+				Role:    string(m.Role),
+				Content: m.Content,
+			})
+		}
+		if !hasReply {
+			continue
+		}
+		if err := enc.Encode(example); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
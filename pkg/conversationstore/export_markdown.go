@@ -0,0 +1,56 @@
+package conversationstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+// renderMarkdown writes the front matter of a single conversation, or of the export
+// if there are several, followed by one section per conversation.
+func renderMarkdown(convos []*spec.Conversation, opts exportOptions, now time.Time) []byte {
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	if len(convos) == 1 {
+		c := convos[0]
+		fmt.Fprintf(&sb, "title: %s\n", strconv.Quote(c.Title))
+		fmt.Fprintf(&sb, "id: %s\n", c.ID)
+		fmt.Fprintf(&sb, "createdAt: %s\n", c.CreatedAt.UTC().Format(time.RFC3339))
+		fmt.Fprintf(&sb, "modifiedAt: %s\n", c.ModifiedAt.UTC().Format(time.RFC3339))
+	} else {
+		sb.WriteString("title: \"FlexiGPT conversations\"\n")
+		fmt.Fprintf(&sb, "exportedAt: %s\n", now.UTC().Format(time.RFC3339))
+		fmt.Fprintf(&sb, "conversations: %d\n", len(convos))
+	}
+	sb.WriteString("---\n")
+
+	for _, c := range convos {
+		fmt.Fprintf(&sb, "\n# %s\n", c.Title)
+		if len(convos) > 1 {
+			fmt.Fprintf(&sb, "\n_%s, %s_\n", c.CreatedAt.UTC().Format(time.DateTime), c.ID)
+		}
+		for _, m := range exportedMessages(c, opts) {
+			fmt.Fprintf(&sb, "\n## %s\n\n", roleTitle(m))
+			if opts.reasoning && len(m.ReasoningContents) > 0 {
+				writeMarkdownDetails(&sb, "Reasoning", reasoningText(m))
+			}
+			sb.WriteString(strings.TrimSpace(m.Content))
+			sb.WriteString("\n")
+			if opts.details && m.Details != nil && *m.Details != "" {
+				sb.WriteString("\n")
+				writeMarkdownDetails(&sb, "Details", *m.Details)
+			}
+		}
+	}
+	return []byte(sb.String())
+}
+
+// writeMarkdownDetails writes a collapsed HTML details block, the blank lines let
+// renderers parse its content as Markdown.
+func writeMarkdownDetails(sb *strings.Builder, summary, content string) {
+	fmt.Fprintf(sb, "<details>\n<summary>%s</summary>\n\n%s\n\n</details>\n\n",
+		summary, strings.TrimSpace(content))
+}
@@ -0,0 +1,193 @@
+package conversationstore
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+func exportTestConversation(t *testing.T, cc *ConversationCollection) *spec.Conversation {
+	t.Helper()
+	details := "request took 2s"
+	c := newConv(t, "Quick sort in Go")
+	c.Messages = []spec.ConversationMessage{
+		{ID: "s", Role: spec.ConversationRoleSystem, Content: "Be brief."},
+		{ID: "u", Role: spec.ConversationRoleUser, Content: "Sort <ints> in $O(n \\log n)$?"},
+		{
+			ID:      "a",
+			Role:    spec.ConversationRoleAssistant,
+			Content: "Use **slices**:\n\n```go\n// sorts\nslices.Sort(x)\n```",
+			Details: &details,
+			ReasoningContents: []spec.ReasoningContent{
+				{Type: spec.ReasoningContentTypeThinking, Text: "Think of the stdlib."},
+			},
+		},
+	}
+	if _, err := cc.PutConversation(t.Context(), getNewPutRequestFromConversation(c)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	return c
+}
+
+func exportConversations(
+	t *testing.T,
+	cc *ConversationCollection,
+	body *spec.ExportConversationsRequestBody,
+) *spec.ExportConversationsResponse {
+	t.Helper()
+	resp, err := cc.ExportConversations(t.Context(), &spec.ExportConversationsRequest{Body: body})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	return resp
+}
+
+func TestExportMarkdown(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), false)
+	c := exportTestConversation(t, cc)
+	ref := []spec.ConversationRef{{ID: c.ID, Title: c.Title}}
+
+	resp := exportConversations(t, cc, &spec.ExportConversationsRequestBody{
+		Format:        spec.ExportFormatMarkdown,
+		Conversations: ref,
+	})
+	md := string(resp.Body)
+	if !strings.HasPrefix(md, "---\ntitle: \"Quick sort in Go\"\nid: "+c.ID) {
+		t.Errorf("front matter missing:\n%s", md)
+	}
+	for _, unwanted := range []string{"Be brief.", "Think of the stdlib.", "request took"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("%q exported without its option", unwanted)
+		}
+	}
+	if resp.FileName != "quick-sort-in-go.md" {
+		t.Errorf("file name = %q", resp.FileName)
+	}
+
+	resp = exportConversations(t, cc, &spec.ExportConversationsRequestBody{
+		Format:               spec.ExportFormatMarkdown,
+		Conversations:        ref,
+		IncludeReasoning:     true,
+		IncludeSystemPrompts: true,
+		IncludeDetails:       true,
+	})
+	md = string(resp.Body)
+	for _, wanted := range []string{"## System", "Think of the stdlib.", "request took 2s"} {
+		if !strings.Contains(md, wanted) {
+			t.Errorf("%q missing:\n%s", wanted, md)
+		}
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), false)
+	c := exportTestConversation(t, cc)
+	resp := exportConversations(t, cc, &spec.ExportConversationsRequestBody{
+		Format:        spec.ExportFormatHTML,
+		Conversations: []spec.ConversationRef{{ID: c.ID, Title: c.Title}},
+	})
+	page := string(resp.Body)
+	for _, wanted := range []string{
+		"<title>Quick sort in Go</title>",
+		"Sort &lt;ints&gt; in <span class=\"math\">$O(n \\log n)$</span>?",
+		"<strong>slices</strong>",
+		"<pre><code class=\"language-go\"><span class=\"tok-comment\">// sorts</span>",
+	} {
+		if !strings.Contains(page, wanted) {
+			t.Errorf("%q missing:\n%s", wanted, page)
+		}
+	}
+	if strings.Contains(page, "<script") || strings.Contains(page, "<link") {
+		t.Error("page loads external resources")
+	}
+}
+
+func TestExportJSONL(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), false)
+	exportTestConversation(t, cc)
+	empty := newConv(t, "No reply")
+	if _, err := cc.PutConversation(
+		t.Context(),
+		getNewPutRequestFromConversation(empty),
+	); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	from := time.Now().Add(-time.Hour)
+	resp := exportConversations(t, cc, &spec.ExportConversationsRequestBody{
+		Format:               spec.ExportFormatJSONL,
+		CreatedFrom:          &from,
+		IncludeSystemPrompts: true,
+	})
+	lines := strings.Split(strings.TrimSpace(string(resp.Body)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("want 1 example, got %d:\n%s", len(lines), resp.Body)
+	}
+	var example fineTuningExample
+	if err := json.Unmarshal([]byte(lines[0]), &example); err != nil {
+		t.Fatalf("invalid line: %v", err)
+	}
+	roles := []string{}
+	for _, m := range example.Messages {
+		roles = append(roles, m.Role)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant" {
+		t.Errorf("roles = %v", roles)
+	}
+	if resp.FileName != "conversations.jsonl" {
+		t.Errorf("file name = %q", resp.FileName)
+	}
+}
+
+func TestExportSelectionErrors(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), false)
+	now := time.Now()
+	for _, body := range []*spec.ExportConversationsRequestBody{
+		{Format: spec.ExportFormatHTML},
+		{Format: spec.ExportFormatHTML, Query: "x", CreatedTo: &now},
+		{Format: spec.ExportFormatHTML, Query: "x"},
+	} {
+		if _, err := cc.ExportConversations(
+			t.Context(),
+			&spec.ExportConversationsRequest{Body: body},
+		); err == nil {
+			t.Errorf("expected an error for %+v", body)
+		}
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"heading", "## Title", "<h2>Title</h2>\n"},
+		{
+			"nested list",
+			"- a\n  - b\n- c",
+			"<ul>\n<li>a\n<ul>\n<li>b</li>\n</ul></li>\n<li>c</li>\n</ul>\n",
+		},
+		{"ordered list", "1. one\n2. two", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n"},
+		{
+			"table",
+			"| a | b |\n|---|---|\n| 1 | 2 |",
+			"<table>\n<thead>\n<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n" +
+				"<tr><td>1</td><td>2</td></tr>\n</tbody>\n</table>\n",
+		},
+		{"display math", "$$\na_1 < b\n$$", "<div class=\"math\">$$\na_1 &lt; b\n$$</div>\n"},
+		{"prices are not math", "costs $5 and $6", "<p>costs $5 and $6</p>\n"},
+		{"code span", "run `a*b*c`", "<p>run <code>a*b*c</code></p>\n"},
+		{"unsafe link", "[x](javascript:alert(1))", "<p>x)</p>\n"},
+		{"link", "[docs](https://go.dev)", "<p><a href=\"https://go.dev\">docs</a></p>\n"},
+		{"quote", "> hi\n> there", "<blockquote>\n<p>hi<br>\nthere</p>\n</blockquote>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownToHTML(tt.in); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package conversationstore
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -37,7 +39,8 @@ func InitConversationStoreHandlers(api huma.API, conversationStoreAPI *Conversat
 		Summary:     "Put a conversation",
 		Description: "Put a conversation",
 		Tags:        []string{tag},
-	}, conversationStoreAPI.PutConversation)
+		Errors:      []int{http.StatusConflict},
+	}, withConflictStatus(conversationStoreAPI.PutConversation))
 
 	huma.Register(api, huma.Operation{
 		OperationID: "put-messages-to-conversation",
@@ -46,7 +49,38 @@ func InitConversationStoreHandlers(api huma.API, conversationStoreAPI *Conversat
 		Summary:     "Put messages to a conversation",
 		Description: "Put messages to a conversation",
 		Tags:        []string{tag},
-	}, conversationStoreAPI.PutMessagesToConversation)
+		Errors:      []int{http.StatusConflict},
+	}, withConflictStatus(conversationStoreAPI.PutMessagesToConversation))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "append-messages",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/{id}/messages",
+		Summary:     "Append messages to a conversation",
+		Description: "Add messages after the active leaf, the version must match the stored one",
+		Tags:        []string{tag},
+		Errors:      []int{http.StatusConflict},
+	}, withConflictStatus(conversationStoreAPI.AppendMessages))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "update-message",
+		Method:      http.MethodPut,
+		Path:        pathPrefix + "/{id}/messages/{messageID}",
+		Summary:     "Update a message",
+		Description: "Replace a message, the version must match the stored one",
+		Tags:        []string{tag},
+		Errors:      []int{http.StatusConflict},
+	}, withConflictStatus(conversationStoreAPI.UpdateMessage))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-message",
+		Method:      http.MethodDelete,
+		Path:        pathPrefix + "/{id}/messages/{messageID}",
+		Summary:     "Delete a message",
+		Description: "Delete a message and the replies below it, the version must match the stored one",
+		Tags:        []string{tag},
+		Errors:      []int{http.StatusConflict},
+	}, withConflictStatus(conversationStoreAPI.DeleteMessage))
 
 	huma.Register(api, huma.Operation{
 		OperationID: "delete-conversation",
@@ -65,4 +99,128 @@ func InitConversationStoreHandlers(api huma.API, conversationStoreAPI *Conversat
 		Description: "Get a conversation",
 		Tags:        []string{tag},
 	}, conversationStoreAPI.GetConversation)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "fork-conversation",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/{id}/messages/{messageID}/fork",
+		Summary:     "Fork a conversation at a message",
+		Description: "Add an active branch next to a message, the version must match the stored one",
+		Tags:        []string{tag},
+		Errors:      []int{http.StatusConflict},
+	}, withConflictStatus(conversationStoreAPI.ForkConversation))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "regenerate-message",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/{id}/messages/{messageID}/regenerate",
+		Summary:     "Regenerate a reply",
+		Description: "Add an active reply next to a reply, the version must match the stored one",
+		Tags:        []string{tag},
+		Errors:      []int{http.StatusConflict},
+	}, withConflictStatus(conversationStoreAPI.RegenerateMessage))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "switch-conversation-branch",
+		Method:      http.MethodPut,
+		Path:        pathPrefix + "/{id}/branch",
+		Summary:     "Switch the active branch",
+		Description: "Make the branch of a message active, the version must match the stored one",
+		Tags:        []string{tag},
+		Errors:      []int{http.StatusConflict},
+	}, withConflictStatus(conversationStoreAPI.SwitchBranch))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "rename-conversation",
+		Method:      http.MethodPut,
+		Path:        pathPrefix + "/{id}/title",
+		Summary:     "Rename a conversation",
+		Description: "Change the title of a conversation",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.RenameConversation)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "generate-conversation-title",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/{id}/title/generate",
+		Summary:     "Generate a conversation title",
+		Description: "Title a conversation from its first exchange with the title model and rename it",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.GenerateConversationTitle)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "export-conversations",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/export",
+		Summary:     "Export conversations",
+		Description: "Export conversations to Markdown, HTML or JSONL",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.ExportConversations)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "import-conversations",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/import",
+		Summary:     "Import conversations",
+		Description: "Import conversations from a ChatGPT or Claude.ai data export",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.ImportConversations)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-trash",
+		Method:      http.MethodGet,
+		Path:        pathPrefix + "/trash",
+		Summary:     "List trashed conversations",
+		Description: "List deleted conversations that can be restored",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.ListTrash)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "restore-conversation",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/trash/{id}/restore",
+		Summary:     "Restore a conversation",
+		Description: "Move a deleted conversation back from the trash",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.RestoreConversation)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "purge-trash",
+		Method:      http.MethodPost,
+		Path:        pathPrefix + "/trash/purge",
+		Summary:     "Purge the trash",
+		Description: "Permanently delete trashed conversations",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.PurgeTrash)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "put-conversation-meta",
+		Method:      http.MethodPut,
+		Path:        pathPrefix + "/{id}/meta",
+		Summary:     "Put conversation meta",
+		Description: "Set the tags, folder, pinned and starred flags of a conversation",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.PutConversationMeta)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-conversation-labels",
+		Method:      http.MethodGet,
+		Path:        pathPrefix + "/labels",
+		Summary:     "List conversation labels",
+		Description: "List the tags and folders in use with their conversation counts",
+		Tags:        []string{tag},
+	}, conversationStoreAPI.ListConversationLabels)
+}
+
+// withConflictStatus answers version conflicts of a versioned write with 409 instead of 500.
+func withConflictStatus[I, O any](
+	handler func(context.Context, *I) (*O, error),
+) func(context.Context, *I) (*O, error) {
+	return func(ctx context.Context, input *I) (*O, error) {
+		resp, err := handler(ctx, input)
+		if errors.Is(err, ErrVersionConflict) {
+			return nil, huma.Error409Conflict(err.Error())
+		}
+		return resp, err
+	}
 }
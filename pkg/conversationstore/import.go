@@ -0,0 +1,236 @@
+package conversationstore
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filenameprovider"
+)
+
+const (
+	exportConversationsFile = "conversations.json"
+	importedTitle           = "Imported conversation"
+)
+
+// ImportConversations imports the conversations of a ChatGPT or Claude.ai data export.
+// Conversations keep their original timestamps and get an ID derived from them and their
+// source ID, so importing the same export again skips the conversations it already imported.
+func (cc *ConversationCollection) ImportConversations(
+	ctx context.Context,
+	req *spec.ImportConversationsRequest,
+) (*spec.ImportConversationsResponse, error) {
+	if req == nil || req.Body == nil || req.Body.FilePath == "" {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	r, err := openExportFile(req.Body.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	body := &spec.ImportConversationsResponseBody{Failed: []spec.ImportFailure{}}
+	err = parseExport(r, req.Body.Source, req.Body.Branches, func(c *spec.Conversation) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(c.Messages) == 0 {
+			body.Skipped++
+			return nil
+		}
+		exists, err := cc.conversationExists(c.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			body.Skipped++
+			return nil
+		}
+		_, err = cc.PutConversation(ctx, &spec.PutConversationRequest{
+			ID: c.ID,
+			Body: &spec.PutConversationRequestBody{
+				Title:        c.Title,
+				CreatedAt:    c.CreatedAt,
+				ModifiedAt:   c.ModifiedAt,
+				Messages:     c.Messages,
+				ActiveLeafID: c.ActiveLeafID,
+				Model:        c.Model,
+			},
+		})
+		if err != nil {
+			body.Failed = append(body.Failed, spec.ImportFailure{
+				Title: c.Title,
+				Error: err.Error(),
+			})
+			return nil
+		}
+		body.Imported++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &spec.ImportConversationsResponse{Body: body}, nil
+}
+
+func (cc *ConversationCollection) conversationExists(id string) (bool, error) {
+	fn, err := cc.fp.Build(filenameprovider.FileInfo{ID: id})
+	if err != nil {
+		return false, err
+	}
+	files, err := filesWithID(cc.store, cc.pp.GetPartitionDir(fn), id)
+	if err != nil {
+		return false, err
+	}
+	return len(files) > 0, nil
+}
+
+// openExportFile opens a conversations.json file, or the one inside an export zip file.
+func openExportFile(filePath string) (io.ReadCloser, error) {
+	if !strings.EqualFold(path.Ext(filePath), ".zip") {
+		return os.Open(filePath)
+	}
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if path.Base(f.Name) != exportConversationsFile {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			zr.Close()
+			return nil, err
+		}
+		return &zipEntryReader{ReadCloser: rc, zr: zr}, nil
+	}
+	zr.Close()
+	return nil, fmt.Errorf("no %s in %s", exportConversationsFile, filePath)
+}
+
+type zipEntryReader struct {
+	io.ReadCloser
+	zr *zip.ReadCloser
+}
+
+func (z *zipEntryReader) Close() error {
+	return errors.Join(z.ReadCloser.Close(), z.zr.Close())
+}
+
+// parseExport reads the conversations of an export array one by one and calls fn for each.
+// An empty source is detected from the first conversation.
+func parseExport(
+	r io.Reader,
+	source spec.ImportSource,
+	branches bool,
+	fn func(c *spec.Conversation) error,
+) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return fmt.Errorf("invalid export: %w", err)
+	} else if tok != json.Delim('[') {
+		return errors.New("invalid export: expected an array of conversations")
+	}
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("invalid export: %w", err)
+		}
+		if source == "" {
+			var err error
+			if source, err = detectImportSource(raw); err != nil {
+				return err
+			}
+		}
+		var c *spec.Conversation
+		var err error
+		switch source {
+		case spec.ImportSourceChatGPT:
+			c, err = convertChatGPTConversation(raw, branches)
+		case spec.ImportSourceClaude:
+			c, err = convertClaudeConversation(raw, branches)
+		default:
+			return fmt.Errorf("unknown import source %q", source)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s export: %w", source, err)
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func detectImportSource(raw json.RawMessage) (spec.ImportSource, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return "", fmt.Errorf("invalid export: %w", err)
+	}
+	if _, ok := keys["mapping"]; ok {
+		return spec.ImportSourceChatGPT, nil
+	}
+	if _, ok := keys["chat_messages"]; ok {
+		return spec.ImportSourceClaude, nil
+	}
+	return "", errors.New("unknown export format, set the source")
+}
+
+// importedConversationID is a UUIDv7 with the creation time of the conversation and the rest
+// derived from its source ID, so that it is the same on every import.
+func importedConversationID(
+	source spec.ImportSource,
+	sourceID string,
+	createdAt time.Time,
+) string {
+	sum := sha256.Sum256([]byte(string(source) + ":" + sourceID))
+	var u uuid.UUID
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(createdAt.UnixMilli()))
+	copy(u[:6], ms[2:])
+	copy(u[6:], sum[:10])
+	u[6] = (u[6] & 0x0f) | 0x70
+	u[8] = (u[8] & 0x3f) | 0x80
+	return u.String()
+}
+
+// activeNodes returns the IDs on the path from leaf to its root.
+func activeNodes(leaf string, parentOf func(id string) (string, bool)) map[string]bool {
+	nodes := map[string]bool{}
+	for id := leaf; id != "" && !nodes[id]; {
+		nodes[id] = true
+		parent, ok := parentOf(id)
+		if !ok {
+			break
+		}
+		id = parent
+	}
+	return nodes
+}
+
+func importedTitleOr(title string) string {
+	if t := strings.TrimSpace(title); t != "" {
+		return t
+	}
+	return importedTitle
+}
+
+func fencedText(lang, text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence
+}
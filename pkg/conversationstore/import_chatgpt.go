@@ -0,0 +1,248 @@
+package conversationstore
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+type chatGPTConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     float64                `json:"create_time"`
+	UpdateTime     float64                `json:"update_time"`
+	Mapping        map[string]chatGPTNode `json:"mapping"`
+	CurrentNode    string                 `json:"current_node"`
+	// DefaultModelSlug is the model picked for the conversation.
+	DefaultModelSlug string `json:"default_model_slug"`
+}
+
+type chatGPTNode struct {
+	ID       string          `json:"id"`
+	Message  *chatGPTMessage `json:"message"`
+	Parent   *string         `json:"parent"`
+	Children []string        `json:"children"`
+}
+
+type chatGPTMessage struct {
+	Author struct {
+		Role string  `json:"role"`
+		Name *string `json:"name"`
+	} `json:"author"`
+	CreateTime *float64 `json:"create_time"`
+	Content    struct {
+		ContentType string `json:"content_type"`
+		Parts       []any  `json:"parts"`
+		Text        string `json:"text"`
+		Language    string `json:"language"`
+		Result      string `json:"result"`
+		Thoughts    []struct {
+			Summary string `json:"summary"`
+			Content string `json:"content"`
+		} `json:"thoughts"`
+	} `json:"content"`
+	Metadata struct {
+		IsVisuallyHidden bool   `json:"is_visually_hidden_from_conversation"`
+		ModelSlug        string `json:"model_slug"`
+	} `json:"metadata"`
+}
+
+// convertChatGPTConversation maps the message tree of a ChatGPT conversation. Hidden, empty
+// and tool internal nodes are dropped and their children moved to the nearest kept ancestor.
+// The thoughts of reasoning models become the reasoning of the reply that follows them.
+func convertChatGPTConversation(raw json.RawMessage, branches bool) (*spec.Conversation, error) {
+	var src chatGPTConversation
+	if err := json.Unmarshal(raw, &src); err != nil {
+		return nil, err
+	}
+	sourceID := src.ConversationID
+	if sourceID == "" {
+		sourceID = src.ID
+	}
+	if sourceID == "" {
+		sourceID = fmt.Sprintf("%s@%f", src.Title, src.CreateTime)
+	}
+	createdAt := unixSecondsTime(src.CreateTime)
+	c := &spec.Conversation{
+		ConversationItem: spec.ConversationItem{
+			ID:        importedConversationID(spec.ImportSourceChatGPT, sourceID, createdAt),
+			Title:     importedTitleOr(src.Title),
+			CreatedAt: createdAt,
+		},
+		ModifiedAt: unixSecondsTime(max(src.UpdateTime, src.CreateTime)),
+		Messages:   []spec.ConversationMessage{},
+	}
+	if src.DefaultModelSlug != "" {
+		c.Model = &spec.ConversationModel{Provider: "openai", Model: src.DefaultModelSlug}
+	}
+
+	parentOf := func(id string) (string, bool) {
+		n, ok := src.Mapping[id]
+		if !ok || n.Parent == nil {
+			return "", false
+		}
+		return *n.Parent, true
+	}
+	var active map[string]bool
+	if !branches {
+		active = activeNodes(src.CurrentNode, parentOf)
+	}
+
+	// keptAncestor maps every visited node to itself if it was kept, else to its kept ancestor.
+	keptAncestor := map[string]string{}
+	var visit func(id, parentID string, reasoning []spec.ReasoningContent)
+	visit = func(id, parentID string, reasoning []spec.ReasoningContent) {
+		node, ok := src.Mapping[id]
+		if _, visited := keptAncestor[id]; !ok || visited || (active != nil && !active[id]) {
+			return
+		}
+		keptAncestor[id] = parentID
+		if node.Message != nil {
+			if m, thoughts, ok := chatGPTMessageToConversation(id, node.Message); ok {
+				if m.Role == spec.ConversationRoleAssistant {
+					m.ReasoningContents, reasoning = reasoning, nil
+				}
+				if parentID != "" {
+					p := parentID
+					m.ParentID = &p
+				}
+				c.Messages = append(c.Messages, m)
+				keptAncestor[id], parentID = id, id
+			} else {
+				reasoning = append(reasoning, thoughts...)
+			}
+		}
+		for _, child := range node.Children {
+			visit(child, parentID, reasoning)
+		}
+	}
+	for _, id := range sortedRoots(src.Mapping) {
+		visit(id, "", nil)
+	}
+
+	// The active leaf is the current node or its nearest kept ancestor.
+	if leaf := keptAncestor[src.CurrentNode]; leaf != "" {
+		c.ActiveLeafID = leaf
+	}
+	return c, nil
+}
+
+// chatGPTMessageToConversation converts a message, messages that are not kept return false
+// with the reasoning they hold.
+func chatGPTMessageToConversation(
+	id string,
+	msg *chatGPTMessage,
+) (spec.ConversationMessage, []spec.ReasoningContent, bool) {
+	m := spec.ConversationMessage{ID: id}
+	if msg.CreateTime != nil {
+		t := unixSecondsTime(*msg.CreateTime)
+		m.CreatedAt = &t
+	}
+	content := msg.Content
+	var text string
+	switch content.ContentType {
+	case "thoughts":
+		reasoning := []spec.ReasoningContent{}
+		for _, th := range content.Thoughts {
+			text := strings.TrimSpace(strings.TrimSpace(th.Summary) + "\n\n" + th.Content)
+			reasoning = append(reasoning, spec.ReasoningContent{
+				Type: spec.ReasoningContentTypeThinking,
+				Text: text,
+			})
+		}
+		return m, reasoning, false
+	case "reasoning_recap", "user_editable_context", "model_editable_context":
+		return m, nil, false
+	case "code":
+		text = fencedText(content.Language, content.Text)
+	case "execution_output":
+		text = fencedText("", content.Text)
+	case "tether_browsing_display", "tether_quote":
+		text = content.Result
+		if text == "" {
+			text = content.Text
+		}
+	default:
+		text = chatGPTPartsText(content.Parts)
+		if text == "" {
+			text = content.Text
+		}
+	}
+	if msg.Metadata.IsVisuallyHidden || strings.TrimSpace(text) == "" {
+		return m, nil, false
+	}
+	m.Content = text
+
+	switch msg.Author.Role {
+	case "user":
+		m.Role = spec.ConversationRoleUser
+	case "assistant":
+		m.Role = spec.ConversationRoleAssistant
+		if msg.Metadata.ModelSlug != "" {
+			m.Provider = "openai"
+			m.Model = msg.Metadata.ModelSlug
+		}
+	case "system":
+		m.Role = spec.ConversationRoleSystem
+	case "tool":
+		m.Role = spec.ConversationRoleFunction
+		m.Name = msg.Author.Name
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// chatGPTPartsText joins the text parts of a message, other parts like images are noted.
+func chatGPTPartsText(parts []any) string {
+	texts := []string{}
+	for _, p := range parts {
+		switch part := p.(type) {
+		case string:
+			if part != "" {
+				texts = append(texts, part)
+			}
+		case map[string]any:
+			if t, ok := part["text"].(string); ok && t != "" {
+				texts = append(texts, t)
+			} else if ct, ok := part["content_type"].(string); ok {
+				texts = append(texts, "["+strings.ReplaceAll(ct, "_", " ")+"]")
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// sortedRoots returns the IDs of the nodes without a known parent, oldest first.
+func sortedRoots(mapping map[string]chatGPTNode) []string {
+	roots := []string{}
+	for id, node := range mapping {
+		if node.Parent == nil {
+			roots = append(roots, id)
+		} else if _, ok := mapping[*node.Parent]; !ok {
+			roots = append(roots, id)
+		}
+	}
+	createTime := func(id string) float64 {
+		if m := mapping[id].Message; m != nil && m.CreateTime != nil {
+			return *m.CreateTime
+		}
+		return 0
+	}
+	slices.SortFunc(roots, func(a, b string) int {
+		return cmp.Or(cmp.Compare(createTime(a), createTime(b)), strings.Compare(a, b))
+	})
+	return roots
+}
+
+func unixSecondsTime(sec float64) time.Time {
+	whole, frac := math.Modf(sec)
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC()
+}
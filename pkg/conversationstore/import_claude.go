@@ -0,0 +1,158 @@
+package conversationstore
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+// claudeRootMessageID is the parent of the first message in Claude.ai exports.
+const claudeRootMessageID = "00000000-0000-4000-8000-000000000000"
+
+type claudeConversation struct {
+	UUID         string          `json:"uuid"`
+	Name         string          `json:"name"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	ChatMessages []claudeMessage `json:"chat_messages"`
+}
+
+type claudeMessage struct {
+	UUID              string    `json:"uuid"`
+	Text              string    `json:"text"`
+	Sender            string    `json:"sender"`
+	CreatedAt         time.Time `json:"created_at"`
+	ParentMessageUUID string    `json:"parent_message_uuid"`
+	Content           []struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		Thinking string `json:"thinking"`
+		Name     string `json:"name"`
+	} `json:"content"`
+	Attachments []struct {
+		FileName         string `json:"file_name"`
+		ExtractedContent string `json:"extracted_content"`
+	} `json:"attachments"`
+	Files []struct {
+		FileName string `json:"file_name"`
+	} `json:"files"`
+}
+
+// convertClaudeConversation maps a Claude.ai conversation. Exports without parent message
+// IDs are a single branch, the last message is the active leaf.
+// The text of attachments is kept in the message details.
+func convertClaudeConversation(raw json.RawMessage, branches bool) (*spec.Conversation, error) {
+	var src claudeConversation
+	if err := json.Unmarshal(raw, &src); err != nil {
+		return nil, err
+	}
+	sourceID := src.UUID
+	if sourceID == "" {
+		sourceID = fmt.Sprintf("%s@%s", src.Name, src.CreatedAt.Format(time.RFC3339Nano))
+	}
+	c := &spec.Conversation{
+		ConversationItem: spec.ConversationItem{
+			ID:        importedConversationID(spec.ImportSourceClaude, sourceID, src.CreatedAt),
+			Title:     importedTitleOr(src.Name),
+			CreatedAt: src.CreatedAt.UTC(),
+		},
+		ModifiedAt: src.UpdatedAt.UTC(),
+		Messages:   []spec.ConversationMessage{},
+	}
+	if c.ModifiedAt.Before(c.CreatedAt) {
+		c.ModifiedAt = c.CreatedAt
+	}
+
+	// Parents are created before their children.
+	msgs := slices.Clone(src.ChatMessages)
+	slices.SortStableFunc(msgs, func(a, b claudeMessage) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	parents := make(map[string]string, len(msgs))
+	known := make(map[string]bool, len(msgs))
+	for _, m := range msgs {
+		known[m.UUID] = true
+	}
+	prevID := ""
+	for _, m := range msgs {
+		switch {
+		case m.ParentMessageUUID == claudeRootMessageID:
+		case known[m.ParentMessageUUID]:
+			parents[m.UUID] = m.ParentMessageUUID
+		case m.ParentMessageUUID == "" && prevID != "":
+			parents[m.UUID] = prevID
+		}
+		prevID = m.UUID
+	}
+
+	var active map[string]bool
+	if !branches && len(msgs) > 0 {
+		active = activeNodes(msgs[len(msgs)-1].UUID, func(id string) (string, bool) {
+			p, ok := parents[id]
+			return p, ok
+		})
+	}
+	for _, src := range msgs {
+		if src.UUID == "" || (active != nil && !active[src.UUID]) {
+			continue
+		}
+		m := claudeMessageToConversation(src)
+		if p, ok := parents[src.UUID]; ok {
+			m.ParentID = &p
+		}
+		c.Messages = append(c.Messages, m)
+		c.ActiveLeafID = m.ID
+	}
+	return c, nil
+}
+
+func claudeMessageToConversation(src claudeMessage) spec.ConversationMessage {
+	createdAt := src.CreatedAt.UTC()
+	m := spec.ConversationMessage{
+		ID:        src.UUID,
+		CreatedAt: &createdAt,
+		Role:      spec.ConversationRoleAssistant,
+	}
+	if src.Sender == "human" {
+		m.Role = spec.ConversationRoleUser
+	}
+
+	texts := []string{}
+	for _, block := range src.Content {
+		switch block.Type {
+		case "text":
+			if block.Text != "" {
+				texts = append(texts, block.Text)
+			}
+		case "thinking":
+			if block.Thinking != "" {
+				m.ReasoningContents = append(m.ReasoningContents, spec.ReasoningContent{
+					Type: spec.ReasoningContentTypeThinking,
+					Text: block.Thinking,
+				})
+			}
+		case "tool_use":
+			texts = append(texts, fmt.Sprintf("[used tool %s]", block.Name))
+		}
+	}
+	m.Content = cmp.Or(strings.Join(texts, "\n\n"), src.Text)
+
+	details := []string{}
+	for _, a := range src.Attachments {
+		details = append(details, fmt.Sprintf("Attachment %s:\n\n%s",
+			a.FileName, fencedText("", a.ExtractedContent)))
+	}
+	for _, f := range src.Files {
+		details = append(details, "File "+f.FileName)
+	}
+	if len(details) > 0 {
+		d := strings.Join(details, "\n\n")
+		m.Details = &d
+	}
+	return m
+}
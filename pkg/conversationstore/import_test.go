@@ -0,0 +1,272 @@
+package conversationstore
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filenameprovider"
+)
+
+// chatGPTExport has a hidden system node, thoughts before the first reply and an edited
+// user message, the edit is the current branch.
+const chatGPTExport = `[{
+  "title": "Sorting help",
+  "create_time": 1717200000.5,
+  "update_time": 1717200900,
+  "conversation_id": "cg-1",
+  "current_node": "a2",
+  "default_model_slug": "gpt-4o",
+  "mapping": {
+    "root": {"id": "root", "message": null, "parent": null, "children": ["sys"]},
+    "sys": {"id": "sys", "parent": "root", "children": ["u1", "u2"], "message": {
+      "author": {"role": "system"}, "create_time": null,
+      "content": {"content_type": "text", "parts": [""]},
+      "metadata": {"is_visually_hidden_from_conversation": true}}},
+    "u1": {"id": "u1", "parent": "sys", "children": ["th"], "message": {
+      "author": {"role": "user"}, "create_time": 1717200001,
+      "content": {"content_type": "text", "parts": ["How do I sort?"]}, "metadata": {}}},
+    "th": {"id": "th", "parent": "u1", "children": ["a1"], "message": {
+      "author": {"role": "assistant"}, "create_time": 1717200002,
+      "content": {"content_type": "thoughts", "thoughts": [{"summary": "Stdlib", "content": "Use slices."}]},
+      "metadata": {}}},
+    "a1": {"id": "a1", "parent": "th", "children": [], "message": {
+      "author": {"role": "assistant"}, "create_time": 1717200003,
+      "content": {"content_type": "text", "parts": ["Use slices.Sort."]}, "metadata": {}}},
+    "u2": {"id": "u2", "parent": "sys", "children": ["a2"], "message": {
+      "author": {"role": "user"}, "create_time": 1717200010,
+      "content": {"content_type": "text", "parts": ["How do I sort in Go?"]}, "metadata": {}}},
+    "a2": {"id": "a2", "parent": "u2", "children": [], "message": {
+      "author": {"role": "assistant"}, "create_time": 1717200011,
+      "content": {"content_type": "code", "language": "go", "text": "slices.Sort(x)"},
+      "metadata": {"model_slug": "gpt-4o"}}}
+  }
+}]`
+
+const claudeExport = `[{
+  "uuid": "cl-1",
+  "name": "",
+  "created_at": "2024-03-10T08:00:00.000000Z",
+  "updated_at": "2024-03-10T08:05:00.000000Z",
+  "chat_messages": [
+    {"uuid": "m2", "sender": "assistant", "created_at": "2024-03-10T08:00:05Z",
+     "parent_message_uuid": "m1", "text": "",
+     "content": [{"type": "thinking", "thinking": "Short answer."}, {"type": "text", "text": "Paris."}]},
+    {"uuid": "m1", "sender": "human", "created_at": "2024-03-10T08:00:01Z",
+     "parent_message_uuid": "00000000-0000-4000-8000-000000000000", "text": "Capital of France?",
+     "attachments": [{"file_name": "notes.txt", "extracted_content": "France facts"}]}
+  ]
+}]`
+
+func writeExportFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return p
+}
+
+func importExport(
+	t *testing.T,
+	cc *ConversationCollection,
+	body *spec.ImportConversationsRequestBody,
+) *spec.ImportConversationsResponseBody {
+	t.Helper()
+	resp, err := cc.ImportConversations(t.Context(), &spec.ImportConversationsRequest{Body: body})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	return resp.Body
+}
+
+func getImported(t *testing.T, cc *ConversationCollection, tree bool) *spec.Conversation {
+	t.Helper()
+	list, err := cc.ListConversations(t.Context(), &spec.ListConversationsRequest{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.Body.ConversationItems) != 1 {
+		t.Fatalf("want 1 conversation, got %d", len(list.Body.ConversationItems))
+	}
+	item := list.Body.ConversationItems[0]
+	resp, err := cc.GetConversation(t.Context(), &spec.GetConversationRequest{
+		ID:    item.ID,
+		Title: item.Title,
+		Tree:  tree,
+	})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	return resp.Body
+}
+
+func messageIDs(msgs []spec.ConversationMessage) []string {
+	ids := []string{}
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestImportChatGPTFlattened(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), true)
+	p := writeExportFile(t, "conversations.json", chatGPTExport)
+
+	res := importExport(t, cc, &spec.ImportConversationsRequestBody{FilePath: p})
+	if res.Imported != 1 || res.Skipped != 0 || len(res.Failed) != 0 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	c := getImported(t, cc, true)
+	if got := messageIDs(c.Messages); len(got) != 2 || got[0] != "u2" || got[1] != "a2" {
+		t.Fatalf("want the current branch only, got %v", got)
+	}
+	if c.Messages[0].ParentID != nil {
+		t.Errorf("hidden system node should be dropped, parent %v", *c.Messages[0].ParentID)
+	}
+	if c.Messages[1].Content != "```go\nslices.Sort(x)\n```" {
+		t.Errorf("code not fenced: %q", c.Messages[1].Content)
+	}
+	if c.Messages[1].Provider != "openai" || c.Messages[1].Model != "gpt-4o" {
+		t.Errorf("model not kept: %q %q", c.Messages[1].Provider, c.Messages[1].Model)
+	}
+	if c.Model == nil || c.Model.Provider != "openai" || c.Model.Model != "gpt-4o" {
+		t.Errorf("conversation model not kept: %+v", c.Model)
+	}
+	if want := time.Unix(1717200000, 5e8).UTC(); !c.CreatedAt.Equal(want) {
+		t.Errorf("created at %v, want %v", c.CreatedAt, want)
+	}
+	uuidTime, err := filenameprovider.ExtractTimeFromUUIDv7(c.ID)
+	if err != nil || uuidTime.UnixMilli() != c.CreatedAt.UnixMilli() {
+		t.Errorf("id %s does not carry the creation time: %v", c.ID, err)
+	}
+}
+
+func TestImportChatGPTBranches(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), false)
+	p := writeExportFile(t, "conversations.json", chatGPTExport)
+
+	importExport(t, cc, &spec.ImportConversationsRequestBody{
+		Source:   spec.ImportSourceChatGPT,
+		FilePath: p,
+		Branches: true,
+	})
+	c := getImported(t, cc, true)
+	if len(c.Messages) != 4 || c.ActiveLeafID != "a2" {
+		t.Fatalf(
+			"want 4 messages with leaf a2, got %v leaf %q",
+			messageIDs(c.Messages),
+			c.ActiveLeafID,
+		)
+	}
+	for _, m := range c.Messages {
+		if m.ID != "a1" {
+			continue
+		}
+		if m.ParentID == nil || *m.ParentID != "u1" {
+			t.Errorf("a1 should follow u1 once the thoughts node is dropped")
+		}
+		if len(m.ReasoningContents) != 1 || m.ReasoningContents[0].Text != "Stdlib\n\nUse slices." {
+			t.Errorf("thoughts not kept as reasoning: %+v", m.ReasoningContents)
+		}
+	}
+
+	active := getImported(t, cc, false)
+	if got := messageIDs(active.Messages); len(got) != 2 || got[1] != "a2" {
+		t.Errorf("active path %v", got)
+	}
+}
+
+func TestImportClaudeZip(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), true)
+	p := filepath.Join(t.TempDir(), "export.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("data/conversations.json")
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	if _, err := w.Write([]byte(claudeExport)); err != nil {
+		t.Fatalf("zip write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+	f.Close()
+
+	if res := importExport(t, cc, &spec.ImportConversationsRequestBody{FilePath: p}); res.Imported != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	c := getImported(t, cc, true)
+	if c.Title != importedTitle {
+		t.Errorf("title %q", c.Title)
+	}
+	if got := messageIDs(c.Messages); len(got) != 2 || got[0] != "m1" || got[1] != "m2" {
+		t.Fatalf("messages %v", got)
+	}
+	user, reply := c.Messages[0], c.Messages[1]
+	if user.Role != spec.ConversationRoleUser || user.Details == nil {
+		t.Errorf("user message %+v", user)
+	}
+	if reply.Content != "Paris." || len(reply.ReasoningContents) != 1 {
+		t.Errorf("reply %+v", reply)
+	}
+
+	search, err := cc.SearchConversations(t.Context(), &spec.SearchConversationsRequest{
+		Query: "paris",
+	})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(search.Body.ConversationItems) != 1 {
+		t.Errorf("imported conversation not indexed")
+	}
+}
+
+func TestImportIsIdempotent(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), false)
+	p := writeExportFile(t, "conversations.json", chatGPTExport)
+
+	importExport(t, cc, &spec.ImportConversationsRequestBody{FilePath: p})
+	res := importExport(t, cc, &spec.ImportConversationsRequestBody{FilePath: p, Branches: true})
+	if res.Imported != 0 || res.Skipped != 1 {
+		t.Fatalf("second import should skip, got %+v", res)
+	}
+	if c := getImported(t, cc, true); len(c.Messages) != 2 {
+		t.Errorf("existing conversation changed: %v", messageIDs(c.Messages))
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	cc := newCollection(t, t.TempDir(), false)
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not an array", `{"title": "x"}`},
+		{"unknown format", `[{"title": "x"}]`},
+		{"zip without conversations", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name := "conversations.json"
+			if tc.content == "" {
+				name = "export.zip"
+			}
+			p := writeExportFile(t, name, tc.content)
+			_, err := cc.ImportConversations(t.Context(), &spec.ImportConversationsRequest{
+				Body: &spec.ImportConversationsRequestBody{FilePath: p},
+			})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package conversationstore
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+var (
+	ErrMessageNotFound    = errors.New("message not found")
+	ErrInvalidMessageTree = errors.New("invalid message tree")
+)
+
+// normalizeTree checks that every parent comes before its children and sets the active leaf.
+// A conversation without an active leaf was stored as a flat list before branching existed,
+// its messages without a parent are chained to the message before them.
+func normalizeTree(c *spec.Conversation) error {
+	legacy := c.ActiveLeafID == ""
+	seen := make(map[string]bool, len(c.Messages))
+	for i := range c.Messages {
+		m := &c.Messages[i]
+		if m.ID == "" {
+			m.ID = newMessageID()
+		}
+		if seen[m.ID] {
+			return fmt.Errorf("%w: duplicate message %s", ErrInvalidMessageTree, m.ID)
+		}
+		if m.ParentID == nil && legacy && i > 0 {
+			prevID := c.Messages[i-1].ID
+			m.ParentID = &prevID
+		}
+		if m.ParentID != nil && !seen[*m.ParentID] {
+			return fmt.Errorf(
+				"%w: parent %s of message %s must come before it",
+				ErrInvalidMessageTree, *m.ParentID, m.ID,
+			)
+		}
+		seen[m.ID] = true
+	}
+	if !seen[c.ActiveLeafID] {
+		c.ActiveLeafID = ""
+		if len(c.Messages) > 0 {
+			c.ActiveLeafID = c.Messages[len(c.Messages)-1].ID
+		}
+	}
+	return nil
+}
+
+// activePath returns the messages from the root to the active leaf.
+func activePath(c *spec.Conversation) []spec.ConversationMessage {
+	byID := make(map[string]int, len(c.Messages))
+	for i, m := range c.Messages {
+		byID[m.ID] = i
+	}
+	path := []spec.ConversationMessage{}
+	id := c.ActiveLeafID
+	for id != "" {
+		idx, ok := byID[id]
+		if !ok {
+			break
+		}
+		m := c.Messages[idx]
+		path = append(path, m)
+		id = ""
+		if m.ParentID != nil {
+			id = *m.ParentID
+		}
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// latestLeaf follows the most recently added child from id down to a leaf.
+func latestLeaf(c *spec.Conversation, id string) string {
+	lastChild := map[string]string{}
+	for _, m := range c.Messages {
+		if m.ParentID != nil {
+			lastChild[*m.ParentID] = m.ID
+		}
+	}
+	for {
+		child, ok := lastChild[id]
+		if !ok {
+			return id
+		}
+		id = child
+	}
+}
+
+func findMessage(c *spec.Conversation, id string) (int, error) {
+	for i, m := range c.Messages {
+		if m.ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+}
+
+// mergePath makes msgs the active path. Known messages are updated in place and keep their
+// parent, new messages without a parent follow the message before them in msgs.
+// Messages of the tree that are not in msgs are kept as other branches.
+func mergePath(c *spec.Conversation, msgs []spec.ConversationMessage) error {
+	for i, m := range msgs {
+		if m.ID == "" {
+			m.ID = newMessageID()
+		}
+		if idx, err := findMessage(c, m.ID); err == nil {
+			stored := c.Messages[idx]
+			if m.ParentID == nil {
+				m.ParentID = stored.ParentID
+			} else if stored.ParentID == nil || *stored.ParentID != *m.ParentID {
+				return fmt.Errorf(
+					"%w: parent of message %s cannot change",
+					ErrInvalidMessageTree, m.ID,
+				)
+			}
+			c.Messages[idx] = m
+		} else {
+			if m.ParentID == nil && i > 0 {
+				prevID := msgs[i-1].ID
+				m.ParentID = &prevID
+			}
+			if err := appendMessage(c, m); err != nil {
+				return err
+			}
+		}
+		msgs[i] = m
+		c.ActiveLeafID = m.ID
+	}
+	return nil
+}
+
+// checkUniqueIDs rejects msgs that contain a message ID more than once.
+func checkUniqueIDs(msgs []spec.ConversationMessage) error {
+	seen := make(map[string]bool, len(msgs))
+	for _, m := range msgs {
+		if m.ID == "" {
+			continue
+		}
+		if seen[m.ID] {
+			return fmt.Errorf("%w: duplicate message %s", ErrInvalidMessageTree, m.ID)
+		}
+		seen[m.ID] = true
+	}
+	return nil
+}
+
+// mergeTree adds msgs, messages of another copy of the tree with their parents set.
+// Known messages are updated in place and must keep their parent, messages of the tree that
+// are not in msgs are kept.
+func mergeTree(c *spec.Conversation, msgs []spec.ConversationMessage) error {
+	for _, m := range msgs {
+		if m.ID == "" {
+			m.ID = newMessageID()
+		}
+		idx, err := findMessage(c, m.ID)
+		if err != nil {
+			if err := appendMessage(c, m); err != nil {
+				return err
+			}
+			continue
+		}
+		stored := c.Messages[idx].ParentID
+		if (stored == nil) != (m.ParentID == nil) || (stored != nil && *stored != *m.ParentID) {
+			return fmt.Errorf("%w: parent of message %s cannot change", ErrInvalidMessageTree, m.ID)
+		}
+		c.Messages[idx] = m
+	}
+	return nil
+}
+
+// addBranch adds msgs as a new branch next to the message siblingOf and makes it active.
+// The first message gets the parent of siblingOf, the rest follow the message before them.
+func addBranch(c *spec.Conversation, siblingOf string, msgs []spec.ConversationMessage) error {
+	idx, err := findMessage(c, siblingOf)
+	if err != nil {
+		return err
+	}
+	parentID := c.Messages[idx].ParentID
+	for _, m := range msgs {
+		if m.ID == "" {
+			m.ID = newMessageID()
+		}
+		if _, err := findMessage(c, m.ID); err == nil {
+			return fmt.Errorf("%w: duplicate message %s", ErrInvalidMessageTree, m.ID)
+		}
+		m.ParentID = parentID
+		if err := appendMessage(c, m); err != nil {
+			return err
+		}
+		c.ActiveLeafID = m.ID
+		id := m.ID
+		parentID = &id
+	}
+	return nil
+}
+
+// appendToLeaf adds msgs after the active leaf and makes the last one active.
+// A message without a parent follows the message before it.
+func appendToLeaf(
+	c *spec.Conversation,
+	msgs []spec.ConversationMessage,
+) ([]spec.ConversationMessage, error) {
+	appended := make([]spec.ConversationMessage, 0, len(msgs))
+	for _, m := range msgs {
+		if m.ID == "" {
+			m.ID = newMessageID()
+		}
+		if _, err := findMessage(c, m.ID); err == nil {
+			return nil, fmt.Errorf("%w: duplicate message %s", ErrInvalidMessageTree, m.ID)
+		}
+		if m.ParentID == nil && c.ActiveLeafID != "" {
+			leafID := c.ActiveLeafID
+			m.ParentID = &leafID
+		}
+		if err := appendMessage(c, m); err != nil {
+			return nil, err
+		}
+		c.ActiveLeafID = m.ID
+		appended = append(appended, m)
+	}
+	return appended, nil
+}
+
+// deleteMessage removes a message and the replies below it. If the active leaf is removed,
+// the latest branch of the parent becomes active.
+func deleteMessage(c *spec.Conversation, id string) error {
+	idx, err := findMessage(c, id)
+	if err != nil {
+		return err
+	}
+	parentID := c.Messages[idx].ParentID
+	removed := map[string]bool{id: true}
+	// Replies come after their parents, so one pass finds all of them.
+	for _, m := range c.Messages[idx+1:] {
+		if m.ParentID != nil && removed[*m.ParentID] {
+			removed[m.ID] = true
+		}
+	}
+	c.Messages = slices.DeleteFunc(c.Messages, func(m spec.ConversationMessage) bool {
+		return removed[m.ID]
+	})
+	if !removed[c.ActiveLeafID] {
+		return nil
+	}
+	switch {
+	case parentID != nil:
+		c.ActiveLeafID = latestLeaf(c, *parentID)
+	case len(c.Messages) > 0:
+		// An empty active leaf would read the tree as a legacy flat list.
+		c.ActiveLeafID = c.Messages[len(c.Messages)-1].ID
+	default:
+		c.ActiveLeafID = ""
+	}
+	return nil
+}
+
+func appendMessage(c *spec.Conversation, m spec.ConversationMessage) error {
+	if m.ParentID != nil {
+		if _, err := findMessage(c, *m.ParentID); err != nil {
+			return fmt.Errorf("%w: parent of message %s: %w", ErrInvalidMessageTree, m.ID, err)
+		}
+	}
+	c.Messages = append(c.Messages, m)
+	return nil
+}
+
+func newMessageID() string {
+	if u, err := uuid.NewV7(); err == nil {
+		return u.String()
+	}
+	return uuid.NewString()
+}
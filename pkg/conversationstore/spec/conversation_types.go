@@ -2,6 +2,8 @@ package spec
 
 import (
 	"time"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
 )
 
 // ConversationRoleEnum represents the role of a participant in a conversation.
@@ -15,15 +17,69 @@ const (
 	ConversationRoleFeedback  ConversationRoleEnum = "feedback"
 )
 
+type ReasoningContentType string
+
+const (
+	ReasoningContentTypeThinking         ReasoningContentType = "thinking"
+	ReasoningContentTypeRedactedThinking ReasoningContentType = "redactedThinking"
+)
+
+// ReasoningContent is a block of model reasoning of an assistant message.
+// Signature and Data are opaque provider values that are needed to send the block back to the model.
+type ReasoningContent struct {
+	Type      ReasoningContentType `json:"type"`
+	Text      string               `json:"text,omitempty"`
+	Signature string               `json:"signature,omitempty"`
+	Data      string               `json:"data,omitempty"`
+}
+
 // ConversationMessage represents a message in a conversation.
+// Messages form a tree through ParentID, a message without a parent is a root.
+// Siblings are alternative branches, e.g. an edited prompt, a regenerated reply or
+// the replies from a multi model fan-out to the same user message.
 type ConversationMessage struct {
 	ID        string               `json:"id"`
+	ParentID  *string              `json:"parentID,omitempty"`
 	CreatedAt *time.Time           `json:"createdAt,omitempty"`
 	Role      ConversationRoleEnum `json:"role"`
 	Content   string               `json:"content"`
 	Timestamp *string              `json:"timestamp,omitempty"`
 	Name      *string              `json:"name,omitempty"`
 	Details   *string              `json:"details,omitempty"`
+	// ReasoningContents is kept apart from Content so that it can be hidden and is not replayed as text.
+	ReasoningContents []ReasoningContent `json:"reasoningContents,omitempty"`
+	// Provider and Model produced an assistant message.
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// The fields below record how an assistant message was produced, they are empty if unknown.
+	// ModelParams are the effective params of the request, after defaults and limits were applied.
+	ModelParams *aiproviderSpec.ModelParams `json:"modelParams,omitempty"`
+	PresetID    string                      `json:"presetID,omitempty"`
+	Usage       *MessageUsage               `json:"usage,omitempty"`
+	// Cost is in USD from the catalog prices of the model.
+	Cost               *float64 `json:"cost,omitempty"`
+	LatencyMs          *int64   `json:"latencyMs,omitempty"`
+	TimeToFirstTokenMs *int64   `json:"timeToFirstTokenMs,omitempty"`
+	FinishReason       string   `json:"finishReason,omitempty"`
+	// RequestID is the ID the provider gave the request.
+	RequestID string `json:"requestID,omitempty"`
+}
+
+// MessageUsage is the token usage reported by the provider for an assistant message.
+type MessageUsage struct {
+	InputTokens      int `json:"inputTokens"`
+	OutputTokens     int `json:"outputTokens"`
+	ReasoningTokens  int `json:"reasoningTokens,omitempty"`
+	CacheReadTokens  int `json:"cacheReadTokens,omitempty"`
+	CacheWriteTokens int `json:"cacheWriteTokens,omitempty"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// ConversationModel is the model setup a conversation continues with when it is reopened.
+type ConversationModel struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	PresetID string `json:"presetID,omitempty"`
 }
 
 // ConversationItem represents a conversation with basic details.
@@ -31,11 +87,110 @@ type ConversationItem struct {
 	ID        string    `json:"id"`
 	Title     string    `json:"title"`
 	CreatedAt time.Time `json:"createdAt"`
+	ConversationMeta
+}
+
+// ConversationMeta organises conversations. It is stored apart from the conversation files,
+// so changing it does not rewrite the messages.
+type ConversationMeta struct {
+	Tags []string `json:"tags,omitempty"`
+	// Folder is the single folder or project of the conversation, empty for none.
+	Folder  string `json:"folder,omitempty"`
+	Pinned  bool   `json:"pinned,omitempty"`
+	Starred bool   `json:"starred,omitempty"`
+}
+
+// ConversationFilter selects conversations by their meta, unset fields match all.
+type ConversationFilter struct {
+	// Tags must all be set on a conversation.
+	Tags    []string `query:"tags"`
+	Folder  string   `query:"folder"`
+	Pinned  bool     `query:"pinned"`
+	Starred bool     `query:"starred"`
+}
+
+type SearchMode string
+
+const (
+	// SearchModeAny matches conversations with any of the words of the query.
+	SearchModeAny SearchMode = "any"
+	// SearchModeAll matches conversations with all of the words of the query.
+	SearchModeAll SearchMode = "all"
+	// SearchModePhrase matches conversations with the words of the query in order.
+	SearchModePhrase SearchMode = "phrase"
+)
+
+// ConversationSearchHit is a conversation that matched a search.
+type ConversationSearchHit struct {
+	ConversationItem
+	// Snippet is the best matching text of the conversation.
+	Snippet []SnippetPart `json:"snippet,omitempty"`
+	// MatchingMessageIDs are the messages with the query words, in conversation order.
+	// Words are compared without the search index stemming, so a hit can have none.
+	MatchingMessageIDs []string `json:"matchingMessageIDs,omitempty"`
+}
+
+// SnippetPart is a piece of a snippet, Match is set for the query words.
+type SnippetPart struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+// LabelCount is a tag or folder and the number of conversations that have it.
+type LabelCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
 }
 
 // Conversation represents a conversation with messages.
+// Stored conversations hold the whole message tree with parents before their children.
+// Conversations returned by GetConversation hold only the active path unless the tree is requested.
 type Conversation struct {
 	ConversationItem
 	ModifiedAt time.Time             `json:"modifiedAt"`
 	Messages   []ConversationMessage `json:"messages"`
+	// ActiveLeafID is the last message of the active path.
+	ActiveLeafID string `json:"activeLeafID,omitempty"`
+	// Model is the default model of the conversation, nil to use the app default.
+	Model *ConversationModel `json:"model,omitempty"`
+	// Version is increased by every write. Writes that name an older version are rejected,
+	// so concurrent writers do not overwrite each other.
+	Version int64 `json:"version"`
+}
+
+// TrashItem is a deleted conversation that can still be restored.
+type TrashItem struct {
+	ConversationItem
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+type ExportFormat string
+
+const (
+	// ExportFormatMarkdown is a Markdown document with YAML front matter.
+	ExportFormatMarkdown ExportFormat = "markdown"
+	// ExportFormatHTML is a self-contained HTML page, math is left in place for the reader.
+	ExportFormatHTML ExportFormat = "html"
+	// ExportFormatJSONL has one line per conversation in the OpenAI fine-tuning chat format.
+	ExportFormatJSONL ExportFormat = "jsonl"
+)
+
+// ConversationRef identifies a conversation, the title is needed to locate its file.
+type ConversationRef struct {
+	ID    string `json:"id"    required:"true"`
+	Title string `json:"title" required:"true"`
+}
+
+type ImportSource string
+
+const (
+	// ImportSourceChatGPT is the conversations.json of a ChatGPT data export.
+	ImportSourceChatGPT ImportSource = "chatgpt"
+	// ImportSourceClaude is the conversations.json of a Claude.ai data export.
+	ImportSourceClaude ImportSource = "claude"
+)
+
+type ImportFailure struct {
+	Title string `json:"title"`
+	Error string `json:"error"`
 }
@@ -8,25 +8,110 @@ type PutConversationRequest struct {
 }
 
 type PutConversationRequestBody struct {
-	Title      string                `json:"title"      required:"true"`
-	CreatedAt  time.Time             `json:"createdAt"  required:"true"`
-	ModifiedAt time.Time             `json:"modifiedAt" required:"true"`
-	Messages   []ConversationMessage `json:"messages"   required:"true"`
+	Title      string                `json:"title"                  required:"true"`
+	CreatedAt  time.Time             `json:"createdAt"              required:"true"`
+	ModifiedAt time.Time             `json:"modifiedAt"             required:"true"`
+	Messages   []ConversationMessage `json:"messages"               required:"true"`
+	// ActiveLeafID defaults to the last message. Messages without it are read as a flat list,
+	// a message without a parent follows the message before it.
+	// Messages of an existing conversation are merged into it and its other branches are kept.
+	ActiveLeafID string             `json:"activeLeafID,omitempty"`
+	Model        *ConversationModel `json:"model,omitempty"`
+	// Version is the version the conversation is expected to have, 0 for a new one.
+	// Nil writes without the check.
+	Version *int64 `json:"version,omitempty"`
 }
 
-type PutConversationResponse struct{}
+type PutConversationResponse struct {
+	Body *ConversationVersionBody
+}
+
+// ConversationVersionBody is the version of a conversation after a write.
+type ConversationVersionBody struct {
+	Version int64 `json:"version"`
+}
 
 type PutMessagesToConversationRequest struct {
 	ID   string `path:"id" required:"true"`
 	Body *PutMessagesToConversationRequestBody
 }
 
+// PutMessagesToConversationRequestBody sets the active path of a conversation.
+// Messages already in the conversation are updated, new messages without a parent follow
+// the message before them. Branches that are not in Messages are kept.
 type PutMessagesToConversationRequestBody struct {
-	Title    string                `json:"title"    required:"true"`
-	Messages []ConversationMessage `json:"messages" required:"true"`
+	Title    string                `json:"title"             required:"true"`
+	Messages []ConversationMessage `json:"messages"          required:"true"`
+	// Model replaces the default model of the conversation, nil keeps it.
+	Model *ConversationModel `json:"model,omitempty"`
+	// Version is the version the conversation is expected to have, nil writes without the check.
+	Version *int64 `json:"version,omitempty"`
+}
+
+type PutMessagesToConversationResponse struct {
+	Body *ConversationVersionBody
 }
 
-type PutMessagesToConversationResponse struct{}
+type AppendMessagesRequest struct {
+	ID   string `path:"id" required:"true"`
+	Body *AppendMessagesRequestBody
+}
+
+// AppendMessagesRequestBody adds messages after the active leaf and makes the last one active.
+// A message without a parent follows the message before it.
+type AppendMessagesRequestBody struct {
+	Title    string                `json:"title"           required:"true"`
+	Version  int64                 `json:"version"         required:"true"`
+	Messages []ConversationMessage `json:"messages"        required:"true"`
+	// Model replaces the default model of the conversation, nil keeps it.
+	Model *ConversationModel `json:"model,omitempty"`
+}
+
+type AppendMessagesResponse struct {
+	Body *AppendMessagesResponseBody
+}
+
+type AppendMessagesResponseBody struct {
+	Version int64 `json:"version"`
+	// Messages are the appended messages with their IDs and parents.
+	Messages []ConversationMessage `json:"messages"`
+}
+
+type UpdateMessageRequest struct {
+	ID        string `path:"id"        required:"true"`
+	MessageID string `path:"messageID" required:"true"`
+	Body      *UpdateMessageRequestBody
+}
+
+// UpdateMessageRequestBody replaces a message, it keeps its ID and parent.
+type UpdateMessageRequestBody struct {
+	Title   string              `json:"title"   required:"true"`
+	Version int64               `json:"version" required:"true"`
+	Message ConversationMessage `json:"message" required:"true"`
+}
+
+type UpdateMessageResponse struct {
+	Body *ConversationVersionBody
+}
+
+// DeleteMessageRequest deletes a message and the replies below it.
+type DeleteMessageRequest struct {
+	ID        string `path:"id"        required:"true"`
+	MessageID string `path:"messageID" required:"true"`
+	Title     string `                 required:"true" query:"title"`
+	Version   int64  `                 required:"true" query:"version"`
+}
+
+type DeleteMessageResponse struct {
+	Body *DeleteMessageResponseBody
+}
+
+type DeleteMessageResponseBody struct {
+	Version int64 `json:"version"`
+	// ActiveLeafID is the active leaf after the delete, the parent's latest branch if the
+	// active path went through the deleted message.
+	ActiveLeafID string `json:"activeLeafID,omitempty"`
+}
 
 type DeleteConversationRequest struct {
 	ID    string `path:"id" required:"true"`
@@ -38,14 +123,107 @@ type DeleteConversationResponse struct{}
 type GetConversationRequest struct {
 	ID    string `path:"id" required:"true"`
 	Title string `          required:"true" query:"title"`
+	// Tree returns all messages instead of the active path.
+	Tree bool `                          query:"tree"`
 }
 
 type GetConversationResponse struct {
 	Body *Conversation
 }
 
+type ForkConversationRequest struct {
+	ID        string `path:"id"        required:"true"`
+	MessageID string `path:"messageID" required:"true"`
+	Body      *ForkConversationRequestBody
+}
+
+// ForkConversationRequestBody holds the messages of a new branch next to MessageID,
+// e.g. an edited prompt followed by its reply.
+type ForkConversationRequestBody struct {
+	Title    string                `json:"title"    required:"true"`
+	Version  int64                 `json:"version"  required:"true"`
+	Messages []ConversationMessage `json:"messages" required:"true"`
+}
+
+type ForkConversationResponse struct {
+	Body *Conversation
+}
+
+type RegenerateMessageRequest struct {
+	ID        string `path:"id"        required:"true"`
+	MessageID string `path:"messageID" required:"true"`
+	Body      *RegenerateMessageRequestBody
+}
+
+// RegenerateMessageRequestBody holds a new assistant reply that is added next to MessageID.
+type RegenerateMessageRequestBody struct {
+	Title   string              `json:"title"   required:"true"`
+	Version int64               `json:"version" required:"true"`
+	Message ConversationMessage `json:"message" required:"true"`
+}
+
+type RegenerateMessageResponse struct {
+	Body *Conversation
+}
+
+type SwitchBranchRequest struct {
+	ID   string `path:"id" required:"true"`
+	Body *SwitchBranchRequestBody
+}
+
+// SwitchBranchRequestBody selects the branch of MessageID,
+// the active leaf becomes the latest message below it.
+type SwitchBranchRequestBody struct {
+	Title     string `json:"title"     required:"true"`
+	Version   int64  `json:"version"   required:"true"`
+	MessageID string `json:"messageID" required:"true"`
+}
+
+type SwitchBranchResponse struct {
+	Body *Conversation
+}
+
+type RenameConversationRequest struct {
+	ID   string `path:"id" required:"true"`
+	Body *RenameConversationRequestBody
+}
+
+type RenameConversationRequestBody struct {
+	Title    string `json:"title"    required:"true"`
+	NewTitle string `json:"newTitle" required:"true"`
+}
+
+type RenameConversationResponse struct {
+	Body *ConversationVersionBody
+}
+
+type GenerateConversationTitleRequest struct {
+	ID   string `path:"id" required:"true"`
+	Body *GenerateConversationTitleRequestBody
+}
+
+type GenerateConversationTitleRequestBody struct {
+	Title string `json:"title"                 required:"true"`
+	// SuggestOnly returns the title without renaming the conversation, e.g. for callers that
+	// order the rename with their other writes.
+	SuggestOnly bool `json:"suggestOnly,omitempty"`
+}
+
+type GenerateConversationTitleResponse struct {
+	Body *GenerateConversationTitleResponseBody
+}
+
+type GenerateConversationTitleResponseBody struct {
+	// Title is the new title of the conversation.
+	Title string `json:"title"`
+	// Version is the version after the rename, 0 for a suggested title.
+	Version int64 `json:"version"`
+}
+
+// ListConversationsRequest lists pinned conversations first. A filtered list is not paged.
 type ListConversationsRequest struct {
 	Token string `query:"token"`
+	ConversationFilter
 }
 
 type ListConversationsResponse struct {
@@ -58,10 +236,23 @@ type ListConversationsResponseBody struct {
 }
 
 type SearchConversationsRequest struct {
-	Query string `query:"query"    required:"true"`
+	Query string `query:"query"       required:"true"`
 	Token string `query:"token"`
 	// Default is 10.
 	PageSize int `query:"pageSize"`
+	// Mode defaults to any.
+	Mode SearchMode `query:"mode"                        enum:"any,all,phrase"`
+	// Roles limits the search to the messages of these roles, the title is searched if empty.
+	Roles []ConversationRoleEnum `query:"roles"                       enum:"system,user,assistant,function,feedback"`
+	// CreatedFrom and CreatedTo keep the conversations created in [CreatedFrom, CreatedTo),
+	// the zero time leaves either open.
+	CreatedFrom time.Time `query:"createdFrom"`
+	CreatedTo   time.Time `query:"createdTo"`
+	// Provider and Model keep the conversations with a message from them.
+	Provider string `query:"provider"`
+	Model    string `query:"model"`
+	// Filtered out hits are dropped from their page, pinned hits come first in a page.
+	ConversationFilter
 }
 
 type SearchConversationsResponse struct {
@@ -69,6 +260,114 @@ type SearchConversationsResponse struct {
 }
 
 type SearchConversationsResponseBody struct {
-	ConversationItems []ConversationItem `json:"conversationItems"`
-	NextPageToken     *string            `json:"nextPageToken"`
+	ConversationItems []ConversationSearchHit `json:"conversationItems"`
+	NextPageToken     *string                 `json:"nextPageToken"`
+}
+
+type ExportConversationsRequest struct {
+	Body *ExportConversationsRequestBody
+}
+
+// ExportConversationsRequestBody selects conversations by exactly one of Conversations,
+// Query or a creation date range. The active path of each conversation is exported.
+type ExportConversationsRequestBody struct {
+	Format        ExportFormat      `json:"format"                  required:"true" enum:"markdown,html,jsonl"`
+	Conversations []ConversationRef `json:"conversations,omitempty"`
+	// Query exports the full-text search results of the query.
+	Query string `json:"query,omitempty"`
+	// CreatedFrom and CreatedTo export the conversations created in [CreatedFrom, CreatedTo),
+	// either can be left open.
+	CreatedFrom *time.Time `json:"createdFrom,omitempty"`
+	CreatedTo   *time.Time `json:"createdTo,omitempty"`
+
+	IncludeReasoning     bool `json:"includeReasoning,omitempty"`
+	IncludeSystemPrompts bool `json:"includeSystemPrompts,omitempty"`
+	IncludeDetails       bool `json:"includeDetails,omitempty"`
+}
+
+type ExportConversationsResponse struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	// FileName is a suggested name for the exported file.
+	FileName string
+	Body     []byte
+}
+
+type ImportConversationsRequest struct {
+	Body *ImportConversationsRequestBody
+}
+
+type ImportConversationsRequestBody struct {
+	// Source is detected from the file if it is empty.
+	Source ImportSource `json:"source,omitempty"   enum:"chatgpt,claude"`
+	// FilePath is the conversations.json of the export, or the export zip file.
+	FilePath string `json:"filePath"                                 required:"true"`
+	// Branches imports all branches of a conversation, otherwise only the active branch is imported.
+	Branches bool `json:"branches,omitempty"`
+}
+
+type ImportConversationsResponse struct {
+	Body *ImportConversationsResponseBody
+}
+
+type ImportConversationsResponseBody struct {
+	Imported int `json:"imported"`
+	// Skipped counts conversations that were imported before and conversations without messages.
+	Skipped int             `json:"skipped"`
+	Failed  []ImportFailure `json:"failed"`
+}
+
+type ListTrashRequest struct {
+	Token string `query:"token"`
+}
+
+type ListTrashResponse struct {
+	Body *ListTrashResponseBody
+}
+
+type ListTrashResponseBody struct {
+	TrashItems    []TrashItem `json:"trashItems"`
+	NextPageToken *string     `json:"nextPageToken"`
+}
+
+type RestoreConversationRequest struct {
+	ID    string `path:"id" required:"true"`
+	Title string `          required:"true" query:"title"`
+}
+
+type RestoreConversationResponse struct{}
+
+type PurgeTrashRequest struct {
+	Body *PurgeTrashRequestBody
+}
+
+type PurgeTrashRequestBody struct {
+	// Conversations are the trashed conversations to purge, all are purged if it is empty.
+	Conversations []ConversationRef `json:"conversations,omitempty"`
+}
+
+type PurgeTrashResponse struct {
+	Body *PurgeTrashResponseBody
+}
+
+type PurgeTrashResponseBody struct {
+	Purged int `json:"purged"`
+}
+
+type PutConversationMetaRequest struct {
+	ID   string `path:"id" required:"true"`
+	Body *ConversationMeta
+}
+
+type PutConversationMetaResponse struct{}
+
+type ListConversationLabelsRequest struct{}
+
+type ListConversationLabelsResponse struct {
+	Body *ListConversationLabelsResponseBody
+}
+
+type ListConversationLabelsResponseBody struct {
+	Tags    []LabelCount `json:"tags"`
+	Folders []LabelCount `json:"folders"`
 }
@@ -5,12 +5,16 @@ import (
 	"errors"
 	"log/slog"
 	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
 	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/dirstore"
 	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/encdec"
 	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filenameprovider"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filestore"
 	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/ftsengine"
 )
 
@@ -19,6 +23,24 @@ type ConversationCollection struct {
 	store     *dirstore.MapDirectoryStore
 	fts       *ftsengine.Engine
 	enableFTS bool
+	// ftsQueue writes to fts, it holds back writes until the startup rebuild is done.
+	ftsQueue *ftsQueue
+	// Tags, folders and flags of conversations.
+	meta *filestore.MapFileStore
+	// Serializes the read, modify and write of the meta file.
+	metaMu sync.Mutex
+	// Deleted conversations, kept for trashRetention.
+	trash          *dirstore.MapDirectoryStore
+	trashRetention TrashRetentionGetter
+	// Time of the last purge of expired trash.
+	trashPurgedAt time.Time
+	trashPurgeMu  sync.Mutex
+	// Serializes the read, modify and write of conversation files, e.g. of a rename and
+	// messages that are added meanwhile.
+	mu sync.Mutex
+	// Optional completions for generated titles.
+	titleCompleter promptrun.Completer
+	titleModel     TitleModelGetter
 	// File-name builder / parser.
 	fp filenameprovider.Provider
 	// Directory partitioning.
@@ -48,6 +70,22 @@ func WithFTS(enabled bool) Option {
 	}
 }
 
+// WithTrashRetention sets how long deleted conversations are kept in the trash.
+// A retention of zero or less keeps them until the trash is purged.
+func WithTrashRetention(d time.Duration) Option {
+	return WithTrashRetentionGetter(func(context.Context) (time.Duration, error) {
+		return d, nil
+	})
+}
+
+// WithTrashRetentionGetter reads the trash retention at every purge, e.g. from the settings.
+func WithTrashRetentionGetter(get TrashRetentionGetter) Option {
+	return func(cc *ConversationCollection) error {
+		cc.trashRetention = get
+		return nil
+	}
+}
+
 // NewConversationCollection creates a collection with sensible defaults
 // (UUID-v7 file names under yyyyMM partitions).  Callers may override either
 // strategy via the Option functions above.
@@ -62,6 +100,9 @@ func NewConversationCollection(baseDir string, opts ...Option) (*ConversationCol
 		fp:      &defFP,
 		pp:      &defPP,
 	}
+	if err := WithTrashRetention(DefaultTrashRetention)(cc); err != nil {
+		return nil, err
+	}
 
 	for _, o := range opts {
 		if err := o(cc); err != nil {
@@ -89,22 +130,37 @@ func NewConversationCollection(baseDir string, opts ...Option) (*ConversationCol
 		if err != nil {
 			return nil, err
 		}
-		StartRebuild(
+		cc.ftsQueue = newFTSQueue(cc.fts, StartRebuild(
 			context.Background(),
 			baseDir,
 			cc.fts,
-		)
+		))
 	}
 
 	optsDir := []dirstore.Option{dirstore.WithPartitionProvider(cc.pp)}
 	if cc.fts != nil {
-		optsDir = append(optsDir, dirstore.WithListeners(NewFTSListner(cc.fts)))
+		optsDir = append(optsDir, dirstore.WithListeners(cc.ftsQueue.listener()))
 	}
 	store, err := dirstore.NewMapDirectoryStore(baseDir, true, optsDir...)
 	if err != nil {
 		return nil, err
 	}
 	cc.store = store
+
+	if cc.meta, err = newMetaStore(baseDir); err != nil {
+		return nil, err
+	}
+	cc.trash, err = dirstore.NewMapDirectoryStore(
+		filepath.Join(baseDir, trashDirName),
+		true,
+		dirstore.WithPartitionProvider(cc.pp),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := cc.purgeExpiredTrash(context.Background()); err != nil {
+		slog.Warn("purge expired trash", "error", err)
+	}
 	return cc, nil
 }
 
@@ -135,35 +191,61 @@ func (cc *ConversationCollection) PutConversation(
 	})
 	partitionDirName := cc.pp.GetPartitionDir(fn)
 
-	// Check if there are files with same id as prefix
-	// We don't iterate as we expect only 1 file max with the id prefix of uuid.
-	files, _, err := cc.store.ListFiles(
-		dirstore.ListingConfig{
-			FilenamePrefix:   req.ID,
-			PageSize:         10,
-			FilterPartitions: []string{partitionDirName},
-		},
-		"",
-	)
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	// Check if there are files with same id as prefix.
+	files, err := filesWithID(cc.store, partitionDirName, req.ID)
 	if err != nil {
 		return nil, err
 	}
-	// If there is a file, that means its a replace of full conversation
-	// May be title has also changed
-	// Remove the current file and add new.
-	for idx := range files {
-		err := cc.store.DeleteFile(filepath.Base(files[idx]))
+	// An unreadable file is replaced as version 0.
+	var existing *spec.Conversation
+	if len(files) > 0 {
+		existing, err = cc.readConversationTree(filepath.Base(files[0]))
 		if err != nil {
-			slog.Warn("Put conversation remove existing file", "error", err)
+			slog.Warn("Put conversation read existing file", "error", err)
+			existing = nil
 		}
 	}
-
+	var version int64
+	if existing != nil {
+		version = existing.Version
+	}
+	if err := checkVersion(req.Body.Version, version); err != nil {
+		return nil, err
+	}
 	currentConversation := &spec.Conversation{}
 	currentConversation.ID = req.ID
 	currentConversation.Title = req.Body.Title
 	currentConversation.CreatedAt = req.Body.CreatedAt
 	currentConversation.ModifiedAt = req.Body.ModifiedAt
-	currentConversation.Messages = req.Body.Messages
+	currentConversation.Messages = slices.Clone(req.Body.Messages)
+	currentConversation.ActiveLeafID = req.Body.ActiveLeafID
+	currentConversation.Model = req.Body.Model
+	currentConversation.Version = version + 1
+	if existing != nil {
+		// Clients usually read only the active path, the messages are merged into the stored
+		// tree so that a put does not remove the other branches.
+		if err := checkUniqueIDs(req.Body.Messages); err != nil {
+			return nil, err
+		}
+		currentConversation.Messages = existing.Messages
+		currentConversation.ActiveLeafID = existing.ActiveLeafID
+		msgs := slices.Clone(req.Body.Messages)
+		if req.Body.ActiveLeafID == "" {
+			err = mergePath(currentConversation, msgs)
+		} else {
+			err = mergeTree(currentConversation, msgs)
+			currentConversation.ActiveLeafID = req.Body.ActiveLeafID
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := normalizeTree(currentConversation); err != nil {
+		return nil, err
+	}
 
 	data, err := encdec.StructWithJSONTagsToMap(currentConversation)
 	if err != nil {
@@ -172,7 +254,21 @@ func (cc *ConversationCollection) PutConversation(
 	if err := cc.store.SetFileData(fn, data); err != nil {
 		return nil, err
 	}
-	return &spec.PutConversationResponse{}, nil
+	// A file under another name is from an old title. It is removed only after the new file
+	// is written, so a rejected write keeps the stored conversation.
+	for idx := range files {
+		existing := filepath.Base(files[idx])
+		if existing == fn {
+			continue
+		}
+		if err := cc.store.DeleteFile(existing); err != nil {
+			slog.Warn("Put conversation remove existing file", "error", err)
+		}
+		cc.deleteFromIndex(existing)
+	}
+	return &spec.PutConversationResponse{
+		Body: &spec.ConversationVersionBody{Version: currentConversation.Version},
+	}, nil
 }
 
 func (cc *ConversationCollection) PutMessagesToConversation(
@@ -183,32 +279,27 @@ func (cc *ConversationCollection) PutMessagesToConversation(
 		return nil, errors.New("request or request body cannot be nil")
 	}
 
-	convoResp, err := cc.GetConversation(ctx,
-		&spec.GetConversationRequest{ID: req.ID, Title: req.Body.Title})
-	if err != nil {
-		return nil, err
-	}
-
-	currentConversation := convoResp.Body
-	currentConversation.ModifiedAt = time.Now()
-	currentConversation.Messages = req.Body.Messages
-
-	fn, err := cc.fileNameFromConversation(*currentConversation)
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := encdec.StructWithJSONTagsToMap(currentConversation)
+	convo, err := cc.updateConversationTree(
+		req.ID,
+		req.Body.Title,
+		req.Body.Version,
+		func(c *spec.Conversation) error {
+			if req.Body.Model != nil {
+				c.Model = req.Body.Model
+			}
+			return mergePath(c, slices.Clone(req.Body.Messages))
+		},
+	)
 	if err != nil {
 		return nil, err
 	}
-	if err := cc.store.SetFileData(fn, data); err != nil {
-		return nil, err
-	}
-
-	return &spec.PutMessagesToConversationResponse{}, nil
+	return &spec.PutMessagesToConversationResponse{
+		Body: &spec.ConversationVersionBody{Version: convo.Version},
+	}, nil
 }
 
+// DeleteConversation moves a conversation to the trash, from where it can be restored until
+// it is purged.
 func (cc *ConversationCollection) DeleteConversation(
 	ctx context.Context,
 	req *spec.DeleteConversationRequest,
@@ -216,18 +307,26 @@ func (cc *ConversationCollection) DeleteConversation(
 	if req == nil {
 		return nil, errors.New("request cannot be nil")
 	}
-	fn, _ := cc.fp.Build(filenameprovider.FileInfo{ID: req.ID, Title: req.Title})
-	if err := cc.store.DeleteFile(fn); err != nil {
+	fn, err := cc.conversationFileName(req.ID, req.Title)
+	if err != nil {
 		return nil, err
 	}
-	// Purge from FTS (absolute path = docID).
-	if cc.fts != nil {
-		full := filepath.Join(cc.baseDir, cc.pp.GetPartitionDir(fn), fn)
-		_ = cc.fts.Delete(ctx, full)
+	if err := cc.moveToTrash(fn, req.ID); err != nil {
+		return nil, err
 	}
+	cc.deleteFromIndex(fn)
+	cc.purgeExpiredTrashEvery(ctx, trashPurgeInterval)
 	return &spec.DeleteConversationResponse{}, nil
 }
 
+// deleteFromIndex removes a conversation file from full-text search (absolute path = docID).
+func (cc *ConversationCollection) deleteFromIndex(fn string) {
+	if cc.ftsQueue == nil {
+		return
+	}
+	cc.ftsQueue.delete(filepath.Join(cc.baseDir, cc.pp.GetPartitionDir(fn), fn))
+}
+
 func (cc *ConversationCollection) GetConversation(
 	ctx context.Context,
 	req *spec.GetConversationRequest,
@@ -235,7 +334,31 @@ func (cc *ConversationCollection) GetConversation(
 	if req == nil {
 		return nil, errors.New("request or request body cannot be nil")
 	}
-	fn, _ := cc.fp.Build(filenameprovider.FileInfo{ID: req.ID, Title: req.Title})
+	convo, err := cc.getConversationTree(req.ID, req.Title)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Tree {
+		convo.Messages = activePath(convo)
+	}
+	if convo.ConversationMeta, err = cc.getMeta(convo.ID); err != nil {
+		return nil, err
+	}
+	return &spec.GetConversationResponse{Body: convo}, nil
+}
+
+// getConversationTree reads a conversation with all its messages.
+func (cc *ConversationCollection) getConversationTree(
+	id, title string,
+) (*spec.Conversation, error) {
+	fn, err := cc.conversationFileName(id, title)
+	if err != nil {
+		return nil, err
+	}
+	return cc.readConversationTree(fn)
+}
+
+func (cc *ConversationCollection) readConversationTree(fn string) (*spec.Conversation, error) {
 	raw, err := cc.store.GetFileData(fn, false)
 	if err != nil {
 		return nil, err
@@ -245,26 +368,65 @@ func (cc *ConversationCollection) GetConversation(
 	if err := encdec.MapToStructWithJSONTags(raw, &convo); err != nil {
 		return nil, err
 	}
-	return &spec.GetConversationResponse{Body: &convo}, nil
+	if err := normalizeTree(&convo); err != nil {
+		return nil, err
+	}
+	return &convo, nil
+}
+
+// saveConversationTree writes a conversation read by getConversationTree back to its file
+// as its next version.
+func (cc *ConversationCollection) saveConversationTree(convo *spec.Conversation) error {
+	convo.ModifiedAt = time.Now()
+	convo.Version++
+	fn, err := cc.fileNameFromConversation(*convo)
+	if err != nil {
+		return err
+	}
+	data, err := encdec.StructWithJSONTagsToMap(convo)
+	if err != nil {
+		return err
+	}
+	return cc.store.SetFileData(fn, data)
 }
 
 // The titles returned here are not from the conversation itself, but sanitized names with alpha numeric chars only.
+// Pinned conversations are listed on the first page and left out of the later ones.
 func (cc *ConversationCollection) ListConversations(
 	ctx context.Context,
 	req *spec.ListConversationsRequest,
 ) (*spec.ListConversationsResponse, error) {
-	token := ""
-	if req != nil {
-		token = req.Token
+	if req == nil {
+		req = &spec.ListConversationsRequest{}
 	}
+	if isFiltered(req.ConversationFilter) {
+		items, err := cc.listByMeta(req.ConversationFilter)
+		if err != nil {
+			return nil, err
+		}
+		next := ""
+		return &spec.ListConversationsResponse{
+			Body: &spec.ListConversationsResponseBody{
+				ConversationItems: items,
+				NextPageToken:     &next,
+			},
+		}, nil
+	}
+
 	files, next, err := cc.store.ListFiles(
 		dirstore.ListingConfig{SortOrder: dirstore.SortOrderDescending},
-		token,
+		req.Token,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	pinned := []spec.ConversationItem{}
+	if req.Token == "" {
+		if pinned, err = cc.listByMeta(spec.ConversationFilter{Pinned: true}); err != nil {
+			return nil, err
+		}
+	}
 	items := make([]spec.ConversationItem, 0, len(files))
 	for _, f := range files {
 		info, err := cc.fp.Parse(filepath.Base(f))
@@ -278,49 +440,13 @@ func (cc *ConversationCollection) ListConversations(
 			CreatedAt: info.CreatedAt,
 		})
 	}
-	return &spec.ListConversationsResponse{
-		Body: &spec.ListConversationsResponseBody{
-			ConversationItems: items,
-			NextPageToken:     &next,
-		},
-	}, nil
-}
-
-func (cc *ConversationCollection) SearchConversations(
-	ctx context.Context,
-	req *spec.SearchConversationsRequest,
-) (*spec.SearchConversationsResponse, error) {
-	if req == nil {
-		return nil, errors.New("request cannot be nil")
-	}
-	if cc.fts == nil {
-		return nil, errors.New("full-text search is disabled")
-	}
-	pageSize := req.PageSize
-	if pageSize <= 0 || pageSize > 1000 {
-		pageSize = 10
-	}
-
-	hits, next, err := cc.fts.Search(ctx, req.Query, req.Token, pageSize)
-	if err != nil {
+	if items, err = cc.withMeta(items, spec.ConversationFilter{}); err != nil {
 		return nil, err
 	}
-
-	items := make([]spec.ConversationItem, 0, len(hits))
-	for _, h := range hits {
-		base := filepath.Base(h.ID)
-		info, err := cc.fp.Parse(base)
-		if err != nil {
-			continue
-		}
-		items = append(items, spec.ConversationItem{
-			ID:        info.ID,
-			Title:     info.Title,
-			CreatedAt: info.CreatedAt,
-		})
-	}
-	return &spec.SearchConversationsResponse{
-		Body: &spec.SearchConversationsResponseBody{
+	items = slices.DeleteFunc(items, func(i spec.ConversationItem) bool { return i.Pinned })
+	items = append(pinned, items...)
+	return &spec.ListConversationsResponse{
+		Body: &spec.ListConversationsResponseBody{
 			ConversationItems: items,
 			NextPageToken:     &next,
 		},
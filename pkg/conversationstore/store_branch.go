@@ -0,0 +1,118 @@
+package conversationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+// ForkConversation adds a new branch next to a message, the original branch is kept.
+// Editing a prompt is a fork at the prompt with the edited prompt and its new reply.
+func (cc *ConversationCollection) ForkConversation(
+	ctx context.Context,
+	req *spec.ForkConversationRequest,
+) (*spec.ForkConversationResponse, error) {
+	if req == nil || req.Body == nil || len(req.Body.Messages) == 0 {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	convo, err := cc.updateConversationTree(
+		req.ID,
+		req.Body.Title,
+		&req.Body.Version,
+		func(c *spec.Conversation) error {
+			return addBranch(c, req.MessageID, slices.Clone(req.Body.Messages))
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.ForkConversationResponse{Body: convo}, nil
+}
+
+// RegenerateMessage adds a new reply next to an assistant message and makes it active.
+func (cc *ConversationCollection) RegenerateMessage(
+	ctx context.Context,
+	req *spec.RegenerateMessageRequest,
+) (*spec.RegenerateMessageResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	if req.Body.Message.Role != spec.ConversationRoleAssistant {
+		return nil, errors.New("regenerated message must be an assistant message")
+	}
+	convo, err := cc.updateConversationTree(
+		req.ID,
+		req.Body.Title,
+		&req.Body.Version,
+		func(c *spec.Conversation) error {
+			idx, err := findMessage(c, req.MessageID)
+			if err != nil {
+				return err
+			}
+			if c.Messages[idx].Role != spec.ConversationRoleAssistant {
+				return fmt.Errorf("message %s is not an assistant message", req.MessageID)
+			}
+			return addBranch(c, req.MessageID, []spec.ConversationMessage{req.Body.Message})
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.RegenerateMessageResponse{Body: convo}, nil
+}
+
+// SwitchBranch makes the branch of a message active, down to its latest message.
+func (cc *ConversationCollection) SwitchBranch(
+	ctx context.Context,
+	req *spec.SwitchBranchRequest,
+) (*spec.SwitchBranchResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	convo, err := cc.updateConversationTree(
+		req.ID,
+		req.Body.Title,
+		&req.Body.Version,
+		func(c *spec.Conversation) error {
+			if _, err := findMessage(c, req.Body.MessageID); err != nil {
+				return err
+			}
+			c.ActiveLeafID = latestLeaf(c, req.Body.MessageID)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.SwitchBranchResponse{Body: convo}, nil
+}
+
+// updateConversationTree applies fn to the stored tree, saves it and returns the active path.
+// A non nil version must match the stored one, else ErrVersionConflict is returned.
+func (cc *ConversationCollection) updateConversationTree(
+	id, title string,
+	version *int64,
+	fn func(c *spec.Conversation) error,
+) (*spec.Conversation, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	convo, err := cc.getConversationTree(id, title)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version, convo.Version); err != nil {
+		return nil, err
+	}
+	if err := fn(convo); err != nil {
+		return nil, err
+	}
+	if err := cc.saveConversationTree(convo); err != nil {
+		return nil, err
+	}
+	convo.Messages = activePath(convo)
+	return convo, nil
+}
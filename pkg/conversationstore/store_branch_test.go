@@ -0,0 +1,279 @@
+package conversationstore_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+func messageIDs(msgs []spec.ConversationMessage) []string {
+	ids := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestConversationBranches(t *testing.T) {
+	ctx := t.Context()
+	cc, err := conversationstore.NewConversationCollection(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convo, err := initConversation("Branches")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	// A flat list as stored before branching, u2 has no parent and follows a1.
+	convo.Messages = []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser, Content: "hi"},
+		{ID: "a1", Role: spec.ConversationRoleAssistant, Content: "hello"},
+		{ID: "u2", Role: spec.ConversationRoleUser, Content: "how are you"},
+		{ID: "a2", Role: spec.ConversationRoleAssistant, Content: "fine"},
+	}
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+
+	get := func(tree bool) *spec.Conversation {
+		t.Helper()
+		resp, err := cc.GetConversation(ctx, &spec.GetConversationRequest{
+			ID:    convo.ID,
+			Title: convo.Title,
+			Tree:  tree,
+		})
+		if err != nil {
+			t.Fatalf("Failed to get conversation: %v", err)
+		}
+		return resp.Body
+	}
+	assertPath := func(got *spec.Conversation, want ...string) {
+		t.Helper()
+		if ids := messageIDs(got.Messages); !slices.Equal(ids, want) {
+			t.Fatalf("Expected messages %v, got %v", want, ids)
+		}
+	}
+
+	got := get(false)
+	assertPath(got, "u1", "a1", "u2", "a2")
+	if got.ActiveLeafID != "a2" || got.Messages[2].ParentID == nil ||
+		*got.Messages[2].ParentID != "a1" {
+		t.Fatalf("Flat list not read as a single branch: %+v", got)
+	}
+
+	// Edit u2.
+	forkResp, err := cc.ForkConversation(ctx, &spec.ForkConversationRequest{
+		ID:        convo.ID,
+		MessageID: "u2",
+		Body: &spec.ForkConversationRequestBody{
+			Title:   convo.Title,
+			Version: got.Version,
+			Messages: []spec.ConversationMessage{
+				{ID: "u2b", Role: spec.ConversationRoleUser, Content: "what is up"},
+				{ID: "a2b", Role: spec.ConversationRoleAssistant, Content: "not much"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to fork: %v", err)
+	}
+	assertPath(forkResp.Body, "u1", "a1", "u2b", "a2b")
+
+	regenResp, err := cc.RegenerateMessage(ctx, &spec.RegenerateMessageRequest{
+		ID:        convo.ID,
+		MessageID: "a2b",
+		Body: &spec.RegenerateMessageRequestBody{
+			Title:   convo.Title,
+			Version: forkResp.Body.Version,
+			Message: spec.ConversationMessage{ID: "a2c", Role: spec.ConversationRoleAssistant},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to regenerate: %v", err)
+	}
+	assertPath(regenResp.Body, "u1", "a1", "u2b", "a2c")
+
+	// Continuing the active path keeps the other branches.
+	_, err = cc.PutMessagesToConversation(ctx, &spec.PutMessagesToConversationRequest{
+		ID: convo.ID,
+		Body: &spec.PutMessagesToConversationRequestBody{
+			Title: convo.Title,
+			Messages: append(get(false).Messages,
+				spec.ConversationMessage{ID: "u3", Role: spec.ConversationRoleUser, Content: "ok"}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to put messages: %v", err)
+	}
+	assertPath(get(false), "u1", "a1", "u2b", "a2c", "u3")
+	if n := len(get(true).Messages); n != 8 {
+		t.Errorf("Expected 8 messages in the tree, got %d", n)
+	}
+
+	switchResp, err := cc.SwitchBranch(ctx, &spec.SwitchBranchRequest{
+		ID: convo.ID,
+		Body: &spec.SwitchBranchRequestBody{
+			Title:     convo.Title,
+			Version:   get(true).Version,
+			MessageID: "u2",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to switch branch: %v", err)
+	}
+	assertPath(switchResp.Body, "u1", "a1", "u2", "a2")
+	assertPath(get(false), "u1", "a1", "u2", "a2")
+
+	// Switching to a shared ancestor follows the latest branch.
+	switchResp, err = cc.SwitchBranch(ctx, &spec.SwitchBranchRequest{
+		ID: convo.ID,
+		Body: &spec.SwitchBranchRequestBody{
+			Title:     convo.Title,
+			Version:   switchResp.Body.Version,
+			MessageID: "a1",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to switch branch: %v", err)
+	}
+	assertPath(switchResp.Body, "u1", "a1", "u2b", "a2c", "u3")
+
+	// Putting back the active path, as read by a client, keeps the other branches.
+	active := get(false)
+	active.Messages[4].Content = "okay"
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(active)); err != nil {
+		t.Fatalf("Failed to put the active path: %v", err)
+	}
+	tree := get(true)
+	if n := len(tree.Messages); n != 8 {
+		t.Errorf("Expected 8 messages in the tree after a put, got %d", n)
+	}
+	assertPath(get(false), "u1", "a1", "u2b", "a2c", "u3")
+	if got := get(false).Messages[4].Content; got != "okay" {
+		t.Errorf("Expected the put to update u3, got %q", got)
+	}
+	switchResp, err = cc.SwitchBranch(ctx, &spec.SwitchBranchRequest{
+		ID: convo.ID,
+		Body: &spec.SwitchBranchRequestBody{
+			Title:     convo.Title,
+			Version:   tree.Version,
+			MessageID: "a2",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to switch to the other branch after a put: %v", err)
+	}
+	assertPath(switchResp.Body, "u1", "a1", "u2", "a2")
+}
+
+func TestConversationBranchErrors(t *testing.T) {
+	ctx := t.Context()
+	cc, err := conversationstore.NewConversationCollection(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convo, err := initConversation("Branch errors")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	convo.Messages = []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser},
+		{ID: "a1", Role: spec.ConversationRoleAssistant},
+	}
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+
+	_, err = cc.ForkConversation(ctx, &spec.ForkConversationRequest{
+		ID:        convo.ID,
+		MessageID: "missing",
+		Body: &spec.ForkConversationRequestBody{
+			Title:    convo.Title,
+			Version:  1,
+			Messages: []spec.ConversationMessage{{ID: "x", Role: spec.ConversationRoleUser}},
+		},
+	})
+	if !errors.Is(err, conversationstore.ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+
+	_, err = cc.ForkConversation(ctx, &spec.ForkConversationRequest{
+		ID:        convo.ID,
+		MessageID: "u1",
+		Body: &spec.ForkConversationRequestBody{
+			Title:    convo.Title,
+			Version:  0,
+			Messages: []spec.ConversationMessage{{ID: "x", Role: spec.ConversationRoleUser}},
+		},
+	})
+	if !errors.Is(err, conversationstore.ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict for a stale fork, got %v", err)
+	}
+
+	_, err = cc.RegenerateMessage(ctx, &spec.RegenerateMessageRequest{
+		ID:        convo.ID,
+		MessageID: "a1",
+		Body: &spec.RegenerateMessageRequestBody{
+			Title:   convo.Title,
+			Version: 0,
+			Message: spec.ConversationMessage{ID: "x", Role: spec.ConversationRoleAssistant},
+		},
+	})
+	if !errors.Is(err, conversationstore.ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict for a stale regenerate, got %v", err)
+	}
+
+	_, err = cc.RegenerateMessage(ctx, &spec.RegenerateMessageRequest{
+		ID:        convo.ID,
+		MessageID: "u1",
+		Body: &spec.RegenerateMessageRequestBody{
+			Title:   convo.Title,
+			Version: 1,
+			Message: spec.ConversationMessage{ID: "x", Role: spec.ConversationRoleAssistant},
+		},
+	})
+	if err == nil {
+		t.Error("Expected error when regenerating a user message")
+	}
+
+	parent := "later"
+	req := getNewPutRequestFromConversation(convo)
+	req.Body.ActiveLeafID = "a1"
+	req.Body.Messages = []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser, ParentID: &parent},
+		{ID: "later", Role: spec.ConversationRoleAssistant},
+	}
+	if _, err := cc.PutConversation(ctx, req); !errors.Is(
+		err,
+		conversationstore.ErrInvalidMessageTree,
+	) {
+		t.Errorf("Expected ErrInvalidMessageTree, got %v", err)
+	}
+
+	// A rejected write under a new title keeps the stored conversation.
+	req = getNewPutRequestFromConversation(convo)
+	req.Body.Title = "Branch errors renamed"
+	req.Body.Messages = []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser},
+		{ID: "u1", Role: spec.ConversationRoleUser},
+	}
+	if _, err := cc.PutConversation(ctx, req); !errors.Is(
+		err,
+		conversationstore.ErrInvalidMessageTree,
+	) {
+		t.Fatalf("Expected ErrInvalidMessageTree for a duplicate message, got %v", err)
+	}
+	got, err := cc.GetConversation(ctx, &spec.GetConversationRequest{
+		ID:    convo.ID,
+		Title: convo.Title,
+	})
+	if err != nil {
+		t.Fatalf("Expected the stored conversation to be kept: %v", err)
+	}
+	if ids := messageIDs(got.Body.Messages); !slices.Equal(ids, []string{"u1", "a1"}) {
+		t.Errorf("Expected the stored messages to be kept, got %v", ids)
+	}
+}
@@ -5,22 +5,90 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filestore"
 	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/ftsengine"
 )
 
-func NewFTSListner(e *ftsengine.Engine) filestore.Listener {
+// ftsQueue writes conversation files to the index. Writes made during the startup rebuild are
+// queued and written once it is done, the rebuild works on a snapshot of the index and would
+// undo them.
+type ftsQueue struct {
+	e  *ftsengine.Engine
+	mu sync.Mutex
+	// pending holds the values of files written during the rebuild, nil for deleted files.
+	// It is nil once the rebuild is done.
+	pending map[string]map[string]string
+	// flushed is closed when the rebuild is done and the queued writes are in the index.
+	flushed chan struct{}
+}
+
+func newFTSQueue(e *ftsengine.Engine, rebuilt <-chan struct{}) *ftsQueue {
+	q := &ftsQueue{
+		e:       e,
+		pending: map[string]map[string]string{},
+		flushed: make(chan struct{}),
+	}
+	go func() {
+		<-rebuilt
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for id, vals := range q.pending {
+			q.write(id, vals)
+		}
+		q.pending = nil
+		close(q.flushed)
+	}()
+	return q
+}
+
+// wait blocks until the index has the writes made so far, so searches see them.
+func (q *ftsQueue) wait(ctx context.Context) error {
+	select {
+	case <-q.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// listener indexes written conversation files.
+func (q *ftsQueue) listener() filestore.Listener {
 	return func(ev filestore.Event) {
 		switch ev.Op {
 		case filestore.OpSetFile, filestore.OpResetFile:
-			_ = e.Upsert(context.Background(), ev.File, extract(ev.File, ev.Data))
+			q.set(ev.File, extract(ev.File, ev.Data))
 		}
 	}
 }
 
+// delete removes a file from the index.
+func (q *ftsQueue) delete(id string) {
+	q.set(id, nil)
+}
+
+func (q *ftsQueue) set(id string, vals map[string]string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending != nil {
+		q.pending[id] = vals
+		return
+	}
+	q.write(id, vals)
+}
+
+func (q *ftsQueue) write(id string, vals map[string]string) {
+	if vals == nil {
+		_ = q.e.Delete(context.Background(), id)
+		return
+	}
+	_ = q.e.Upsert(context.Background(), id, vals)
+}
+
 // extract converts the in-memory JSON map (produced by MapFileStore)
 // into the column → text map expected by ftsengine.
 func extract(fullPath string, m map[string]any) map[string]string {
@@ -106,7 +174,8 @@ func processFTSDataForFile(
 		Unchanged: false,
 		Skip:      true,
 	}
-	if !strings.HasSuffix(fullPath, ".json") {
+	if !strings.HasSuffix(fullPath, ".json") || filepath.Base(fullPath) == metaFileName ||
+		isTrashPath(baseDir, fullPath) {
 		return skipSyncDecision, nil
 	}
 	cmp := fileMTime(fullPath)
@@ -143,8 +212,22 @@ func processFTSDataForFile(
 	return syncDecision, nil
 }
 
-func StartRebuild(ctx context.Context, baseDir string, e *ftsengine.Engine) {
+// isTrashPath tells if a file is in the trash, trashed conversations are not searchable.
+func isTrashPath(baseDir, fullPath string) bool {
+	rel, err := filepath.Rel(baseDir, fullPath)
+	if err != nil {
+		return false
+	}
+	first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return first == trashDirName
+}
+
+// StartRebuild syncs the index with the files in the background.
+// The returned channel is closed when it is done.
+func StartRebuild(ctx context.Context, baseDir string, e *ftsengine.Engine) <-chan struct{} {
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		_ = ftsengine.SyncDirToFTS(
 			ctx,
 			e,
@@ -155,4 +238,5 @@ func StartRebuild(ctx context.Context, baseDir string, e *ftsengine.Engine) {
 			processFTSDataForFile,
 		)
 	}()
+	return done
 }
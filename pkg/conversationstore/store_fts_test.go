@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/ftsengine"
 )
 
 func getNewPutRequestFromConversation(c *spec.Conversation) *spec.PutConversationRequest {
@@ -285,3 +286,56 @@ func TestFTSScaleSearch(t *testing.T) {
 		t.Fatalf("expected %d total hits for 'common', got %d", nConvos, total)
 	}
 }
+
+func TestFTSWritesQueuedDuringRebuild(t *testing.T) {
+	e, err := ftsengine.NewEngine(ftsengine.Config{
+		BaseDir:    t.TempDir(),
+		DBFileName: "queue.fts.sqlite",
+		Table:      "conversations",
+		Columns:    []ftsengine.Column{{Name: "title"}},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	if err := e.Upsert(t.Context(), "old", map[string]string{"title": "stale apple"}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	rebuilt := make(chan struct{})
+	q := newFTSQueue(e, rebuilt)
+
+	// Writes during the rebuild return at once and are not in the index yet.
+	written := make(chan struct{})
+	go func() {
+		q.set("new", map[string]string{"title": "fresh apple"})
+		q.delete("old")
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writes blocked on the rebuild")
+	}
+	searchIDs := func() []string {
+		t.Helper()
+		hits, _, err := e.Search(t.Context(), "apple", "", 10)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		ids := []string{}
+		for _, h := range hits {
+			ids = append(ids, h.ID)
+		}
+		return ids
+	}
+	if got := searchIDs(); len(got) != 1 || got[0] != "old" {
+		t.Fatalf("expected only the old hit during the rebuild, got %v", got)
+	}
+
+	close(rebuilt)
+	if err := q.wait(t.Context()); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if got := searchIDs(); len(got) != 1 || got[0] != "new" {
+		t.Fatalf("expected the queued writes after the rebuild, got %v", got)
+	}
+}
@@ -0,0 +1,128 @@
+package conversationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+// VersionConflictCode starts the message of ErrVersionConflict. Clients that only get the error
+// message, like the desktop app bindings, match on it.
+const VersionConflictCode = "CONVERSATION_VERSION_CONFLICT"
+
+// ErrVersionConflict is returned for a write with an older version of a conversation, it was
+// changed by another writer meanwhile. Callers should read it again and retry.
+var ErrVersionConflict = errors.New(
+	VersionConflictCode + ": conversation changed by another writer",
+)
+
+// checkVersion compares the version a writer expects with the stored one, nil skips the check.
+func checkVersion(want *int64, got int64) error {
+	if want == nil || *want == got {
+		return nil
+	}
+	return fmt.Errorf(
+		"%w: expected version %d, stored version is %d",
+		ErrVersionConflict,
+		*want,
+		got,
+	)
+}
+
+// AppendMessages adds messages after the active leaf of a conversation.
+func (cc *ConversationCollection) AppendMessages(
+	ctx context.Context,
+	req *spec.AppendMessagesRequest,
+) (*spec.AppendMessagesResponse, error) {
+	if req == nil || req.Body == nil || len(req.Body.Messages) == 0 {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	var appended []spec.ConversationMessage
+	convo, err := cc.updateConversationTree(
+		req.ID,
+		req.Body.Title,
+		&req.Body.Version,
+		func(c *spec.Conversation) error {
+			if req.Body.Model != nil {
+				c.Model = req.Body.Model
+			}
+			var err error
+			appended, err = appendToLeaf(c, req.Body.Messages)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.AppendMessagesResponse{
+		Body: &spec.AppendMessagesResponseBody{Version: convo.Version, Messages: appended},
+	}, nil
+}
+
+// UpdateMessage replaces a message of a conversation, e.g. a reply that was streamed.
+func (cc *ConversationCollection) UpdateMessage(
+	ctx context.Context,
+	req *spec.UpdateMessageRequest,
+) (*spec.UpdateMessageResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	convo, err := cc.updateConversationTree(
+		req.ID,
+		req.Body.Title,
+		&req.Body.Version,
+		func(c *spec.Conversation) error {
+			idx, err := findMessage(c, req.MessageID)
+			if err != nil {
+				return err
+			}
+			m := req.Body.Message
+			stored := c.Messages[idx]
+			if m.ParentID != nil && (stored.ParentID == nil || *stored.ParentID != *m.ParentID) {
+				return fmt.Errorf(
+					"%w: parent of message %s cannot change",
+					ErrInvalidMessageTree, req.MessageID,
+				)
+			}
+			m.ID = stored.ID
+			m.ParentID = stored.ParentID
+			c.Messages[idx] = m
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.UpdateMessageResponse{
+		Body: &spec.ConversationVersionBody{Version: convo.Version},
+	}, nil
+}
+
+// DeleteMessage deletes a message of a conversation and the replies below it.
+func (cc *ConversationCollection) DeleteMessage(
+	ctx context.Context,
+	req *spec.DeleteMessageRequest,
+) (*spec.DeleteMessageResponse, error) {
+	if req == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	convo, err := cc.updateConversationTree(
+		req.ID,
+		req.Title,
+		&req.Version,
+		func(c *spec.Conversation) error {
+			return deleteMessage(c, req.MessageID)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.DeleteMessageResponse{
+		Body: &spec.DeleteMessageResponseBody{
+			Version:      convo.Version,
+			ActiveLeafID: convo.ActiveLeafID,
+		},
+	}, nil
+}
@@ -0,0 +1,243 @@
+package conversationstore_test
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+func TestIncrementalMessages(t *testing.T) {
+	ctx := t.Context()
+	cc, err := conversationstore.NewConversationCollection(
+		t.TempDir(),
+		conversationstore.WithFTS(true),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convo, err := initConversation("Incremental")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	convo.Messages = []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser, Content: "hi"},
+	}
+	put, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo))
+	if err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	if put.Body.Version != 1 {
+		t.Fatalf("Expected version 1 for a new conversation, got %d", put.Body.Version)
+	}
+
+	appendMsgs := func(version int64, msgs ...spec.ConversationMessage) (*spec.AppendMessagesResponseBody, error) {
+		resp, err := cc.AppendMessages(ctx, &spec.AppendMessagesRequest{
+			ID: convo.ID,
+			Body: &spec.AppendMessagesRequestBody{
+				Title:    convo.Title,
+				Version:  version,
+				Messages: msgs,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	get := func(tree bool) *spec.Conversation {
+		t.Helper()
+		resp, err := cc.GetConversation(ctx, &spec.GetConversationRequest{
+			ID:    convo.ID,
+			Title: convo.Title,
+			Tree:  tree,
+		})
+		if err != nil {
+			t.Fatalf("Failed to get conversation: %v", err)
+		}
+		return resp.Body
+	}
+
+	appended, err := appendMsgs(1,
+		spec.ConversationMessage{ID: "a1", Role: spec.ConversationRoleAssistant, Content: "hello"},
+		spec.ConversationMessage{ID: "u2", Role: spec.ConversationRoleUser, Content: "sourdough"},
+	)
+	if err != nil {
+		t.Fatalf("Failed to append messages: %v", err)
+	}
+	if appended.Version != 2 || *appended.Messages[0].ParentID != "u1" ||
+		*appended.Messages[1].ParentID != "a1" {
+		t.Fatalf("Unexpected append result %+v", appended)
+	}
+	// A second writer that still has version 1 must not overwrite the first.
+	_, err = appendMsgs(
+		1,
+		spec.ConversationMessage{Role: spec.ConversationRoleUser, Content: "lost"},
+	)
+	if !errors.Is(err, conversationstore.ErrVersionConflict) {
+		t.Fatalf("Expected a version conflict, got %v", err)
+	}
+	if _, err := appendMsgs(2, spec.ConversationMessage{ID: "u1"}); err == nil {
+		t.Fatal("Expected an error for a duplicate message")
+	}
+
+	search, err := cc.SearchConversations(ctx, &spec.SearchConversationsRequest{Query: "sourdough"})
+	if err != nil {
+		t.Fatalf("Failed to search conversations: %v", err)
+	}
+	if got := hitIDs(search.Body.ConversationItems); len(got) != 1 || got[0] != convo.ID {
+		t.Errorf("Expected the appended message to be searchable, got %v", got)
+	}
+
+	updated, err := cc.UpdateMessage(ctx, &spec.UpdateMessageRequest{
+		ID:        convo.ID,
+		MessageID: "a1",
+		Body: &spec.UpdateMessageRequestBody{
+			Title:   convo.Title,
+			Version: 2,
+			Message: spec.ConversationMessage{Role: spec.ConversationRoleAssistant, Content: "hey"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update message: %v", err)
+	}
+	if updated.Body.Version != 3 {
+		t.Errorf("Expected version 3, got %d", updated.Body.Version)
+	}
+	got := get(false)
+	if ids := messageIDs(got.Messages); !slices.Equal(ids, []string{"u1", "a1", "u2"}) ||
+		got.Messages[1].Content != "hey" || got.Version != 3 {
+		t.Fatalf("Unexpected conversation after update %v %+v", ids, got.Messages)
+	}
+
+	// A second branch below u1, then deleting a1 removes u2 and keeps the other branch.
+	_, err = cc.ForkConversation(ctx, &spec.ForkConversationRequest{
+		ID:        convo.ID,
+		MessageID: "a1",
+		Body: &spec.ForkConversationRequestBody{
+			Title:    convo.Title,
+			Version:  3,
+			Messages: []spec.ConversationMessage{{ID: "b1", Role: spec.ConversationRoleAssistant}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to fork conversation: %v", err)
+	}
+	if _, err := cc.DeleteMessage(ctx, &spec.DeleteMessageRequest{
+		ID:        convo.ID,
+		MessageID: "a1",
+		Title:     convo.Title,
+		Version:   3,
+	}); !errors.Is(err, conversationstore.ErrVersionConflict) {
+		t.Fatalf("Expected a version conflict after the fork, got %v", err)
+	}
+	// Branch writes check the version too.
+	switchBranch := func(version int64) error {
+		_, err := cc.SwitchBranch(ctx, &spec.SwitchBranchRequest{
+			ID: convo.ID,
+			Body: &spec.SwitchBranchRequestBody{
+				Title:     convo.Title,
+				Version:   version,
+				MessageID: "u2",
+			},
+		})
+		return err
+	}
+	if err := switchBranch(3); !errors.Is(err, conversationstore.ErrVersionConflict) {
+		t.Fatalf("Expected a version conflict for switch branch, got %v", err)
+	}
+	if err := switchBranch(4); err != nil {
+		t.Fatalf("Failed to switch branch: %v", err)
+	}
+	deleted, err := cc.DeleteMessage(ctx, &spec.DeleteMessageRequest{
+		ID:        convo.ID,
+		MessageID: "a1",
+		Title:     convo.Title,
+		Version:   5,
+	})
+	if err != nil {
+		t.Fatalf("Failed to delete message: %v", err)
+	}
+	if deleted.Body.Version != 6 || deleted.Body.ActiveLeafID != "b1" {
+		t.Errorf("Unexpected delete result %+v", deleted.Body)
+	}
+	if ids := messageIDs(get(true).Messages); !slices.Equal(ids, []string{"u1", "b1"}) {
+		t.Errorf("Expected a1 and its replies to be deleted, got %v", ids)
+	}
+
+	// Full writes check the version when one is given.
+	stale := int64(5)
+	req := getNewPutRequestFromConversation(convo)
+	req.Body.Version = &stale
+	if _, err := cc.PutConversation(ctx, req); !errors.Is(
+		err,
+		conversationstore.ErrVersionConflict,
+	) {
+		t.Fatalf("Expected a version conflict for put conversation, got %v", err)
+	}
+	_, err = cc.PutMessagesToConversation(ctx, &spec.PutMessagesToConversationRequest{
+		ID: convo.ID,
+		Body: &spec.PutMessagesToConversationRequestBody{
+			Title:    convo.Title,
+			Messages: convo.Messages,
+			Version:  &stale,
+		},
+	})
+	if !errors.Is(err, conversationstore.ErrVersionConflict) {
+		t.Fatalf("Expected a version conflict for put messages, got %v", err)
+	}
+	current := int64(6)
+	req.Body.Version = &current
+	put, err = cc.PutConversation(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to replace conversation: %v", err)
+	}
+	if put.Body.Version != 7 {
+		t.Errorf("Expected version 7, got %d", put.Body.Version)
+	}
+}
+
+func TestVersionConflictStatus(t *testing.T) {
+	cc, err := conversationstore.NewConversationCollection(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convo, err := initConversation("Conflict status")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	if _, err := cc.PutConversation(t.Context(), getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	_, api := humatest.New(t)
+	conversationstore.InitConversationStoreHandlers(api, cc)
+
+	body := map[string]any{
+		"title":    convo.Title,
+		"messages": []spec.ConversationMessage{{Role: spec.ConversationRoleUser, Content: "hi"}},
+	}
+	for _, tc := range []struct {
+		version int64
+		status  int
+	}{
+		{version: 1, status: http.StatusOK},
+		{version: 1, status: http.StatusConflict},
+	} {
+		body["version"] = tc.version
+		resp := api.Post("/conversations/"+convo.ID+"/messages", body)
+		if resp.Code != tc.status {
+			t.Fatalf("Expected status %d for version %d, got %d: %s",
+				tc.status, tc.version, resp.Code, resp.Body.String())
+		}
+		if tc.status == http.StatusConflict &&
+			!strings.Contains(resp.Body.String(), conversationstore.VersionConflictCode) {
+			t.Errorf("Expected the conflict code in %s", resp.Body.String())
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package conversationstore
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/encdec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filenameprovider"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filestore"
+)
+
+const (
+	// metaFileName holds the meta of all conversations, keyed by conversation ID.
+	metaFileName         = "conversations.meta.json"
+	metaConversationsKey = "conversations"
+)
+
+func newMetaStore(baseDir string) (*filestore.MapFileStore, error) {
+	store, err := filestore.NewMapFileStore(
+		filepath.Join(baseDir, metaFileName),
+		map[string]any{metaConversationsKey: map[string]any{}},
+		filestore.WithCreateIfNotExists(true),
+		filestore.WithAutoFlush(true),
+		filestore.WithEncoderDecoder(encdec.JSONEncoderDecoder{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation meta store: %w", err)
+	}
+	return store, nil
+}
+
+// PutConversationMeta replaces the tags, folder and flags of a conversation.
+func (cc *ConversationCollection) PutConversationMeta(
+	ctx context.Context,
+	req *spec.PutConversationMetaRequest,
+) (*spec.PutConversationMetaResponse, error) {
+	if req == nil || req.Body == nil || req.ID == "" {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	fn, err := cc.fp.Build(filenameprovider.FileInfo{ID: req.ID})
+	if err != nil {
+		return nil, err
+	}
+	files, err := filesWithID(cc.store, cc.pp.GetPartitionDir(fn), req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("conversation %s not found", req.ID)
+	}
+	if err := cc.setMeta(req.ID, *req.Body); err != nil {
+		return nil, err
+	}
+	return &spec.PutConversationMetaResponse{}, nil
+}
+
+// ListConversationLabels lists the tags and folders of stored conversations, most used first.
+func (cc *ConversationCollection) ListConversationLabels(
+	ctx context.Context,
+	req *spec.ListConversationLabelsRequest,
+) (*spec.ListConversationLabelsResponse, error) {
+	// Trashed conversations keep their meta for a restore, but are not counted.
+	items, err := cc.listByMeta(spec.ConversationFilter{})
+	if err != nil {
+		return nil, err
+	}
+	tags, folders := map[string]int{}, map[string]int{}
+	for _, item := range items {
+		m := item.ConversationMeta
+		for _, t := range m.Tags {
+			tags[t]++
+		}
+		if m.Folder != "" {
+			folders[m.Folder]++
+		}
+	}
+	return &spec.ListConversationLabelsResponse{
+		Body: &spec.ListConversationLabelsResponseBody{
+			Tags:    labelCounts(tags),
+			Folders: labelCounts(folders),
+		},
+	}, nil
+}
+
+func labelCounts(counts map[string]int) []spec.LabelCount {
+	labels := make([]spec.LabelCount, 0, len(counts))
+	for name, n := range counts {
+		labels = append(labels, spec.LabelCount{Name: name, Count: n})
+	}
+	slices.SortFunc(labels, func(a, b spec.LabelCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Name, b.Name))
+	})
+	return labels
+}
+
+// listByMeta lists the conversations whose meta matches a filter, pinned first and then
+// newest first. Only conversations with meta can match, so the conversation files are
+// not walked.
+func (cc *ConversationCollection) listByMeta(
+	filter spec.ConversationFilter,
+) ([]spec.ConversationItem, error) {
+	metas, err := cc.allMeta()
+	if err != nil {
+		return nil, err
+	}
+	items := []spec.ConversationItem{}
+	for id, m := range metas {
+		if !matchesFilter(m, filter) {
+			continue
+		}
+		item, ok, err := cc.itemByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			item.ConversationMeta = m
+			items = append(items, item)
+		}
+	}
+	sortPinnedFirst(items)
+	return items, nil
+}
+
+// itemByID finds a stored conversation by ID. Trashed conversations are not found.
+func (cc *ConversationCollection) itemByID(id string) (spec.ConversationItem, bool, error) {
+	fn, err := cc.fp.Build(filenameprovider.FileInfo{ID: id})
+	if err != nil {
+		return spec.ConversationItem{}, false, err
+	}
+	files, err := filesWithID(cc.store, cc.pp.GetPartitionDir(fn), id)
+	if err != nil || len(files) == 0 {
+		return spec.ConversationItem{}, false, err
+	}
+	info, err := cc.fp.Parse(filepath.Base(files[0]))
+	if err != nil {
+		return spec.ConversationItem{}, false, nil
+	}
+	return spec.ConversationItem{
+		ID:        info.ID,
+		Title:     info.Title,
+		CreatedAt: info.CreatedAt,
+	}, true, nil
+}
+
+// withMeta sets the meta of items and drops the ones that do not match the filter.
+func (cc *ConversationCollection) withMeta(
+	items []spec.ConversationItem,
+	filter spec.ConversationFilter,
+) ([]spec.ConversationItem, error) {
+	metas, err := cc.allMeta()
+	if err != nil {
+		return nil, err
+	}
+	kept := items[:0]
+	for _, item := range items {
+		item.ConversationMeta = metas[item.ID]
+		if matchesFilter(item.ConversationMeta, filter) {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
+
+func matchesFilter(m spec.ConversationMeta, f spec.ConversationFilter) bool {
+	if (f.Pinned && !m.Pinned) || (f.Starred && !m.Starred) {
+		return false
+	}
+	if f.Folder != "" && f.Folder != m.Folder {
+		return false
+	}
+	for _, t := range f.Tags {
+		if !slices.Contains(m.Tags, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func isFiltered(f spec.ConversationFilter) bool {
+	return len(f.Tags) > 0 || f.Folder != "" || f.Pinned || f.Starred
+}
+
+// sortPinnedFirst moves pinned items first and sorts them newest first, the order of the
+// other items is kept.
+func sortPinnedFirst(items []spec.ConversationItem) {
+	slices.SortStableFunc(items, comparePinnedFirst)
+}
+
+func comparePinnedFirst(a, b spec.ConversationItem) int {
+	switch {
+	case a.Pinned && !b.Pinned:
+		return -1
+	case !a.Pinned && b.Pinned:
+		return 1
+	case a.Pinned:
+		return b.CreatedAt.Compare(a.CreatedAt)
+	}
+	return 0
+}
+
+func (cc *ConversationCollection) getMeta(id string) (spec.ConversationMeta, error) {
+	var m spec.ConversationMeta
+	if _, err := cc.meta.GetAll(true); err != nil {
+		return m, err
+	}
+	raw, err := cc.meta.GetKey([]string{metaConversationsKey, id})
+	if err != nil {
+		var notFound *filestore.KeyNotFoundError
+		if errors.As(err, &notFound) {
+			return m, nil
+		}
+		return m, err
+	}
+	data, ok := raw.(map[string]any)
+	if !ok {
+		return m, nil
+	}
+	err = encdec.MapToStructWithJSONTags(data, &m)
+	return m, err
+}
+
+func (cc *ConversationCollection) allMeta() (map[string]spec.ConversationMeta, error) {
+	// The desktop app and the HTTP backend share the file, so it is read again every time.
+	all, err := cc.meta.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := all[metaConversationsKey].(map[string]any)
+	metas := make(map[string]spec.ConversationMeta, len(raw))
+	for id, v := range raw {
+		data, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		var m spec.ConversationMeta
+		if err := encdec.MapToStructWithJSONTags(data, &m); err != nil {
+			continue
+		}
+		metas[id] = m
+	}
+	return metas, nil
+}
+
+// setMeta stores a cleaned up copy of m, empty meta is removed.
+func (cc *ConversationCollection) setMeta(id string, m spec.ConversationMeta) error {
+	tags := []string{}
+	for _, t := range m.Tags {
+		if t = strings.TrimSpace(t); t != "" && !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	m.Tags = tags
+	m.Folder = strings.TrimSpace(m.Folder)
+	if len(m.Tags) == 0 && m.Folder == "" && !m.Pinned && !m.Starred {
+		return cc.deleteMeta(id)
+	}
+	data, err := encdec.StructWithJSONTagsToMap(m)
+	if err != nil {
+		return err
+	}
+	return cc.updateMeta(func() error {
+		return cc.meta.SetKey([]string{metaConversationsKey, id}, data)
+	})
+}
+
+func (cc *ConversationCollection) deleteMeta(id string) error {
+	return cc.updateMeta(func() error {
+		return cc.meta.DeleteKey([]string{metaConversationsKey, id})
+	})
+}
+
+// updateMeta runs a write of the meta file on a fresh read of it. The write flushes the whole
+// file, without the read it would undo the changes of another process that shares it.
+func (cc *ConversationCollection) updateMeta(write func() error) error {
+	cc.metaMu.Lock()
+	defer cc.metaMu.Unlock()
+	if _, err := cc.meta.GetAll(true); err != nil {
+		return err
+	}
+	return write()
+}
@@ -0,0 +1,221 @@
+package conversationstore_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+func itemIDs(items []spec.ConversationItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, it.ID)
+	}
+	return ids
+}
+
+func TestConversationMeta(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	cc, err := conversationstore.NewConversationCollection(dir, conversationstore.WithFTS(true))
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convos := make([]*spec.Conversation, 3)
+	for i, title := range []string{"Mango one", "Mango two", "Mango three"} {
+		convo, err := initConversation(title)
+		if err != nil {
+			t.Fatalf("Failed to init conversation: %v", err)
+		}
+		convo.Messages = []spec.ConversationMessage{
+			{ID: "u", Role: spec.ConversationRoleUser, Content: "mango"},
+		}
+		if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+		convos[i] = convo
+		time.Sleep(2 * time.Millisecond)
+	}
+	oldest, middle, newest := convos[0], convos[1], convos[2]
+
+	putMeta := func(id string, m spec.ConversationMeta) {
+		t.Helper()
+		_, err := cc.PutConversationMeta(ctx, &spec.PutConversationMetaRequest{ID: id, Body: &m})
+		if err != nil {
+			t.Fatalf("Failed to put meta: %v", err)
+		}
+	}
+	putMeta(oldest.ID, spec.ConversationMeta{Pinned: true, Tags: []string{"fruit", " fruit", ""}})
+	putMeta(middle.ID, spec.ConversationMeta{
+		Tags:    []string{"fruit", "work"},
+		Folder:  "Project X",
+		Starred: true,
+	})
+
+	fileBefore, err := os.ReadFile(findConversationFile(t, dir, middle))
+	if err != nil {
+		t.Fatalf("Failed to read conversation file: %v", err)
+	}
+
+	list := func(filter spec.ConversationFilter) []spec.ConversationItem {
+		t.Helper()
+		resp, err := cc.ListConversations(ctx, &spec.ListConversationsRequest{
+			ConversationFilter: filter,
+		})
+		if err != nil {
+			t.Fatalf("Failed to list conversations: %v", err)
+		}
+		return resp.Body.ConversationItems
+	}
+	tests := []struct {
+		name   string
+		filter spec.ConversationFilter
+		want   []string
+	}{
+		{"pinned first", spec.ConversationFilter{}, []string{oldest.ID, newest.ID, middle.ID}},
+		{"tag", spec.ConversationFilter{Tags: []string{"fruit"}}, []string{oldest.ID, middle.ID}},
+		{"all tags", spec.ConversationFilter{Tags: []string{"fruit", "work"}}, []string{middle.ID}},
+		{"folder", spec.ConversationFilter{Folder: "Project X"}, []string{middle.ID}},
+		{"starred", spec.ConversationFilter{Starred: true}, []string{middle.ID}},
+		{"pinned", spec.ConversationFilter{Pinned: true}, []string{oldest.ID}},
+		{"no match", spec.ConversationFilter{Tags: []string{"none"}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := itemIDs(list(tt.filter)); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	items := list(spec.ConversationFilter{})
+	if !slices.Equal(items[0].Tags, []string{"fruit"}) {
+		t.Errorf("Expected cleaned up tags, got %q", items[0].Tags)
+	}
+
+	search, err := cc.SearchConversations(ctx, &spec.SearchConversationsRequest{
+		Query:              "mango",
+		ConversationFilter: spec.ConversationFilter{Tags: []string{"fruit"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to search conversations: %v", err)
+	}
+	if got := hitIDs(search.Body.ConversationItems); len(got) != 2 || got[0] != oldest.ID {
+		t.Errorf("Expected the 2 fruit hits with the pinned one first, got %v", got)
+	}
+
+	got, err := cc.GetConversation(ctx, &spec.GetConversationRequest{
+		ID:    middle.ID,
+		Title: middle.Title,
+	})
+	if err != nil {
+		t.Fatalf("Failed to get conversation: %v", err)
+	}
+	if got.Body.Folder != "Project X" || !got.Body.Starred {
+		t.Errorf("Expected the meta with the conversation, got %+v", got.Body.ConversationMeta)
+	}
+	fileAfter, err := os.ReadFile(findConversationFile(t, dir, middle))
+	if err != nil || string(fileAfter) != string(fileBefore) {
+		t.Errorf("Meta changes must not rewrite the conversation file")
+	}
+
+	labels, err := cc.ListConversationLabels(ctx, &spec.ListConversationLabelsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list labels: %v", err)
+	}
+	wantTags := []spec.LabelCount{{Name: "fruit", Count: 2}, {Name: "work", Count: 1}}
+	if !slices.Equal(labels.Body.Tags, wantTags) || len(labels.Body.Folders) != 1 {
+		t.Errorf("Unexpected labels %+v", labels.Body)
+	}
+
+	// Meta survives the trash and is purged with the conversation.
+	if _, err := cc.DeleteConversation(ctx, &spec.DeleteConversationRequest{
+		ID:    oldest.ID,
+		Title: oldest.Title,
+	}); err != nil {
+		t.Fatalf("Failed to delete conversation: %v", err)
+	}
+	if got := itemIDs(list(spec.ConversationFilter{Pinned: true})); len(got) != 0 {
+		t.Errorf("Trashed conversations must not be listed, got %v", got)
+	}
+	if _, err := cc.RestoreConversation(ctx, &spec.RestoreConversationRequest{
+		ID:    oldest.ID,
+		Title: oldest.Title,
+	}); err != nil {
+		t.Fatalf("Failed to restore conversation: %v", err)
+	}
+	if got := itemIDs(list(spec.ConversationFilter{Pinned: true})); len(got) != 1 {
+		t.Errorf("Expected the restored conversation to stay pinned, got %v", got)
+	}
+
+	_, err = cc.PutConversationMeta(ctx, &spec.PutConversationMetaRequest{
+		ID:   "0190163d-8694-739b-aea5-966c26f8ad91",
+		Body: &spec.ConversationMeta{Pinned: true},
+	})
+	if err == nil {
+		t.Error("Expected an error for an unknown conversation")
+	}
+}
+
+func findConversationFile(t *testing.T, dir string, c *spec.Conversation) string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*", c.ID+"_*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one file for conversation %s, got %v: %v", c.ID, files, err)
+	}
+	return files[0]
+}
+
+func TestConversationMetaSharedDir(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	// Two processes on the same data dir, e.g. the desktop app and the HTTP backend.
+	first, err := conversationstore.NewConversationCollection(dir)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	second, err := conversationstore.NewConversationCollection(dir)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	ids := []string{}
+	for _, title := range []string{"Shared one", "Shared two"} {
+		convo, err := initConversation(title)
+		if err != nil {
+			t.Fatalf("Failed to init conversation: %v", err)
+		}
+		if _, err := first.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+		ids = append(ids, convo.ID)
+	}
+
+	for i, cc := range []*conversationstore.ConversationCollection{first, second} {
+		_, err := cc.PutConversationMeta(ctx, &spec.PutConversationMetaRequest{
+			ID:   ids[i],
+			Body: &spec.ConversationMeta{Tags: []string{"shared"}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to put meta: %v", err)
+		}
+	}
+	for _, cc := range []*conversationstore.ConversationCollection{first, second} {
+		resp, err := cc.ListConversations(ctx, &spec.ListConversationsRequest{
+			ConversationFilter: spec.ConversationFilter{Tags: []string{"shared"}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to list conversations: %v", err)
+		}
+		got := itemIDs(resp.Body.ConversationItems)
+		slices.Sort(got)
+		want := slices.Sorted(slices.Values(ids))
+		if !slices.Equal(got, want) {
+			t.Errorf("Expected the tags of both writers %v, got %v", want, got)
+		}
+	}
+}
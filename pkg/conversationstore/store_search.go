@@ -0,0 +1,275 @@
+package conversationstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/ftsengine"
+)
+
+const (
+	snippetTokens = 16
+	// The markers are not letters or digits, so a query can not match them.
+	snippetMatchStart = "\x02"
+	snippetMatchEnd   = "\x03"
+)
+
+// SearchConversations returns the conversations that match the query, best first.
+// The titles returned here are sanitized names like in ListConversations.
+func (cc *ConversationCollection) SearchConversations(
+	ctx context.Context,
+	req *spec.SearchConversationsRequest,
+) (*spec.SearchConversationsResponse, error) {
+	if req == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	if cc.fts == nil {
+		return nil, errors.New("full-text search is disabled")
+	}
+	if err := cc.ftsQueue.wait(ctx); err != nil {
+		return nil, err
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 10
+	}
+
+	// The index has one column per role, named after it.
+	columns := make([]string, 0, len(req.Roles))
+	for _, r := range req.Roles {
+		columns = append(columns, string(r))
+	}
+	opts := ftsengine.SearchOptions{
+		Mode:           ftsengine.MatchMode(req.Mode),
+		Columns:        columns,
+		SnippetTokens:  snippetTokens,
+		HighlightStart: snippetMatchStart,
+		HighlightEnd:   snippetMatchEnd,
+	}
+	metas, err := cc.allMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	// The filters are not in the index, so index pages are read until the page is full or the
+	// index has no more hits. A page can end in the middle of an index page, the token then
+	// names the index page and how many of its hits were used.
+	ftsToken, skip := decodeSearchToken(req.Token)
+	items := make([]spec.ConversationSearchHit, 0, pageSize)
+	next := ""
+	for {
+		hits, ftsNext, err := cc.fts.SearchWithOptions(ctx, req.Query, opts, ftsToken, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for idx := skip; idx < len(hits) && len(items) < pageSize; idx++ {
+			if item, ok := cc.searchHit(hits[idx], req, metas); ok {
+				items = append(items, item)
+			}
+			if len(items) == pageSize {
+				switch {
+				case idx+1 < len(hits):
+					next = encodeSearchToken(ftsToken, idx+1)
+				case ftsNext != "":
+					next = encodeSearchToken(ftsNext, 0)
+				}
+			}
+		}
+		if len(items) == pageSize || ftsNext == "" {
+			break
+		}
+		ftsToken, skip = ftsNext, 0
+	}
+	slices.SortStableFunc(items, func(a, b spec.ConversationSearchHit) int {
+		return comparePinnedFirst(a.ConversationItem, b.ConversationItem)
+	})
+	return &spec.SearchConversationsResponse{
+		Body: &spec.SearchConversationsResponseBody{
+			ConversationItems: items,
+			NextPageToken:     &next,
+		},
+	}, nil
+}
+
+// searchHit returns the conversation of an index hit if it passes the filters of req.
+func (cc *ConversationCollection) searchHit(
+	h ftsengine.SearchResult,
+	req *spec.SearchConversationsRequest,
+	metas map[string]spec.ConversationMeta,
+) (spec.ConversationSearchHit, bool) {
+	info, err := cc.fp.Parse(filepath.Base(h.ID))
+	if err != nil {
+		return spec.ConversationSearchHit{}, false
+	}
+	if !inCreatedRange(info.CreatedAt, req.CreatedFrom, req.CreatedTo) ||
+		!matchesFilter(metas[info.ID], req.ConversationFilter) {
+		return spec.ConversationSearchHit{}, false
+	}
+	convo, err := cc.getConversationTree(info.ID, info.Title)
+	if err != nil {
+		// The file is gone since it was indexed.
+		return spec.ConversationSearchHit{}, false
+	}
+	if !hasMessageFrom(convo.Messages, req.Provider, req.Model) {
+		return spec.ConversationSearchHit{}, false
+	}
+	return spec.ConversationSearchHit{
+		ConversationItem: spec.ConversationItem{
+			ID:               info.ID,
+			Title:            info.Title,
+			CreatedAt:        info.CreatedAt,
+			ConversationMeta: metas[info.ID],
+		},
+		Snippet:            snippetParts(h.Snippet),
+		MatchingMessageIDs: matchingMessageIDs(convo.Messages, req.Query, req.Mode, req.Roles),
+	}, true
+}
+
+// searchToken is the position of a search page, the index page and the hits of it that are used.
+type searchToken struct {
+	FTSToken string `json:"t,omitempty"`
+	Skip     int    `json:"s,omitempty"`
+}
+
+func encodeSearchToken(ftsToken string, skip int) string {
+	buf, _ := json.Marshal(searchToken{FTSToken: ftsToken, Skip: skip})
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// decodeSearchToken reads a token of encodeSearchToken, an invalid token starts from the top.
+func decodeSearchToken(token string) (ftsToken string, skip int) {
+	if token == "" {
+		return "", 0
+	}
+	var t searchToken
+	buf, err := base64.StdEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(buf, &t) != nil || t.Skip < 0 {
+		return "", 0
+	}
+	return t.FTSToken, t.Skip
+}
+
+func inCreatedRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+func hasMessageFrom(msgs []spec.ConversationMessage, provider, model string) bool {
+	if provider == "" && model == "" {
+		return true
+	}
+	return slices.ContainsFunc(msgs, func(m spec.ConversationMessage) bool {
+		return (provider == "" || strings.EqualFold(m.Provider, provider)) &&
+			(model == "" || strings.EqualFold(m.Model, model))
+	})
+}
+
+// snippetParts splits a snippet of the index at its match markers.
+func snippetParts(s string) []spec.SnippetPart {
+	var parts []spec.SnippetPart
+	for s != "" {
+		before, rest, found := strings.Cut(s, snippetMatchStart)
+		if before != "" {
+			parts = append(parts, spec.SnippetPart{Text: before})
+		}
+		if !found {
+			break
+		}
+		match, after, _ := strings.Cut(rest, snippetMatchEnd)
+		if match != "" {
+			parts = append(parts, spec.SnippetPart{Text: match, Match: true})
+		}
+		s = after
+	}
+	return parts
+}
+
+// matchingMessageIDs returns the messages that match the query the way the index does.
+// The index stems words, here words only match by prefix or a trimmed plural or verb suffix.
+func matchingMessageIDs(
+	msgs []spec.ConversationMessage,
+	query string,
+	mode spec.SearchMode,
+	roles []spec.ConversationRoleEnum,
+) []string {
+	queryWords := searchWords(query)
+	if mode != spec.SearchModePhrase {
+		// The index skips one letter words outside of phrases.
+		queryWords = slices.DeleteFunc(queryWords, func(w string) bool {
+			r := []rune(w)
+			return len(r) == 1 && !unicode.IsDigit(r[0])
+		})
+	}
+	if len(queryWords) == 0 {
+		return nil
+	}
+
+	var ids []string
+	for _, m := range msgs {
+		if len(roles) > 0 && !slices.Contains(roles, m.Role) {
+			continue
+		}
+		if wordsMatch(searchWords(m.Content), queryWords, mode) {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+func wordsMatch(text, queryWords []string, mode spec.SearchMode) bool {
+	contains := func(q string) bool {
+		return slices.ContainsFunc(text, func(w string) bool { return wordMatches(w, q) })
+	}
+	switch mode {
+	case spec.SearchModePhrase:
+		for i := 0; i+len(queryWords) <= len(text); i++ {
+			matched := true
+			for j, q := range queryWords {
+				if !wordMatches(text[i+j], q) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				return true
+			}
+		}
+		return false
+	case spec.SearchModeAll:
+		for _, q := range queryWords {
+			if !contains(q) {
+				return false
+			}
+		}
+		return true
+	default:
+		return slices.ContainsFunc(queryWords, contains)
+	}
+}
+
+func wordMatches(word, query string) bool {
+	return strings.HasPrefix(word, query) || trimWordSuffix(word) == trimWordSuffix(query)
+}
+
+func trimWordSuffix(w string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if trimmed, ok := strings.CutSuffix(w, suffix); ok && len(trimmed) >= 3 {
+			return trimmed
+		}
+	}
+	return w
+}
+
+// searchWords splits text into lower case words of letters and digits.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
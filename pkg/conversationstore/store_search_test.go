@@ -0,0 +1,232 @@
+package conversationstore_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+func hitIDs(hits []spec.ConversationSearchHit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+func TestSearchConversationsOptions(t *testing.T) {
+	ctx := t.Context()
+	cc, err := conversationstore.NewConversationCollection(
+		t.TempDir(),
+		conversationstore.WithFTS(true),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+
+	put := func(title string, msgs []spec.ConversationMessage) *spec.Conversation {
+		t.Helper()
+		convo, err := initConversation(title)
+		if err != nil {
+			t.Fatalf("Failed to init conversation: %v", err)
+		}
+		convo.Messages = msgs
+		if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+		return convo
+	}
+	recipe := put("Dinner", []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser, Content: "How do I bake sourdough bread?"},
+		{
+			ID:       "a1",
+			Role:     spec.ConversationRoleAssistant,
+			Content:  "Feed the starter, then bake the bread hot.",
+			Provider: "openai",
+			Model:    "gpt-4o",
+		},
+		{ID: "u2", Role: spec.ConversationRoleUser, Content: "Thanks"},
+	})
+	shopping := put("Shopping", []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser, Content: "Buy bread and sourdough starter"},
+		{
+			ID:       "a1",
+			Role:     spec.ConversationRoleAssistant,
+			Content:  "Noted.",
+			Provider: "anthropic",
+			Model:    "claude-sonnet",
+		},
+	})
+
+	search := func(req spec.SearchConversationsRequest) []spec.ConversationSearchHit {
+		t.Helper()
+		resp, err := cc.SearchConversations(ctx, &req)
+		if err != nil {
+			t.Fatalf("Failed to search conversations: %v", err)
+		}
+		return resp.Body.ConversationItems
+	}
+	sorted := func(ids []string) []string {
+		slices.Sort(ids)
+		return ids
+	}
+	both := sorted([]string{recipe.ID, shopping.ID})
+
+	tests := []struct {
+		name string
+		req  spec.SearchConversationsRequest
+		want []string
+	}{
+		{"any", spec.SearchConversationsRequest{Query: "sourdough thanks"}, both},
+		{
+			"all",
+			spec.SearchConversationsRequest{Query: "sourdough thanks", Mode: spec.SearchModeAll},
+			[]string{recipe.ID},
+		},
+		{
+			"phrase",
+			spec.SearchConversationsRequest{
+				Query: "bread and sourdough",
+				Mode:  spec.SearchModePhrase,
+			},
+			[]string{shopping.ID},
+		},
+		{
+			"assistant only",
+			spec.SearchConversationsRequest{
+				Query: "starter",
+				Roles: []spec.ConversationRoleEnum{spec.ConversationRoleAssistant},
+			},
+			[]string{recipe.ID},
+		},
+		{
+			"created range",
+			spec.SearchConversationsRequest{
+				Query:       "bread",
+				CreatedFrom: time.Now().Add(-time.Hour),
+				CreatedTo:   time.Now().Add(time.Hour),
+			},
+			both,
+		},
+		{
+			"created later",
+			spec.SearchConversationsRequest{Query: "bread", CreatedFrom: time.Now().Add(time.Hour)},
+			[]string{},
+		},
+		{
+			"model",
+			spec.SearchConversationsRequest{Query: "bread", Model: "Claude-Sonnet"},
+			[]string{shopping.ID},
+		},
+		{
+			"provider and model",
+			spec.SearchConversationsRequest{
+				Query:    "bread",
+				Provider: "openai",
+				Model:    "claude-sonnet",
+			},
+			[]string{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sorted(hitIDs(search(tc.req))); !slices.Equal(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	hits := search(spec.SearchConversationsRequest{
+		Query: "bake bread",
+		Mode:  spec.SearchModeAll,
+	})
+	if len(hits) != 1 {
+		t.Fatalf("Expected 1 hit, got %v", hitIDs(hits))
+	}
+	if !slices.Equal(hits[0].MatchingMessageIDs, []string{"u1", "a1"}) {
+		t.Errorf("Expected the messages u1 and a1 to match, got %v", hits[0].MatchingMessageIDs)
+	}
+	matched := []string{}
+	for _, p := range hits[0].Snippet {
+		if p.Match {
+			matched = append(matched, p.Text)
+		}
+	}
+	if len(matched) == 0 {
+		t.Errorf("Expected highlighted words in snippet %+v", hits[0].Snippet)
+	}
+
+	hits = search(spec.SearchConversationsRequest{
+		Query: "bread",
+		Roles: []spec.ConversationRoleEnum{spec.ConversationRoleAssistant},
+	})
+	if len(hits) != 1 || !slices.Equal(hits[0].MatchingMessageIDs, []string{"a1"}) {
+		t.Errorf("Expected only the assistant message to match, got %+v", hits)
+	}
+}
+
+func TestSearchConversationsFilteredPages(t *testing.T) {
+	ctx := t.Context()
+	cc, err := conversationstore.NewConversationCollection(
+		t.TempDir(),
+		conversationstore.WithFTS(true),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	// More hits that do not pass the filter than fit on one page, before the ones that do.
+	want := []string{}
+	for i := range 8 {
+		convo, err := initConversation(fmt.Sprintf("Bread %d", i))
+		if err != nil {
+			t.Fatalf("Failed to init conversation: %v", err)
+		}
+		model := "gpt-4o"
+		if i >= 5 {
+			model = "claude-sonnet"
+			want = append(want, convo.ID)
+		}
+		convo.Messages = []spec.ConversationMessage{
+			{ID: "u1", Role: spec.ConversationRoleUser, Content: "bake bread"},
+			{ID: "a1", Role: spec.ConversationRoleAssistant, Content: "ok", Model: model},
+		}
+		if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+	}
+
+	got := []string{}
+	token := ""
+	for page := 0; ; page++ {
+		if page > 5 {
+			t.Fatal("Search did not end")
+		}
+		resp, err := cc.SearchConversations(ctx, &spec.SearchConversationsRequest{
+			Query:    "bread",
+			Token:    token,
+			PageSize: 2,
+			Model:    "claude-sonnet",
+		})
+		if err != nil {
+			t.Fatalf("Failed to search conversations: %v", err)
+		}
+		ids := hitIDs(resp.Body.ConversationItems)
+		token = *resp.Body.NextPageToken
+		if token != "" && len(ids) != 2 {
+			t.Fatalf("Expected a full page before the last one, got %v", ids)
+		}
+		got = append(got, ids...)
+		if token == "" {
+			break
+		}
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected filtered hits %v, got %v", want, got)
+	}
+}
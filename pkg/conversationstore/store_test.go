@@ -3,10 +3,12 @@ package conversationstore_test
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
 	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
 	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
 )
@@ -21,6 +23,7 @@ func getNewPutRequestFromConversation(c *spec.Conversation) *spec.PutConversatio
 			CreatedAt:  c.CreatedAt,
 			ModifiedAt: c.ModifiedAt,
 			Messages:   c.Messages,
+			Model:      c.Model,
 		},
 	}
 }
@@ -564,3 +567,90 @@ func TestConversationCollectionListing(t *testing.T) {
 		}
 	})
 }
+
+func TestMessageProvenance(t *testing.T) {
+	ctx := t.Context()
+	cc, err := conversationstore.NewConversationCollection(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convo, err := initConversation("Provenance")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	convo.Model = &spec.ConversationModel{Provider: "openai", Model: "gpt-4o", PresetID: "p1"}
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+
+	temperature := 0.2
+	cost := 0.0125
+	latency, ttft := int64(1500), int64(300)
+	reply := spec.ConversationMessage{
+		ID:      "a1",
+		Role:    spec.ConversationRoleAssistant,
+		Content: "Hi",
+		ModelParams: &aiproviderSpec.ModelParams{
+			Name:        "gpt-4o",
+			Temperature: &temperature,
+		},
+		Provider:           "openai",
+		Model:              "gpt-4o",
+		PresetID:           "p1",
+		Usage:              &spec.MessageUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+		Cost:               &cost,
+		LatencyMs:          &latency,
+		TimeToFirstTokenMs: &ttft,
+		FinishReason:       "stop",
+		RequestID:          "req_1",
+	}
+	messages := []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser, Content: "Hello"},
+		reply,
+	}
+	put := func(model *spec.ConversationModel) {
+		t.Helper()
+		_, err := cc.PutMessagesToConversation(ctx, &spec.PutMessagesToConversationRequest{
+			ID: convo.ID,
+			Body: &spec.PutMessagesToConversationRequestBody{
+				Title:    convo.Title,
+				Messages: messages,
+				Model:    model,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to put messages: %v", err)
+		}
+	}
+	get := func() *spec.Conversation {
+		t.Helper()
+		resp, err := cc.GetConversation(ctx, &spec.GetConversationRequest{
+			ID:    convo.ID,
+			Title: convo.Title,
+		})
+		if err != nil {
+			t.Fatalf("Failed to get conversation: %v", err)
+		}
+		return resp.Body
+	}
+
+	put(nil)
+	got := get()
+	if !reflect.DeepEqual(got.Model, convo.Model) {
+		t.Errorf("Expected the conversation model to be kept, got %+v", got.Model)
+	}
+	if len(got.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(got.Messages))
+	}
+	gotReply := got.Messages[1]
+	gotReply.ParentID = nil
+	if !reflect.DeepEqual(gotReply, reply) {
+		t.Errorf("Provenance not kept\nwant %+v\ngot  %+v", reply, gotReply)
+	}
+
+	changed := &spec.ConversationModel{Provider: "anthropic", Model: "claude-sonnet-4-20250514"}
+	put(changed)
+	if got := get(); !reflect.DeepEqual(got.Model, changed) {
+		t.Errorf("Expected the conversation model to change, got %+v", got.Model)
+	}
+}
@@ -0,0 +1,252 @@
+package conversationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filenameprovider"
+)
+
+const (
+	// The file name keeps the first 64 chars of a title.
+	maxTitleLength = 64
+	// Only the start of long messages is sent to the title model.
+	maxTitleInputLength = 2000
+	titleSystemPrompt   = "You write titles for chat conversations. " +
+		"Reply with the title only: at most six words, no quotes and no trailing punctuation."
+)
+
+// ErrTitlesDisabled is returned by GenerateConversationTitle when generated titles are turned off.
+var ErrTitlesDisabled = errors.New("generated conversation titles are disabled")
+
+// TitleModel is the model that titles conversations.
+type TitleModel struct {
+	Provider    aiproviderSpec.ProviderName
+	ModelParams aiproviderSpec.ModelParams
+	// Language of the titles, the language of the conversation if empty.
+	Language string
+}
+
+// TitleModelGetter returns the current title model, nil if generated titles are turned off.
+// It is called for every title, so changed settings apply without a restart.
+type TitleModelGetter func(ctx context.Context) (*TitleModel, error)
+
+// WithTitleGenerator enables GenerateConversationTitle.
+func WithTitleGenerator(completer promptrun.Completer, model TitleModelGetter) Option {
+	return func(cc *ConversationCollection) error {
+		if completer == nil || model == nil {
+			return errors.New("title generator needs a completer and a model")
+		}
+		cc.titleCompleter = completer
+		cc.titleModel = model
+		return nil
+	}
+}
+
+// GenerateConversationTitle titles a conversation from its first exchange and renames it.
+// It makes a completion call, callers should not wait on it before showing a reply.
+func (cc *ConversationCollection) GenerateConversationTitle(
+	ctx context.Context,
+	req *spec.GenerateConversationTitleRequest,
+) (*spec.GenerateConversationTitleResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	if cc.titleCompleter == nil {
+		return nil, errors.New("title generation is not configured")
+	}
+	model, err := cc.titleModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, ErrTitlesDisabled
+	}
+
+	convo, err := cc.getConversationTree(req.ID, req.Body.Title)
+	if err != nil {
+		return nil, err
+	}
+	exchange := firstExchange(activePath(convo))
+	if exchange == nil {
+		return nil, errors.New("conversation has no reply to title yet")
+	}
+	res, err := cc.titleCompleter.Complete(ctx, titleRequest(model, exchange))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate title: %w", err)
+	}
+	title := cleanTitle(res.Content)
+	if title == "" {
+		return nil, errors.New("got an empty title")
+	}
+	if req.Body.SuggestOnly {
+		return &spec.GenerateConversationTitleResponse{
+			Body: &spec.GenerateConversationTitleResponseBody{Title: title},
+		}, nil
+	}
+	version, err := cc.renameConversation(ctx, req.ID, req.Body.Title, title)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.GenerateConversationTitleResponse{
+		Body: &spec.GenerateConversationTitleResponseBody{Title: title, Version: version},
+	}, nil
+}
+
+// RenameConversation changes the title of a conversation and so the name of its file.
+func (cc *ConversationCollection) RenameConversation(
+	ctx context.Context,
+	req *spec.RenameConversationRequest,
+) (*spec.RenameConversationResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	title := strings.TrimSpace(req.Body.NewTitle)
+	if title == "" {
+		return nil, errors.New("new title cannot be empty")
+	}
+	version, err := cc.renameConversation(ctx, req.ID, req.Body.Title, title)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.RenameConversationResponse{
+		Body: &spec.ConversationVersionBody{Version: version},
+	}, nil
+}
+
+// renameConversation writes the conversation under its new file name, then removes the old
+// file and its search index entry. It returns the new version of the conversation.
+func (cc *ConversationCollection) renameConversation(
+	ctx context.Context,
+	id, title, newTitle string,
+) (int64, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	oldFn, err := cc.conversationFileName(id, title)
+	if err != nil {
+		return 0, err
+	}
+	convo, err := cc.readConversationTree(oldFn)
+	if err != nil {
+		return 0, err
+	}
+	convo.Title = newTitle
+	if err := cc.saveConversationTree(convo); err != nil {
+		return 0, err
+	}
+	newFn, err := cc.fileNameFromConversation(*convo)
+	if err != nil {
+		return 0, err
+	}
+	if newFn == oldFn {
+		return convo.Version, nil
+	}
+	if err := cc.store.DeleteFile(oldFn); err != nil {
+		return 0, err
+	}
+	cc.deleteFromIndex(oldFn)
+	return convo.Version, nil
+}
+
+// conversationFileName returns the file of a conversation. Titles can be stale, e.g. after a
+// generated title, so a file with the ID is used if there is none for the title.
+func (cc *ConversationCollection) conversationFileName(id, title string) (string, error) {
+	fn, err := cc.fp.Build(filenameprovider.FileInfo{ID: id, Title: title})
+	if err != nil {
+		return "", err
+	}
+	partitionDir := cc.pp.GetPartitionDir(fn)
+	if _, err := os.Stat(filepath.Join(cc.baseDir, partitionDir, fn)); err == nil {
+		return fn, nil
+	}
+	files, err := filesWithID(cc.store, partitionDir, id)
+	if err != nil || len(files) == 0 {
+		return fn, nil
+	}
+	return filepath.Base(files[0]), nil
+}
+
+// firstExchange returns the first user message and the reply to it, nil if there is no reply yet.
+func firstExchange(msgs []spec.ConversationMessage) []spec.ConversationMessage {
+	for i, m := range msgs {
+		if m.Role != spec.ConversationRoleUser || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		for _, reply := range msgs[i+1:] {
+			if reply.Role == spec.ConversationRoleAssistant &&
+				strings.TrimSpace(reply.Content) != "" {
+				return []spec.ConversationMessage{m, reply}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func titleRequest(model *TitleModel, exchange []spec.ConversationMessage) *promptrun.Request {
+	params := model.ModelParams
+	params.Stream = false
+	params.SystemPrompt = titleSystemPrompt
+	if model.Language != "" {
+		params.SystemPrompt += " Write the title in " + model.Language + "."
+	} else {
+		params.SystemPrompt += " Write the title in the language of the conversation."
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Title this conversation.\n")
+	for _, m := range exchange {
+		content := []rune(strings.TrimSpace(m.Content))
+		if len(content) > maxTitleInputLength {
+			content = append(content[:maxTitleInputLength], '…')
+		}
+		fmt.Fprintf(&prompt, "\n%s: %s\n", roleTitle(m), string(content))
+	}
+	return &promptrun.Request{
+		Provider:    model.Provider,
+		ModelParams: params,
+		Prompt:      prompt.String(),
+	}
+}
+
+// cleanTitle keeps the first line of a reply without labels, markdown and quotes.
+func cleanTitle(s string) string {
+	for line := range strings.SplitSeq(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if label, rest, found := strings.Cut(line, ":"); found &&
+			strings.EqualFold(strings.Trim(label, "#* "), "title") {
+			line = rest
+		}
+		line = strings.Trim(line, "#*_`\"'“”‘’ ")
+		line = strings.TrimRightFunc(line, func(r rune) bool {
+			return unicode.IsPunct(r) && r != ')' || unicode.IsSpace(r)
+		})
+		line = strings.Join(strings.Fields(line), " ")
+		return truncateTitle(line)
+	}
+	return ""
+}
+
+// truncateTitle cuts a long title at the last word that fits.
+func truncateTitle(s string) string {
+	if len(s) <= maxTitleLength {
+		return s
+	}
+	cut := s[:maxTitleLength]
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		return cut[:i]
+	}
+	return strings.ToValidUTF8(cut, "")
+}
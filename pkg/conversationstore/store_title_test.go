@@ -0,0 +1,176 @@
+package conversationstore_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+type fakeCompleter struct {
+	reply string
+	req   *promptrun.Request
+}
+
+func (f *fakeCompleter) Complete(
+	ctx context.Context,
+	req *promptrun.Request,
+) (*promptrun.Result, error) {
+	f.req = req
+	return &promptrun.Result{Content: f.reply}, nil
+}
+
+func TestGenerateConversationTitle(t *testing.T) {
+	ctx := t.Context()
+	completer := &fakeCompleter{reply: "Title: \"Weekend Sourdough Plan.\"\nIt is about bread."}
+	var model *conversationstore.TitleModel
+	cc, err := conversationstore.NewConversationCollection(
+		t.TempDir(),
+		conversationstore.WithFTS(true),
+		conversationstore.WithTitleGenerator(
+			completer,
+			func(ctx context.Context) (*conversationstore.TitleModel, error) { return model, nil },
+		),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+
+	convo, err := initConversation("How do I bake bread")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	convo.Messages = []spec.ConversationMessage{
+		{ID: "u1", Role: spec.ConversationRoleUser, Content: "How do I bake bread?"},
+	}
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	generate := func(title string) (string, error) {
+		resp, err := cc.GenerateConversationTitle(ctx, &spec.GenerateConversationTitleRequest{
+			ID:   convo.ID,
+			Body: &spec.GenerateConversationTitleRequestBody{Title: title},
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.Body.Title, nil
+	}
+
+	if _, err := generate(convo.Title); !errors.Is(err, conversationstore.ErrTitlesDisabled) {
+		t.Fatalf("Expected disabled titles, got %v", err)
+	}
+	model = &conversationstore.TitleModel{Provider: "openai", Language: "German"}
+	if _, err := generate(convo.Title); err == nil {
+		t.Fatal("Expected an error for a conversation without a reply")
+	}
+
+	_, err = cc.PutMessagesToConversation(ctx, &spec.PutMessagesToConversationRequest{
+		ID: convo.ID,
+		Body: &spec.PutMessagesToConversationRequestBody{
+			Title: convo.Title,
+			Messages: append(convo.Messages, spec.ConversationMessage{
+				ID:      "a1",
+				Role:    spec.ConversationRoleAssistant,
+				Content: "Mix flour, water and starter.",
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add reply: %v", err)
+	}
+	suggested, err := cc.GenerateConversationTitle(ctx, &spec.GenerateConversationTitleRequest{
+		ID:   convo.ID,
+		Body: &spec.GenerateConversationTitleRequestBody{Title: convo.Title, SuggestOnly: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to suggest title: %v", err)
+	}
+	if suggested.Body.Title != "Weekend Sourdough Plan" || suggested.Body.Version != 0 {
+		t.Errorf("Unexpected suggested title %+v", suggested.Body)
+	}
+	list, err := cc.ListConversations(ctx, &spec.ListConversationsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list conversations: %v", err)
+	}
+	if got := list.Body.ConversationItems; len(got) != 1 || got[0].Title == suggested.Body.Title {
+		t.Errorf("Expected a suggested title to keep the conversation title, got %+v", got)
+	}
+	title, err := generate(convo.Title)
+	if err != nil {
+		t.Fatalf("Failed to generate title: %v", err)
+	}
+	if title != "Weekend Sourdough Plan" {
+		t.Errorf("Expected cleaned up title, got %q", title)
+	}
+	if completer.req.ModelParams.Stream ||
+		!strings.Contains(completer.req.ModelParams.SystemPrompt, "German") ||
+		!strings.Contains(completer.req.Prompt, "Mix flour") {
+		t.Errorf("Unexpected title request %+v", completer.req)
+	}
+
+	list, err = cc.ListConversations(ctx, &spec.ListConversationsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list conversations: %v", err)
+	}
+	if got := list.Body.ConversationItems; len(got) != 1 || got[0].Title != title {
+		t.Fatalf("Expected one renamed conversation, got %+v", got)
+	}
+	search, err := cc.SearchConversations(ctx, &spec.SearchConversationsRequest{Query: "weekend"})
+	if err != nil {
+		t.Fatalf("Failed to search conversations: %v", err)
+	}
+	if got := hitIDs(search.Body.ConversationItems); len(got) != 1 || got[0] != convo.ID {
+		t.Errorf("Expected the new title to be searchable, got %v", got)
+	}
+
+	// Callers that still have the old title keep working.
+	_, err = cc.PutMessagesToConversation(ctx, &spec.PutMessagesToConversationRequest{
+		ID: convo.ID,
+		Body: &spec.PutMessagesToConversationRequestBody{
+			Title: convo.Title,
+			Messages: []spec.ConversationMessage{
+				{ID: "u1", Role: spec.ConversationRoleUser, Content: "How do I bake bread?"},
+				{
+					ID:      "a1",
+					Role:    spec.ConversationRoleAssistant,
+					Content: "Mix flour, water and starter.",
+				},
+				{ID: "u2", Role: spec.ConversationRoleUser, Content: "And rye?"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add message with the old title: %v", err)
+	}
+	_, err = cc.RenameConversation(ctx, &spec.RenameConversationRequest{
+		ID:   convo.ID,
+		Body: &spec.RenameConversationRequestBody{Title: title, NewTitle: "Bread notes"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to rename conversation: %v", err)
+	}
+	got, err := cc.GetConversation(ctx, &spec.GetConversationRequest{
+		ID:    convo.ID,
+		Title: "Bread notes",
+	})
+	if err != nil {
+		t.Fatalf("Failed to get conversation: %v", err)
+	}
+	if got.Body.Title != "Bread notes" || len(got.Body.Messages) != 3 {
+		t.Errorf("Expected renamed conversation with 3 messages, got %q with %d",
+			got.Body.Title, len(got.Body.Messages))
+	}
+	search, err = cc.SearchConversations(ctx, &spec.SearchConversationsRequest{Query: "weekend"})
+	if err != nil {
+		t.Fatalf("Failed to search conversations: %v", err)
+	}
+	if len(search.Body.ConversationItems) != 0 {
+		t.Errorf("Expected the old title to be gone from search, got %v",
+			hitIDs(search.Body.ConversationItems))
+	}
+}
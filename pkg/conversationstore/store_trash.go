@@ -0,0 +1,278 @@
+package conversationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/dirstore"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filenameprovider"
+)
+
+const (
+	// trashDirName is the directory of the trash store inside the base directory.
+	// It is not a month, so conversation listings and partitions never include it.
+	trashDirName = "trash"
+	deletedAtKey = "deletedAt"
+	// trashPurgeInterval limits how often deletes purge expired trash in a long running app.
+	trashPurgeInterval = time.Hour
+)
+
+// DefaultTrashRetention is how long deleted conversations are kept without another setting.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashRetentionGetter returns how long deleted conversations are kept in the trash.
+// A retention of zero or less keeps them until the trash is purged.
+type TrashRetentionGetter func(ctx context.Context) (time.Duration, error)
+
+// ListTrash lists trashed conversations, newest conversation first.
+func (cc *ConversationCollection) ListTrash(
+	ctx context.Context,
+	req *spec.ListTrashRequest,
+) (*spec.ListTrashResponse, error) {
+	token := ""
+	if req != nil {
+		token = req.Token
+	}
+	// Do not list what is due to be purged.
+	if err := cc.purgeExpiredTrash(ctx); err != nil {
+		slog.Warn("purge expired trash", "error", err)
+	}
+	files, next, err := cc.trash.ListFiles(
+		dirstore.ListingConfig{SortOrder: dirstore.SortOrderDescending},
+		token,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]spec.TrashItem, 0, len(files))
+	for _, f := range files {
+		fn := filepath.Base(f)
+		info, err := cc.fp.Parse(fn)
+		if err != nil {
+			continue
+		}
+		deletedAt, err := cc.trashedAt(fn)
+		if err != nil {
+			slog.Warn("list trash", "file", fn, "error", err)
+			continue
+		}
+		items = append(items, spec.TrashItem{
+			ConversationItem: spec.ConversationItem{
+				ID:        info.ID,
+				Title:     info.Title,
+				CreatedAt: info.CreatedAt,
+			},
+			DeletedAt: deletedAt,
+		})
+	}
+	return &spec.ListTrashResponse{
+		Body: &spec.ListTrashResponseBody{
+			TrashItems:    items,
+			NextPageToken: &next,
+		},
+	}, nil
+}
+
+// RestoreConversation moves a trashed conversation back. It fails if a conversation with the
+// same ID was stored since.
+func (cc *ConversationCollection) RestoreConversation(
+	ctx context.Context,
+	req *spec.RestoreConversationRequest,
+) (*spec.RestoreConversationResponse, error) {
+	if req == nil || req.ID == "" {
+		return nil, errors.New("request cannot be nil")
+	}
+	fn, err := cc.fp.Build(filenameprovider.FileInfo{ID: req.ID, Title: req.Title})
+	if err != nil {
+		return nil, err
+	}
+	data, err := cc.trash.GetFileData(fn, false)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := filesWithID(cc.store, cc.pp.GetPartitionDir(fn), req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("conversation %s already exists", req.ID)
+	}
+
+	delete(data, deletedAtKey)
+	// Writing to the store indexes the conversation again.
+	if err := cc.store.SetFileData(fn, data); err != nil {
+		return nil, err
+	}
+	if err := cc.trash.DeleteFile(fn); err != nil {
+		return nil, err
+	}
+	return &spec.RestoreConversationResponse{}, nil
+}
+
+// PurgeTrash permanently deletes trashed conversations.
+func (cc *ConversationCollection) PurgeTrash(
+	ctx context.Context,
+	req *spec.PurgeTrashRequest,
+) (*spec.PurgeTrashResponse, error) {
+	if req == nil || req.Body == nil {
+		return nil, errors.New("request or request body cannot be nil")
+	}
+	purged := 0
+	if len(req.Body.Conversations) == 0 {
+		err := cc.eachTrashFile(func(fn string) error {
+			if err := cc.purgeTrashFile(fn); err != nil {
+				return err
+			}
+			purged++
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, ref := range req.Body.Conversations {
+		fn, err := cc.fp.Build(filenameprovider.FileInfo{ID: ref.ID, Title: ref.Title})
+		if err != nil {
+			return nil, err
+		}
+		if err := cc.purgeTrashFile(fn); err != nil {
+			return nil, err
+		}
+		purged++
+	}
+	return &spec.PurgeTrashResponse{Body: &spec.PurgeTrashResponseBody{Purged: purged}}, nil
+}
+
+// moveToTrash stamps the conversation file with the deletion time and moves it to the trash.
+// An earlier trashed copy of the same conversation is replaced.
+func (cc *ConversationCollection) moveToTrash(fn, id string) error {
+	data, err := cc.store.GetFileData(fn, false)
+	if err != nil {
+		return err
+	}
+	data[deletedAtKey] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	earlier, err := filesWithID(cc.trash, cc.pp.GetPartitionDir(fn), id)
+	if err != nil {
+		return err
+	}
+	for _, f := range earlier {
+		if err := cc.trash.DeleteFile(filepath.Base(f)); err != nil {
+			return err
+		}
+	}
+	if err := cc.trash.SetFileData(fn, data); err != nil {
+		return err
+	}
+	return cc.store.DeleteFile(fn)
+}
+
+// purgeExpiredTrashEvery purges expired trash if the last purge is older than the interval.
+func (cc *ConversationCollection) purgeExpiredTrashEvery(ctx context.Context, d time.Duration) {
+	cc.trashPurgeMu.Lock()
+	due := time.Since(cc.trashPurgedAt) >= d
+	cc.trashPurgeMu.Unlock()
+	if !due {
+		return
+	}
+	if err := cc.purgeExpiredTrash(ctx); err != nil {
+		slog.Warn("purge expired trash", "error", err)
+	}
+}
+
+// purgeExpiredTrash deletes conversations that are in the trash for longer than the retention.
+func (cc *ConversationCollection) purgeExpiredTrash(ctx context.Context) error {
+	cc.trashPurgeMu.Lock()
+	defer cc.trashPurgeMu.Unlock()
+	retention, err := cc.trashRetention(ctx)
+	if err != nil {
+		return err
+	}
+	cc.trashPurgedAt = time.Now()
+	if retention <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-retention)
+	return cc.eachTrashFile(func(fn string) error {
+		deletedAt, err := cc.trashedAt(fn)
+		if err != nil {
+			// Keep what cannot be dated, it can still be purged by hand.
+			slog.Warn("purge expired trash", "file", fn, "error", err)
+			return nil
+		}
+		if deletedAt.After(cutoff) {
+			return nil
+		}
+		return cc.purgeTrashFile(fn)
+	})
+}
+
+// purgeTrashFile deletes a trashed conversation and its meta.
+func (cc *ConversationCollection) purgeTrashFile(fn string) error {
+	if err := cc.trash.DeleteFile(fn); err != nil {
+		return err
+	}
+	info, err := cc.fp.Parse(fn)
+	if err != nil {
+		return nil
+	}
+	return cc.deleteMeta(info.ID)
+}
+
+func (cc *ConversationCollection) trashedAt(fn string) (time.Time, error) {
+	data, err := cc.trash.GetFileData(fn, false)
+	if err != nil {
+		return time.Time{}, err
+	}
+	s, _ := data[deletedAtKey].(string)
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// eachTrashFile calls fn with the name of every trashed file. Files are listed before fn is
+// called, so fn may delete them.
+func (cc *ConversationCollection) eachTrashFile(fn func(name string) error) error {
+	names := []string{}
+	token := ""
+	for {
+		files, next, err := cc.trash.ListFiles(dirstore.ListingConfig{PageSize: 1000}, token)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			names = append(names, filepath.Base(f))
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	for _, name := range names {
+		if err := fn(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filesWithID lists the files of a partition that belong to the conversation with the ID.
+// We expect only 1 file max with the id prefix of uuid.
+func filesWithID(
+	store *dirstore.MapDirectoryStore,
+	partitionDir, id string,
+) ([]string, error) {
+	files, _, err := store.ListFiles(
+		dirstore.ListingConfig{
+			FilenamePrefix:   id,
+			PageSize:         10,
+			FilterPartitions: []string{partitionDir},
+		},
+		"",
+	)
+	return files, err
+}
@@ -0,0 +1,219 @@
+package conversationstore_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore"
+	"github.com/ppipada/flexigpt-app/pkg/conversationstore/spec"
+)
+
+func TestConversationTrash(t *testing.T) {
+	ctx := t.Context()
+	cc, err := conversationstore.NewConversationCollection(
+		t.TempDir(),
+		conversationstore.WithFTS(true),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convo, err := initConversation("Trashed kiwi")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	countListed := func() int {
+		t.Helper()
+		resp, err := cc.ListConversations(ctx, &spec.ListConversationsRequest{})
+		if err != nil {
+			t.Fatalf("Failed to list conversations: %v", err)
+		}
+		return len(resp.Body.ConversationItems)
+	}
+	countFound := func() int {
+		t.Helper()
+		resp, err := cc.SearchConversations(ctx, &spec.SearchConversationsRequest{Query: "kiwi"})
+		if err != nil {
+			t.Fatalf("Failed to search conversations: %v", err)
+		}
+		return len(resp.Body.ConversationItems)
+	}
+	listTrash := func() []spec.TrashItem {
+		t.Helper()
+		resp, err := cc.ListTrash(ctx, &spec.ListTrashRequest{})
+		if err != nil {
+			t.Fatalf("Failed to list trash: %v", err)
+		}
+		return resp.Body.TrashItems
+	}
+
+	before := time.Now()
+	_, err = cc.DeleteConversation(ctx, &spec.DeleteConversationRequest{
+		ID:    convo.ID,
+		Title: convo.Title,
+	})
+	if err != nil {
+		t.Fatalf("Failed to delete conversation: %v", err)
+	}
+	if n := countListed(); n != 0 {
+		t.Errorf("Expected no listed conversations, got %d", n)
+	}
+	if n := countFound(); n != 0 {
+		t.Errorf("Expected no search hits, got %d", n)
+	}
+	trash := listTrash()
+	if len(trash) != 1 || trash[0].ID != convo.ID {
+		t.Fatalf("Expected the conversation in the trash, got %+v", trash)
+	}
+	if trash[0].DeletedAt.Before(before.Add(-time.Second)) {
+		t.Errorf("Unexpected deletion time %v", trash[0].DeletedAt)
+	}
+
+	_, err = cc.RestoreConversation(ctx, &spec.RestoreConversationRequest{
+		ID:    trash[0].ID,
+		Title: trash[0].Title,
+	})
+	if err != nil {
+		t.Fatalf("Failed to restore conversation: %v", err)
+	}
+	if n := countListed(); n != 1 {
+		t.Errorf("Expected the restored conversation to be listed, got %d", n)
+	}
+	if n := countFound(); n != 1 {
+		t.Errorf("Expected the restored conversation to be searchable, got %d", n)
+	}
+	if trash := listTrash(); len(trash) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", trash)
+	}
+	got, err := cc.GetConversation(ctx, &spec.GetConversationRequest{
+		ID:    convo.ID,
+		Title: convo.Title,
+	})
+	if err != nil || len(got.Body.Messages) != len(convo.Messages) {
+		t.Errorf("Restored conversation differs: %v", err)
+	}
+
+	// Restoring over a stored conversation fails.
+	if _, err := cc.DeleteConversation(ctx, &spec.DeleteConversationRequest{
+		ID:    convo.ID,
+		Title: convo.Title,
+	}); err != nil {
+		t.Fatalf("Failed to delete conversation: %v", err)
+	}
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	_, err = cc.RestoreConversation(ctx, &spec.RestoreConversationRequest{
+		ID:    convo.ID,
+		Title: convo.Title,
+	})
+	if err == nil {
+		t.Error("Expected an error restoring over an existing conversation")
+	}
+
+	resp, err := cc.PurgeTrash(ctx, &spec.PurgeTrashRequest{Body: &spec.PurgeTrashRequestBody{}})
+	if err != nil {
+		t.Fatalf("Failed to purge trash: %v", err)
+	}
+	if resp.Body.Purged != 1 || len(listTrash()) != 0 {
+		t.Errorf("Expected 1 purged conversation, got %d", resp.Body.Purged)
+	}
+	if n := countListed(); n != 1 {
+		t.Errorf("Purge must not touch stored conversations, got %d", n)
+	}
+}
+
+func TestConversationTrashRetention(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	cc, err := conversationstore.NewConversationCollection(dir)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convo, err := initConversation("Expiring")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	if _, err := cc.DeleteConversation(ctx, &spec.DeleteConversationRequest{
+		ID:    convo.ID,
+		Title: convo.Title,
+	}); err != nil {
+		t.Fatalf("Failed to delete conversation: %v", err)
+	}
+
+	countTrash := func(retention time.Duration) int {
+		t.Helper()
+		cc, err := conversationstore.NewConversationCollection(
+			dir,
+			conversationstore.WithTrashRetention(retention),
+		)
+		if err != nil {
+			t.Fatalf("Failed to reopen conversation collection: %v", err)
+		}
+		resp, err := cc.ListTrash(ctx, &spec.ListTrashRequest{})
+		if err != nil {
+			t.Fatalf("Failed to list trash: %v", err)
+		}
+		return len(resp.Body.TrashItems)
+	}
+	if n := countTrash(time.Hour); n != 1 {
+		t.Fatalf("Expected the conversation to be kept, got %d", n)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := countTrash(time.Millisecond); n != 0 {
+		t.Errorf("Expected the expired conversation to be purged, got %d", n)
+	}
+}
+
+func TestConversationTrashRetentionSetting(t *testing.T) {
+	ctx := t.Context()
+	var retention atomic.Int64
+	retention.Store(int64(time.Hour))
+	cc, err := conversationstore.NewConversationCollection(
+		t.TempDir(),
+		conversationstore.WithTrashRetentionGetter(func(context.Context) (time.Duration, error) {
+			return time.Duration(retention.Load()), nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create conversation collection: %v", err)
+	}
+	convo, err := initConversation("Setting")
+	if err != nil {
+		t.Fatalf("Failed to init conversation: %v", err)
+	}
+	if _, err := cc.PutConversation(ctx, getNewPutRequestFromConversation(convo)); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	if _, err := cc.DeleteConversation(ctx, &spec.DeleteConversationRequest{
+		ID:    convo.ID,
+		Title: convo.Title,
+	}); err != nil {
+		t.Fatalf("Failed to delete conversation: %v", err)
+	}
+	countTrash := func() int {
+		t.Helper()
+		resp, err := cc.ListTrash(ctx, &spec.ListTrashRequest{})
+		if err != nil {
+			t.Fatalf("Failed to list trash: %v", err)
+		}
+		return len(resp.Body.TrashItems)
+	}
+	if n := countTrash(); n != 1 {
+		t.Fatalf("Expected the conversation to be kept, got %d", n)
+	}
+
+	// A changed setting applies without reopening the collection.
+	time.Sleep(10 * time.Millisecond)
+	retention.Store(int64(time.Millisecond))
+	if n := countTrash(); n != 0 {
+		t.Errorf("Expected the expired conversation to be purged, got %d", n)
+	}
+}
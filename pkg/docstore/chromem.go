@@ -28,7 +28,25 @@ func getEmbeddingFunc(
 	_ context.Context,
 	funcID spec.EmbeddingFuncID,
 	apiKey string,
+	embedder spec.Embedder,
 ) (chromem.EmbeddingFunc, error) {
+	// Provider backed IDs use the provider set configuration, apiKey is ignored.
+	if provider, modelName, ok := spec.ParseProviderEmbeddingFuncID(funcID); ok {
+		if embedder == nil {
+			return nil, errors.New("no embedder configured for provider embedding function ID")
+		}
+		return func(ctx context.Context, text string) ([]float32, error) {
+			embeddings, err := embedder.Embed(ctx, provider, modelName, []string{text})
+			if err != nil {
+				return nil, err
+			}
+			if len(embeddings) != 1 {
+				return nil, errors.New("unexpected number of embeddings")
+			}
+			return embeddings[0], nil
+		}, nil
+	}
+
 	// These are from OpenAI platform
 	// If other providers like Azure or OLlama are to be used which provide OpenAI compatible API, declareNewIDs and use.
 	omodel, exists := embeddingModelMapOpenAI[funcID]
@@ -51,6 +69,7 @@ type ChromemDocumentDB struct {
 	collections map[spec.DocumentCollectionID]*spec.DocumentCollection
 	basePath    string
 	compress    bool
+	embedder    spec.Embedder
 	chromemDB   *chromem.DB
 }
 
@@ -89,6 +108,14 @@ func WithCompression(compress bool) Option {
 	}
 }
 
+// WithEmbedder sets the embedder used for provider backed embedding function IDs.
+func WithEmbedder(embedder spec.Embedder) Option {
+	return func(db *ChromemDocumentDB) error {
+		db.embedder = embedder
+		return nil
+	}
+}
+
 // NewChromemDocumentDB initializes a new ChromemDocumentDB with the given options.
 func NewChromemDocumentDB(options ...Option) (*ChromemDocumentDB, error) {
 	db := &ChromemDocumentDB{
@@ -138,7 +165,7 @@ func (db *ChromemDocumentDB) CreateCollection(
 	}
 	metadata["name"] = name
 
-	efunc, err := getEmbeddingFunc(ctx, embeddingFuncID, apiKey)
+	efunc, err := getEmbeddingFunc(ctx, embeddingFuncID, apiKey, db.embedder)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package docstore
 
 import (
 	"errors"
+	"path/filepath"
 
 	"github.com/ppipada/flexigpt-app/pkg/docstore/spec"
 )
@@ -12,7 +13,9 @@ type DocumentDBSet struct {
 	docDBs   map[spec.DocumentDBID]spec.IDocumentDB
 }
 
-func InitDocumentDBSet(dds *DocumentDBSet, basePath string) error {
+// InitDocumentDBSet initializes the set with the default local docstore.
+// Embedder may be nil, in which case only the inbuilt embedding functions are available.
+func InitDocumentDBSet(dds *DocumentDBSet, basePath string, embedder spec.Embedder) error {
 	if dds == nil || basePath == "" {
 		return errors.New("invalid input arguments to InitDocumentDBSet")
 	}
@@ -23,8 +26,9 @@ func InitDocumentDBSet(dds *DocumentDBSet, basePath string) error {
 	cdb, err := NewChromemDocumentDB(
 		WithName(spec.ChromemDocStoreName),
 		WithMetadata(map[string]string{}),
-		WithBasePath(spec.ChromemDocStorePath),
+		WithBasePath(filepath.Join(basePath, spec.ChromemDocStorePath)),
 		WithCompression(true),
+		WithEmbedder(embedder),
 	)
 	if err != nil {
 		return err
@@ -23,3 +23,7 @@ const (
 	EmbeddingModelCohereMultilingualV3      EmbeddingFuncID = "embed-multilingual-v3.0"
 	EmbeddingModelCohereEnglishV3           EmbeddingFuncID = "embed-english-v3.0"
 )
+
+// ProviderEmbeddingFuncIDPrefix marks an EmbeddingFuncID that is served by a configured AI provider.
+// The full ID is "provider:<providerName>/<modelName>".
+const ProviderEmbeddingFuncIDPrefix = "provider:"
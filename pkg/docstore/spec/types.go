@@ -1,9 +1,49 @@
 package spec
 
-import "context"
+import (
+	"context"
+	"strings"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
 
 type EmbeddingFuncID string
 
+// NewProviderEmbeddingFuncID returns the EmbeddingFuncID for a model of a configured AI provider.
+func NewProviderEmbeddingFuncID(
+	provider aiproviderSpec.ProviderName,
+	modelName aiproviderSpec.ModelName,
+) EmbeddingFuncID {
+	return EmbeddingFuncID(
+		ProviderEmbeddingFuncIDPrefix + string(provider) + "/" + string(modelName),
+	)
+}
+
+// ParseProviderEmbeddingFuncID splits a provider EmbeddingFuncID into its provider and model.
+func ParseProviderEmbeddingFuncID(
+	id EmbeddingFuncID,
+) (provider aiproviderSpec.ProviderName, modelName aiproviderSpec.ModelName, ok bool) {
+	rest, found := strings.CutPrefix(string(id), ProviderEmbeddingFuncIDPrefix)
+	if !found {
+		return "", "", false
+	}
+	p, m, found := strings.Cut(rest, "/")
+	if !found || p == "" || m == "" {
+		return "", "", false
+	}
+	return aiproviderSpec.ProviderName(p), aiproviderSpec.ModelName(m), true
+}
+
+// Embedder computes embeddings using the keys and endpoints of a configured AI provider.
+type Embedder interface {
+	Embed(
+		ctx context.Context,
+		provider aiproviderSpec.ProviderName,
+		modelName aiproviderSpec.ModelName,
+		inputs []string,
+	) ([][]float32, error)
+}
+
 type DocumentID string
 
 type Document struct {
@@ -0,0 +1,234 @@
+package evals
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+const defaultJudgePrompt = `You grade the output of an AI assistant against a rubric.
+
+Rubric:
+{{rubric}}
+
+Input given to the assistant:
+{{input}}
+
+Output of the assistant:
+{{output}}
+
+Reply with only a JSON object like {"pass": true, "reason": "one sentence"}.`
+
+type AssertionResult struct {
+	Type    AssertionType `json:"type"`
+	Passed  bool          `json:"passed"`
+	Message string        `json:"message,omitempty"`
+	// JudgePrompt and JudgeOutput record the exchange of llmJudge, so that it can be replayed.
+	JudgePrompt string `json:"judgePrompt,omitempty"`
+	JudgeOutput string `json:"judgeOutput,omitempty"`
+}
+
+// completion is what assertions check.
+type completion struct {
+	input     string
+	output    string
+	usage     *promptrun.Usage
+	latencyMs int64
+}
+
+// check runs a validated assertion. Only judge errors are returned, failed checks are results.
+func (r *Runner) check(
+	ctx context.Context,
+	judge *promptrun.Renderer,
+	a Assertion,
+	c completion,
+) (AssertionResult, error) {
+	res := AssertionResult{Type: a.Type}
+	switch a.Type {
+	case AssertContains, AssertNotContains:
+		found := strings.Contains(c.output, a.Value)
+		if a.IgnoreCase {
+			found = strings.Contains(strings.ToLower(c.output), strings.ToLower(a.Value))
+		}
+		res.Passed = found == (a.Type == AssertContains)
+		if !res.Passed {
+			res.Message = fmt.Sprintf("%s %q", a.Type, a.Value)
+		}
+	case AssertRegex:
+		re, err := compilePattern(a)
+		if err != nil {
+			return res, err
+		}
+		res.Passed = re.MatchString(c.output)
+		if !res.Passed {
+			res.Message = fmt.Sprintf("no match for %q", a.Value)
+		}
+	case AssertJSONSchema:
+		res.Passed, res.Message = checkJSONSchema(a.Schema, c.output)
+	case AssertMaxTokens:
+		if c.usage == nil {
+			res.Message = "no token usage reported"
+			break
+		}
+		res.Passed = c.usage.OutputTokens <= a.Max
+		if !res.Passed {
+			res.Message = fmt.Sprintf("%d output tokens, max %d", c.usage.OutputTokens, a.Max)
+		}
+	case AssertMaxLatency:
+		res.Passed = c.latencyMs <= int64(a.Max)
+		if !res.Passed {
+			res.Message = fmt.Sprintf("took %dms, max %dms", c.latencyMs, a.Max)
+		}
+	case AssertLLMJudge:
+		return r.checkWithJudge(ctx, judge, a, c)
+	}
+	return res, nil
+}
+
+func (r *Runner) checkWithJudge(
+	ctx context.Context,
+	judge *promptrun.Renderer,
+	a Assertion,
+	c completion,
+) (AssertionResult, error) {
+	res := AssertionResult{Type: a.Type}
+	req, err := judge.Render(map[string]any{
+		"rubric": a.Rubric,
+		"input":  c.input,
+		"output": c.output,
+	})
+	if err != nil {
+		return res, err
+	}
+	res.JudgePrompt = req.Prompt
+	result, err := r.completer.Complete(ctx, req)
+	if err != nil {
+		return res, fmt.Errorf("judge: %w", err)
+	}
+	res.JudgeOutput = result.Content
+	res.Passed, res.Message = parseVerdict(result.Content)
+	return res, nil
+}
+
+// parseVerdict reads the judge reply. Replies that are not the asked for JSON fall back
+// to a leading PASS or FAIL, anything else fails.
+func parseVerdict(reply string) (bool, string) {
+	var verdict struct {
+		Pass   *bool  `json:"pass"`
+		Reason string `json:"reason"`
+	}
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start >= 0 && end > start &&
+		json.Unmarshal([]byte(reply[start:end+1]), &verdict) == nil && verdict.Pass != nil {
+		return *verdict.Pass, verdict.Reason
+	}
+	trimmed := strings.ToUpper(strings.TrimSpace(reply))
+	switch {
+	case strings.HasPrefix(trimmed, "PASS"):
+		return true, ""
+	case strings.HasPrefix(trimmed, "FAIL"):
+		return false, strings.TrimSpace(reply)
+	}
+	return false, "unreadable judge reply"
+}
+
+var codeFenceRE = regexp.MustCompile("(?s)^\\s*```[A-Za-z]*\\s*\n(.*?)\n\\s*```\\s*$")
+
+func checkJSONSchema(rawSchema json.RawMessage, output string) (bool, string) {
+	schema, err := parseSchema(rawSchema)
+	if err != nil {
+		return false, err.Error()
+	}
+	if m := codeFenceRE.FindStringSubmatch(output); m != nil {
+		output = m[1]
+	}
+	var v any
+	if err := json.Unmarshal([]byte(output), &v); err != nil {
+		return false, "output is not JSON: " + err.Error()
+	}
+	res := &huma.ValidateResult{}
+	huma.Validate(
+		schemaRegistry,
+		schema,
+		huma.NewPathBuffer([]byte(""), 0),
+		huma.ModeWriteToServer,
+		v,
+		res,
+	)
+	if len(res.Errors) > 0 {
+		msgs := make([]string, 0, len(res.Errors))
+		for _, e := range res.Errors {
+			msgs = append(msgs, e.Error())
+		}
+		return false, strings.Join(msgs, "; ")
+	}
+	return true, ""
+}
+
+// schemaRegistry is empty, $ref in suite schemas is not supported.
+var schemaRegistry = huma.NewMapRegistry("#/components/schemas/", huma.DefaultSchemaNamer)
+
+func parseSchema(raw json.RawMessage) (*huma.Schema, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("schema is required")
+	}
+	// Schema fields have no JSON tags and are matched by name, except for $ref.
+	if bytes.Contains(raw, []byte(`"$ref"`)) {
+		return nil, errors.New("$ref is not supported in schemas")
+	}
+	schema := &huma.Schema{}
+	if err := json.Unmarshal(raw, schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := prepareSchema(schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// prepareSchema precomputes the validation messages of schema and all its subschemas,
+// huma does this for schemas it generates but not for decoded ones.
+func prepareSchema(s *huma.Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("invalid schema pattern: %w", err)
+		}
+	}
+	s.PrecomputeMessages()
+	subs := []*huma.Schema{s.Items, s.Not}
+	for _, p := range s.Properties {
+		subs = append(subs, p)
+	}
+	// A decoded additionalProperties schema is a map, huma expects a *huma.Schema.
+	if m, ok := s.AdditionalProperties.(map[string]any); ok {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		ap := &huma.Schema{}
+		if err := json.Unmarshal(data, ap); err != nil {
+			return fmt.Errorf("invalid schema: %w", err)
+		}
+		s.AdditionalProperties = ap
+		subs = append(subs, ap)
+	}
+	subs = append(subs, s.OneOf...)
+	subs = append(subs, s.AnyOf...)
+	subs = append(subs, s.AllOf...)
+	for _, sub := range subs {
+		if err := prepareSchema(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
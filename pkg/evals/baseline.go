@@ -0,0 +1,144 @@
+package evals
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ChangeType string
+
+const (
+	// ChangeRegressed is a case that passed in the baseline and fails now.
+	ChangeRegressed ChangeType = "regressed"
+	// ChangeFixed is a case that failed in the baseline and passes now.
+	ChangeFixed         ChangeType = "fixed"
+	ChangeAdded         ChangeType = "added"
+	ChangeRemoved       ChangeType = "removed"
+	ChangeOutputChanged ChangeType = "outputChanged"
+)
+
+type CaseDiff struct {
+	Name           string     `json:"name"`
+	Change         ChangeType `json:"change"`
+	BaselinePassed bool       `json:"baselinePassed"`
+	Passed         bool       `json:"passed"`
+	// OutputDiff is a line diff of the baseline and current output, if the output changed.
+	OutputDiff string `json:"outputDiff,omitempty"`
+}
+
+type Comparison struct {
+	Regressions int        `json:"regressions"`
+	Diffs       []CaseDiff `json:"diffs"`
+}
+
+// SaveReport writes report as indented JSON, creating the parent directory.
+func SaveReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// Compare lists the cases of current that differ from baseline, in the case order of current
+// followed by removed cases. Cases with the same result and output are left out.
+func Compare(baseline, current *Report) *Comparison {
+	cmp := &Comparison{Diffs: []CaseDiff{}}
+	base := make(map[string]CaseResult, len(baseline.Cases))
+	for _, c := range baseline.Cases {
+		base[c.Name] = c
+	}
+	seen := make(map[string]bool, len(current.Cases))
+	for _, c := range current.Cases {
+		seen[c.Name] = true
+		b, ok := base[c.Name]
+		if !ok {
+			cmp.Diffs = append(
+				cmp.Diffs,
+				CaseDiff{Name: c.Name, Change: ChangeAdded, Passed: c.Passed},
+			)
+			continue
+		}
+		diff := CaseDiff{Name: c.Name, BaselinePassed: b.Passed, Passed: c.Passed}
+		if b.Output != c.Output {
+			diff.OutputDiff = diffLines(b.Output, c.Output)
+		}
+		switch {
+		case b.Passed && !c.Passed:
+			diff.Change = ChangeRegressed
+			cmp.Regressions++
+		case !b.Passed && c.Passed:
+			diff.Change = ChangeFixed
+		case diff.OutputDiff != "":
+			diff.Change = ChangeOutputChanged
+		default:
+			continue
+		}
+		cmp.Diffs = append(cmp.Diffs, diff)
+	}
+	for _, b := range baseline.Cases {
+		if !seen[b.Name] {
+			cmp.Diffs = append(cmp.Diffs, CaseDiff{
+				Name:           b.Name,
+				Change:         ChangeRemoved,
+				BaselinePassed: b.Passed,
+			})
+		}
+	}
+	return cmp
+}
+
+// diffLines is a longest common subsequence diff, removed lines are prefixed with "- " and
+// added lines with "+ ". Outputs are short, so the quadratic table is fine.
+func diffLines(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			sb.WriteString("  " + x[i] + "\n")
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + x[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + y[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,265 @@
+package evals
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+// fakeCompleter answers with the first reply whose key is a substring of the prompt.
+type fakeCompleter struct {
+	replies [][2]string
+}
+
+func (f *fakeCompleter) Complete(
+	ctx context.Context,
+	req *promptrun.Request,
+) (*promptrun.Result, error) {
+	for _, r := range f.replies {
+		if key, reply := r[0], r[1]; strings.Contains(req.Prompt, key) {
+			return &promptrun.Result{
+				Content: reply,
+				Usage:   &promptrun.Usage{OutputTokens: len(strings.Fields(reply))},
+			}, nil
+		}
+	}
+	return nil, errors.New("no reply")
+}
+
+func testSuite() *Suite {
+	target := promptrun.Target{
+		Prompt:      "Answer: {{question}}",
+		Provider:    "openai",
+		ModelParams: aiproviderSpec.ModelParams{Name: "gpt"},
+	}
+	judge := target
+	judge.Prompt = ""
+	return &Suite{
+		Name:   "qa",
+		Target: target,
+		Judge:  &judge,
+		Assertions: []Assertion{
+			{Type: AssertNotContains, Value: "sorry", IgnoreCase: true},
+		},
+		Cases: []Case{
+			{
+				Name: "capital",
+				Vars: map[string]any{"question": "capital of France"},
+				Assertions: []Assertion{
+					{Type: AssertContains, Value: "paris", IgnoreCase: true},
+					{Type: AssertLLMJudge, Rubric: "names a city"},
+				},
+			},
+			{
+				Name: "json",
+				Vars: map[string]any{"question": "json please"},
+				Assertions: []Assertion{
+					{Type: AssertJSONSchema, Schema: json.RawMessage(`{
+						"type": "object",
+						"required": ["n"],
+						"properties": {"n": {"type": "integer", "minimum": 1}}
+					}`)},
+					{Type: AssertMaxTokens, Max: 4},
+				},
+			},
+			{
+				Name:       "refusal",
+				Vars:       map[string]any{"question": "secret"},
+				Assertions: []Assertion{{Type: AssertRegex, Value: `^\d+$`}},
+			},
+		},
+	}
+}
+
+func testCompleter() *fakeCompleter {
+	return &fakeCompleter{replies: [][2]string{
+		{"You grade", `Sure. {"pass": true, "reason": "a city"}`},
+		{"capital of France", "It is Paris."},
+		{"json please", "```json\n{\"n\": 2}\n```"},
+		{"secret", "Sorry, I cannot."},
+	}}
+}
+
+func TestRun(t *testing.T) {
+	suite := testSuite()
+	if err := suite.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	report, err := NewRunner(testCompleter()).Run(context.Background(), suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Passed != 2 || report.Failed != 1 || report.Model != "gpt" {
+		t.Fatalf("report = %+v", report)
+	}
+	capital := report.Cases[0]
+	if !capital.Passed || len(capital.Assertions) != 3 || capital.Assertions[2].JudgeOutput == "" {
+		t.Errorf("capital = %+v", capital)
+	}
+	refusal := report.Cases[2]
+	if refusal.Passed || refusal.Assertions[0].Passed || refusal.Assertions[1].Passed {
+		t.Errorf("refusal = %+v", refusal)
+	}
+}
+
+func TestCheckJSONSchema(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {"tags": {"type": "array", "items": {"type": "string"}}},
+		"additionalProperties": {"type": "number"}
+	}`)
+	tests := []struct {
+		output string
+		passed bool
+	}{
+		{`{"tags": ["a"], "score": 1}`, true},
+		{`{"tags": [1]}`, false},
+		{`{"score": "high"}`, false},
+		{"not json", false},
+	}
+	for _, tt := range tests {
+		if passed, msg := checkJSONSchema(schema, tt.output); passed != tt.passed {
+			t.Errorf("%s: passed = %v (%s)", tt.output, passed, msg)
+		}
+	}
+}
+
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		reply  string
+		passed bool
+	}{
+		{`{"pass": false, "reason": "x"}`, false},
+		{"```json\n{\"pass\": true}\n```", true},
+		{"PASS - looks right", true},
+		{"FAIL", false},
+		{"maybe", false},
+	}
+	for _, tt := range tests {
+		if passed, _ := parseVerdict(tt.reply); passed != tt.passed {
+			t.Errorf("%q: passed = %v", tt.reply, passed)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Cases: []CaseResult{
+		{Name: "a", Passed: true, Output: "one\ntwo"},
+		{Name: "b", Passed: false, Output: "x"},
+		{Name: "c", Passed: true, Output: "same"},
+		{Name: "gone", Passed: true},
+	}}
+	current := &Report{Cases: []CaseResult{
+		{Name: "a", Passed: false, Output: "one\nthree"},
+		{Name: "b", Passed: true, Output: "x"},
+		{Name: "c", Passed: true, Output: "same"},
+		{Name: "new", Passed: true},
+	}}
+	cmp := Compare(baseline, current)
+	if cmp.Regressions != 1 {
+		t.Errorf("regressions = %d", cmp.Regressions)
+	}
+	got := map[string]ChangeType{}
+	for _, d := range cmp.Diffs {
+		got[d.Name] = d.Change
+	}
+	want := map[string]ChangeType{
+		"a":    ChangeRegressed,
+		"b":    ChangeFixed,
+		"new":  ChangeAdded,
+		"gone": ChangeRemoved,
+	}
+	if len(got) != len(want) {
+		t.Errorf("diffs = %+v", cmp.Diffs)
+	}
+	for name, change := range want {
+		if got[name] != change {
+			t.Errorf("%s: change = %q, want %q", name, got[name], change)
+		}
+	}
+	if d := cmp.Diffs[0].OutputDiff; d != "  one\n- two\n+ three\n" {
+		t.Errorf("output diff = %q", d)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	suite := testSuite()
+	report, err := NewRunner(testCompleter()).Run(context.Background(), suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := SaveReport(path, report); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := LoadReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := NewRunner(NewReplayCompleter(baseline)).Run(context.Background(), suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp := Compare(baseline, replayed); len(cmp.Diffs) != 0 {
+		t.Errorf("replay differs from baseline: %+v", cmp.Diffs)
+	}
+
+	suite.Cases[0].Vars["question"] = "capital of Spain"
+	replayed, err = NewRunner(NewReplayCompleter(baseline)).Run(context.Background(), suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := replayed.Cases[0]; c.Passed || !strings.Contains(c.Error, ErrNoRecording.Error()) {
+		t.Errorf("unrecorded case = %+v", c)
+	}
+}
+
+func TestLoadSuite(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadSuite(filepath.Join(dir, "suite.yaml")); !errors.Is(err, ErrInvalidSuite) {
+		t.Errorf("yaml: err = %v", err)
+	}
+
+	valid := `{
+		"name": "s",
+		"target": {"prompt": "{{q}}", "provider": "openai", "modelParams": {"name": "gpt"}},
+		"cases": [{"name": "c", "vars": {"q": 1}, "assertions": [%s]}]
+	}`
+	tests := []struct {
+		name      string
+		assertion string
+		wantErr   bool
+	}{
+		{"valid", `{"type": "maxLatencyMs", "max": 100}`, false},
+		{"unknown field", `{"type": "contains", "valu": "x"}`, true},
+		{"unknown type", `{"type": "equals", "value": "x"}`, true},
+		{"bad regex", `{"type": "regex", "value": "("}`, true},
+		{"bad schema pattern", `{"type": "jsonSchema", "schema": {"pattern": "("}}`, true},
+		{"schema ref", `{"type": "jsonSchema", "schema": {"$ref": "#/x"}}`, true},
+		{"judge without judge", `{"type": "llmJudge", "rubric": "x"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "suite.json")
+			content := strings.Replace(valid, "%s", tt.assertion, 1)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadSuite(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSuite) {
+				t.Errorf("err = %v is not ErrInvalidSuite", err)
+			}
+		})
+	}
+}
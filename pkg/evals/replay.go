@@ -0,0 +1,57 @@
+package evals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+// ErrNoRecording is returned by ReplayCompleter for prompts that are not in its report.
+var ErrNoRecording = errors.New("no recorded completion")
+
+// ReplayCompleter answers prompts with the outputs recorded in a report, so that suites can
+// run in CI without a provider. Completions are matched on the rendered prompt, judge
+// completions on the judge prompt. Replayed latency is close to zero.
+type ReplayCompleter struct {
+	results map[string]*promptrun.Result
+}
+
+func NewReplayCompleter(report *Report) *ReplayCompleter {
+	rc := &ReplayCompleter{results: map[string]*promptrun.Result{}}
+	for _, c := range report.Cases {
+		if c.Error != "" {
+			continue
+		}
+		rc.results[c.Prompt] = &promptrun.Result{Content: c.Output, Usage: c.Usage}
+		for _, a := range c.Assertions {
+			if a.JudgePrompt != "" && a.JudgeOutput != "" {
+				rc.results[a.JudgePrompt] = &promptrun.Result{Content: a.JudgeOutput}
+			}
+		}
+	}
+	return rc
+}
+
+func (rc *ReplayCompleter) Complete(
+	ctx context.Context,
+	req *promptrun.Request,
+) (*promptrun.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res, ok := rc.results[req.Prompt]
+	if !ok {
+		return nil, fmt.Errorf("%w for prompt %q", ErrNoRecording, truncate(req.Prompt, 60))
+	}
+	return res, nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
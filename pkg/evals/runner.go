@@ -0,0 +1,144 @@
+package evals
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+const defaultConcurrency = 4
+
+type CaseResult struct {
+	Name string `json:"name"`
+	// Prompt is the rendered user prompt, replays are matched on it.
+	Prompt     string            `json:"prompt"`
+	Output     string            `json:"output"`
+	Usage      *promptrun.Usage  `json:"usage,omitempty"`
+	LatencyMs  int64             `json:"latencyMs"`
+	Error      string            `json:"error,omitempty"`
+	Passed     bool              `json:"passed"`
+	Assertions []AssertionResult `json:"assertions"`
+}
+
+// Report is the result of a suite run. It is also the format of stored baselines.
+type Report struct {
+	Suite     string                      `json:"suite"`
+	Provider  aiproviderSpec.ProviderName `json:"provider"`
+	Model     aiproviderSpec.ModelName    `json:"model"`
+	StartedAt time.Time                   `json:"startedAt"`
+	Passed    int                         `json:"passed"`
+	Failed    int                         `json:"failed"`
+	Cases     []CaseResult                `json:"cases"`
+}
+
+// Runner runs suites through a Completer, which is the provider set outside of tests.
+type Runner struct {
+	completer promptrun.Completer
+}
+
+func NewRunner(completer promptrun.Completer) *Runner {
+	return &Runner{completer: completer}
+}
+
+// Run runs all cases of a validated suite. Cases that fail to render or complete are
+// failed results, only a cancelled ctx stops the run with an error.
+func (r *Runner) Run(ctx context.Context, suite *Suite) (*Report, error) {
+	renderer, err := promptrun.NewRenderer(suite.Target)
+	if err != nil {
+		return nil, err
+	}
+	var judge *promptrun.Renderer
+	if suite.Judge != nil {
+		target := *suite.Judge
+		if target.Prompt == "" && target.Template == nil {
+			target.Prompt = defaultJudgePrompt
+		}
+		if judge, err = promptrun.NewRenderer(target); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &Report{
+		Suite:     suite.Name,
+		StartedAt: time.Now().UTC(),
+		Cases:     make([]CaseResult, len(suite.Cases)),
+	}
+	report.Provider, report.Model = renderer.Model()
+
+	concurrency := suite.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, c := range suite.Cases {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			assertions := append(append([]Assertion{}, suite.Assertions...), c.Assertions...)
+			report.Cases[i] = r.runCase(ctx, renderer, judge, c, assertions)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range report.Cases {
+		if c.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+func (r *Runner) runCase(
+	ctx context.Context,
+	renderer, judge *promptrun.Renderer,
+	c Case,
+	assertions []Assertion,
+) CaseResult {
+	res := CaseResult{Name: c.Name, Assertions: []AssertionResult{}}
+	req, err := renderer.Render(c.Vars)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Prompt = req.Prompt
+
+	start := time.Now()
+	result, err := r.completer.Complete(ctx, req)
+	res.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Output, res.Usage = result.Content, result.Usage
+
+	comp := completion{
+		input:     req.Prompt,
+		output:    result.Content,
+		usage:     result.Usage,
+		latencyMs: res.LatencyMs,
+	}
+	res.Passed = true
+	for _, a := range assertions {
+		ar, err := r.check(ctx, judge, a, comp)
+		if err != nil {
+			ar.Passed = false
+			ar.Message = err.Error()
+		}
+		res.Passed = res.Passed && ar.Passed
+		res.Assertions = append(res.Assertions, ar)
+	}
+	return res
+}
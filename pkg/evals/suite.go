@@ -0,0 +1,184 @@
+// Package evals runs prompt regression suites: every case is rendered into a prompt, completed,
+// and its output checked by assertions. Reports can be compared against a stored baseline run.
+package evals
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ppipada/flexigpt-app/pkg/promptrun"
+)
+
+// ErrInvalidSuite is returned for suites that cannot be run.
+var ErrInvalidSuite = errors.New("invalid eval suite")
+
+type AssertionType string
+
+const (
+	AssertContains    AssertionType = "contains"
+	AssertNotContains AssertionType = "notContains"
+	AssertRegex       AssertionType = "regex"
+	// AssertJSONSchema checks that the output is JSON that is valid against Schema.
+	// A surrounding markdown code fence is ignored.
+	AssertJSONSchema AssertionType = "jsonSchema"
+	// AssertMaxTokens limits the output tokens reported by the provider.
+	AssertMaxTokens AssertionType = "maxTokens"
+	// AssertMaxLatency limits the completion time in milliseconds.
+	AssertMaxLatency AssertionType = "maxLatencyMs"
+	// AssertLLMJudge asks the suite judge model whether the output meets Rubric.
+	AssertLLMJudge AssertionType = "llmJudge"
+)
+
+type Assertion struct {
+	Type AssertionType `json:"type"`
+	// Value is the text of contains and notContains, or the pattern of regex.
+	Value      string `json:"value,omitempty"`
+	IgnoreCase bool   `json:"ignoreCase,omitempty"`
+	// Schema is the JSON schema of jsonSchema.
+	Schema json.RawMessage `json:"schema,omitempty"`
+	// Max is the limit of maxTokens and maxLatencyMs.
+	Max int `json:"max,omitempty"`
+	// Rubric is what the judge grades the output against.
+	Rubric string `json:"rubric,omitempty"`
+}
+
+// Case is one input of a suite.
+type Case struct {
+	Name string `json:"name"`
+	// Vars fill the {{name}} placeholders of the suite target.
+	Vars       map[string]any `json:"vars,omitempty"`
+	Assertions []Assertion    `json:"assertions,omitempty"`
+}
+
+type Suite struct {
+	Name   string           `json:"name"`
+	Target promptrun.Target `json:"target"`
+	// Judge is the model of llmJudge assertions. If its prompt is empty, a built-in grading
+	// prompt is used, a custom one can use the {{rubric}}, {{input}} and {{output}} placeholders.
+	Judge *promptrun.Target `json:"judge,omitempty"`
+	// Assertions are checked for every case, before the assertions of the case.
+	Assertions []Assertion `json:"assertions,omitempty"`
+	Cases      []Case      `json:"cases"`
+	// Concurrency is the number of cases run at once, default is 4.
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// LoadSuite reads and validates a JSON suite file. Unknown fields are rejected, so that a typo
+// in an assertion does not silently skip it.
+func LoadSuite(path string) (*Suite, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return nil, fmt.Errorf("%w: YAML suites are not supported, use JSON", ErrInvalidSuite)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	suite := &Suite{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(suite); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSuite, path, err)
+	}
+	if err := suite.Validate(); err != nil {
+		return nil, err
+	}
+	return suite, nil
+}
+
+// Validate checks the target, the case names and all assertions.
+func (s *Suite) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSuite)
+	}
+	if _, err := promptrun.NewRenderer(s.Target); err != nil {
+		return fmt.Errorf("%w: target: %w", ErrInvalidSuite, err)
+	}
+	if s.Judge != nil {
+		judge := *s.Judge
+		if judge.Prompt == "" && judge.Template == nil {
+			judge.Prompt = defaultJudgePrompt
+		}
+		if _, err := promptrun.NewRenderer(judge); err != nil {
+			return fmt.Errorf("%w: judge: %w", ErrInvalidSuite, err)
+		}
+	}
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("%w: no cases", ErrInvalidSuite)
+	}
+	if err := s.validateAssertions("suite", s.Assertions); err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			return fmt.Errorf("%w: case %d has no name", ErrInvalidSuite, i)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("%w: duplicate case %q", ErrInvalidSuite, c.Name)
+		}
+		names[c.Name] = true
+		if err := s.validateAssertions("case "+c.Name, c.Assertions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Suite) validateAssertions(where string, assertions []Assertion) error {
+	for i, a := range assertions {
+		if err := s.validateAssertion(a); err != nil {
+			return fmt.Errorf("%w: %s: assertion %d: %w", ErrInvalidSuite, where, i, err)
+		}
+	}
+	return nil
+}
+
+func (s *Suite) validateAssertion(a Assertion) error {
+	switch a.Type {
+	case AssertContains, AssertNotContains:
+		if a.Value == "" {
+			return errors.New("value is required")
+		}
+	case AssertRegex:
+		if _, err := compilePattern(a); err != nil {
+			return err
+		}
+	case AssertJSONSchema:
+		if _, err := parseSchema(a.Schema); err != nil {
+			return err
+		}
+	case AssertMaxTokens, AssertMaxLatency:
+		if a.Max <= 0 {
+			return errors.New("max must be positive")
+		}
+	case AssertLLMJudge:
+		if a.Rubric == "" {
+			return errors.New("rubric is required")
+		}
+		if s.Judge == nil {
+			return errors.New("the suite has no judge")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", a.Type)
+	}
+	return nil
+}
+
+func compilePattern(a Assertion) (*regexp.Regexp, error) {
+	if a.Value == "" {
+		return nil, errors.New("value is required")
+	}
+	pattern := a.Value
+	if a.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
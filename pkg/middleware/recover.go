@@ -8,12 +8,8 @@ import (
 
 // WithRecoveryResp is a helper that recovers from any panic, logs the stack trace,
 // and returns an error to the caller. T must match the response type of your function.
-func WithRecoveryResp[T any](fn func() (T, error)) (T, error) {
-	var (
-		result T
-		err    error
-	)
-
+// The results are named so that the deferred recover can set the returned error.
+func WithRecoveryResp[T any](fn func() (T, error)) (result T, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			// Log the panic plus stack trace.
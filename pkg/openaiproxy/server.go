@@ -0,0 +1,389 @@
+package openaiproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+const maxRequestBodyBytes = 16 * 1024 * 1024
+
+// ErrModelNotFound is returned by backends for models that are not configured.
+var ErrModelNotFound = errors.New("model not found")
+
+// CompletionRequest is an OpenAI chat request resolved to a provider and model.
+type CompletionRequest struct {
+	Provider  spec.ProviderName
+	ModelName spec.ModelName
+	// SystemPrompt overrides the system prompt of the model preset if set.
+	SystemPrompt *string
+	Prompt       string
+	PrevMessages []spec.ChatCompletionRequestMessage
+	Temperature  *float64
+	MaxTokens    *int
+	Stream       bool
+	// OnStreamData is set for streaming requests, backends that cannot stream may ignore it.
+	OnStreamData func(data string) error
+}
+
+type CompletionResult struct {
+	Content string
+	Usage   *Usage
+}
+
+// Backend routes proxy requests to the configured providers.
+type Backend interface {
+	Complete(ctx context.Context, req *CompletionRequest) (*CompletionResult, error)
+	ListModels(ctx context.Context) ([]Model, error)
+}
+
+// TokenVerifier reports whether a bearer token may use the proxy.
+type TokenVerifier interface {
+	Verify(token string) bool
+}
+
+// ConversationLogger is called after every successful completion.
+type ConversationLogger func(ctx context.Context, req *CompletionRequest, result *CompletionResult) error
+
+type Option func(*Server) error
+
+// WithConversationLogger logs every completed exchange using l.
+func WithConversationLogger(l ConversationLogger) Option {
+	return func(s *Server) error {
+		s.logger = l
+		return nil
+	}
+}
+
+// Server serves /v1/chat/completions and /v1/models in OpenAI wire format.
+type Server struct {
+	backend  Backend
+	verifier TokenVerifier
+	logger   ConversationLogger
+	mux      *http.ServeMux
+}
+
+func NewServer(backend Backend, verifier TokenVerifier, opts ...Option) (*Server, error) {
+	if backend == nil || verifier == nil {
+		return nil, errors.New("openaiproxy: backend and token verifier are required")
+	}
+	s := &Server{backend: backend, verifier: verifier}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("GET /v1/models", s.handleModels)
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !s.verifier.Verify(strings.TrimSpace(token)) {
+		writeError(w, http.StatusUnauthorized, "invalid_request_error", "invalid_api_key",
+			"invalid or missing proxy token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	models, err := s.backend.ListModels(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "", err.Error())
+		return
+	}
+	for i := range models {
+		if models[i].Object == "" {
+			models[i].Object = objectModel
+		}
+	}
+	writeJSON(w, http.StatusOK, ModelList{Object: objectList, Data: models})
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var in ChatCompletionRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	if err := dec.Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "",
+			"invalid request body: "+err.Error())
+		return
+	}
+	req, err := toCompletionRequest(&in)
+	if err != nil {
+		if errors.Is(err, ErrModelNotFound) {
+			writeBackendError(w, err)
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+
+	u, err := uuid.NewV7()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "", err.Error())
+		return
+	}
+	id := "chatcmpl-" + u.String()
+	created := time.Now().Unix()
+
+	if in.Stream {
+		includeUsage := in.StreamOptions != nil && in.StreamOptions.IncludeUsage
+		s.streamCompletion(r.Context(), w, req, id, created, in.Model, includeUsage)
+		return
+	}
+
+	result, err := s.backend.Complete(r.Context(), req)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+	s.logConversation(r.Context(), req, result)
+	writeJSON(w, http.StatusOK, ChatCompletionResponse{
+		ID:      id,
+		Object:  objectChatCompletion,
+		Created: created,
+		Model:   in.Model,
+		Choices: []Choice{{
+			Message:      ResponseMessage{Role: string(spec.Assistant), Content: result.Content},
+			FinishReason: finishReasonStop,
+		}},
+		Usage: result.Usage,
+	})
+}
+
+// streamCompletion sends the completion as server sent events.
+// Headers are written with the first chunk, so errors before that still get a proper status code.
+func (s *Server) streamCompletion(
+	ctx context.Context,
+	w http.ResponseWriter,
+	req *CompletionRequest,
+	id string,
+	created int64,
+	model string,
+	includeUsage bool,
+) {
+	flusher, _ := w.(http.Flusher)
+	started := false
+	streamed := false
+	send := func(v any) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	chunk := func(delta ChunkDelta, finishReason *string) ChatCompletionChunk {
+		return ChatCompletionChunk{
+			ID:      id,
+			Object:  objectChatCompletionChunk,
+			Created: created,
+			Model:   model,
+			Choices: []ChunkChoice{{Delta: delta, FinishReason: finishReason}},
+		}
+	}
+
+	role := string(spec.Assistant)
+	req.OnStreamData = func(data string) error {
+		if data == "" {
+			return nil
+		}
+		delta := ChunkDelta{Content: data}
+		if !streamed {
+			delta.Role = role
+			streamed = true
+		}
+		return send(chunk(delta, nil))
+	}
+
+	result, err := s.backend.Complete(ctx, req)
+	if err != nil {
+		if !started {
+			writeBackendError(w, err)
+			return
+		}
+		_ = send(ErrorResponse{Error: ErrorBody{Message: err.Error(), Type: "server_error"}})
+		return
+	}
+
+	// Models that do not support streaming return the whole content at once.
+	if !streamed && result.Content != "" {
+		if err := send(chunk(ChunkDelta{Role: role, Content: result.Content}, nil)); err != nil {
+			return
+		}
+	}
+	stop := finishReasonStop
+	if err := send(chunk(ChunkDelta{}, &stop)); err != nil {
+		return
+	}
+	if includeUsage && result.Usage != nil {
+		usageChunk := chunk(ChunkDelta{}, nil)
+		usageChunk.Choices = []ChunkChoice{}
+		usageChunk.Usage = result.Usage
+		if err := send(usageChunk); err != nil {
+			return
+		}
+	}
+	if _, err := fmt.Fprint(w, "data: [DONE]\n\n"); err != nil {
+		return
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	s.logConversation(ctx, req, result)
+}
+
+func (s *Server) logConversation(
+	ctx context.Context,
+	req *CompletionRequest,
+	result *CompletionResult,
+) {
+	if s.logger == nil {
+		return
+	}
+	if err := s.logger(ctx, req, result); err != nil {
+		slog.Warn("openaiproxy: failed to log conversation", "error", err)
+	}
+}
+
+// ParseModelID splits a "provider/model" ID at the first slash, model names may contain slashes.
+func ParseModelID(id string) (spec.ProviderName, spec.ModelName, error) {
+	provider, model, ok := strings.Cut(id, "/")
+	if !ok || provider == "" || model == "" {
+		return "", "", fmt.Errorf(
+			"%w: model %q must be of the form provider/model",
+			ErrModelNotFound,
+			id,
+		)
+	}
+	return spec.ProviderName(provider), spec.ModelName(model), nil
+}
+
+// ModelID is the inverse of ParseModelID.
+func ModelID(provider spec.ProviderName, model spec.ModelName) string {
+	return string(provider) + "/" + string(model)
+}
+
+// toCompletionRequest maps an OpenAI request to a prompt with history.
+// Leading system messages become the system prompt and the last message must be from the user.
+func toCompletionRequest(in *ChatCompletionRequest) (*CompletionRequest, error) {
+	provider, model, err := ParseModelID(in.Model)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.Messages) == 0 {
+		return nil, errors.New("messages must not be empty")
+	}
+
+	req := &CompletionRequest{
+		Provider:    provider,
+		ModelName:   model,
+		Temperature: in.Temperature,
+		MaxTokens:   in.MaxCompletionTokens,
+		Stream:      in.Stream,
+	}
+	if req.MaxTokens == nil {
+		req.MaxTokens = in.MaxTokens
+	}
+
+	messages := in.Messages
+	var systemParts []string
+	for len(messages) > 0 && isSystemRole(messages[0].Role) {
+		text, err := messages[0].Text()
+		if err != nil {
+			return nil, err
+		}
+		systemParts = append(systemParts, text)
+		messages = messages[1:]
+	}
+	if len(systemParts) > 0 {
+		systemPrompt := strings.Join(systemParts, "\n\n")
+		req.SystemPrompt = &systemPrompt
+	}
+	if len(messages) == 0 || messages[len(messages)-1].Role != string(spec.User) {
+		return nil, errors.New("last message must be a user message")
+	}
+
+	for idx, m := range messages {
+		text, err := m.Text()
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", idx, err)
+		}
+		if idx == len(messages)-1 {
+			req.Prompt = text
+			break
+		}
+		var role spec.ChatCompletionRoleEnum
+		switch {
+		case m.Role == string(spec.User):
+			role = spec.User
+		case m.Role == string(spec.Assistant):
+			role = spec.Assistant
+		case isSystemRole(m.Role):
+			role = spec.System
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role %q", idx, m.Role)
+		}
+		msg := spec.ChatCompletionRequestMessage{Role: role, Content: &text}
+		if m.Name != "" {
+			name := m.Name
+			msg.Name = &name
+		}
+		req.PrevMessages = append(req.PrevMessages, msg)
+	}
+	if req.Prompt == "" {
+		return nil, errors.New("last user message must not be empty")
+	}
+	return req, nil
+}
+
+func isSystemRole(role string) bool {
+	return role == string(spec.System) || role == string(spec.Developer)
+}
+
+func writeBackendError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrModelNotFound) {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "model_not_found", err.Error())
+		return
+	}
+	writeError(w, http.StatusBadGateway, "api_error", "", err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, errType, code, message string) {
+	body := ErrorResponse{Error: ErrorBody{Message: message, Type: errType}}
+	if code != "" {
+		body.Error.Code = &code
+	}
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("openaiproxy: failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,283 @@
+package openaiproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+const testToken = "fgpt_test"
+
+type staticVerifier struct{}
+
+func (staticVerifier) Verify(token string) bool { return token == testToken }
+
+type fakeBackend struct {
+	chunks  []string
+	content string
+	err     error
+	got     *CompletionRequest
+}
+
+func (f *fakeBackend) Complete(
+	ctx context.Context,
+	req *CompletionRequest,
+) (*CompletionResult, error) {
+	f.got = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	if req.OnStreamData != nil {
+		for _, c := range f.chunks {
+			if err := req.OnStreamData(c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &CompletionResult{
+		Content: f.content,
+		Usage:   &Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+	}, nil
+}
+
+func (f *fakeBackend) ListModels(ctx context.Context) ([]Model, error) {
+	return []Model{{ID: "anthropic/claude-sonnet-4", OwnedBy: "anthropic"}}, nil
+}
+
+func newTestServer(t *testing.T, b *fakeBackend, opts ...Option) *httptest.Server {
+	t.Helper()
+	s, err := NewServer(b, staticVerifier{}, opts...)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func doRequest(t *testing.T, ts *httptest.Server, method, path, token, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(
+		t.Context(),
+		method,
+		ts.URL+path,
+		strings.NewReader(body),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServer_Auth(t *testing.T) {
+	ts := newTestServer(t, &fakeBackend{})
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "fgpt_wrong", http.StatusUnauthorized},
+		{"valid token", testToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, ts, http.MethodGet, "/v1/models", tt.token, "")
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestServer_Models(t *testing.T) {
+	ts := newTestServer(t, &fakeBackend{})
+	resp := doRequest(t, ts, http.MethodGet, "/v1/models", testToken, "")
+	var list ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Object != "list" || len(list.Data) != 1 || list.Data[0].Object != "model" ||
+		list.Data[0].ID != "anthropic/claude-sonnet-4" {
+		t.Fatalf("unexpected models list %+v", list)
+	}
+}
+
+func TestServer_ChatCompletion(t *testing.T) {
+	var logged *CompletionResult
+	b := &fakeBackend{content: "hello there"}
+	ts := newTestServer(t, b, WithConversationLogger(
+		func(ctx context.Context, req *CompletionRequest, result *CompletionResult) error {
+			logged = result
+			return nil
+		},
+	))
+	body := `{
+		"model": "anthropic/claude-sonnet-4",
+		"temperature": 0.2,
+		"max_tokens": 100,
+		"messages": [
+			{"role": "system", "content": "be brief"},
+			{"role": "user", "content": "hi"},
+			{"role": "assistant", "content": "hello"},
+			{"role": "user", "content": [{"type": "text", "text": "how"}, {"type": "text", "text": "are you"}]}
+		]
+	}`
+	resp := doRequest(t, ts, http.MethodPost, "/v1/chat/completions", testToken, body)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, b)
+	}
+	var out ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Object != "chat.completion" || len(out.Choices) != 1 ||
+		out.Choices[0].Message.Content != "hello there" || out.Usage == nil ||
+		out.Usage.TotalTokens != 5 {
+		t.Fatalf("unexpected response %+v", out)
+	}
+
+	got := b.got
+	if got.Provider != "anthropic" || got.ModelName != "claude-sonnet-4" {
+		t.Fatalf("unexpected provider/model %s/%s", got.Provider, got.ModelName)
+	}
+	if got.SystemPrompt == nil || *got.SystemPrompt != "be brief" {
+		t.Fatalf("unexpected system prompt %v", got.SystemPrompt)
+	}
+	if got.Prompt != "how\nare you" || len(got.PrevMessages) != 2 ||
+		got.PrevMessages[1].Role != spec.Assistant {
+		t.Fatalf("unexpected prompt %q / history %+v", got.Prompt, got.PrevMessages)
+	}
+	if got.MaxTokens == nil || *got.MaxTokens != 100 || got.Stream || got.OnStreamData != nil {
+		t.Fatalf("unexpected request params %+v", got)
+	}
+	if logged == nil {
+		t.Fatal("conversation was not logged")
+	}
+}
+
+func TestServer_ChatCompletionStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		backend     *fakeBackend
+		wantContent string
+	}{
+		{"streamed chunks", &fakeBackend{chunks: []string{"a", "b"}, content: "ab"}, "ab"},
+		{"non streaming model", &fakeBackend{content: "whole"}, "whole"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, tt.backend)
+			body := `{"model": "openai/gpt-4o", "stream": true,
+				"stream_options": {"include_usage": true},
+				"messages": [{"role": "user", "content": "hi"}]}`
+			resp := doRequest(t, ts, http.MethodPost, "/v1/chat/completions", testToken, body)
+			if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+				t.Fatalf("unexpected content type %q", ct)
+			}
+			raw, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var content strings.Builder
+			var finished, gotUsage, done bool
+			for _, line := range strings.Split(string(raw), "\n") {
+				data, ok := strings.CutPrefix(line, "data: ")
+				if !ok {
+					continue
+				}
+				if data == "[DONE]" {
+					done = true
+					continue
+				}
+				var c ChatCompletionChunk
+				if err := json.Unmarshal([]byte(data), &c); err != nil {
+					t.Fatalf("bad chunk %q: %v", data, err)
+				}
+				if c.Usage != nil {
+					gotUsage = true
+				}
+				for _, ch := range c.Choices {
+					content.WriteString(ch.Delta.Content)
+					if ch.FinishReason != nil && *ch.FinishReason == "stop" {
+						finished = true
+					}
+				}
+			}
+			if content.String() != tt.wantContent || !finished || !gotUsage || !done {
+				t.Fatalf("content=%q finished=%v usage=%v done=%v\n%s",
+					content.String(), finished, gotUsage, done, raw)
+			}
+		})
+	}
+}
+
+func TestServer_ChatCompletionErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		backendErr error
+		body       string
+		wantStatus int
+	}{
+		{
+			"model without provider",
+			nil,
+			`{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`,
+			http.StatusNotFound,
+		},
+		{
+			"last message not user",
+			nil,
+			`{"model": "openai/gpt-4o", "messages": [{"role": "assistant", "content": "hi"}]}`,
+			http.StatusBadRequest,
+		},
+		{
+			"unsupported content part",
+			nil,
+			`{"model": "openai/gpt-4o", "messages": [{"role": "user", "content": [{"type": "image_url"}]}]}`,
+			http.StatusBadRequest,
+		},
+		{
+			"unknown model",
+			ErrModelNotFound,
+			`{"model": "openai/nope", "messages": [{"role": "user", "content": "hi"}]}`,
+			http.StatusNotFound,
+		},
+		{
+			"upstream failure",
+			errors.New("boom"),
+			`{"model": "openai/gpt-4o", "stream": true, "messages": [{"role": "user", "content": "hi"}]}`,
+			http.StatusBadGateway,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, &fakeBackend{err: tt.backendErr})
+			resp := doRequest(t, ts, http.MethodPost, "/v1/chat/completions", testToken, tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			var out ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&out); err != nil ||
+				out.Error.Message == "" {
+				t.Fatalf("expected error body, got %+v (%v)", out, err)
+			}
+		})
+	}
+}
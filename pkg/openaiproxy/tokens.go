@@ -0,0 +1,166 @@
+package openaiproxy
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/encdec"
+	"github.com/ppipada/flexigpt-app/pkg/simplemapdb/filestore"
+)
+
+const (
+	tokenPrefix     = "fgpt_"
+	tokenRandomSize = 32
+	tokensKey       = "tokens"
+)
+
+// TokenInfo describes an issued proxy token, the token itself is never stored.
+type TokenInfo struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TokenStore keeps sha256 hashes of locally issued proxy tokens in a JSON file.
+type TokenStore struct {
+	store *filestore.MapFileStore
+	mu    sync.Mutex
+}
+
+func NewTokenStore(filename string) (*TokenStore, error) {
+	store, err := filestore.NewMapFileStore(
+		filename,
+		map[string]any{tokensKey: map[string]any{}},
+		filestore.WithCreateIfNotExists(true),
+		filestore.WithAutoFlush(true),
+		filestore.WithEncoderDecoder(encdec.JSONEncoderDecoder{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token store: %w", err)
+	}
+	return &TokenStore{store: store}, nil
+}
+
+// Issue creates a new token with a unique name and returns it.
+// The token is only available at this point, only its hash is persisted.
+func (t *TokenStore) Issue(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("token name must not be empty")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tokens, err := t.getTokens()
+	if err != nil {
+		return "", err
+	}
+	if _, exists := tokens[name]; exists {
+		return "", fmt.Errorf("token %q already exists", name)
+	}
+
+	b := make([]byte, tokenRandomSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	err = t.store.SetKey([]string{tokensKey, name}, map[string]any{
+		"hash":      hashToken(token),
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save token: %w", err)
+	}
+	return token, nil
+}
+
+// Revoke deletes the token with the given name.
+func (t *TokenStore) Revoke(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tokens, err := t.getTokens()
+	if err != nil {
+		return err
+	}
+	if _, exists := tokens[name]; !exists {
+		return fmt.Errorf("token %q not found", name)
+	}
+	return t.store.DeleteKey([]string{tokensKey, name})
+}
+
+// List returns the issued tokens sorted by name.
+func (t *TokenStore) List() ([]TokenInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tokens, err := t.getTokens()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]TokenInfo, 0, len(tokens))
+	for name, v := range tokens {
+		info := TokenInfo{Name: name}
+		if m, ok := v.(map[string]any); ok {
+			if s, ok := m["createdAt"].(string); ok {
+				info.CreatedAt, _ = time.Parse(time.RFC3339, s)
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Verify reports whether token matches any issued token.
+func (t *TokenStore) Verify(token string) bool {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tokens, err := t.getTokens()
+	if err != nil {
+		return false
+	}
+	want := []byte(hashToken(token))
+	found := false
+	for _, v := range tokens {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		hash, _ := m["hash"].(string)
+		if subtle.ConstantTimeCompare([]byte(hash), want) == 1 {
+			found = true
+		}
+	}
+	return found
+}
+
+// getTokens reloads the tokens from the file, they are issued and revoked by other processes too.
+func (t *TokenStore) getTokens() (map[string]any, error) {
+	data, err := t.store.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+	tokens, _ := data[tokensKey].(map[string]any)
+	if tokens == nil {
+		tokens = map[string]any{}
+	}
+	return tokens, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+package openaiproxy
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "proxy_tokens.json")
+	ts, err := NewTokenStore(filename)
+	if err != nil {
+		t.Fatalf("NewTokenStore: %v", err)
+	}
+
+	token, err := ts.Issue("vscode")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !strings.HasPrefix(token, tokenPrefix) {
+		t.Fatalf("unexpected token format %q", token)
+	}
+	if _, err := ts.Issue("vscode"); err == nil {
+		t.Fatal("expected error for duplicate token name")
+	}
+	if _, err := ts.Issue("  "); err == nil {
+		t.Fatal("expected error for empty token name")
+	}
+
+	if !ts.Verify(token) {
+		t.Fatal("issued token did not verify")
+	}
+	if ts.Verify(token+"x") || ts.Verify("") {
+		t.Fatal("unexpected verification of wrong token")
+	}
+
+	// Tokens survive a reload and only the hash is persisted.
+	reloaded, err := NewTokenStore(filename)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !reloaded.Verify(token) {
+		t.Fatal("token did not verify after reload")
+	}
+	infos, err := reloaded.List()
+	if err != nil || len(infos) != 1 || infos[0].Name != "vscode" || infos[0].CreatedAt.IsZero() {
+		t.Fatalf("unexpected list %+v (%v)", infos, err)
+	}
+
+	if err := reloaded.Revoke("vscode"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if reloaded.Verify(token) {
+		t.Fatal("revoked token still verifies")
+	}
+	if err := reloaded.Revoke("vscode"); err == nil {
+		t.Fatal("expected error revoking unknown token")
+	}
+}
+
+func TestTokenStoreSharedFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "proxy_tokens.json")
+	// The server and the tokens command use separate stores on the same file.
+	server, err := NewTokenStore(filename)
+	if err != nil {
+		t.Fatalf("NewTokenStore: %v", err)
+	}
+	cli, err := NewTokenStore(filename)
+	if err != nil {
+		t.Fatalf("NewTokenStore: %v", err)
+	}
+
+	token, err := cli.Issue("vscode")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !server.Verify(token) {
+		t.Fatal("token issued by another store did not verify")
+	}
+	other, err := server.Issue("cursor")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if err := cli.Revoke("vscode"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if server.Verify(token) {
+		t.Fatal("token revoked by another store still verifies")
+	}
+	if !server.Verify(other) || !cli.Verify(other) {
+		t.Fatal("token issued by the server was lost")
+	}
+}
@@ -0,0 +1,35 @@
+// Package promptrun renders prompt templates with variables and runs them through a Completer.
+// It is shared by the batch job runner and the eval harness.
+package promptrun
+
+import (
+	"context"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+// Request is a rendered prompt.
+type Request struct {
+	Provider     aiproviderSpec.ProviderName
+	ModelParams  aiproviderSpec.ModelParams
+	Prompt       string
+	PrevMessages []aiproviderSpec.ChatCompletionRequestMessage
+}
+
+type Usage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+type Result struct {
+	Content string
+	// Usage is nil if the provider did not report it.
+	Usage *Usage
+}
+
+// Completer runs rendered prompts.
+// It is expected to apply the provider rate limits, callers only bound their own concurrency.
+type Completer interface {
+	Complete(ctx context.Context, req *Request) (*Result, error)
+}
@@ -0,0 +1,163 @@
+package promptrun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+var placeholderRE = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// Target is the prompt and model that variables are rendered into.
+//
+// Text in the prompt or template blocks may hold {{name}} placeholders.
+// Either Prompt or Template must be set. The model comes from Preset if it is set,
+// otherwise from Provider and ModelParams.
+type Target struct {
+	// Prompt is a single user message template.
+	Prompt string `json:"prompt,omitempty"`
+	// Template is a list of message blocks. System and developer blocks become the system prompt,
+	// the last block must be a user block and is sent as the prompt.
+	Template    *aiproviderSpec.PromptTemplate `json:"template,omitempty"`
+	Provider    aiproviderSpec.ProviderName    `json:"provider,omitempty"`
+	ModelParams aiproviderSpec.ModelParams     `json:"modelParams,omitempty"`
+	Preset      *aiproviderSpec.ModelPreset    `json:"preset,omitempty"`
+}
+
+// Renderer turns variables into completion requests for a target.
+type Renderer struct {
+	provider aiproviderSpec.ProviderName
+	params   aiproviderSpec.ModelParams
+	blocks   []aiproviderSpec.MessageBlock
+	// staticVals are used for placeholders missing from the variables.
+	staticVals map[string]string
+}
+
+// NewRenderer checks that target has a model and a usable prompt.
+// Requests are not streamed, there is no one to stream to.
+func NewRenderer(target Target) (*Renderer, error) {
+	r := &Renderer{
+		provider:   target.Provider,
+		params:     target.ModelParams,
+		staticVals: map[string]string{},
+	}
+	if target.Preset != nil {
+		r.params = modelParamsFromPreset(target.Preset)
+		if target.Preset.Provider != "" {
+			r.provider = aiproviderSpec.ProviderName(target.Preset.Provider)
+		}
+	}
+	r.params.Stream = false
+	if r.provider == "" || r.params.Name == "" {
+		return nil, errors.New("provider and model are required")
+	}
+
+	switch {
+	case target.Template != nil && target.Prompt != "":
+		return nil, errors.New("only one of prompt and template can be set")
+	case target.Template != nil:
+		r.blocks = target.Template.Blocks
+		for _, v := range target.Template.Variables {
+			if v.Source == aiproviderSpec.SourceStatic {
+				r.staticVals[v.Name] = v.StaticVal
+			}
+		}
+	case target.Prompt != "":
+		r.blocks = []aiproviderSpec.MessageBlock{
+			{Role: aiproviderSpec.User, Content: target.Prompt},
+		}
+	default:
+		return nil, errors.New("prompt or template is required")
+	}
+	if len(r.blocks) == 0 || r.blocks[len(r.blocks)-1].Role != aiproviderSpec.User {
+		return nil, errors.New("the last template block must be a user block")
+	}
+	return r, nil
+}
+
+// Model returns the provider and model that requests are sent to.
+func (r *Renderer) Model() (aiproviderSpec.ProviderName, aiproviderSpec.ModelName) {
+	return r.provider, r.params.Name
+}
+
+// Render replaces the placeholders with vars. Placeholders without a value are an error.
+func (r *Renderer) Render(vars map[string]any) (*Request, error) {
+	req := &Request{Provider: r.provider, ModelParams: r.params}
+	systemPrompts := []string{}
+	last := len(r.blocks) - 1
+	for i, block := range r.blocks {
+		content, err := r.renderText(block.Content, vars)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case block.Role == aiproviderSpec.System || block.Role == aiproviderSpec.Developer:
+			systemPrompts = append(systemPrompts, content)
+		case i == last:
+			req.Prompt = content
+		default:
+			req.PrevMessages = append(req.PrevMessages, aiproviderSpec.ChatCompletionRequestMessage{
+				Role:    block.Role,
+				Content: &content,
+			})
+		}
+	}
+	if len(systemPrompts) > 0 {
+		req.ModelParams.SystemPrompt = strings.Join(systemPrompts, "\n\n")
+	}
+	return req, nil
+}
+
+func (r *Renderer) renderText(text string, vars map[string]any) (string, error) {
+	var missing []string
+	out := placeholderRE.ReplaceAllStringFunc(text, func(m string) string {
+		name := placeholderRE.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return formatValue(v)
+		}
+		if v, ok := r.staticVals[name]; ok {
+			return v
+		}
+		missing = append(missing, name)
+		return m
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("no value for %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func modelParamsFromPreset(p *aiproviderSpec.ModelPreset) aiproviderSpec.ModelParams {
+	return aiproviderSpec.ModelParams{
+		Name:                 aiproviderSpec.ModelName(p.Engine),
+		Stream:               p.Stream,
+		MaxPromptLength:      p.MaxPromptLength,
+		MaxOutputLength:      p.MaxOutputLength,
+		Temperature:          p.Temperature,
+		Reasoning:            p.Reasoning,
+		SystemPrompt:         p.SystemPrompt,
+		Timeout:              p.Timeout,
+		PromptCaching:        p.PromptCaching,
+		AdditionalParameters: p.AdditionalParameters,
+	}
+}
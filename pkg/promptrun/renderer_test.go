@@ -0,0 +1,97 @@
+package promptrun
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
+)
+
+func TestRender_Template(t *testing.T) {
+	r, err := NewRenderer(Target{
+		Provider:    "anthropic",
+		ModelParams: aiproviderSpec.ModelParams{Name: "claude", Stream: true},
+		Template: &aiproviderSpec.PromptTemplate{
+			Blocks: []aiproviderSpec.MessageBlock{
+				{Role: aiproviderSpec.System, Content: "You label {{kind}}."},
+				{Role: aiproviderSpec.User, Content: "Example"},
+				{Role: aiproviderSpec.Assistant, Content: "label"},
+				{Role: aiproviderSpec.User, Content: "Text: {{ text }}"},
+			},
+			Variables: []aiproviderSpec.PromptVariable{
+				{Name: "kind", Source: aiproviderSpec.SourceStatic, StaticVal: "tickets"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := r.Render(map[string]any{"text": json.Number("42")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ModelParams.SystemPrompt != "You label tickets." || req.ModelParams.Stream {
+		t.Errorf("model params = %+v", req.ModelParams)
+	}
+	if req.Prompt != "Text: 42" || len(req.PrevMessages) != 2 {
+		t.Errorf("request = %+v", req)
+	}
+
+	if _, err := r.Render(map[string]any{}); err == nil ||
+		!strings.Contains(err.Error(), "text") {
+		t.Errorf("expected missing value error, got %v", err)
+	}
+}
+
+func TestRender_Preset(t *testing.T) {
+	r, err := NewRenderer(Target{
+		Prompt: "{{a}} and {{b}}",
+		Preset: &aiproviderSpec.ModelPreset{Provider: "openai", Engine: "gpt-4.1", Timeout: 30},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := r.Render(map[string]any{"a": nil, "b": []any{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Provider != "openai" || req.ModelParams.Name != "gpt-4.1" ||
+		req.ModelParams.Timeout != 30 {
+		t.Errorf("request = %+v", req)
+	}
+	if req.Prompt != ` and ["x"]` {
+		t.Errorf("prompt = %q", req.Prompt)
+	}
+}
+
+func TestNewRenderer_Invalid(t *testing.T) {
+	model := aiproviderSpec.ModelParams{Name: "m"}
+	tests := []struct {
+		name   string
+		target Target
+	}{
+		{"no model", Target{Prompt: "x", Provider: "p"}},
+		{"no prompt", Target{Provider: "p", ModelParams: model}},
+		{"both prompts", Target{
+			Provider:    "p",
+			ModelParams: model,
+			Prompt:      "x",
+			Template:    &aiproviderSpec.PromptTemplate{},
+		}},
+		{"last block not user", Target{
+			Provider:    "p",
+			ModelParams: model,
+			Template: &aiproviderSpec.PromptTemplate{Blocks: []aiproviderSpec.MessageBlock{
+				{Role: aiproviderSpec.Assistant, Content: "x"},
+			}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRenderer(tt.target); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLimiterClosed is returned to waiters when the limiter is closed.
+var ErrLimiterClosed = errors.New("rate limiter closed")
+
+// Limits configures a Limiter. A zero value for any field means that dimension is unlimited.
+type Limits struct {
+	RequestsPerMinute     int
+	TokensPerMinute       int
+	MaxConcurrentRequests int
+}
+
+// IsZero reports whether no limit is set.
+func (l Limits) IsZero() bool {
+	return l.RequestsPerMinute <= 0 && l.TokensPerMinute <= 0 && l.MaxConcurrentRequests <= 0
+}
+
+// Stats is a point in time view of a Limiter.
+type Stats struct {
+	// QueueDepth is the number of callers currently waiting.
+	QueueDepth int
+	// InFlight is the number of acquired and not yet released reservations.
+	InFlight int
+	// LastWait is the time the most recently admitted caller spent in the queue.
+	LastWait time.Duration
+	// OldestWait is how long the caller at the head of the queue has been waiting.
+	OldestWait time.Duration
+}
+
+// Release must be called once the guarded request is done.
+// ActualTokens reconciles the token reservation with the real usage, pass a value < 0 if unknown.
+type Release func(actualTokens int)
+
+type waiter struct {
+	tokens   int
+	queuedAt time.Time
+}
+
+// Limiter is a token bucket limiter for requests per minute and tokens per minute,
+// combined with a cap on concurrent requests.
+// Waiters are admitted strictly in FIFO order so that a large reservation is not starved by smaller ones.
+type Limiter struct {
+	mu     sync.Mutex
+	limits Limits
+
+	reqBucket float64
+	tokBucket float64
+	lastFill  time.Time
+	inFlight  int
+
+	queue *list.List
+	// Closed and replaced whenever state changes so that waiters re-check.
+	notify   chan struct{}
+	lastWait time.Duration
+	closed   bool
+
+	now func() time.Time
+}
+
+// New creates a limiter with full buckets.
+func New(limits Limits) *Limiter {
+	l := &Limiter{
+		limits: limits,
+		queue:  list.New(),
+		notify: make(chan struct{}),
+		now:    time.Now,
+	}
+	l.lastFill = l.now()
+	l.reqBucket = float64(limits.RequestsPerMinute)
+	l.tokBucket = float64(limits.TokensPerMinute)
+	return l
+}
+
+// SetLimits updates the limits in place, queued callers are re-evaluated.
+func (l *Limiter) SetLimits(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	// A dimension that was unlimited so far starts with a full bucket.
+	if l.limits.RequestsPerMinute <= 0 {
+		l.reqBucket = float64(limits.RequestsPerMinute)
+	}
+	if l.limits.TokensPerMinute <= 0 {
+		l.tokBucket = float64(limits.TokensPerMinute)
+	}
+	l.limits = limits
+	l.reqBucket = min(l.reqBucket, float64(limits.RequestsPerMinute))
+	l.tokBucket = min(l.tokBucket, float64(limits.TokensPerMinute))
+	l.broadcast()
+}
+
+// Limits returns the current limits.
+func (l *Limiter) Limits() Limits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits
+}
+
+// Stats returns the current queue depth and wait times.
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := Stats{
+		QueueDepth: l.queue.Len(),
+		InFlight:   l.inFlight,
+		LastWait:   l.lastWait,
+	}
+	if front := l.queue.Front(); front != nil {
+		if w, ok := front.Value.(*waiter); ok {
+			s.OldestWait = l.now().Sub(w.queuedAt)
+		}
+	}
+	return s
+}
+
+// Close wakes up all waiters with ErrLimiterClosed.
+func (l *Limiter) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	l.broadcast()
+}
+
+// Acquire blocks until a request estimated to use tokens can be sent, or ctx is done.
+// Tokens larger than the per minute token limit are clamped so that the caller is not blocked forever.
+func (l *Limiter) Acquire(ctx context.Context, tokens int) (Release, error) {
+	if tokens < 0 {
+		tokens = 0
+	}
+
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, ErrLimiterClosed
+	}
+	w := &waiter{tokens: tokens, queuedAt: l.now()}
+	elem := l.queue.PushBack(w)
+
+	for {
+		l.refill()
+		if l.closed {
+			l.queue.Remove(elem)
+			l.broadcast()
+			l.mu.Unlock()
+			return nil, ErrLimiterClosed
+		}
+
+		var wait time.Duration
+		isHead := l.queue.Front() == elem
+		if isHead {
+			var ok bool
+			ok, wait = l.tryGrant(w)
+			if ok {
+				l.queue.Remove(elem)
+				l.lastWait = l.now().Sub(w.queuedAt)
+				// Next in line may be admissible as well.
+				l.broadcast()
+				l.mu.Unlock()
+				return l.releaseFunc(w.tokens), nil
+			}
+		}
+		notify := l.notify
+		l.mu.Unlock()
+
+		var timer *time.Timer
+		var timerC <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timerC = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			l.mu.Lock()
+			l.queue.Remove(elem)
+			l.broadcast()
+			l.mu.Unlock()
+			return nil, ctx.Err()
+		case <-notify:
+		case <-timerC:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		l.mu.Lock()
+	}
+}
+
+// tryGrant consumes capacity for w if possible, else returns the time until the buckets may suffice.
+// A zero wait with !ok means the caller must wait for a release.
+// Must be called with the lock held.
+func (l *Limiter) tryGrant(w *waiter) (ok bool, wait time.Duration) {
+	if l.limits.MaxConcurrentRequests > 0 && l.inFlight >= l.limits.MaxConcurrentRequests {
+		return false, 0
+	}
+
+	need := l.clampTokens(w.tokens)
+	if l.limits.RequestsPerMinute > 0 && l.reqBucket < 1 {
+		wait = max(wait, timeToFill(1-l.reqBucket, l.limits.RequestsPerMinute))
+	}
+	if l.limits.TokensPerMinute > 0 && l.tokBucket < float64(need) {
+		wait = max(wait, timeToFill(float64(need)-l.tokBucket, l.limits.TokensPerMinute))
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	if l.limits.RequestsPerMinute > 0 {
+		l.reqBucket--
+	}
+	if l.limits.TokensPerMinute > 0 {
+		l.tokBucket -= float64(need)
+	}
+	l.inFlight++
+	return true, 0
+}
+
+func (l *Limiter) releaseFunc(reserved int) Release {
+	var once sync.Once
+	return func(actualTokens int) {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.refill()
+			l.inFlight--
+			if actualTokens >= 0 && l.limits.TokensPerMinute > 0 {
+				// Refund over reservation or take the debt of under reservation.
+				l.tokBucket += float64(l.clampTokens(reserved) - actualTokens)
+				l.tokBucket = min(l.tokBucket, float64(l.limits.TokensPerMinute))
+			}
+			l.broadcast()
+		})
+	}
+}
+
+func (l *Limiter) clampTokens(tokens int) int {
+	if l.limits.TokensPerMinute > 0 && tokens > l.limits.TokensPerMinute {
+		return l.limits.TokensPerMinute
+	}
+	return tokens
+}
+
+// Must be called with the lock held.
+func (l *Limiter) refill() {
+	now := l.now()
+	elapsed := now.Sub(l.lastFill)
+	l.lastFill = now
+	if elapsed <= 0 {
+		return
+	}
+	minutes := elapsed.Minutes()
+	if rpm := l.limits.RequestsPerMinute; rpm > 0 {
+		l.reqBucket = min(l.reqBucket+minutes*float64(rpm), float64(rpm))
+	}
+	if tpm := l.limits.TokensPerMinute; tpm > 0 {
+		l.tokBucket = min(l.tokBucket+minutes*float64(tpm), float64(tpm))
+	}
+}
+
+// Must be called with the lock held.
+func (l *Limiter) broadcast() {
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+func timeToFill(missing float64, perMinute int) time.Duration {
+	d := time.Duration(missing / float64(perMinute) * float64(time.Minute))
+	// Avoid busy looping on rounding errors.
+	return max(d, time.Millisecond)
+}
@@ -0,0 +1,240 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Unlimited(t *testing.T) {
+	l := New(Limits{})
+	for range 100 {
+		release, err := l.Acquire(t.Context(), 1000)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		release(-1)
+	}
+	if s := l.Stats(); s.QueueDepth != 0 || s.InFlight != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestLimiter_RequestsPerMinute(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		tokens    int
+		acquireN  int
+		wantError bool
+	}{
+		{"within rpm", Limits{RequestsPerMinute: 5}, 0, 5, false},
+		{"exceeds rpm", Limits{RequestsPerMinute: 5}, 0, 6, true},
+		{"within tpm", Limits{TokensPerMinute: 100}, 50, 2, false},
+		{"exceeds tpm", Limits{TokensPerMinute: 100}, 50, 3, true},
+		{"large reservation is clamped", Limits{TokensPerMinute: 100}, 1000, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(tt.limits)
+			ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+			defer cancel()
+			var err error
+			for range tt.acquireN {
+				var release Release
+				release, err = l.Acquire(ctx, tt.tokens)
+				if err != nil {
+					break
+				}
+				release(-1)
+			}
+			if (err != nil) != tt.wantError {
+				t.Fatalf("want error %v, got %v", tt.wantError, err)
+			}
+			if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected deadline exceeded, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	// 600 rpm refills one request every 100ms.
+	l := New(Limits{RequestsPerMinute: 600})
+	l.reqBucket = 0
+	start := time.Now()
+	release, err := l.Acquire(t.Context(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release(-1)
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Fatalf("expected to wait for refill, waited %v", waited)
+	}
+	if l.Stats().LastWait <= 0 {
+		t.Fatalf("expected last wait to be recorded")
+	}
+}
+
+func TestLimiter_MaxConcurrent(t *testing.T) {
+	l := New(Limits{MaxConcurrentRequests: 1})
+	release, err := l.Acquire(t.Context(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		r, err := l.Acquire(context.Background(), 0)
+		if err != nil {
+			return
+		}
+		close(acquired)
+		r(-1)
+	}()
+
+	waitFor(t, func() bool { return l.Stats().QueueDepth == 1 })
+	select {
+	case <-acquired:
+		t.Fatalf("second request admitted while first is in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release(-1)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatalf("second request not admitted after release")
+	}
+}
+
+func TestLimiter_FIFO(t *testing.T) {
+	l := New(Limits{MaxConcurrentRequests: 1})
+	release, err := l.Acquire(t.Context(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := l.Acquire(context.Background(), 0)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			r(-1)
+		}()
+		// Make queue order deterministic.
+		waitFor(t, func() bool { return l.Stats().QueueDepth == i+1 })
+	}
+	release(-1)
+	wg.Wait()
+
+	for i, v := range order {
+		if v != i {
+			t.Fatalf("admission order not FIFO: %v", order)
+		}
+	}
+}
+
+func TestLimiter_ReleaseReconcilesTokens(t *testing.T) {
+	l := New(Limits{TokensPerMinute: 1000})
+	release, err := l.Acquire(t.Context(), 800)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release(100)
+	// Double release must be a no-op.
+	release(100)
+
+	l.mu.Lock()
+	bucket, inFlight := l.tokBucket, l.inFlight
+	l.mu.Unlock()
+	if bucket < 899 || bucket > 1000 {
+		t.Fatalf("expected refund of unused tokens, bucket %v", bucket)
+	}
+	if inFlight != 0 {
+		t.Fatalf("expected no in flight requests, got %d", inFlight)
+	}
+}
+
+func TestLimiter_ContextCancelRemovesWaiter(t *testing.T) {
+	l := New(Limits{MaxConcurrentRequests: 1})
+	release, err := l.Acquire(t.Context(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release(-1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() {
+		_, err := l.Acquire(ctx, 0)
+		done <- err
+	}()
+	waitFor(t, func() bool { return l.Stats().QueueDepth == 1 })
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if d := l.Stats().QueueDepth; d != 0 {
+		t.Fatalf("expected empty queue, got %d", d)
+	}
+}
+
+func TestLimiter_SetLimitsAndClose(t *testing.T) {
+	l := New(Limits{MaxConcurrentRequests: 1})
+	release, err := l.Acquire(t.Context(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release(-1)
+
+	done := make(chan error)
+	go func() {
+		r, err := l.Acquire(context.Background(), 0)
+		if err == nil {
+			r(-1)
+		}
+		done <- err
+	}()
+	waitFor(t, func() bool { return l.Stats().QueueDepth == 1 })
+
+	// Raising the limit admits the waiter.
+	l.SetLimits(Limits{MaxConcurrentRequests: 2})
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error after raising limits: %v", err)
+	}
+
+	l.SetLimits(Limits{MaxConcurrentRequests: 1})
+	go func() {
+		_, err := l.Acquire(context.Background(), 0)
+		done <- err
+	}()
+	waitFor(t, func() bool { return l.Stats().QueueDepth == 1 })
+	l.Close()
+	if err := <-done; !errors.Is(err, ErrLimiterClosed) {
+		t.Fatalf("expected closed error, got %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,127 @@
+package secretscan
+
+import (
+	"regexp"
+	"strings"
+)
+
+func newBuiltinRule(
+	name, pattern string,
+	action Action,
+	description string,
+	validate func(string) bool,
+) compiledRule {
+	return compiledRule{
+		Rule: Rule{
+			Name:        name,
+			Pattern:     pattern,
+			Action:      action,
+			Description: description,
+		},
+		re:       regexp.MustCompile(pattern),
+		validate: validate,
+	}
+}
+
+// Built-in rules are checked in this order, more specific key formats come first.
+var builtinRules = []compiledRule{
+	newBuiltinRule(
+		"privateKey",
+		`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`,
+		ActionBlock,
+		"Private key",
+		nil,
+	),
+	newBuiltinRule(
+		"awsAccessKey",
+		`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`,
+		ActionMask,
+		"AWS access key ID",
+		nil,
+	),
+	newBuiltinRule(
+		"gcpAPIKey",
+		`\bAIza[0-9A-Za-z_-]{35}\b`,
+		ActionMask,
+		"Google Cloud API key",
+		nil,
+	),
+	newBuiltinRule(
+		"anthropicAPIKey",
+		`\bsk-ant-[A-Za-z0-9_-]{20,}`,
+		ActionMask,
+		"Anthropic API key",
+		nil,
+	),
+	newBuiltinRule(
+		"openaiAPIKey",
+		`\bsk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{20,}`,
+		ActionMask,
+		"OpenAI API key",
+		nil,
+	),
+	newBuiltinRule(
+		"githubToken",
+		`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`,
+		ActionMask,
+		"GitHub token",
+		nil,
+	),
+	newBuiltinRule(
+		"jwt",
+		`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`,
+		ActionMask,
+		"JSON web token",
+		nil,
+	),
+	newBuiltinRule(
+		"email",
+		`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`,
+		ActionWarn,
+		"Email address",
+		nil,
+	),
+	newBuiltinRule(
+		"creditCard",
+		`\b\d(?:[ -]?\d){12,18}\b`,
+		ActionMask,
+		"Credit card number",
+		isLuhnValid,
+	),
+}
+
+// BuiltinRules returns the rules that NewScanner uses unless they are disabled.
+func BuiltinRules() []Rule {
+	rules := make([]Rule, 0, len(builtinRules))
+	for _, b := range builtinRules {
+		rules = append(rules, b.Rule)
+	}
+	return rules
+}
+
+// isLuhnValid checks the card number checksum, spaces and dashes are ignored.
+func isLuhnValid(number string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, number)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
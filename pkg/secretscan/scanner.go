@@ -0,0 +1,183 @@
+// Package secretscan finds secrets and personal data in text before it is sent to a remote service.
+package secretscan
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Action is what happens to text that matches a rule.
+type Action string
+
+const (
+	// ActionBlock stops the text from being sent.
+	ActionBlock Action = "block"
+	// ActionMask replaces the match with a placeholder.
+	ActionMask Action = "mask"
+	// ActionWarn only reports the match.
+	ActionWarn Action = "warn"
+)
+
+// severity orders actions, a stronger action wins when matches overlap.
+func (a Action) severity() int {
+	switch a {
+	case ActionBlock:
+		return 3
+	case ActionMask:
+		return 2
+	case ActionWarn:
+		return 1
+	}
+	return 0
+}
+
+// ErrInvalidRule is returned by NewScanner for rules with a bad name, pattern or action.
+var ErrInvalidRule = errors.New("invalid scan rule")
+
+// Rule is a regular expression with the action to take on a match.
+type Rule struct {
+	Name        string
+	Pattern     string
+	Action      Action
+	Description string
+}
+
+// Config configures a Scanner.
+type Config struct {
+	// DisableBuiltinRules skips the rules returned by BuiltinRules.
+	DisableBuiltinRules bool
+	// ActionOverrides changes the action of built-in rules, keyed by rule name.
+	ActionOverrides map[string]Action
+	// Rules are user defined rules, checked after the built-in rules.
+	Rules []Rule
+}
+
+// Finding is a match of a rule. The matched text itself is never included.
+type Finding struct {
+	Rule        string
+	Description string
+	Action      Action
+	// Start and End are byte offsets of the match in the scanned text.
+	Start int
+	End   int
+}
+
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+	// validate filters out matches that fit the pattern but are not real, like failed checksums.
+	validate func(match string) bool
+}
+
+// Scanner checks text against a fixed set of rules, it is safe for concurrent use.
+type Scanner struct {
+	rules []compiledRule
+}
+
+// NewScanner compiles the built-in and user rules of cfg.
+func NewScanner(cfg Config) (*Scanner, error) {
+	s := &Scanner{}
+	if !cfg.DisableBuiltinRules {
+		for _, r := range builtinRules {
+			if action, ok := cfg.ActionOverrides[r.Name]; ok {
+				if action.severity() == 0 {
+					return nil, fmt.Errorf(
+						"%w: %s: unknown action %q",
+						ErrInvalidRule,
+						r.Name,
+						action,
+					)
+				}
+				r.Action = action
+			}
+			s.rules = append(s.rules, r)
+		}
+	}
+	for _, rule := range cfg.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("%w: name is required", ErrInvalidRule)
+		}
+		if rule.Action.severity() == 0 {
+			return nil, fmt.Errorf(
+				"%w: %s: unknown action %q",
+				ErrInvalidRule,
+				rule.Name,
+				rule.Action,
+			)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidRule, rule.Name, err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("%w: %s: pattern matches empty text", ErrInvalidRule, rule.Name)
+		}
+		s.rules = append(s.rules, compiledRule{Rule: rule, re: re})
+	}
+	return s, nil
+}
+
+// Scan returns text with masked matches replaced by placeholders, and all findings in text order.
+// Where matches overlap, only the one with the strongest action is kept.
+func (s *Scanner) Scan(text string) (string, []Finding) {
+	if s == nil || text == "" {
+		return text, nil
+	}
+	var all []Finding
+	for _, r := range s.rules {
+		for _, loc := range r.re.FindAllStringIndex(text, -1) {
+			if r.validate != nil && !r.validate(text[loc[0]:loc[1]]) {
+				continue
+			}
+			all = append(all, Finding{
+				Rule:        r.Name,
+				Description: r.Description,
+				Action:      r.Action,
+				Start:       loc[0],
+				End:         loc[1],
+			})
+		}
+	}
+	if len(all) == 0 {
+		return text, nil
+	}
+	// The stable sort keeps rule order for matches at the same offset, so earlier rules win ties.
+	slices.SortStableFunc(all, func(a, b Finding) int { return a.Start - b.Start })
+
+	findings := make([]Finding, 0, len(all))
+	for _, f := range all {
+		if n := len(findings); n > 0 && f.Start < findings[n-1].End {
+			last := &findings[n-1]
+			if f.Action.severity() > last.Action.severity() {
+				f.Start = min(f.Start, last.Start)
+				f.End = max(f.End, last.End)
+				*last = f
+			} else {
+				last.End = max(last.End, f.End)
+			}
+			continue
+		}
+		findings = append(findings, f)
+	}
+
+	var b strings.Builder
+	prev := 0
+	for _, f := range findings {
+		if f.Action != ActionMask {
+			continue
+		}
+		b.WriteString(text[prev:f.Start])
+		b.WriteString(Placeholder(f.Rule))
+		prev = f.End
+	}
+	b.WriteString(text[prev:])
+	return b.String(), findings
+}
+
+// Placeholder is the text that replaces a masked match of a rule.
+func Placeholder(rule string) string {
+	return "[REDACTED:" + rule + "]"
+}
@@ -88,6 +88,9 @@ var DefaultAISettings = map[aiproviderSpec.ProviderName]AISetting{
 	},
 }
 
+// DefaultTrashRetentionDays is how long deleted conversations stay in the trash by default.
+const DefaultTrashRetentionDays = 30
+
 // Define the default settings data.
 var DefaultSettingsData = SettingsSchema{
 	Version: "1.0",
@@ -54,11 +54,15 @@ type SetAISettingAttrsRequest struct {
 	Body         *SetAISettingAttrsRequestBody
 }
 
+// SetAISettingAttrsRequestBody updates the attributes that are not nil.
+// Empty RateLimits clear the configured limits.
 type SetAISettingAttrsRequestBody struct {
-	IsEnabled                *bool                     `json:"isEnabled,omitempty"`
-	Origin                   *string                   `json:"origin,omitempty"`
-	ChatCompletionPathPrefix *string                   `json:"chatCompletionPathPrefix,omitempty"`
-	DefaultModel             *aiproviderSpec.ModelName `json:"defaultModel,omitempty"`
+	IsEnabled                *bool                         `json:"isEnabled,omitempty"`
+	Origin                   *string                       `json:"origin,omitempty"`
+	ChatCompletionPathPrefix *string                       `json:"chatCompletionPathPrefix,omitempty"`
+	DefaultModel             *aiproviderSpec.ModelName     `json:"defaultModel,omitempty"`
+	RateLimits               *aiproviderSpec.RateLimits    `json:"rateLimits,omitempty"`
+	Network                  *aiproviderSpec.NetworkConfig `json:"network,omitempty"`
 }
 
 type SetAISettingAttrsResponse struct{}
@@ -1,20 +1,26 @@
 package spec
 
 import (
+	"time"
+
 	aiproviderSpec "github.com/ppipada/flexigpt-app/pkg/aiprovider/spec"
 )
 
 type ModelSetting struct {
-	DisplayName          string                          `json:"displayName"                    required:"true"`
-	IsEnabled            bool                            `json:"isEnabled"                      required:"true"`
-	Stream               *bool                           `json:"stream,omitempty"`
-	MaxPromptLength      *int                            `json:"maxPromptLength,omitempty"`
-	MaxOutputLength      *int                            `json:"maxOutputLength,omitempty"`
-	Temperature          *float64                        `json:"temperature,omitempty"`
-	Reasoning            *aiproviderSpec.ReasoningParams `json:"reasoning,omitempty"`
-	SystemPrompt         *string                         `json:"systemPrompt,omitempty"`
-	Timeout              *int                            `json:"timeout,omitempty"`
-	AdditionalParameters *map[string]any                 `json:"additionalParameters,omitempty"`
+	DisplayName          string                              `json:"displayName"                    required:"true"`
+	IsEnabled            bool                                `json:"isEnabled"                      required:"true"`
+	Stream               *bool                               `json:"stream,omitempty"`
+	MaxPromptLength      *int                                `json:"maxPromptLength,omitempty"`
+	MaxOutputLength      *int                                `json:"maxOutputLength,omitempty"`
+	Temperature          *float64                            `json:"temperature,omitempty"`
+	Reasoning            *aiproviderSpec.ReasoningParams     `json:"reasoning,omitempty"`
+	SystemPrompt         *string                             `json:"systemPrompt,omitempty"`
+	Timeout              *int                                `json:"timeout,omitempty"`
+	FirstTokenTimeout    *int                                `json:"firstTokenTimeout,omitempty"`
+	IdleTimeout          *int                                `json:"idleTimeout,omitempty"`
+	PromptCaching        *aiproviderSpec.PromptCachingParams `json:"promptCaching,omitempty"`
+	RateLimits           *aiproviderSpec.RateLimits          `json:"rateLimits,omitempty"`
+	AdditionalParameters *map[string]any                     `json:"additionalParameters,omitempty"`
 }
 
 // AISetting represents the settings for an AI provider.
@@ -25,6 +31,9 @@ type AISetting struct {
 	Origin                   string                                    `json:"origin"`
 	ChatCompletionPathPrefix string                                    `json:"chatCompletionPathPrefix"`
 	ModelSettings            map[aiproviderSpec.ModelName]ModelSetting `json:"modelSettings"`
+	RateLimits               *aiproviderSpec.RateLimits                `json:"rateLimits,omitempty"`
+	// Network overrides the app wide network config for this provider.
+	Network *aiproviderSpec.NetworkConfig `json:"network,omitempty"`
 }
 
 // AISettingsSchema represents the schema for AI settings for different providers.
@@ -33,6 +42,34 @@ type AISettingsSchema map[aiproviderSpec.ProviderName]AISetting
 // AppSettings app settings.
 type AppSettings struct {
 	DefaultProvider aiproviderSpec.ProviderName `json:"defaultProvider"`
+	// Network is the default network config for providers without their own.
+	Network *aiproviderSpec.NetworkConfig `json:"network,omitempty"`
+	// OutboundScan checks prompts for secrets and personal data before they are sent to any provider.
+	OutboundScan *aiproviderSpec.OutboundScanConfig `json:"outboundScan,omitempty"`
+	// ConversationTitles configures the titles generated after the first exchange of a conversation.
+	ConversationTitles *ConversationTitleSettings `json:"conversationTitles,omitempty"`
+	// TrashRetentionDays is how long deleted conversations stay in the trash, 0 keeps them until
+	// the trash is purged. Defaults to DefaultTrashRetentionDays.
+	TrashRetentionDays *int `json:"trashRetentionDays,omitempty"`
+}
+
+// TrashRetention returns how long deleted conversations stay in the trash.
+func (a *AppSettings) TrashRetention() time.Duration {
+	days := DefaultTrashRetentionDays
+	if a.TrashRetentionDays != nil {
+		days = *a.TrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ConversationTitleSettings picks the model that titles conversations, a small and cheap one is enough.
+type ConversationTitleSettings struct {
+	IsEnabled bool `json:"isEnabled"`
+	// Provider defaults to the default provider and Model to the default model of the provider.
+	Provider aiproviderSpec.ProviderName `json:"provider,omitempty"`
+	Model    aiproviderSpec.ModelName    `json:"model,omitempty"`
+	// Language of the titles, the language of the conversation if empty.
+	Language string `json:"language,omitempty"`
 }
 
 // SettingsSchema represents the complete settings schema including app settings.
@@ -49,6 +49,15 @@ func (s *SettingStore) ValueEncDecGetter(pathSoFar []string) encdec.EncoderDecod
 		if pathSoFar[2] == "apiKey" {
 			return s.encryptEncDec
 		}
+		// App wide client key: ["app", "network", "clientKeyPEM"].
+		if pathSoFar[0] == "app" && pathSoFar[1] == "network" && pathSoFar[2] == "clientKeyPEM" {
+			return s.encryptEncDec
+		}
+	}
+	// Provider client key: ["aiSettings", <providerName>, "network", "clientKeyPEM"].
+	if len(pathSoFar) == 4 && pathSoFar[0] == "aiSettings" && pathSoFar[2] == "network" &&
+		pathSoFar[3] == "clientKeyPEM" {
+		return s.encryptEncDec
 	}
 	return nil
 }
@@ -103,6 +112,46 @@ func (s *SettingStore) SetAppSettings(
 	if err := s.store.SetKey([]string{"app", "defaultProvider"}, string(req.Body.DefaultProvider)); err != nil {
 		return nil, fmt.Errorf("failed to set app settings: %w", err)
 	}
+	if req.Body.Network != nil {
+		val, err := encdec.StructWithJSONTagsToMap(req.Body.Network)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set app network settings: %w", err)
+		}
+		if err := s.store.SetKey([]string{"app", "network"}, val); err != nil {
+			return nil, fmt.Errorf("failed to set app network settings: %w", err)
+		}
+	}
+	if req.Body.OutboundScan != nil {
+		val, err := encdec.StructWithJSONTagsToMap(req.Body.OutboundScan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set outbound scan settings: %w", err)
+		}
+		if err := s.store.SetKey([]string{"app", "outboundScan"}, val); err != nil {
+			return nil, fmt.Errorf("failed to set outbound scan settings: %w", err)
+		}
+	}
+	if titles := req.Body.ConversationTitles; titles != nil {
+		if titles.Provider != "" {
+			if _, _, _, err := s.getProviderData(titles.Provider, false); err != nil {
+				return nil, err
+			}
+		}
+		val, err := encdec.StructWithJSONTagsToMap(titles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set conversation title settings: %w", err)
+		}
+		if err := s.store.SetKey([]string{"app", "conversationTitles"}, val); err != nil {
+			return nil, fmt.Errorf("failed to set conversation title settings: %w", err)
+		}
+	}
+	if days := req.Body.TrashRetentionDays; days != nil {
+		if *days < 0 {
+			return nil, fmt.Errorf("invalid trash retention of %d days", *days)
+		}
+		if err := s.store.SetKey([]string{"app", "trashRetentionDays"}, *days); err != nil {
+			return nil, fmt.Errorf("failed to set trash retention: %w", err)
+		}
+	}
 	return &spec.SetAppSettingsResponse{}, nil
 }
 
@@ -269,6 +318,29 @@ func (s *SettingStore) SetAISettingAttrs(
 			return nil, fmt.Errorf("failed updating defaultModel: %w", err)
 		}
 	}
+	if rl := req.Body.RateLimits; rl != nil && *rl == (aiproviderSpec.RateLimits{}) {
+		// Empty limits clear the configured ones.
+		if err := s.store.DeleteKey([]string{"aiSettings", string(req.ProviderName), "rateLimits"}); err != nil {
+			return nil, fmt.Errorf("failed clearing rateLimits: %w", err)
+		}
+	} else if rl != nil {
+		val, err := encdec.StructWithJSONTagsToMap(rl)
+		if err != nil {
+			return nil, fmt.Errorf("failed updating rateLimits: %w", err)
+		}
+		if err := s.store.SetKey([]string{"aiSettings", string(req.ProviderName), "rateLimits"}, val); err != nil {
+			return nil, fmt.Errorf("failed updating rateLimits: %w", err)
+		}
+	}
+	if req.Body.Network != nil {
+		val, err := encdec.StructWithJSONTagsToMap(req.Body.Network)
+		if err != nil {
+			return nil, fmt.Errorf("failed updating network: %w", err)
+		}
+		if err := s.store.SetKey([]string{"aiSettings", string(req.ProviderName), "network"}, val); err != nil {
+			return nil, fmt.Errorf("failed updating network: %w", err)
+		}
+	}
 
 	return &spec.SetAISettingAttrsResponse{}, nil
 }
@@ -2,6 +2,7 @@ package settingstore_test
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -184,6 +185,35 @@ func TestSettingStore_SetAppSettings(t *testing.T) {
 			wantErr:       false,
 			expectedError: "",
 		},
+		{
+			name: "ConversationTitles",
+			req: &settingSpec.SetAppSettingsRequest{
+				Body: &settingSpec.AppSettings{
+					DefaultProvider: "openai2",
+					ConversationTitles: &settingSpec.ConversationTitleSettings{
+						IsEnabled: true,
+						Provider:  "openai2",
+						Model:     "gpt-3.5-turbo",
+					},
+				},
+			},
+			wantErr:       false,
+			expectedError: "",
+		},
+		{
+			name: "ConversationTitlesUnknownProvider",
+			req: &settingSpec.SetAppSettingsRequest{
+				Body: &settingSpec.AppSettings{
+					DefaultProvider: "openai2",
+					ConversationTitles: &settingSpec.ConversationTitleSettings{
+						IsEnabled: true,
+						Provider:  "unknown-provider",
+					},
+				},
+			},
+			wantErr:       true,
+			expectedError: "does not exist in aiSettings",
+		},
 		{
 			name:          "NilRequest",
 			req:           nil,
@@ -230,6 +260,34 @@ func TestSettingStore_SetAppSettings(t *testing.T) {
 			}
 		})
 	}
+
+	// The outbound scan config survives a restart.
+	scan := &spec.OutboundScanConfig{
+		Enabled:         true,
+		ActionOverrides: map[string]spec.OutboundScanAction{"email": spec.OutboundScanActionWarn},
+		Rules: []spec.OutboundScanRule{{
+			Name:    "ticket",
+			Pattern: `TICKET-\d+`,
+			Action:  spec.OutboundScanActionMask,
+		}},
+	}
+	_, err = store.SetAppSettings(ctx, &settingSpec.SetAppSettingsRequest{
+		Body: &settingSpec.AppSettings{DefaultProvider: "openai2", OutboundScan: scan},
+	})
+	if err != nil {
+		t.Fatalf("Failed to set the outbound scan config: %v", err)
+	}
+	restarted := &settingstore.SettingStore{}
+	if err := settingstore.InitSettingStore(restarted, filename); err != nil {
+		t.Fatalf("Failed to reopen settings store: %v", err)
+	}
+	resp, err := restarted.GetAllSettings(ctx, &settingSpec.GetAllSettingsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to get settings: %v", err)
+	}
+	if got := resp.Body.App.OutboundScan; !reflect.DeepEqual(got, scan) {
+		t.Errorf("Outbound scan config = %+v, want %+v", got, scan)
+	}
 }
 
 // TestSettingStore_AddAISetting exercises the AddAISetting method.
@@ -602,6 +660,21 @@ func TestSettingStore_SetAISettingAttrs(t *testing.T) {
 			wantErr:       false,
 			expectedError: "",
 		},
+		{
+			name: "RateLimitsAndNetwork",
+			req: &settingSpec.SetAISettingAttrsRequest{
+				ProviderName: spec.ProviderName("openai2"),
+				Body: &settingSpec.SetAISettingAttrsRequestBody{
+					RateLimits: &spec.RateLimits{RequestsPerMinute: 60},
+					Network: &spec.NetworkConfig{
+						ProxyURL:     "socks5://127.0.0.1:1080",
+						ClientKeyPEM: "secret-key",
+					},
+				},
+			},
+			wantErr:       false,
+			expectedError: "",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -619,6 +692,55 @@ func TestSettingStore_SetAISettingAttrs(t *testing.T) {
 			}
 		})
 	}
+
+	// Empty rate limits clear the ones set above.
+	_, err = store.SetAISettingAttrs(ctx, &settingSpec.SetAISettingAttrsRequest{
+		ProviderName: spec.ProviderName("openai2"),
+		Body:         &settingSpec.SetAISettingAttrsRequestBody{RateLimits: &spec.RateLimits{}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to clear rate limits: %v", err)
+	}
+	resp, err := store.GetAllSettings(ctx, &settingSpec.GetAllSettingsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to get settings: %v", err)
+	}
+	if rl := resp.Body.AISettings["openai2"].RateLimits; rl != nil {
+		t.Errorf("Expected cleared rate limits, got %+v", rl)
+	}
+}
+
+func TestSettingStore_ValueEncDecGetter(t *testing.T) {
+	filename := "test_value_encdec.json"
+	if err := initTestFile(filename); err != nil {
+		t.Fatalf("Failed to init test file: %v", err)
+	}
+	defer os.Remove(filename)
+
+	store := &settingstore.SettingStore{}
+	err := settingstore.InitSettingStore(store, filename)
+	if err != nil {
+		t.Fatalf("Failed to create settings store: %v", err)
+	}
+	testCases := []struct {
+		name    string
+		path    []string
+		encrypt bool
+	}{
+		{"ProviderAPIKey", []string{"aiSettings", "openai", "apiKey"}, true},
+		{"ProviderClientKey", []string{"aiSettings", "openai", "network", "clientKeyPEM"}, true},
+		{"AppClientKey", []string{"app", "network", "clientKeyPEM"}, true},
+		{"ProviderClientCert", []string{"aiSettings", "openai", "network", "clientCertPEM"}, false},
+		{"ProviderOrigin", []string{"aiSettings", "openai", "origin"}, false},
+		{"AppProxy", []string{"app", "network", "proxyURL"}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := store.ValueEncDecGetter(tc.path) != nil; got != tc.encrypt {
+				t.Errorf("ValueEncDecGetter(%v) encrypt = %v, want %v", tc.path, got, tc.encrypt)
+			}
+		})
+	}
 }
 
 // TestSettingStore_AddModelSetting exercises the AddModelSetting method.
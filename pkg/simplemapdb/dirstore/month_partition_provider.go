@@ -28,6 +28,7 @@ func (p *MonthPartitionProvider) GetPartitionDir(filename string) string {
 }
 
 // ListPartitions returns a paginated and sorted list of partition directories in the base directory.
+// Directories whose names are not all digits, like yyyyMM, are not partitions and are left out.
 func (p *MonthPartitionProvider) ListPartitions(
 	baseDir string,
 	sortOrder string,
@@ -40,7 +41,7 @@ func (p *MonthPartitionProvider) ListPartitions(
 	}
 
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() && isMonthPartition(entry.Name()) {
 			partitions = append(partitions, entry.Name())
 		}
 	}
@@ -78,3 +79,15 @@ func (p *MonthPartitionProvider) ListPartitions(
 
 	return partitions[start:end], nextPageToken, nil
 }
+
+func isMonthPartition(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
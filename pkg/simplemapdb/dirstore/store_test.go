@@ -934,7 +934,8 @@ func TestMapDirectoryStore_ListPartitions_Pagination(t *testing.T) {
 		t.Fatalf("failed to create MapDirectoryStore: %v", err)
 	}
 
-	partitions := []string{"202301", "202302", "202303"}
+	// Trash is not a month and must not be listed.
+	partitions := []string{"202301", "202302", "202303", "trash"}
 	for _, partition := range partitions {
 		if err := os.Mkdir(filepath.Join(baseDir, partition), os.ModePerm); err != nil {
 			t.Fatalf("failed to create partition directory: %v", err)
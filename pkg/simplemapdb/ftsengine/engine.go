@@ -563,9 +563,8 @@ func (e *Engine) BatchList(
 	return rows, nextToken, nil
 }
 
-// cleanQueryWithOr converts a raw string into `"a" OR "b" OR "c"`.
-// Expect input: words separated by blanks.
-func cleanQueryWithOr(q string) string {
+// queryTokens splits a raw string into its words, dropping non alphanumeric chars.
+func queryTokens(q string) []string {
 	var tokens []string
 	var buf strings.Builder
 
@@ -586,12 +585,28 @@ func cleanQueryWithOr(q string) string {
 	}
 	// Final word.
 	flush()
+	return tokens
+}
 
+// cleanQueryWithOr converts a raw string into `"a" OR "b" OR "c"`.
+// Expect input: words separated by blanks.
+func cleanQueryWithOr(q string) string {
+	return cleanQuery(q, MatchAny)
+}
+
+// cleanQuery converts a raw string into a fts5 expression for the mode:
+// `"a" OR "b"` for MatchAny, `"a" AND "b"` for MatchAll and `"a b"` for MatchPhrase.
+func cleanQuery(q string, mode MatchMode) string {
+	tokens := queryTokens(q)
 	// Nothing to search for, only non alphanumeric input.
 	if len(tokens) == 0 {
 		// Caller can skip the SQL.
 		return ""
 	}
+	if mode == MatchPhrase {
+		// Every word counts in a phrase.
+		return quote(strings.Join(tokens, " "))
+	}
 
 	// Deduplicate *before* quoting.
 	seen := make(map[string]struct{}, len(tokens))
@@ -609,11 +624,15 @@ func cleanQueryWithOr(q string) string {
 		}
 	}
 
+	op := " OR "
+	if mode == MatchAll {
+		op = " AND "
+	}
 	if len(out) == 0 {
-		return strings.Join(tokens, " OR ")
+		return strings.Join(tokens, op)
 	}
 
-	return strings.Join(out, " OR ")
+	return strings.Join(out, op)
 }
 
 // Search returns one page of results and, if more results exist,
@@ -624,6 +643,17 @@ func (e *Engine) Search(
 	query string,
 	pageToken string,
 	pageSize int,
+) (hits []SearchResult, nextToken string, err error) {
+	return e.SearchWithOptions(ctx, query, SearchOptions{}, pageToken, pageSize)
+}
+
+// SearchWithOptions is Search with a match mode, a column filter and snippets.
+func (e *Engine) SearchWithOptions(
+	ctx context.Context,
+	query string,
+	opts SearchOptions,
+	pageToken string,
+	pageSize int,
 ) (hits []SearchResult, nextToken string, err error) {
 	if query == "" {
 		return nil, "", ErrEmptyQuery
@@ -632,6 +662,30 @@ func (e *Engine) Search(
 	if pageSize <= 0 || pageSize > 10000 {
 		pageSize = 10
 	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = MatchAny
+	case MatchAny, MatchAll, MatchPhrase:
+	default:
+		return nil, "", fmt.Errorf("ftsengine: unknown match mode %q", opts.Mode)
+	}
+	for _, name := range opts.Columns {
+		idx := slices.IndexFunc(e.cfg.Columns, func(c Column) bool { return c.Name == name })
+		if idx < 0 || e.cfg.Columns[idx].Unindexed {
+			return nil, "", fmt.Errorf("ftsengine: unknown indexed column %q", name)
+		}
+	}
+
+	// Tokens are only valid for the same query and options.
+	tokenQuery := query
+	if opts.Mode != MatchAny || len(opts.Columns) > 0 {
+		tokenQuery = fmt.Sprintf(
+			"%s\x00%s\x00%s",
+			query,
+			opts.Mode,
+			strings.Join(opts.Columns, ","),
+		)
+	}
 
 	// Decode / reset token.
 	var offset int
@@ -645,7 +699,7 @@ func (e *Engine) Search(
 			_ = json.Unmarshal(b, &t)
 		}
 		// Token belongs to same query.
-		if t.Query == query {
+		if t.Query == tokenQuery {
 			offset = t.Offset
 		}
 	}
@@ -660,23 +714,40 @@ func (e *Engine) Search(
 		}
 	}
 
-	const sqlSearch = `SELECT %s, bm25(%s%s) AS s
+	// Column -1 lets fts5 pick the best matching column for the snippet.
+	snippetCol := ""
+	if opts.SnippetTokens > 0 {
+		snippetCol = fmt.Sprintf(", snippet(%s, -1, ?, ?, '…', %d)",
+			quote(e.cfg.Table), min(opts.SnippetTokens, 64))
+	}
+
+	const sqlSearch = `SELECT %s, bm25(%s%s) AS s%s
 			FROM %s WHERE %s MATCH ?
 			ORDER BY s ASC, %s
 			LIMIT ? OFFSET ?;`
 
 	sqlQ := fmt.Sprintf(sqlSearch, ColNameExternalID,
-		quote(e.cfg.Table), paramPlaceholders(len(weights)),
+		quote(e.cfg.Table), paramPlaceholders(len(weights)), snippetCol,
 		quote(e.cfg.Table), e.cfg.Table, ColNameRowID)
 
 	args := slices.Clone(weights)
+	if opts.SnippetTokens > 0 {
+		args = append(args, opts.HighlightStart, opts.HighlightEnd)
+	}
 	// Escape any embedded double quotes.
 	// FTS5 has special chars like - * etc that only quote for SQL, not for token.
-	cQ := cleanQueryWithOr(query)
+	cQ := cleanQuery(query, opts.Mode)
 	if cQ == "" {
 		// Return empty result.
 		return []SearchResult{}, "", nil
 	}
+	if len(opts.Columns) > 0 {
+		cols := make([]string, 0, len(opts.Columns))
+		for _, c := range opts.Columns {
+			cols = append(cols, quote(c))
+		}
+		cQ = fmt.Sprintf("{%s} : (%s)", strings.Join(cols, " "), cQ)
+	}
 	args = append(args, cQ, pageSize, offset)
 
 	rows, err := e.db.QueryContext(ctx, sqlQ, args...)
@@ -687,7 +758,11 @@ func (e *Engine) Search(
 
 	for rows.Next() {
 		var r SearchResult
-		if err := rows.Scan(&r.ID, &r.Score); err != nil {
+		dest := []any{&r.ID, &r.Score}
+		if opts.SnippetTokens > 0 {
+			dest = append(dest, &r.Snippet)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, "", err
 		}
 		hits = append(hits, r)
@@ -699,7 +774,7 @@ func (e *Engine) Search(
 		buf, _ := json.Marshal(struct {
 			Query  string `json:"q"`
 			Offset int    `json:"o"`
-		}{query, offset})
+		}{tokenQuery, offset})
 		nextToken = base64.StdEncoding.EncodeToString(buf)
 	}
 	return hits, nextToken, rows.Err()
@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"fmt"
 	"math/big"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -965,6 +967,118 @@ func TestSearchOnlySpecialChars(t *testing.T) {
 	}
 }
 
+func TestSearchWithOptions(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := t.Context()
+	docs := map[string]map[string]string{
+		"both":    {"title": "red apple", "body": "a fruit"},
+		"phrase":  {"title": "notes", "body": "the red apple is sweet"},
+		"reverse": {"title": "notes", "body": "apple trees are never red"},
+		"one":     {"title": "apple", "body": "green"},
+	}
+	if err := e.BatchUpsert(ctx, docs); err != nil {
+		t.Fatalf("batch upsert: %v", err)
+	}
+
+	ids := func(hits []SearchResult) []string {
+		out := make([]string, 0, len(hits))
+		for _, h := range hits {
+			out = append(out, h.ID)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		opts    SearchOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			"default any",
+			"red apple",
+			SearchOptions{},
+			[]string{"both", "one", "phrase", "reverse"},
+			false,
+		},
+		{
+			"all",
+			"red apple",
+			SearchOptions{Mode: MatchAll},
+			[]string{"both", "phrase", "reverse"},
+			false,
+		},
+		{
+			"phrase",
+			"red apple",
+			SearchOptions{Mode: MatchPhrase},
+			[]string{"both", "phrase"},
+			false,
+		},
+		{
+			"phrase in body",
+			"red apple",
+			SearchOptions{Mode: MatchPhrase, Columns: []string{"body"}},
+			[]string{"phrase"},
+			false,
+		},
+		{"unknown mode", "red", SearchOptions{Mode: "near"}, nil, true},
+		{"unknown column", "red", SearchOptions{Columns: []string{"nope"}}, nil, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hits, _, err := e.SearchWithOptions(ctx, tc.query, tc.opts, "", 10)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if got := ids(hits); !slices.Equal(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	hits, _, err := e.SearchWithOptions(ctx, "sweet", SearchOptions{
+		SnippetTokens:  8,
+		HighlightStart: "[",
+		HighlightEnd:   "]",
+	}, "", 10)
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("snippet search: %v %v", hits, err)
+	}
+	if !strings.Contains(hits[0].Snippet, "[sweet]") {
+		t.Fatalf("snippet %q does not highlight the match", hits[0].Snippet)
+	}
+}
+
+func TestSearchWithOptionsTokenIsPerMode(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := t.Context()
+	for i := range 4 {
+		_ = e.Upsert(ctx, fmt.Sprintf("d%d", i), map[string]string{"title": "red apple"})
+	}
+	_, tok, err := e.SearchWithOptions(ctx, "red apple", SearchOptions{Mode: MatchAll}, "", 2)
+	if err != nil || tok == "" {
+		t.Fatalf("expected a next token, got %q %v", tok, err)
+	}
+	// A token from another mode starts over.
+	hits, _, _ := e.SearchWithOptions(ctx, "red apple", SearchOptions{}, tok, 10)
+	if len(hits) != 4 {
+		t.Fatalf("expected the first page, got %d hits", len(hits))
+	}
+	hits, _, _ = e.SearchWithOptions(ctx, "red apple", SearchOptions{Mode: MatchAll}, tok, 10)
+	if len(hits) != 2 {
+		t.Fatalf("expected the second page, got %d hits", len(hits))
+	}
+}
+
 func TestBatchUpsertAllNilMaps(t *testing.T) {
 	e := newTestEngine(t)
 	ctx := t.Context()
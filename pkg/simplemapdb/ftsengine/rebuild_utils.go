@@ -105,11 +105,14 @@ func SyncDirToFTS(
 	}
 
 	err := filepath.WalkDir(baseDir, func(p string, d fs.DirEntry, we error) error {
-		if d.IsDir() || we != nil {
-			// Its a dir, we dont want to process it other than walking.
-			// There was some walk error in this particular path.
+		if we != nil {
+			// There was some walk error in this particular path, d may be nil.
 			return we
 		}
+		if d.IsDir() {
+			// Its a dir, we dont want to process it other than walking.
+			return nil
+		}
 
 		dec, err := processFile(ctx, baseDir, p, getPrev)
 		if err != nil {
@@ -369,6 +369,25 @@ func TestSyncDirToFTS_ErrorCases(t *testing.T) {
 	})
 }
 
+func TestSyncDirToFTS_MissingDir(t *testing.T) {
+	withTempDir(t, func(tmpDir string) {
+		engine, err := NewEngine(minimalConfig(tmpDir, "fts.db",
+			Column{Name: "title"},
+			Column{Name: "mtime"},
+		))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer engine.Close()
+
+		missing := filepath.Join(tmpDir, "gone")
+		err = SyncDirToFTS(t.Context(), engine, missing, "mtime", 2, testProcessFile)
+		if err == nil {
+			t.Error("expected an error for a missing directory")
+		}
+	})
+}
+
 func TestFTSEngine_IsEmpty(t *testing.T) {
 	withTempDir(t, func(tmpDir string) {
 		cfg := minimalConfig(tmpDir, "fts.db",
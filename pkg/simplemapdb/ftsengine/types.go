@@ -16,6 +16,32 @@ type SearchResult struct {
 	ID string
 	// Bm25.
 	Score float64
+	// Snippet is the best matching text, set if SearchOptions.SnippetTokens > 0.
+	Snippet string
+}
+
+// MatchMode decides how the words of a query are combined.
+type MatchMode string
+
+const (
+	// MatchAny matches documents with any of the words.
+	MatchAny MatchMode = "any"
+	// MatchAll matches documents with all of the words.
+	MatchAll MatchMode = "all"
+	// MatchPhrase matches documents with the words next to each other, in order.
+	MatchPhrase MatchMode = "phrase"
+)
+
+type SearchOptions struct {
+	// Mode defaults to MatchAny.
+	Mode MatchMode
+	// Columns limits matching to these indexed columns, all columns if empty.
+	Columns []string
+	// SnippetTokens is the max number of tokens of a snippet, 0 for no snippet.
+	SnippetTokens int
+	// HighlightStart and HighlightEnd enclose the matches in snippets.
+	HighlightStart string
+	HighlightEnd   string
 }
 
 // ListResult is returned by BatchList().